@@ -0,0 +1,230 @@
+// Package cache manages the dependency caches shared by the workspaces of a
+// project, such as the Go module cache or the npm cache, so each workspace
+// doesn't download everything again
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/aki/amux/internal/config"
+)
+
+// presets are the environment variables of well-known caches
+var presets = map[string]string{
+	"go":  "GOMODCACHE",
+	"npm": "npm_config_cache",
+	"pip": "PIP_CACHE_DIR",
+}
+
+var (
+	namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	envPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Cache is a cache directory and the environment variable pointing tools at it
+type Cache struct {
+	Name string `json:"name"`
+	Env  string `json:"env,omitempty"` // Empty for directories no longer configured
+	Path string `json:"path"`
+}
+
+// Usage is the disk space a cache takes
+type Usage struct {
+	Cache
+	Files int   `json:"files"`
+	Size  int64 `json:"size"`
+}
+
+// Manager finds the caches of a project
+type Manager struct {
+	configManager *config.Manager
+}
+
+// NewManager creates a cache manager for a project
+func NewManager(configManager *config.Manager) *Manager {
+	return &Manager{configManager: configManager}
+}
+
+// Caches returns the caches configured for the project. A project without a
+// configuration has none.
+func (m *Manager) Caches() ([]Cache, error) {
+	if !m.configManager.IsInitialized() {
+		return nil, nil
+	}
+	cfg, err := m.configManager.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var caches []Cache
+	seen := make(map[string]bool)
+	for _, c := range cfg.Caches {
+		if !namePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("invalid cache name %q: must start with a letter or digit and contain only letters, digits, '.', '-' and '_'", c.Name)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("cache %q is configured twice", c.Name)
+		}
+		seen[c.Name] = true
+
+		env := c.Env
+		if env == "" {
+			env = presets[c.Name]
+		}
+		if env == "" {
+			return nil, fmt.Errorf("cache %q needs env: only go, npm and pip have a default", c.Name)
+		}
+		if !envPattern.MatchString(env) {
+			return nil, fmt.Errorf("invalid environment variable %q for cache %q", env, c.Name)
+		}
+		caches = append(caches, Cache{
+			Name: c.Name,
+			Env:  env,
+			Path: filepath.Join(m.configManager.GetCacheDir(), c.Name),
+		})
+	}
+	return caches, nil
+}
+
+// Environment returns the environment variables of the configured caches,
+// creating their directories the first time
+func (m *Manager) Environment() (map[string]string, error) {
+	caches, err := m.Caches()
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(caches))
+	for _, c := range caches {
+		if err := os.MkdirAll(c.Path, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache %s: %w", c.Name, err)
+		}
+		env[c.Env] = c.Path
+	}
+	return env, nil
+}
+
+// Usage returns the disk space of the configured caches, and of directories
+// left under .amux/cache by caches that are no longer configured
+func (m *Manager) Usage() ([]Usage, error) {
+	caches, err := m.all()
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]Usage, 0, len(caches))
+	for _, c := range caches {
+		files, size, err := diskUsage(c.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure cache %s: %w", c.Name, err)
+		}
+		usage = append(usage, Usage{Cache: c, Files: files, Size: size})
+	}
+	return usage, nil
+}
+
+// Clean empties the named caches, or all of them, and returns what they took.
+// Directories of caches that are no longer configured are removed.
+func (m *Manager) Clean(names []string) ([]Usage, error) {
+	usage, err := m.Usage()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Usage, len(usage))
+	for _, u := range usage {
+		byName[u.Name] = u
+	}
+
+	selected := usage
+	if len(names) > 0 {
+		selected = make([]Usage, 0, len(names))
+		for _, name := range names {
+			u, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("cache %q not found", name)
+			}
+			selected = append(selected, u)
+		}
+	}
+
+	for _, u := range selected {
+		if err := removeAll(u.Path); err != nil {
+			return nil, fmt.Errorf("failed to clean cache %s: %w", u.Name, err)
+		}
+		// Keep the directory of configured caches for running sessions
+		if u.Env != "" {
+			if err := os.MkdirAll(u.Path, 0o755); err != nil {
+				return nil, fmt.Errorf("failed to clean cache %s: %w", u.Name, err)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// all returns the configured caches followed by the directories under
+// .amux/cache that no cache is configured for
+func (m *Manager) all() ([]Cache, error) {
+	caches, err := m.Caches()
+	if err != nil {
+		return nil, err
+	}
+	configured := make(map[string]bool, len(caches))
+	for _, c := range caches {
+		configured[c.Name] = true
+	}
+
+	entries, err := os.ReadDir(m.configManager.GetCacheDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	var leftover []Cache
+	for _, entry := range entries {
+		if entry.IsDir() && !configured[entry.Name()] {
+			leftover = append(leftover, Cache{
+				Name: entry.Name(),
+				Path: filepath.Join(m.configManager.GetCacheDir(), entry.Name()),
+			})
+		}
+	}
+	sort.Slice(leftover, func(i, j int) bool { return leftover[i].Name < leftover[j].Name })
+	return append(caches, leftover...), nil
+}
+
+// diskUsage counts the files under a directory and their size. A missing
+// directory is empty.
+func diskUsage(dir string) (files int, size int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		return nil
+	})
+	return files, size, err
+}
+
+// removeAll removes a directory like os.RemoveAll, first making read-only
+// directories writable: the Go module cache is read-only
+func removeAll(dir string) error {
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = os.Chmod(path, 0o755)
+		}
+		return nil
+	})
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/config"
+)
+
+func setupManager(t *testing.T, caches ...config.CacheConfig) (*Manager, *config.Manager) {
+	t.Helper()
+	configMgr := config.NewManager(t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Caches = caches
+	require.NoError(t, configMgr.Save(cfg))
+	return NewManager(configMgr), configMgr
+}
+
+func TestManager_Caches(t *testing.T) {
+	t.Run("presets and custom", func(t *testing.T) {
+		m, configMgr := setupManager(t,
+			config.CacheConfig{Name: "go"},
+			config.CacheConfig{Name: "npm"},
+			config.CacheConfig{Name: "pip"},
+			config.CacheConfig{Name: "gradle", Env: "GRADLE_USER_HOME"},
+		)
+		env, err := m.Environment()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"GOMODCACHE":       filepath.Join(configMgr.GetCacheDir(), "go"),
+			"npm_config_cache": filepath.Join(configMgr.GetCacheDir(), "npm"),
+			"PIP_CACHE_DIR":    filepath.Join(configMgr.GetCacheDir(), "pip"),
+			"GRADLE_USER_HOME": filepath.Join(configMgr.GetCacheDir(), "gradle"),
+		}, env)
+		for _, dir := range env {
+			assert.DirExists(t, dir)
+		}
+	})
+
+	t.Run("no configuration", func(t *testing.T) {
+		m := NewManager(config.NewManager(t.TempDir()))
+		env, err := m.Environment()
+		require.NoError(t, err)
+		assert.Empty(t, env)
+	})
+
+	invalid := map[string][]config.CacheConfig{
+		"custom without env": {{Name: "gradle"}},
+		"duplicate":          {{Name: "go"}, {Name: "go", Env: "GOCACHE"}},
+		"path as name":       {{Name: "../go", Env: "GOMODCACHE"}},
+	}
+	for name, caches := range invalid {
+		t.Run(name, func(t *testing.T) {
+			m, _ := setupManager(t, caches...)
+			_, err := m.Caches()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestManager_UsageAndClean(t *testing.T) {
+	m, configMgr := setupManager(t, config.CacheConfig{Name: "go"}, config.CacheConfig{Name: "npm"})
+	_, err := m.Environment()
+	require.NoError(t, err)
+
+	// Module cache directories are read-only
+	module := filepath.Join(configMgr.GetCacheDir(), "go", "example.com", "mod@v1.0.0")
+	require.NoError(t, os.MkdirAll(module, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/mod\n"), 0o444))
+	require.NoError(t, os.Chmod(module, 0o555))
+
+	// Left behind by a cache that was removed from the configuration
+	old := filepath.Join(configMgr.GetCacheDir(), "yarn")
+	require.NoError(t, os.MkdirAll(old, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(old, "pkg.tgz"), []byte("data"), 0o644))
+
+	usage, err := m.Usage()
+	require.NoError(t, err)
+	require.Len(t, usage, 3)
+	assert.Equal(t, "go", usage[0].Name)
+	assert.Equal(t, 1, usage[0].Files)
+	assert.EqualValues(t, len("module example.com/mod\n"), usage[0].Size)
+	assert.Equal(t, 0, usage[1].Files)
+	assert.Equal(t, "yarn", usage[2].Name)
+	assert.Empty(t, usage[2].Env)
+
+	_, err = m.Clean([]string{"missing"})
+	assert.Error(t, err)
+
+	cleaned, err := m.Clean([]string{"go"})
+	require.NoError(t, err)
+	require.Len(t, cleaned, 1)
+	assert.Equal(t, 1, cleaned[0].Files)
+	assert.NoDirExists(t, module)
+	assert.DirExists(t, filepath.Join(configMgr.GetCacheDir(), "go"), "configured caches keep their directory")
+	assert.DirExists(t, old)
+
+	cleaned, err = m.Clean(nil)
+	require.NoError(t, err)
+	assert.Len(t, cleaned, 3)
+	assert.NoDirExists(t, old)
+}
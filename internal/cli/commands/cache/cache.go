@@ -0,0 +1,144 @@
+// Package cache provides CLI commands for the dependency caches shared by workspaces.
+package cache
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cache"
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+)
+
+var cleanForce bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage dependency caches shared by workspaces",
+	Long: `Manage the dependency caches shared by all workspaces of the project.
+
+Caches listed under caches in .amux/config.yaml are kept under .amux/cache, and
+sessions and workspace hooks get an environment variable pointing at each, so
+every workspace reuses the Go modules or npm packages already downloaded:
+
+  caches:
+    - name: go     # GOMODCACHE
+    - name: npm    # npm_config_cache
+    - name: pip    # PIP_CACHE_DIR
+    - name: gradle
+      env: GRADLE_USER_HOME`,
+	Example: `  # Show how much space the caches take
+  amux cache usage
+
+  # Empty the npm cache
+  amux cache clean npm`,
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show the disk space of the caches",
+	Args:  cobra.NoArgs,
+	RunE:  runUsage,
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean [cache...]",
+	Short: "Empty caches",
+	Long: `Empty the named caches, or all of them. Directories left under .amux/cache
+by caches that are no longer configured are removed. Tools fill the caches
+again the next time they need them.`,
+	RunE: runClean,
+}
+
+func init() {
+	cacheCmd.AddCommand(usageCmd)
+	cacheCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().BoolVarP(&cleanForce, "force", "f", false, "Empty all caches without confirmation")
+}
+
+// Command returns the cache command
+func Command() *cobra.Command {
+	return cacheCmd
+}
+
+func setupManager() (*cache.Manager, error) {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewManager(config.NewManager(projectRoot)), nil
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	manager, err := setupManager()
+	if err != nil {
+		return err
+	}
+	usage, err := manager.Usage()
+	if err != nil {
+		return err
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(usage)
+	}
+	if len(usage) == 0 {
+		ui.OutputLine("No caches configured (see caches in .amux/config.yaml)")
+		return nil
+	}
+
+	var total int64
+	tbl := ui.NewTable("NAME", "ENV", "FILES", "SIZE")
+	for _, u := range usage {
+		env := u.Env
+		if env == "" {
+			env = "(not configured)"
+		}
+		tbl.AddRow(u.Name, env, u.Files, ui.FormatSize(u.Size))
+		total += u.Size
+	}
+	tbl.Print()
+	ui.OutputLine("")
+	ui.OutputLine("Total: %s", ui.FormatSize(total))
+	return nil
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	manager, err := setupManager()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 && !cleanForce && !ui.GlobalFormatter.IsJSON() {
+		ui.Warning("This will empty all caches")
+		response := ui.Prompt("Are you sure? (y/N): ")
+		if response != "y" && response != "Y" {
+			ui.OutputLine("Clean cancelled")
+			return nil
+		}
+	}
+
+	cleaned, err := manager.Clean(args)
+	if err != nil {
+		return err
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(cleaned)
+	}
+	if len(cleaned) == 0 {
+		ui.Info("No caches to clean")
+		return nil
+	}
+	var total int64
+	for _, u := range cleaned {
+		total += u.Size
+	}
+	ui.Success("Freed %s in %d cache(s)", ui.FormatSize(total), len(cleaned))
+	for _, u := range cleaned {
+		ui.OutputLine("  - %s %s", u.Name, ui.DimStyle.Render(fmt.Sprintf("(%s)", ui.FormatSize(u.Size))))
+	}
+	return nil
+}
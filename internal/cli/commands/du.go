@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cache"
+	sessioncmd "github.com/aki/amux/internal/cli/commands/session"
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// duResult is the JSON output of the du command
+type duResult struct {
+	Workspaces []*workspace.Workspace `json:"workspaces"`
+	Caches     []cache.Usage          `json:"caches"`
+	Total      int64                  `json:"total"`
+	Quota      config.QuotaConfig     `json:"quota"`
+}
+
+// NewDuCommand creates the command summarizing the disk space of the project
+func NewDuCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "du",
+		Short: "Show the disk space of workspaces and caches",
+		Long: `Show the disk space each workspace takes, split into its worktree, its
+storage under .amux/workspaces and the logs of its sessions, along with the
+shared caches and the total for the project.
+
+Quotas set under workspace.quota in .amux/config.yaml are shown below the total.`,
+		Example: `  # Find the workspaces taking the most space
+  amux du
+
+  # Get the sizes in bytes
+  amux du --format json`,
+		Args: cobra.NoArgs,
+		RunE: runDu,
+	}
+}
+
+func runDu(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	configMgr := config.NewManager(projectRoot)
+	cfg, err := configMgr.Load()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.NewManager(configMgr)
+	if err != nil {
+		return err
+	}
+	manager.SetLogUsage(sessioncmd.SetupManager(configMgr).LogUsage)
+
+	workspaces, caches, total, err := manager.ProjectUsage(cmd.Context())
+	if err != nil {
+		return err
+	}
+	quota := cfg.Workspace.Quota
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(duResult{Workspaces: workspaces, Caches: caches, Total: total, Quota: quota})
+	}
+
+	if len(workspaces) > 0 {
+		tbl := ui.NewTable("WORKSPACE", "WORKTREE", "STORAGE", "LOGS", "TOTAL")
+		for _, ws := range workspaces {
+			u := ws.Usage
+			tbl.AddRow(ws.Name, ui.FormatSize(u.Worktree), ui.FormatSize(u.Storage), ui.FormatSize(u.Logs), ui.FormatSize(u.Total()))
+		}
+		tbl.Print()
+		ui.OutputLine("")
+	}
+	if len(caches) > 0 {
+		tbl := ui.NewTable("CACHE", "FILES", "SIZE")
+		for _, c := range caches {
+			tbl.AddRow(c.Name, c.Files, ui.FormatSize(c.Size))
+		}
+		tbl.Print()
+		ui.OutputLine("")
+	}
+
+	ui.OutputLine("Total: %s", ui.FormatSize(total))
+	if quota.Workspace != "" || quota.Project != "" {
+		action := quota.Action
+		if action == "" {
+			action = workspace.QuotaWarn
+		}
+		ui.PrintKeyValue("Quota", formatQuota(quota)+" ("+action+")")
+	}
+	return nil
+}
+
+// formatQuota describes the quotas that are set
+func formatQuota(quota config.QuotaConfig) string {
+	var s string
+	if quota.Workspace != "" {
+		s = quota.Workspace + " per workspace"
+	}
+	if quota.Project != "" {
+		if s != "" {
+			s += ", "
+		}
+		s += quota.Project + " for the project"
+	}
+	return s
+}
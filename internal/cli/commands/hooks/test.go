@@ -28,6 +28,7 @@ func testHooks(cmd *cobra.Command, args []string) error {
 	event := hooks.Event(eventName)
 	switch event {
 	case hooks.EventWorkspaceCreate, hooks.EventWorkspaceRemove,
+		hooks.EventWorkspacePreMerge, hooks.EventWorkspacePostMerge,
 		hooks.EventSessionStart, hooks.EventSessionStop:
 		// Valid event
 	default:
@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 
 	"github.com/spf13/cobra"
 
@@ -16,14 +19,18 @@ func NewProxyCommand() *cobra.Command {
 		socketPath string
 		sessionDir string
 		foreground bool
+		tap        bool
 	)
 
 	cmd := &cobra.Command{
 		Use:    "proxy",
 		Short:  "Internal command to proxy process I/O and monitor status",
 		Hidden: true, // This is an internal command
-		Args:   cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// A command is required unless output is tapped from stdin
+			if len(args) == 0 && !tap {
+				return fmt.Errorf("command is required unless --tap is set")
+			}
 			// All paths must be provided by the runtime
 			if statusPath == "" {
 				return fmt.Errorf("--status-path is required")
@@ -42,6 +49,9 @@ func NewProxyCommand() *cobra.Command {
 				SocketPath: socketPath,
 				Command:    args,
 				Foreground: foreground,
+				Tap:        tap,
+				// Sessions in a workspace journal the files changed in it
+				WatchDir: os.Getenv("AMUX_WORKSPACE_PATH"),
 			}
 
 			p, err := proxy.New(opts)
@@ -49,7 +59,16 @@ func NewProxyCommand() *cobra.Command {
 				return err
 			}
 
-			return p.Run()
+			if err := p.Run(); err != nil {
+				// Exit with the command's own status so that runtimes
+				// watching the proxy see the real exit code
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+					os.Exit(exitErr.ExitCode())
+				}
+				return err
+			}
+			return nil
 		},
 	}
 
@@ -58,6 +77,7 @@ func NewProxyCommand() *cobra.Command {
 	cmd.Flags().StringVar(&socketPath, "socket-path", "", "Path to Unix socket for output streaming")
 	cmd.Flags().StringVar(&sessionDir, "session-dir", "", "Session directory for storing run data")
 	cmd.Flags().BoolVar(&foreground, "foreground", false, "Run in foreground mode (direct I/O)")
+	cmd.Flags().BoolVar(&tap, "tap", false, "Proxy output read from stdin instead of running a command")
 	_ = cmd.MarkFlagRequired("status-path")
 	_ = cmd.MarkFlagRequired("socket-path")
 	_ = cmd.MarkFlagRequired("session-dir")
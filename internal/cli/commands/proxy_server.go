@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/commands/session"
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/preview"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// NewProxyServerCommand creates the proxy-server command
+func NewProxyServerCommand() *cobra.Command {
+	var (
+		host string
+		port int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "proxy-server",
+		Short: "Serve workspace dev servers under <workspace>.localhost",
+		Long: `Start a local reverse proxy for the dev servers of workspaces.
+
+http://<workspace>.localhost:<port> is routed to the dev server started in that
+workspace, so each branch gets a stable preview address. The port of a workspace
+comes from its allocated ports (see 'amux ws ports'): the "web" port, or the
+first one by name. Other ports are reached with <port-name>.<workspace>.localhost.
+
+http://localhost:<port> shows a status page listing all previews.
+
+Examples:
+  # Start the proxy on the default port
+  amux proxy-server
+
+  # Open the preview of the feature-auth workspace
+  open http://feature-auth.localhost:8080`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectRoot, err := config.FindProjectRoot()
+			if err != nil {
+				return err
+			}
+			configMgr := config.NewManager(projectRoot)
+			if !configMgr.IsInitialized() {
+				return fmt.Errorf("amux not initialized. Run 'amux init' first")
+			}
+
+			wsMgr, err := workspace.SetupManager(projectRoot)
+			if err != nil {
+				return err
+			}
+
+			server := &http.Server{
+				Addr:              net.JoinHostPort(host, strconv.Itoa(port)),
+				Handler:           preview.NewServer(wsMgr, session.SetupManager(configMgr), port),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- server.ListenAndServe()
+			}()
+
+			ui.Success("Preview proxy listening on http://localhost:%d", port)
+			ui.Info("Workspaces are served at http://<workspace>.localhost:%d", port)
+
+			select {
+			case err := <-errCh:
+				if errors.Is(err, http.ErrServerClosed) {
+					return nil
+				}
+				return fmt.Errorf("proxy server failed: %w", err)
+			case <-ctx.Done():
+			}
+
+			ui.Info("Shutting down preview proxy...")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "Address to listen on")
+	cmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
+
+	return cmd
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/aki/amux/internal/cli/commands/cache"
 	"github.com/aki/amux/internal/cli/commands/config"
 	"github.com/aki/amux/internal/cli/commands/hooks"
 	"github.com/aki/amux/internal/cli/commands/session"
@@ -43,7 +44,7 @@ func init() {
 	// This is important for tests and some commands that don't need runtime
 
 	// Add global flags
-	rootCmd.PersistentFlags().StringVar(&formatFlag, "format", "pretty", "Output format (pretty, json)")
+	rootCmd.PersistentFlags().StringVar(&formatFlag, "format", "pretty", "Output format (pretty, wide, json)")
 
 	// Register global logger flags
 	RegisterLoggerFlags(rootCmd)
@@ -55,6 +56,9 @@ func init() {
 	rootCmd.AddCommand(mcpCmd)
 	rootCmd.AddCommand(config.Command())
 	rootCmd.AddCommand(hooks.Cmd)
+	rootCmd.AddCommand(cache.Command())
+	rootCmd.AddCommand(NewDuCommand())
+	rootCmd.AddCommand(NewProxyServerCommand())
 
 	// Add shortcut commands
 	rootCmd.AddCommand(NewRunCommand())
@@ -30,7 +30,10 @@ Examples:
   amux run --task dev --workspace myworkspace
 
   # Run with tmux runtime
-  amux run --task dev --runtime tmux`,
+  amux run --task dev --runtime tmux
+
+  # Start an agent configured under agents in .amux/config.yaml
+  amux run --agent claude`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Bind flags to session.runOpts
 			session.BindRunFlags(cmd)
@@ -40,11 +43,17 @@ Examples:
 
 	// Add flags that will be bound to session.runOpts
 	cmd.Flags().StringP("task", "t", "", "Task name to run")
+	cmd.Flags().String("agent", "", "Agent to run, with its command and runtime options from the configuration")
 	cmd.Flags().StringP("workspace", "w", "", "Workspace to run in")
 	cmd.Flags().StringP("runtime", "r", "local", "Runtime to use (local, local-detached, tmux)")
 	cmd.Flags().StringArrayP("env", "e", nil, "Environment variables (KEY=VALUE)")
 	cmd.Flags().StringP("dir", "d", "", "Working directory")
 	cmd.Flags().BoolP("follow", "f", false, "Follow logs")
+	cmd.Flags().String("tmux-group", "", "Start as a pane in this shared tmux session (tmux runtime)")
+	cmd.Flags().String("tmux-layout", "", "Layout of the shared tmux window (default: tiled)")
+	cmd.Flags().StringArray("port", nil, "Allocate a named workspace port, passed as AMUX_PORT_<NAME> (repeatable)")
+	cmd.Flags().Bool("wait", false, "Wait for a free slot if the workspace's concurrency policy has none")
+	cmd.Flags().Duration("wait-timeout", 0, "Give up waiting after this long (default: no limit)")
 
 	return cmd
 }
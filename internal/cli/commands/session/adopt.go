@@ -0,0 +1,95 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Adopt an existing tmux session or pane",
+	Long: `Adopt an existing tmux session or pane as an amux session.
+
+The adopted process keeps running where it is. amux starts tracking its
+output and activity, so it shows up in 'amux ps' and can be watched,
+attached to, sent keys and stopped like any other session.
+
+Examples:
+  # Adopt a tmux session started outside amux
+  amux session adopt --tmux my-agent --workspace feature-auth
+
+  # Adopt a single pane
+  amux session adopt --tmux my-agent:0.1 --workspace 3 --name reviewer`,
+	Args: cobra.NoArgs,
+	RunE: AdoptSession,
+}
+
+var adoptOpts struct {
+	tmux        string
+	workspace   string
+	name        string
+	description string
+	enableLog   bool
+}
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptOpts.tmux, "tmux", "", "tmux session or pane to adopt (e.g., name or name:0.1)")
+	adoptCmd.Flags().StringVarP(&adoptOpts.workspace, "workspace", "w", "", "Workspace the process works in (name or ID)")
+	adoptCmd.Flags().StringVarP(&adoptOpts.name, "name", "n", "", "Human-readable name for the session")
+	adoptCmd.Flags().StringVar(&adoptOpts.description, "description", "", "Description of session purpose")
+	adoptCmd.Flags().BoolVar(&adoptOpts.enableLog, "log", false, "Enable logging to file")
+	_ = adoptCmd.MarkFlagRequired("tmux")
+	_ = adoptCmd.MarkFlagRequired("workspace")
+}
+
+// AdoptSession implements the session adopt command
+func AdoptSession(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	configMgr, sessionMgr, err := setupManagers()
+	if err != nil {
+		return err
+	}
+
+	wsMgr, err := workspace.SetupManager(configMgr.GetProjectRoot())
+	if err != nil {
+		return fmt.Errorf("failed to create workspace manager: %w", err)
+	}
+	ws, err := wsMgr.ResolveWorkspace(ctx, workspace.Identifier(adoptOpts.workspace))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	// Default to the target, keeping pane separators out of the session ID
+	name := adoptOpts.name
+	if name == "" {
+		name = strings.NewReplacer(":", "-", ".", "-").Replace(adoptOpts.tmux)
+	}
+
+	sess, err := sessionMgr.Adopt(ctx, session.AdoptOptions{
+		WorkspaceID: ws.ID,
+		Name:        name,
+		Description: adoptOpts.description,
+		Runtime:     "tmux",
+		Target:      adoptOpts.tmux,
+		EnableLog:   adoptOpts.enableLog,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to adopt session: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(sess)
+	}
+
+	ui.Success("Session adopted: %s", sess.ID)
+	ui.Info("Workspace: %s", ws.Name)
+	ui.OutputLine("")
+	ui.OutputLine("Use 'amux session watch %s' to follow its output", sess.ShortID)
+	return nil
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <session-id> [path...]",
+	Short: "Show what a session changed in its workspace",
+	Long: `Show what a session changed in its workspace.
+
+The workspace state, including uncommitted and untracked files, is recorded
+when a session starts and again when it exits. The changes between the two are
+shown; for a running session, the start state is compared with the current
+worktree. Changes made in the workspace by others while the session ran are
+included as well.
+
+Examples:
+  # Summarize changed files
+  amux session diff session-1
+
+  # Show the full patch of some files
+  amux session diff session-1 --patch src/auth.go`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: DiffSession,
+}
+
+var diffOpts struct {
+	patch    bool
+	nameOnly bool
+}
+
+func init() {
+	diffCmd.Flags().BoolVarP(&diffOpts.patch, "patch", "p", false, "Show the full patch")
+	diffCmd.Flags().BoolVar(&diffOpts.nameOnly, "name-only", false, "Only list the names of changed files")
+}
+
+// DiffSession implements the session diff command
+func DiffSession(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	sessionID := args[0]
+
+	// Setup managers with project root detection
+	_, sessionMgr, err := setupManagers()
+	if err != nil {
+		return err
+	}
+
+	if _, err := sessionMgr.Get(ctx, sessionID); err != nil {
+		return fmt.Errorf("session '%s' not found. Run 'amux ps' to see active sessions", sessionID)
+	}
+
+	changes, err := sessionMgr.Diff(ctx, sessionID, session.DiffOptions{
+		Patch: diffOpts.patch,
+		Paths: args[1:],
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff session '%s': %w", sessionID, err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(changes)
+	}
+
+	if diffOpts.patch {
+		ui.Raw(changes.Patch)
+		return nil
+	}
+
+	if diffOpts.nameOnly {
+		for _, f := range changes.Files {
+			ui.OutputLine("%s", f.Path)
+		}
+		return nil
+	}
+
+	printSessionChanges(changes)
+	return nil
+}
+
+// printSessionChanges prints the files a session changed with their line counts
+func printSessionChanges(changes *session.Changes) {
+	ui.PrintKeyValue("From", describeSnapshot(changes.From, "session start"))
+	if changes.To != nil {
+		ui.PrintKeyValue("To", describeSnapshot(changes.To, "session exit"))
+	} else {
+		ui.PrintKeyValue("To", "current worktree")
+	}
+
+	if len(changes.Files) == 0 {
+		ui.OutputLine("")
+		ui.Info("No changes")
+		return
+	}
+
+	ui.OutputLine("")
+	tbl := ui.NewTable("FILE", "STATUS", "CHANGES")
+	for _, f := range changes.Files {
+		name := f.Path
+		if f.OldPath != "" {
+			name = fmt.Sprintf("%s → %s", f.OldPath, f.Path)
+		}
+		stat := ui.FormatChanges(f.Insertions, f.Deletions)
+		if f.Binary {
+			stat = ui.DimStyle.Render("binary")
+		}
+		tbl.AddRow(name, string(f.Status), stat)
+	}
+	tbl.Print()
+
+	ui.OutputLine("")
+	ui.OutputLine("%d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)", len(changes.Files), changes.Insertions, changes.Deletions)
+}
+
+// describeSnapshot describes when a snapshot was taken and on which commit
+func describeSnapshot(snapshot *workspace.Snapshot, point string) string {
+	head := snapshot.Head
+	if len(head) > 7 {
+		head = head[:7]
+	}
+	return fmt.Sprintf("%s, %s (HEAD %s)", point, ui.FormatTime(snapshot.CreatedAt), head)
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+)
+
+var filesCmd = &cobra.Command{
+	Use:   "files <session-id>",
+	Short: "List the files changed while a session ran",
+	Long: `List the files that changed in a session's workspace while the session ran.
+
+While a session runs in a workspace, file creations, writes and deletions in
+the worktree are recorded in a journal in the session directory. Ignored
+directories and .git are not watched. File changes are only recorded on Linux.
+
+A change cannot be traced to the process that made it, so it is attributed to
+all sessions active in the workspace at the time. Files that changed while
+other sessions were active are flagged, since more than one session may have
+edited them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: ListSessionFiles,
+}
+
+// ListSessionFiles implements the session files command
+func ListSessionFiles(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	sessionID := args[0]
+
+	// Setup managers with project root detection
+	_, sessionMgr, err := setupManagers()
+	if err != nil {
+		return err
+	}
+
+	if _, err := sessionMgr.Get(ctx, sessionID); err != nil {
+		return fmt.Errorf("session '%s' not found. Run 'amux ps' to see active sessions", sessionID)
+	}
+
+	activity, err := sessionMgr.Files(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list files of session '%s': %w", sessionID, err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(activity)
+	}
+
+	if len(activity.Files) == 0 {
+		ui.Info("No file changes recorded for session %s", activity.SessionID)
+		return nil
+	}
+
+	tbl := ui.NewTable("FILE", "LAST CHANGE", "CHANGES", "LAST CHANGED", "ALSO ACTIVE")
+	for _, f := range activity.Files {
+		shared := "-"
+		if len(f.Shared) > 0 {
+			shared = ui.WarningStyle.Render(strings.Join(f.Shared, ", "))
+		}
+		tbl.AddRow(f.Path, string(f.Op), fmt.Sprintf("%d", f.Changes), ui.FormatTime(f.LastAt), shared)
+	}
+	tbl.Print()
+
+	if len(activity.Conflicts) > 0 {
+		ui.OutputLine("")
+		ui.Warning("%d file(s) changed while other sessions were active in the workspace and may have been edited by more than one session", len(activity.Conflicts))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/runtime"
+	"github.com/aki/amux/internal/runtime/tmux"
+	"github.com/aki/amux/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var gridCmd = &cobra.Command{
+	Use:   "grid <session-id>...",
+	Short: "Show tmux sessions side by side",
+	Long: `Show running tmux sessions side by side in a single tmux window.
+
+The pane of each session moves into a shared tmux session. The sessions
+keep running, and stop, kill and send-keys keep targeting their panes.
+
+Examples:
+  # Compare three agents of a best-of-N run
+  amux session grid 1 2 3
+
+  # Arrange them in columns and attach
+  amux session grid 1 2 3 --layout even-horizontal --attach`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: GridSessions,
+}
+
+var gridOpts struct {
+	name   string
+	layout string
+	attach bool
+}
+
+func init() {
+	gridCmd.Flags().StringVar(&gridOpts.name, "name", "amux-grid", "tmux session holding the grid")
+	gridCmd.Flags().StringVar(&gridOpts.layout, "layout", "tiled", "tmux layout (tiled, even-horizontal, even-vertical, main-horizontal, main-vertical)")
+	gridCmd.Flags().BoolVar(&gridOpts.attach, "attach", false, "Attach to the grid afterwards")
+}
+
+// GridSessions implements the session grid command
+func GridSessions(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	_, sessionMgr, err := setupManagers()
+	if err != nil {
+		return err
+	}
+
+	sessions := make([]*session.Session, 0, len(args))
+	spec := tmux.GridSpec{Name: gridOpts.name, Layout: gridOpts.layout}
+	for _, id := range args {
+		sess, err := sessionMgr.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("session '%s' not found. Run 'amux ps' to see active sessions", id)
+		}
+		if sess.Runtime != "tmux" {
+			return fmt.Errorf("session '%s' uses the %s runtime; only tmux sessions can be shown in a grid", id, sess.Runtime)
+		}
+		if sess.Status != session.StatusRunning {
+			return fmt.Errorf("session '%s' is not running (status: %s)", id, sess.Status)
+		}
+
+		title := sess.ID
+		if sess.Name != "" {
+			title = fmt.Sprintf("%s (%s)", sess.Name, sess.ShortID)
+		}
+		spec.Sessions = append(spec.Sessions, tmux.GridSession{ID: sess.ID, Title: title})
+		sessions = append(sessions, sess)
+	}
+
+	rt, err := runtime.Get("tmux")
+	if err != nil {
+		return fmt.Errorf("tmux runtime not available: %w", err)
+	}
+	tmuxRuntime, ok := rt.(*tmux.Runtime)
+	if !ok {
+		return fmt.Errorf("unexpected tmux runtime type %T", rt)
+	}
+
+	name, err := tmuxRuntime.Grid(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("failed to build grid: %w", err)
+	}
+
+	if gridOpts.attach {
+		// Attaching to a session lands on its pane in the grid
+		return sessionMgr.Attach(ctx, sessions[0].ID)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(map[string]interface{}{
+			"tmux_session": name,
+			"layout":       gridOpts.layout,
+			"sessions":     sessions,
+		})
+	}
+
+	ui.Success("Grid ready: %d sessions in tmux session %s", len(sessions), name)
+	ui.OutputLine("")
+	ui.OutputLine("Use 'tmux attach -t %s' to view it", name)
+	return nil
+}
@@ -29,6 +29,12 @@ func setupManagers() (*config.Manager, session.Manager, error) {
 	return configMgr, sessionMgr, nil
 }
 
+// SetupManager creates a session manager for the project, for use by
+// commands outside of the session command group
+func SetupManager(configMgr *config.Manager) session.Manager {
+	return getSessionManager(configMgr)
+}
+
 // getSessionManager creates a session manager for the project
 func getSessionManager(configMgr *config.Manager) session.Manager {
 	// Get runtimes
@@ -39,23 +45,24 @@ func getSessionManager(configMgr *config.Manager) session.Manager {
 		}
 	}
 
-	// Create task manager
-	taskMgr := task.NewManager()
-	// TODO: Load tasks from config
+	// Create task manager with the tasks defined in the config
+	taskMgr, err := configMgr.GetTaskManager()
+	if err != nil {
+		taskMgr = task.NewManager()
+	}
 
 	// Create session store
 	store := session.NewFileStore(configMgr.GetAmuxDir())
 
 	// Create workspace manager
 	// Note: workspace manager may fail in test environments without git
-	var workspaceMgr *workspace.Manager
-	workspaceMgr, wsErr := workspace.SetupManager(configMgr.GetProjectRoot())
-	if wsErr != nil {
-		// In tests or non-git environments, workspace manager might not be available
-		// Session manager will work with nil workspace manager but auto-workspace
-		// creation will not be available
-		workspaceMgr = nil
+	var workspaceMgr session.WorkspaceManager
+	if wsMgr, err := workspace.SetupManager(configMgr.GetProjectRoot()); err == nil {
+		workspaceMgr = wsMgr
 	}
+	// Otherwise, in tests or non-git environments, the session manager works
+	// without a workspace manager but auto-workspace creation and port
+	// allocation will not be available
 
 	// Create session manager
 	return session.NewManager(store, runtimes, taskMgr, workspaceMgr, configMgr)
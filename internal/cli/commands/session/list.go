@@ -113,10 +113,34 @@ func ListSessions(cmd *cobra.Command, args []string) error {
 	default:
 		displaySessions(sessions)
 	}
+	if listOpts.format != "json" {
+		displayWarnings(sessions)
+	}
 
 	return nil
 }
 
+// displayWarnings shows the warnings of running sessions below the table,
+// such as an exceeded disk quota or files also changed by other sessions in
+// the same workspace
+func displayWarnings(sessions []*session.Session) {
+	for _, s := range sessions {
+		if s.Status != session.StatusRunning && s.Status != session.StatusStarting {
+			continue
+		}
+		id := s.ShortID
+		if id == "" {
+			id = s.ID
+		}
+		if s.QuotaExceeded != nil {
+			ui.Warning("Session %s: %s", id, ui.FormatQuotaExceeded(s.QuotaExceeded))
+		}
+		for _, w := range s.Warnings {
+			ui.Warning("Session %s: %s", id, w)
+		}
+	}
+}
+
 // displaySessions shows sessions in a table format
 func displaySessions(sessions []*session.Session) {
 	// Prepare table data
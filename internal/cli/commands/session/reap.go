@@ -0,0 +1,71 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var reapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "Clean up orphaned proxy processes and sockets",
+	Long: `Clean up resources left behind by sessions that no longer exist.
+
+This terminates amux proxy processes whose session record has been removed
+and deletes output sockets that nothing is listening on any more.`,
+	Args: cobra.NoArgs,
+	RunE: ReapSessions,
+}
+
+var reapOpts struct {
+	dryRun bool
+}
+
+func init() {
+	reapCmd.Flags().BoolVar(&reapOpts.dryRun, "dry-run", false, "Show what would be cleaned up without doing it")
+}
+
+// ReapSessions implements the session reap command
+func ReapSessions(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	_, sessionMgr, err := setupManagers()
+	if err != nil {
+		return err
+	}
+
+	entries, err := sessionMgr.Reap(ctx, session.ReapOptions{DryRun: reapOpts.dryRun})
+	if err != nil {
+		return fmt.Errorf("failed to reap sessions: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(entries)
+	}
+
+	if len(entries) == 0 {
+		ui.Info("Nothing to reap")
+		return nil
+	}
+
+	tbl := ui.NewTable("KIND", "SESSION", "TARGET", "REASON", "RESULT")
+	for _, e := range entries {
+		target := e.Path
+		if e.Kind == session.ReapKindProcess {
+			target = fmt.Sprintf("pid %d", e.PID)
+		}
+		result := "would reap"
+		switch {
+		case e.Error != "":
+			result = "error: " + e.Error
+		case e.Reaped:
+			result = "reaped"
+		}
+		tbl.AddRow(string(e.Kind), e.SessionID, target, e.Reason, result)
+	}
+	tbl.Print()
+
+	return nil
+}
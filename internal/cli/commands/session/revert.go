@@ -0,0 +1,69 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <session-id>",
+	Short: "Undo what a session did to its workspace",
+	Long: `Restore a session's workspace to the state it was in when the session started.
+
+The branch, uncommitted changes and untracked files all go back to how they
+were; files created since are removed, while ignored files are kept. This also
+undoes changes made in the workspace by others after the session started.
+
+The state before the revert is saved as a workspace checkpoint, so a revert can
+be undone with 'amux ws rollback'.
+
+Running sessions, and sessions whose workspace is used by other running
+sessions, are only reverted with --force.`,
+	Args: cobra.ExactArgs(1),
+	RunE: RevertSession,
+}
+
+var revertOpts struct {
+	force bool
+}
+
+func init() {
+	revertCmd.Flags().BoolVarP(&revertOpts.force, "force", "f", false, "Revert even if sessions are running in the workspace")
+}
+
+// RevertSession implements the session revert command
+func RevertSession(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	sessionID := args[0]
+
+	// Setup managers with project root detection
+	_, sessionMgr, err := setupManagers()
+	if err != nil {
+		return err
+	}
+
+	sess, err := sessionMgr.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session '%s' not found. Run 'amux ps' to see active sessions", sessionID)
+	}
+
+	checkpoint, err := sessionMgr.Revert(ctx, sessionID, revertOpts.force)
+	if err != nil {
+		return fmt.Errorf("failed to revert session '%s': %w", sessionID, err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(map[string]interface{}{
+			"session":    sess.ID,
+			"workspace":  sess.WorkspaceID,
+			"checkpoint": checkpoint,
+		})
+	}
+
+	ui.Success("Reverted the changes of session %s", sess.ID)
+	ui.Info("The previous state was saved as checkpoint %s; undo with 'amux ws rollback %s %s'", checkpoint.ID, sess.WorkspaceID, checkpoint.ID)
+	return nil
+}
@@ -1,11 +1,14 @@
 package session
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aki/amux/internal/cli/ui"
 	"github.com/aki/amux/internal/runtime"
+	"github.com/aki/amux/internal/runtime/tmux"
 	"github.com/aki/amux/internal/session"
 	"github.com/aki/amux/internal/workspace"
 	"github.com/spf13/cobra"
@@ -32,13 +35,26 @@ Examples:
   # Run in a specific workspace
   amux session run --task dev --workspace myworkspace
 
+  # Run a dev server on a port of its own, passed as AMUX_PORT_WEB
+  amux session run --workspace myworkspace --port web -- sh -c 'npm run dev -- --port $AMUX_PORT_WEB'
+
   # Run with tmux runtime
-  amux session run --task dev --runtime tmux`,
+  amux session run --task dev --runtime tmux
+
+  # Start an agent configured under agents in .amux/config.yaml
+  amux session run --agent claude --workspace myworkspace
+
+  # Queue behind the session working in an exclusive workspace
+  amux session run --workspace refactor --wait -- claude
+
+  # Run side by side with other sessions in a shared tmux window
+  amux session run --runtime tmux --tmux-group best-of-3 -- claude`,
 	RunE: RunSession,
 }
 
 var runOpts struct {
 	task        string
+	agent       string
 	workspace   string
 	runtime     string
 	environment []string
@@ -47,10 +63,16 @@ var runOpts struct {
 	name        string
 	description string
 	enableLog   bool
+	tmuxGroup   string
+	tmuxLayout  string
+	ports       []string
+	wait        bool
+	waitTimeout time.Duration
 }
 
 func init() {
 	runCmd.Flags().StringVarP(&runOpts.task, "task", "t", "", "Task name to run")
+	runCmd.Flags().StringVar(&runOpts.agent, "agent", "", "Agent to run, with its command and runtime options from the configuration")
 	runCmd.Flags().StringVarP(&runOpts.workspace, "workspace", "w", "", "Workspace to run in")
 	runCmd.Flags().StringVarP(&runOpts.runtime, "runtime", "r", "local", "Runtime to use (local, local-detached, tmux)")
 	runCmd.Flags().StringArrayVarP(&runOpts.environment, "env", "e", nil, "Environment variables (KEY=VALUE)")
@@ -59,11 +81,17 @@ func init() {
 	runCmd.Flags().StringVarP(&runOpts.name, "name", "n", "", "Human-readable name for the session")
 	runCmd.Flags().StringVar(&runOpts.description, "description", "", "Description of session purpose")
 	runCmd.Flags().BoolVar(&runOpts.enableLog, "log", false, "Enable logging to file (default: false)")
+	runCmd.Flags().StringVar(&runOpts.tmuxGroup, "tmux-group", "", "Start as a pane in this shared tmux session (tmux runtime)")
+	runCmd.Flags().StringVar(&runOpts.tmuxLayout, "tmux-layout", "", "Layout of the shared tmux window (default: tiled)")
+	runCmd.Flags().StringArrayVar(&runOpts.ports, "port", nil, "Allocate a named workspace port, passed as AMUX_PORT_<NAME> (repeatable)")
+	runCmd.Flags().BoolVar(&runOpts.wait, "wait", false, "Wait for a free slot if the workspace's concurrency policy has none")
+	runCmd.Flags().DurationVar(&runOpts.waitTimeout, "wait-timeout", 0, "Give up waiting after this long (default: no limit)")
 }
 
 // BindRunFlags binds command flags to runOpts
 func BindRunFlags(cmd *cobra.Command) {
 	runOpts.task, _ = cmd.Flags().GetString("task")
+	runOpts.agent, _ = cmd.Flags().GetString("agent")
 	runOpts.workspace, _ = cmd.Flags().GetString("workspace")
 	runOpts.runtime, _ = cmd.Flags().GetString("runtime")
 	runOpts.environment, _ = cmd.Flags().GetStringArray("env")
@@ -72,6 +100,11 @@ func BindRunFlags(cmd *cobra.Command) {
 	runOpts.name, _ = cmd.Flags().GetString("name")
 	runOpts.description, _ = cmd.Flags().GetString("description")
 	runOpts.enableLog, _ = cmd.Flags().GetBool("log")
+	runOpts.tmuxGroup, _ = cmd.Flags().GetString("tmux-group")
+	runOpts.tmuxLayout, _ = cmd.Flags().GetString("tmux-layout")
+	runOpts.ports, _ = cmd.Flags().GetStringArray("port")
+	runOpts.wait, _ = cmd.Flags().GetBool("wait")
+	runOpts.waitTimeout, _ = cmd.Flags().GetDuration("wait-timeout")
 }
 
 // RunSession implements the session run command
@@ -89,8 +122,14 @@ func RunSession(cmd *cobra.Command, args []string) error {
 	} else if taskName == "" && len(args) > 0 {
 		// Direct command execution
 		command = args
-	} else if taskName == "" && len(args) == 0 {
-		return fmt.Errorf("either --task or command must be specified")
+	} else if taskName == "" && len(args) == 0 && runOpts.agent == "" {
+		return fmt.Errorf("either --task, --agent or command must be specified")
+	}
+
+	// Agents bring their own runtime unless one is asked for
+	runtimeName := runOpts.runtime
+	if runOpts.agent != "" && !cmd.Flags().Changed("runtime") {
+		runtimeName = ""
 	}
 
 	// Setup managers with project root detection
@@ -134,10 +173,12 @@ func RunSession(cmd *cobra.Command, args []string) error {
 
 	// Create runtime options based on runtime type
 	var runtimeOptions runtime.RuntimeOptions
-	// Currently, no runtime-specific options are needed
-
-	// For local runtime, show minimal messages
-	showDetailedInfo := runOpts.runtime != "local"
+	if runOpts.tmuxGroup != "" || runOpts.tmuxLayout != "" {
+		if runtimeName != "tmux" && (runtimeName != "" || runOpts.agent == "") {
+			return fmt.Errorf("--tmux-group and --tmux-layout require the tmux runtime")
+		}
+		runtimeOptions = tmux.Options{Group: runOpts.tmuxGroup, Layout: runOpts.tmuxLayout}
+	}
 
 	// Create session
 	sess, err := sessionMgr.Create(ctx, session.CreateOptions{
@@ -145,15 +186,23 @@ func RunSession(cmd *cobra.Command, args []string) error {
 		AutoCreateWorkspace: autoCreateWorkspace,
 		Name:                runOpts.name,
 		Description:         runOpts.description,
+		Agent:               runOpts.agent,
 		TaskName:            taskName,
 		Command:             command,
-		Runtime:             runOpts.runtime,
+		Runtime:             runtimeName,
 		Environment:         env,
 		WorkingDir:          runOpts.workingDir,
 		RuntimeOptions:      runtimeOptions,
 		EnableLog:           runOpts.enableLog,
+		Ports:               runOpts.ports,
+		WaitForWorkspace:    runOpts.wait || runOpts.waitTimeout > 0,
+		WaitTimeout:         runOpts.waitTimeout,
 	})
 	if err != nil {
+		var exceeded *workspace.ErrQuotaExceeded
+		if errors.As(err, &exceeded) {
+			return fmt.Errorf("cannot start session: %s", ui.FormatQuotaExceeded(exceeded))
+		}
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 
@@ -169,8 +218,16 @@ func RunSession(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Display session information based on runtime type
+	// Display session information based on runtime type; for the local
+	// runtime, show minimal messages
+	showDetailedInfo := sess.Runtime != "local"
 	ui.Success("Session started: %s", sess.ID)
+	if sess.QuotaExceeded != nil {
+		ui.Warning("%s", ui.FormatQuotaExceeded(sess.QuotaExceeded))
+	}
+	for _, warning := range sess.Warnings {
+		ui.Warning("%s", warning)
+	}
 
 	if showDetailedInfo {
 		// For detached runtimes, show full session information
@@ -190,7 +247,7 @@ func RunSession(cmd *cobra.Command, args []string) error {
 	}
 
 	// Provide appropriate feedback based on runtime
-	if runOpts.runtime == "local-detached" || runOpts.runtime == "tmux" {
+	if sess.Runtime == "local-detached" || sess.Runtime == "tmux" {
 		ui.OutputLine("")
 		ui.OutputLine("Running in detached mode")
 		ui.OutputLine("Use 'amux session ps' to view status")
@@ -22,7 +22,13 @@ func Command() *cobra.Command {
 	cmd.AddCommand(logsCmd)
 	cmd.AddCommand(watchCmd)
 	cmd.AddCommand(removeCmd)
+	cmd.AddCommand(diffCmd)
+	cmd.AddCommand(revertCmd)
+	cmd.AddCommand(filesCmd)
 	cmd.AddCommand(sendKeysCmd)
+	cmd.AddCommand(adoptCmd)
+	cmd.AddCommand(reapCmd)
+	cmd.AddCommand(gridCmd)
 	cmd.AddCommand(storage.Command())
 
 	return cmd
@@ -3,6 +3,8 @@ package session
 import (
 	"bytes"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -21,7 +23,7 @@ func TestSessionCommand(t *testing.T) {
 	}
 
 	// Check subcommands
-	subcommands := []string{"run", "list", "attach", "stop", "logs", "remove", "storage"}
+	subcommands := []string{"run", "list", "attach", "stop", "logs", "remove", "diff", "revert", "files", "storage"}
 	for _, subcmd := range subcommands {
 		found := false
 		for _, c := range cmd.Commands() {
@@ -105,6 +107,40 @@ func TestLogsCommandFlags(t *testing.T) {
 	}
 }
 
+// Test watch command flags
+func TestWatchCommandFlags(t *testing.T) {
+	for _, name := range []string{"workspace", "all", "filter"} {
+		if watchCmd.Flag(name) == nil {
+			t.Errorf("Expected --%s flag", name)
+		}
+	}
+}
+
+// Test prefixing and filtering of combined watch output
+func TestPrefixLines(t *testing.T) {
+	input := "building\r\n\x1b[31mERROR\x1b[0m: failed\nok\npartial"
+
+	var buf bytes.Buffer
+	if err := prefixLines(strings.NewReader(input), &lineWriter{w: &buf}, "[a] ", nil); err != nil {
+		t.Fatalf("prefixLines failed: %v", err)
+	}
+	expected := "[a] building\n[a] \x1b[31mERROR\x1b[0m: failed\x1b[0m\n[a] ok\n[a] partial\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	// Filters ignore escape sequences
+	buf.Reset()
+	filter := regexp.MustCompile(`^ERROR:`)
+	if err := prefixLines(strings.NewReader(input), &lineWriter{w: &buf}, "[a] ", filter); err != nil {
+		t.Fatalf("prefixLines failed: %v", err)
+	}
+	expected = "[a] \x1b[31mERROR\x1b[0m: failed\x1b[0m\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
 // Test command without amux initialization
 func TestCommandsNotInAmuxProject(t *testing.T) {
 	// Create temp directory without .amux
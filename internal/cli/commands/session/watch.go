@@ -1,63 +1,274 @@
 package session
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
-	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/runtime/proxy"
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/workspace"
 )
 
 var watchCmd = &cobra.Command{
-	Use:   "watch <session-id>",
-	Short: "Watch real-time output from a session",
-	Long:  `Watch real-time output from a session by connecting to its output socket.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  WatchSession,
+	Use:   "watch [session-id...]",
+	Short: "Watch real-time output from sessions",
+	Long: `Watch real-time output from sessions by connecting to their output sockets.
+
+A single session is streamed as is. When several sessions are watched, their
+output is combined line by line, each line prefixed with the session name.
+
+Examples:
+  # Watch one session
+  amux session watch 1
+
+  # Watch several sessions in one stream
+  amux session watch 1 3 5
+
+  # Watch all running sessions of a workspace, only showing errors
+  amux session watch --workspace feature-auth --filter '(?i)error'
+
+  # Watch every running session
+  amux session watch --all`,
+	RunE: WatchSession,
+}
+
+var watchOpts struct {
+	workspace string
+	all       bool
+	filter    string
 }
 
+func init() {
+	watchCmd.Flags().StringVarP(&watchOpts.workspace, "workspace", "w", "", "Watch all running sessions in a workspace")
+	watchCmd.Flags().BoolVarP(&watchOpts.all, "all", "a", false, "Watch all running sessions")
+	watchCmd.Flags().StringVar(&watchOpts.filter, "filter", "", "Only show lines matching this regular expression")
+}
+
+// watchColors are the colors cycled through for session prefixes
+var watchColors = []lipgloss.Color{"6", "3", "2", "5", "4", "1", "14", "11", "10", "13", "12", "9"}
+
+// ansiPattern matches terminal escape sequences, which are ignored by filters
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07]*\x07|\x1b[@-Z\\-_]`)
+
 // WatchSession implements the session watch command
 func WatchSession(cmd *cobra.Command, args []string) error {
-	sessionID := args[0]
+	ctx := cmd.Context()
+
+	if len(args) == 0 && !watchOpts.all && watchOpts.workspace == "" {
+		return fmt.Errorf("specify session IDs, --workspace or --all")
+	}
+
+	var filter *regexp.Regexp
+	if watchOpts.filter != "" {
+		re, err := regexp.Compile(watchOpts.filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+		filter = re
+	}
 
 	// Get managers
-	_, sessionMgr, err := setupManagers()
+	configMgr, sessionMgr, err := setupManagers()
 	if err != nil {
 		return err
 	}
 
-	// Get session info to find socket path
-	ctx := cmd.Context()
-	sess, err := sessionMgr.Get(ctx, sessionID)
+	sessions, err := watchedSessions(ctx, args, configMgr.GetProjectRoot(), sessionMgr)
 	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
+		return err
+	}
+	if len(sessions) == 0 {
+		return fmt.Errorf("no running sessions to watch")
+	}
+
+	// A single session is copied unchanged so that terminal output renders as is
+	if len(sessions) == 1 && len(args) == 1 && filter == nil {
+		conn, err := dialSession(sessions[0])
+		if err != nil {
+			return err
+		}
+		defer func() { _ = conn.Close() }()
+
+		// Copy output to stdout
+		_, err = io.Copy(os.Stdout, conn)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("error reading output: %w", err)
+		}
+		return nil
+	}
+
+	return watchMultiplexed(ctx, sessions, filter)
+}
+
+// watchedSessions resolves the sessions selected by arguments and flags
+func watchedSessions(ctx context.Context, args []string, projectRoot string, sessionMgr session.Manager) ([]*session.Session, error) {
+	var sessions []*session.Session
+	seen := make(map[string]bool)
+	add := func(sess *session.Session) {
+		if !seen[sess.ID] {
+			seen[sess.ID] = true
+			sessions = append(sessions, sess)
+		}
+	}
+
+	for _, id := range args {
+		sess, err := sessionMgr.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+		add(sess)
 	}
 
+	if watchOpts.all || watchOpts.workspace != "" {
+		// Sessions record the workspace as given when they were started,
+		// which may be its name or its ID
+		var workspaceIDs map[string]bool
+		if watchOpts.workspace != "" {
+			workspaceIDs = map[string]bool{watchOpts.workspace: true}
+			if wsMgr, err := workspace.SetupManager(projectRoot); err == nil {
+				if ws, err := wsMgr.ResolveWorkspace(ctx, workspace.Identifier(watchOpts.workspace)); err == nil {
+					workspaceIDs[ws.ID] = true
+					workspaceIDs[ws.Name] = true
+				}
+			}
+		}
+
+		list, err := sessionMgr.List(ctx, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		for _, sess := range list {
+			if sess.Status != session.StatusRunning {
+				continue
+			}
+			if workspaceIDs != nil && !workspaceIDs[sess.WorkspaceID] {
+				continue
+			}
+			add(sess)
+		}
+	}
+
+	return sessions, nil
+}
+
+// dialSession connects to the output socket of a session
+func dialSession(sess *session.Session) (net.Conn, error) {
 	// Get socket path from session info
 	socketPath := sess.SocketPath
 	if socketPath == "" {
 		// Fallback for old sessions without socket path
-		tmpDir := os.Getenv("TMPDIR")
-		if tmpDir == "" {
-			tmpDir = "/tmp"
-		}
-		socketPath = filepath.Join(tmpDir, fmt.Sprintf("amux-%s.sock", sessionID))
+		socketPath = proxy.SocketPath(sess.ID)
 	}
 
-	// Connect to socket
 	conn, err := net.Dial("unix", socketPath)
 	if err != nil {
-		return fmt.Errorf("failed to connect to session output: %w", err)
+		return nil, fmt.Errorf("failed to connect to session output: %w", err)
+	}
+	return conn, nil
+}
+
+// watchMultiplexed streams the output of several sessions as prefixed lines
+func watchMultiplexed(ctx context.Context, sessions []*session.Session, filter *regexp.Regexp) error {
+	names := make([]string, len(sessions))
+	width := 0
+	for i, sess := range sessions {
+		names[i] = sessionLabel(sess)
+		width = max(width, len(names[i]))
+	}
+
+	out := &lineWriter{w: os.Stdout}
+	var wg sync.WaitGroup
+	connected := 0
+	for i, sess := range sessions {
+		label := fmt.Sprintf("%-*s", width+2, "["+names[i]+"]")
+		prefix := lipgloss.NewStyle().Foreground(watchColors[i%len(watchColors)]).Render(label) + " "
+
+		conn, err := dialSession(sess)
+		if err != nil {
+			out.WriteLine(prefix, err.Error())
+			continue
+		}
+		connected++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { _ = conn.Close() }()
+
+			// Unblock the read when the command is interrupted
+			stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+			defer stop()
+
+			if err := prefixLines(conn, out, prefix, filter); err != nil && ctx.Err() == nil {
+				out.WriteLine(prefix, "error reading output: "+err.Error())
+			}
+		}()
 	}
-	defer func() { _ = conn.Close() }()
 
-	// Copy output to stdout
-	_, err = io.Copy(os.Stdout, conn)
-	if err != nil && err != io.EOF {
-		return fmt.Errorf("error reading output: %w", err)
+	if connected == 0 {
+		return fmt.Errorf("failed to connect to any session output")
 	}
 
+	wg.Wait()
 	return nil
 }
+
+// sessionLabel returns the name a session is shown as in combined output
+func sessionLabel(sess *session.Session) string {
+	if sess.Name != "" {
+		return sess.Name
+	}
+	if sess.ShortID != "" {
+		return sess.ShortID
+	}
+	return sess.ID
+}
+
+// prefixLines copies lines from r to w with a prefix, skipping lines which do
+// not match the filter. A trailing partial line is written when r ends.
+func prefixLines(r io.Reader, w *lineWriter, prefix string, filter *regexp.Regexp) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			line = strings.TrimRight(line, "\r\n")
+			if filter == nil || filter.MatchString(ansiPattern.ReplaceAllString(line, "")) {
+				w.WriteLine(prefix, line)
+			}
+		}
+		if err == io.EOF || errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lineWriter writes whole lines from several goroutines without interleaving
+type lineWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WriteLine writes a prefixed line, resetting terminal attributes left over
+// from the line so they do not bleed into the next prefix
+func (lw *lineWriter) WriteLine(prefix, line string) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if strings.Contains(line, "\x1b[") {
+		line += "\x1b[0m"
+	}
+	_, _ = fmt.Fprintf(lw.w, "%s%s\n", prefix, line)
+}
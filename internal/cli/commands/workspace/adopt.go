@@ -0,0 +1,109 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var adoptWorkspaceCmd = &cobra.Command{
+	Use:   "adopt <path>",
+	Short: "Register an existing git worktree as a workspace",
+	Long: `Register a git worktree created outside amux, for example with
+'git worktree add', as a workspace. The worktree is not moved; the workspace
+gets storage, an index and workspace_create hooks like any other.
+
+The workspace uses the worktree's branch, or is detached when the worktree
+has none. Removing an adopted workspace removes its worktree but keeps its
+branch. Use 'amux ws detach' to forget it again without touching anything.
+
+Examples:
+  # Adopt a worktree under its folder name
+  amux ws adopt ../repo-hotfix
+
+  # Adopt it under another name and base branch
+  amux ws adopt ../repo-hotfix --name hotfix --base release`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAdoptWorkspace,
+}
+
+var detachWorkspaceCmd = &cobra.Command{
+	Use:   "detach <workspace-name-or-id>",
+	Short: "Forget a workspace but keep its worktree",
+	Long: `Stop managing a workspace without removing its worktree or branch. The
+workspace metadata, storage, index, checkpoints and session snapshots are
+dropped; the worktree stays registered with git where it is, and can be
+adopted again with 'amux ws adopt'.
+
+Examples:
+  # Hand a worktree back to plain git
+  amux ws detach hotfix`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDetachWorkspace,
+}
+
+func runAdoptWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.Adopt(cmd.Context(), workspace.AdoptOptions{
+		Path:        args[0],
+		Name:        adoptName,
+		BaseBranch:  adoptBaseBranch,
+		Description: adoptDescription,
+		NoHooks:     adoptNoHooks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to adopt worktree: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(ws)
+	}
+
+	id := ws.ID
+	if ws.Index != "" {
+		id = ws.Index
+	}
+	ui.Success("Worktree adopted as workspace '%s'", ws.Name)
+	ui.OutputLine("")
+	ui.PrintKeyValue("ID", id)
+	ui.PrintKeyValue("Branch", ws.BranchLabel())
+	ui.PrintKeyValue("Path", ws.Path)
+	return nil
+}
+
+func runDetachWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.Detach(cmd.Context(), workspace.Identifier(args[0]), workspace.DetachOptions{
+		Force:   detachForce,
+		NoHooks: detachNoHooks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to detach workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(ws)
+	}
+	ui.Success("Workspace '%s' detached; its worktree remains at %s", ws.Name, ws.Path)
+	return nil
+}
@@ -0,0 +1,160 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var archiveWorkspaceCmd = &cobra.Command{
+	Use:   "archive <workspace-name-or-id>",
+	Short: "Archive a workspace instead of removing it",
+	Long: `Archive a workspace so it can be restored later.
+
+The workspace metadata, its storage directory and a patch of uncommitted
+changes (including untracked files) are packed into .amux/archive/<id>.tar.gz,
+and the worktree is removed. The branch and checkpoints are kept.
+
+Examples:
+  # Put a workspace aside
+  amux ws archive feature-auth
+
+  # Bring it back later
+  amux ws restore feature-auth`,
+	Args: cobra.ExactArgs(1),
+	RunE: runArchiveWorkspace,
+}
+
+var archivesWorkspaceCmd = &cobra.Command{
+	Use:   "archives",
+	Short: "List archived workspaces",
+	Args:  cobra.NoArgs,
+	RunE:  runArchivesWorkspace,
+}
+
+var restoreWorkspaceCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore an archived workspace",
+	Long: `Restore an archived workspace with the same ID, name, branch and storage.
+
+The archive can be given by workspace name, workspace ID or archive file. The
+worktree is checked out again, uncommitted changes are reapplied, files from
+workspace.copy are brought in and workspace_create hooks run. The archive is
+deleted once the workspace is back. A branch deleted since archiving is
+recreated at the commit it was archived at.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestoreWorkspace,
+}
+
+func runArchiveWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	info, err := manager.Archive(cmd.Context(), workspace.Identifier(args[0]), workspace.ArchiveOptions{
+		Force:      archiveForce,
+		NoHooks:    archiveNoHooks,
+		CurrentDir: cwd,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(info)
+	}
+	ui.Success("Workspace archived: %s (%s)", info.Name, info.ID)
+	ui.PrintKeyValue("Archive", fmt.Sprintf("%s (%s)", info.Path, ui.FormatSize(info.Size)))
+	if info.Branch != "" {
+		ui.PrintKeyValue("Branch", info.Branch+" (kept)")
+	}
+	if info.HasChanges {
+		ui.Info("Uncommitted changes were saved with the archive")
+	}
+	return nil
+}
+
+func runArchivesWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	archives, err := manager.ListArchives()
+	if err != nil {
+		return err
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(archives)
+	}
+	if len(archives) == 0 {
+		ui.OutputLine("No archived workspaces")
+		return nil
+	}
+
+	tbl := ui.NewTable("NAME", "BRANCH", "ARCHIVED", "SIZE", "CHANGES", "ID")
+	for _, a := range archives {
+		branch := a.Branch
+		if a.Detached {
+			branch = "(detached at " + shortHash(a.Head) + ")"
+		}
+		changes := "-"
+		if a.HasChanges {
+			changes = "uncommitted"
+		}
+		tbl.AddRow(a.Name, branch, ui.FormatTime(a.ArchivedAt), ui.FormatSize(a.Size), changes, a.ID)
+	}
+	ui.PrintSectionHeader("📦", "Archived workspaces", len(archives))
+	tbl.Print()
+	return nil
+}
+
+func runRestoreWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.Restore(cmd.Context(), args[0], workspace.RestoreOptions{NoHooks: restoreNoHooks})
+	if err != nil {
+		return fmt.Errorf("failed to restore workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(ws)
+	}
+	id := ws.ID
+	if ws.Index != "" {
+		id = ws.Index
+	}
+	ui.Success("Workspace restored successfully")
+	ui.OutputLine("")
+	ui.PrintKeyValue("ID", id)
+	ui.PrintKeyValue("Branch", ws.BranchLabel())
+	ui.PrintKeyValue("Path", ws.Path)
+	return nil
+}
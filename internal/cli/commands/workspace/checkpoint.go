@@ -0,0 +1,180 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var checkpointWorkspaceCmd = &cobra.Command{
+	Use:   "checkpoint <workspace-name-or-id>",
+	Short: "Record the current state of a workspace",
+	Long: `Record the current state of a workspace's worktree as a checkpoint.
+
+A checkpoint includes uncommitted changes and untracked files (but not ignored
+files). It is stored as a hidden git ref and does not touch the workspace's
+branch, index or files. Roll back to it with 'amux ws rollback'.
+
+With --auto, a checkpoint is also taken before each session starts in the
+workspace; --auto=false turns that off again.
+
+Examples:
+  # Checkpoint before a risky change
+  amux ws checkpoint feature-auth -m "before splitting the auth module"
+
+  # Checkpoint automatically before every session
+  amux ws checkpoint feature-auth --auto`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheckpointWorkspace,
+}
+
+var checkpointsWorkspaceCmd = &cobra.Command{
+	Use:   "checkpoints <workspace-name-or-id>",
+	Short: "List the checkpoints of a workspace",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCheckpointsWorkspace,
+}
+
+var rollbackWorkspaceCmd = &cobra.Command{
+	Use:   "rollback <workspace-name-or-id> <checkpoint>",
+	Short: "Restore a workspace to a checkpoint",
+	Long: `Restore a workspace's worktree to a checkpoint.
+
+Files are put back exactly as they were when the checkpoint was taken, and the
+branch is reset to the commit it was at. Untracked files created since then are
+removed; ignored files are kept. The current state is checkpointed first, so
+the rollback can be undone by rolling back to that checkpoint.
+
+Examples:
+  # Roll back to checkpoint 2
+  amux ws rollback feature-auth 2`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRollbackWorkspace,
+}
+
+func runCheckpointWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	identifier := workspace.Identifier(args[0])
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	if cmd.Flags().Changed("auto") {
+		if err := manager.SetAutoCheckpoint(ctx, identifier, checkpointAuto); err != nil {
+			return fmt.Errorf("failed to update workspace: %w", err)
+		}
+		if !checkpointAuto {
+			if ui.GlobalFormatter.IsJSON() {
+				return ui.GlobalFormatter.Output(map[string]interface{}{
+					"workspace":      ws.ID,
+					"autoCheckpoint": false,
+				})
+			}
+			ui.Success("Automatic checkpoints turned off for workspace %s", ws.Name)
+			return nil
+		}
+	}
+
+	cp, err := manager.Checkpoint(ctx, identifier, checkpointMessage)
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(cp)
+	}
+	ui.Success("Checkpoint %s of workspace %s: %s", cp.ID, ws.Name, cp.Message)
+	if cmd.Flags().Changed("auto") {
+		ui.Info("A checkpoint will be taken before each session starts in %s", ws.Name)
+	}
+	return nil
+}
+
+func runCheckpointsWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(ctx, workspace.Identifier(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	checkpoints, err := manager.ListCheckpoints(ctx, workspace.Identifier(ws.ID))
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(checkpoints)
+	}
+
+	if len(checkpoints) == 0 {
+		ui.Info("No checkpoints in workspace %s", ws.Name)
+		return nil
+	}
+
+	ui.PrintSectionHeader("📍", fmt.Sprintf("Checkpoints of %s", ws.Name), len(checkpoints))
+	tbl := ui.NewTable("ID", "CREATED", "HEAD", "MESSAGE")
+	for _, cp := range checkpoints {
+		tbl.AddRow(cp.ID, ui.FormatTime(cp.CreatedAt), shortHash(cp.Head), cp.Message)
+	}
+	tbl.Print()
+	return nil
+}
+
+func runRollbackWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(ctx, workspace.Identifier(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	safety, err := manager.Rollback(ctx, workspace.Identifier(ws.ID), args[1], rollbackForce)
+	if err != nil {
+		return fmt.Errorf("failed to roll back workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(map[string]interface{}{
+			"workspace":  ws.ID,
+			"checkpoint": args[1],
+			"saved":      safety,
+		})
+	}
+	ui.Success("Rolled back workspace %s to checkpoint %s", ws.Name, args[1])
+	ui.Info("The previous state was saved as checkpoint %s; roll back to it to undo", safety.ID)
+	return nil
+}
@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -26,7 +27,40 @@ Examples:
   amux ws create fix-auth -c feature/existing-work
 
   # Create workspace with new branch from specific base
-  amux ws create fix-auth --base develop -b feature/auth-fix`,
+  amux ws create fix-auth --base develop -b feature/auth-fix
+
+  # Create workspace from a tag or commit
+  amux ws create hotfix --from v1.4.0
+
+  # Create workspace tracking a remote branch (fetched if needed)
+  amux ws create review --from origin/feature/login
+
+  # Create a detached workspace to investigate an old release
+  amux ws create bisect --from v1.2.0 --detach
+
+  # Tag the workspace with labels and metadata for filtering
+  amux ws create fix-auth -l bug -l auth --meta issue=142 --meta owner=ana
+
+  # Let only one session work in the workspace at a time
+  amux ws create refactor --concurrency exclusive
+
+  # Check out only two directories of a large monorepo
+  amux ws create api-fix --sparse services/api,libs/common
+
+  # Show the files workspace.copy would bring in, without creating anything
+  amux ws create fix-auth --dry-run
+
+Files that git doesn't track, such as .env or node_modules, can be brought into
+new workspaces from the main checkout with the workspace.copy section of
+.amux/config.yaml. Each entry is a glob pattern relative to the project root and
+a mode: copy (default), symlink, hardlink or reflink. This happens before the
+workspace_create hooks run:
+
+  workspace:
+    copy:
+      - pattern: .env*
+      - pattern: node_modules
+        mode: symlink`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCreateWorkspace,
 }
@@ -38,6 +72,20 @@ func runCreateWorkspace(cmd *cobra.Command, args []string) error {
 	if createBranch != "" && createCheckout != "" {
 		return fmt.Errorf("cannot specify both --branch (-b) and --checkout (-c) flags")
 	}
+	if createDetach && (createBranch != "" || createCheckout != "") {
+		return fmt.Errorf("cannot specify --detach with --branch (-b) or --checkout (-c)")
+	}
+	if createFrom != "" && createCheckout != "" {
+		return fmt.Errorf("cannot specify both --from and --checkout (-c) flags")
+	}
+
+	metadata, unset, err := workspace.ParseMetadata(createMeta)
+	if err != nil {
+		return err
+	}
+	if len(unset) > 0 {
+		return fmt.Errorf("--meta expects key=value, got '%s-'", unset[0])
+	}
 
 	projectRoot, err := config.FindProjectRoot()
 	if err != nil {
@@ -52,7 +100,12 @@ func runCreateWorkspace(cmd *cobra.Command, args []string) error {
 		Name:        name,
 		BaseBranch:  createBaseBranch,
 		Description: createDescription,
+		Labels:      createLabels,
+		Metadata:    metadata,
 		BranchMode:  workspace.BranchModeCreate, // Default to create mode
+		From:        createFrom,
+		Concurrency: createConcurrency,
+		Sparse:      createSparse,
 		NoHooks:     createNoHooks,
 	}
 
@@ -63,6 +116,20 @@ func runCreateWorkspace(cmd *cobra.Command, args []string) error {
 	} else if createCheckout != "" {
 		opts.Branch = createCheckout
 		opts.BranchMode = workspace.BranchModeCheckout // Explicit: use existing branch
+	} else if createDetach {
+		opts.BranchMode = workspace.BranchModeDetach // No branch at all
+	}
+
+	if createDryRun {
+		plan, err := manager.PlanCreate(opts)
+		if err != nil {
+			return fmt.Errorf("cannot create workspace: %w", err)
+		}
+		if ui.GlobalFormatter.IsJSON() {
+			return ui.GlobalFormatter.Output(plan)
+		}
+		printCreatePlan(plan)
+		return nil
 	}
 
 	ws, err := manager.Create(cmd.Context(), opts)
@@ -77,8 +144,75 @@ func runCreateWorkspace(cmd *cobra.Command, args []string) error {
 	ui.Success("Workspace created successfully")
 	ui.OutputLine("")
 	ui.PrintKeyValue("ID", id)
-	ui.PrintKeyValue("Branch", ws.Branch)
+	ui.PrintKeyValue("Branch", ws.BranchLabel())
 	ui.PrintKeyValue("Path", ws.Path)
+	if ws.From != "" && !ws.Detached {
+		ui.PrintKeyValue("From", ws.From)
+	}
+	if len(ws.Labels) > 0 {
+		ui.PrintKeyValue("Labels", strings.Join(ws.Labels, ", "))
+	}
+	if len(ws.Sparse) > 0 {
+		ui.PrintKeyValue("Sparse", strings.Join(ws.Sparse, ", "))
+	}
+	if files, size := copyTotals(ws.Copied); files > 0 {
+		ui.PrintKeyValue("Copied", fmt.Sprintf("%d file(s), %s", files, ui.FormatSize(size)))
+	}
 
 	return nil
 }
+
+// printCreatePlan prints what creating a workspace would do
+func printCreatePlan(plan *workspace.CreatePlan) {
+	branch := plan.Branch
+	switch {
+	case plan.Detached:
+		branch = "(detached)"
+	case branch == "":
+		branch = "amux/<workspace-id>"
+	case plan.Upstream != "":
+		branch += " (tracking " + plan.Upstream + ")"
+	}
+	ui.Info("Dry run: no workspace was created")
+	ui.OutputLine("")
+	ui.PrintKeyValue("Name", plan.Name)
+	ui.PrintKeyValue("Branch", branch)
+	ui.PrintKeyValue("Base", plan.BaseBranch)
+	switch {
+	case plan.NeedsFetch:
+		ui.PrintKeyValue("From", plan.From+" (needs fetch)")
+	case plan.From != "":
+		ui.PrintKeyValue("From", fmt.Sprintf("%s (%s)", plan.From, shortHash(plan.Commit)))
+	}
+	if len(plan.Sparse) > 0 {
+		ui.PrintKeyValue("Sparse", strings.Join(plan.Sparse, ", "))
+	}
+
+	if len(plan.Copy) == 0 {
+		ui.OutputLine("")
+		ui.OutputLine("No files to copy (see workspace.copy in .amux/config.yaml)")
+		return
+	}
+
+	ui.OutputLine("")
+	tbl := ui.NewTable("PATH", "MODE", "FILES", "SIZE", "NOTE")
+	for _, e := range plan.Copy {
+		tbl.AddRow(e.Path, e.Mode, e.Files, ui.FormatSize(e.Size), e.Skipped)
+	}
+	tbl.Print()
+
+	files, size := copyTotals(plan.Copy)
+	ui.OutputLine("")
+	ui.OutputLine("%d file(s), %s would be brought in", files, ui.FormatSize(size))
+}
+
+// copyTotals sums the files and bytes of the entries that are brought in
+func copyTotals(entries []workspace.CopyEntry) (files int, size int64) {
+	for _, e := range entries {
+		if e.Skipped == "" {
+			files += e.Files
+			size += e.Size
+		}
+	}
+	return files, size
+}
@@ -0,0 +1,131 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var diffWorkspaceCmd = &cobra.Command{
+	Use:   "diff <workspace-name-or-id> [path...]",
+	Short: "Show what changed in a workspace",
+	Long: `Show what changed in a workspace relative to its base branch.
+
+Changes are compared against the point where the workspace branch forked from
+its base, and include commits on the branch as well as uncommitted and
+untracked files in the worktree.
+
+Examples:
+  # Summarize changed files
+  amux ws diff feature-auth
+
+  # List changed file names
+  amux ws diff feature-auth --name-only
+
+  # Show the full patch of some files
+  amux ws diff feature-auth --patch src/auth.go`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runDiffWorkspace,
+}
+
+func runDiffWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	identifier := workspace.Identifier(args[0])
+	paths := args[1:]
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	if diffPatch {
+		patch, err := manager.DiffPatch(ctx, identifier, paths...)
+		if err != nil {
+			return fmt.Errorf("failed to diff workspace: %w", err)
+		}
+		if ui.GlobalFormatter.IsJSON() {
+			return ui.GlobalFormatter.Output(map[string]string{"patch": patch})
+		}
+		ui.Raw(patch)
+		return nil
+	}
+
+	summary, err := manager.Diff(ctx, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to diff workspace: %w", err)
+	}
+	summary.Filter(paths...)
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(summary)
+	}
+
+	if diffNameOnly {
+		for _, f := range summary.Files {
+			ui.OutputLine("%s", f.Path)
+		}
+		return nil
+	}
+
+	printDiffStat(summary)
+	return nil
+}
+
+// printDiffStat prints the changed files with their line counts
+func printDiffStat(summary *git.DiffSummary) {
+	ui.PrintKeyValue("Base", fmt.Sprintf("%s (%s)", summary.Base, shortHash(summary.MergeBase)))
+	ui.PrintKeyValue("Commits", fmt.Sprintf("%d ahead, %d behind", summary.Ahead, summary.Behind))
+
+	if len(summary.Files) == 0 {
+		ui.OutputLine("")
+		ui.Info("No changes")
+		return
+	}
+
+	ui.OutputLine("")
+	tbl := ui.NewTable("FILE", "STATUS", "CHANGES", "STATE")
+	for _, f := range summary.Files {
+		name := f.Path
+		if f.OldPath != "" {
+			name = fmt.Sprintf("%s → %s", f.OldPath, f.Path)
+		}
+		changes := ui.FormatChanges(f.Insertions, f.Deletions)
+		if f.Binary {
+			changes = ui.DimStyle.Render("binary")
+		}
+		tbl.AddRow(name, string(f.Status), changes, fileState(f))
+	}
+	tbl.Print()
+
+	ui.OutputLine("")
+	ui.OutputLine("%d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)", len(summary.Files), summary.Insertions, summary.Deletions)
+}
+
+// fileState describes whether a change is committed, uncommitted or both
+func fileState(f git.FileChange) string {
+	switch {
+	case f.Committed && f.Uncommitted:
+		return "committed + uncommitted"
+	case f.Committed:
+		return "committed"
+	default:
+		return ui.WarningStyle.Render("uncommitted")
+	}
+}
+
+// shortHash abbreviates a commit hash
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,124 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var labelWorkspaceCmd = &cobra.Command{
+	Use:   "label <workspace-name-or-id> [label...]",
+	Short: "Add or remove workspace labels",
+	Long: `Add labels to a workspace, or remove them with --remove.
+
+Labels are short tags such as bug or needs-review. Without labels to change,
+the current labels are shown. Use 'amux ws list --label' to filter by them.
+
+Examples:
+  # Label a workspace
+  amux ws label fix-auth bug urgent
+
+  # Swap one label for another
+  amux ws label fix-auth needs-review -r urgent`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runLabelWorkspace,
+}
+
+var annotateWorkspaceCmd = &cobra.Command{
+	Use:   "annotate <workspace-name-or-id> [key=value|key-]...",
+	Short: "Set or unset workspace metadata",
+	Long: `Set free-form key/value metadata on a workspace, such as the issue it
+addresses, its owner or the agent working in it. A trailing dash unsets a key.
+Without entries to change, the current metadata is shown.
+
+Examples:
+  # Record the issue and owner
+  amux ws annotate fix-auth issue=142 owner=ana
+
+  # Drop the owner
+  amux ws annotate fix-auth owner-`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAnnotateWorkspace,
+}
+
+func runLabelWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	identifier := workspace.Identifier(args[0])
+	var ws *workspace.Workspace
+	if len(args) == 1 && len(labelRemove) == 0 {
+		ws, err = manager.ResolveWorkspace(cmd.Context(), identifier)
+	} else {
+		ws, err = manager.SetLabels(cmd.Context(), identifier, args[1:], labelRemove)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to label workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(ws.Labels)
+	}
+	if len(ws.Labels) == 0 {
+		ui.OutputLine("Workspace '%s' has no labels", ws.Name)
+		return nil
+	}
+	ui.PrintKeyValue("Labels", strings.Join(ws.Labels, ", "))
+	return nil
+}
+
+func runAnnotateWorkspace(cmd *cobra.Command, args []string) error {
+	set, unset, err := workspace.ParseMetadata(args[1:])
+	if err != nil {
+		return err
+	}
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	identifier := workspace.Identifier(args[0])
+	var ws *workspace.Workspace
+	if len(args) == 1 {
+		ws, err = manager.ResolveWorkspace(cmd.Context(), identifier)
+	} else {
+		ws, err = manager.SetMetadata(cmd.Context(), identifier, set, unset)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to annotate workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(ws.Metadata)
+	}
+	if len(ws.Metadata) == 0 {
+		ui.OutputLine("Workspace '%s' has no metadata", ws.Name)
+		return nil
+	}
+	keys := make([]string, 0, len(ws.Metadata))
+	for key := range ws.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ui.PrintKeyValue(key, ws.Metadata[key])
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -20,9 +21,18 @@ Examples:
   # List workspaces with detailed view
   amux ws ls
 
+  # Also show how much disk space each workspace takes
+  amux ws ls --format wide
+
   # List workspaces in oneline format for scripting
   amux ws ls --oneline
 
+  # List workspaces labelled bug whose branch starts with feature/
+  amux ws ls -l bug --branch 'feature/*'
+
+  # List workspaces without running sessions, least recently modified first
+  amux ws ls --in-use=false --sort updated --reverse
+
   # Use with fzf to select a workspace
   amux ws ls --oneline | fzf | cut -f1
 
@@ -41,10 +51,22 @@ func runListWorkspace(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	workspaces, err := manager.List(cmd.Context(), workspace.ListOptions{})
+	opts, err := listOptions(cmd)
+	if err != nil {
+		return err
+	}
+	// The oneline format is used for quick selection, so skip the per-workspace git calls
+	opts.IncludeChanges = !listOneline
+
+	workspaces, err := manager.List(cmd.Context(), opts)
 	if err != nil {
 		return fmt.Errorf("failed to list workspaces: %w", err)
 	}
+	if ui.GlobalFormatter.IsWide() && !listOneline {
+		if err := measureUsage(manager, projectRoot, workspaces); err != nil {
+			return err
+		}
+	}
 
 	// Handle JSON output
 	if ui.GlobalFormatter.IsJSON() {
@@ -65,7 +87,7 @@ func runListWorkspace(cmd *cobra.Command, args []string) error {
 			if ws.Index != "" {
 				id = ws.Index
 			}
-			ui.PrintTSV([][]string{{ws.Name, id, ws.Branch, ws.Status.String(), ws.Path, description}})
+			ui.PrintTSV([][]string{{ws.Name, id, ws.BranchLabel(), ws.Status.String(), ws.Path, description}})
 		}
 	} else {
 		ui.PrintWorkspaceList(workspaces)
@@ -73,3 +95,48 @@ func runListWorkspace(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// listOptions builds the list filters and sort order from the flags
+func listOptions(cmd *cobra.Command) (workspace.ListOptions, error) {
+	opts := workspace.ListOptions{
+		Labels:  listLabels,
+		Branch:  listBranch,
+		SortBy:  listSort,
+		Reverse: listReverse,
+	}
+
+	for _, s := range listStatus {
+		status, err := workspace.ParseConsistencyStatus(s)
+		if err != nil {
+			return opts, err
+		}
+		opts.Statuses = append(opts.Statuses, status)
+	}
+
+	if len(listMeta) > 0 {
+		opts.Metadata = make(map[string]string, len(listMeta))
+		for _, entry := range listMeta {
+			key, value, _ := strings.Cut(entry, "=")
+			opts.Metadata[key] = value
+		}
+	}
+
+	var err error
+	if listOlderThan != "" {
+		if opts.OlderThan, err = workspace.ParseAge(listOlderThan); err != nil {
+			return opts, err
+		}
+	}
+	if listNewerThan != "" {
+		if opts.NewerThan, err = workspace.ParseAge(listNewerThan); err != nil {
+			return opts, err
+		}
+	}
+
+	if cmd.Flags().Changed("in-use") {
+		inUse := listInUse
+		opts.InUse = &inUse
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,126 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var mergeWorkspaceCmd = &cobra.Command{
+	Use:   "merge <workspace-name-or-id>",
+	Short: "Merge a workspace into its base branch",
+	Long: `Merge a workspace branch into the base branch it was created from.
+
+The merge happens in the main repository where the base branch is checked out,
+or in a temporary worktree if it is not checked out anywhere. Workspaces used
+by running sessions or with uncommitted changes are not merged. If the merge
+would conflict, it is undone and the conflicting files are listed; sync the
+workspace with 'amux ws sync' to resolve them there.
+
+The workspace_pre_merge and workspace_post_merge hooks run before and after the
+merge. A pre-merge hook with on_error: fail stops the merge.
+
+Examples:
+  # Merge, fast-forwarding when possible
+  amux ws merge feature-auth
+
+  # Squash into one commit and remove the workspace afterwards
+  amux ws merge feature-auth --squash -m "Add OAuth login" --remove
+
+  # Always create a merge commit
+  amux ws merge feature-auth --no-ff`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMergeWorkspace,
+}
+
+func runMergeWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(ctx, workspace.Identifier(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	// Get current working directory for the removal safety check
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	opts := workspace.MergeOptions{
+		Message:    mergeMessage,
+		Remove:     mergeRemove,
+		Force:      mergeForce,
+		NoHooks:    mergeNoHooks,
+		CurrentDir: cwd,
+	}
+	switch {
+	case mergeSquash:
+		opts.Mode = git.MergeSquash
+	case mergeFFOnly:
+		opts.Mode = git.MergeFastForwardOnly
+	case mergeNoFF:
+		opts.Mode = git.MergeNoFastForward
+	}
+
+	result, err := manager.Merge(ctx, workspace.Identifier(ws.ID), opts)
+	if err != nil {
+		return fmt.Errorf("failed to merge workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		if err := ui.GlobalFormatter.Output(result); err != nil {
+			return err
+		}
+	} else {
+		printMergeResult(ws, result)
+	}
+
+	if len(result.Conflicts) > 0 {
+		return fmt.Errorf("merge of %s into %s would conflict", ws.Name, result.Base)
+	}
+	return nil
+}
+
+// printMergeResult reports the outcome of a merge
+func printMergeResult(ws *workspace.Workspace, result *git.MergeResult) {
+	if len(result.Conflicts) > 0 {
+		ui.Warning("Merging %s into %s would conflict; nothing was merged", ws.Name, result.Base)
+		ui.OutputLine("")
+		tbl := ui.NewTable("FILE", "CONFLICT")
+		for _, c := range result.Conflicts {
+			tbl.AddRow(c.Path, c.Kind)
+		}
+		tbl.Print()
+		ui.OutputLine("")
+		ui.Info("Run 'amux ws sync %s' to resolve the conflicts in the workspace, then merge again", ws.Name)
+		return
+	}
+
+	how := "merge commit"
+	switch {
+	case result.Mode == git.MergeSquash:
+		how = "squashed"
+	case result.FastForward:
+		how = "fast-forward"
+	}
+	ui.Success("Merged workspace %s into %s (%d commit(s), %s) at %s", ws.Name, result.Base, result.Commits, how, shortHash(result.Commit))
+	if mergeRemove {
+		ui.Success("Workspace removed: %s (%s)", ws.Name, ws.ID)
+	}
+}
@@ -0,0 +1,78 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var portsWorkspaceCmd = &cobra.Command{
+	Use:   "ports <workspace-name-or-id> [port-name...]",
+	Short: "Show the ports allocated to a workspace",
+	Long: `Show the ports allocated to a workspace.
+
+Tasks and agents declare named ports (e.g. ports: [web, api]). Each workspace
+gets its own free port for every name, passed to sessions as AMUX_PORT_<NAME>.
+Naming ports on the command line allocates them right away.
+
+Examples:
+  # Show the ports of a workspace
+  amux ws ports feature-auth
+
+  # Allocate a port before starting anything
+  amux ws ports feature-auth web`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPortsWorkspace,
+}
+
+func runPortsWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(ctx, workspace.Identifier(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	ports := ws.Ports
+	if len(args) > 1 {
+		ports, err = manager.AllocatePorts(ctx, workspace.Identifier(ws.ID), args[1:])
+		if err != nil {
+			return fmt.Errorf("failed to allocate ports: %w", err)
+		}
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(map[string]interface{}{
+			"workspace": ws.ID,
+			"ports":     ports,
+			"env":       workspace.PortEnvironment(ports),
+		})
+	}
+
+	if len(ports) == 0 {
+		ui.Info("No ports allocated to workspace %s", ws.Name)
+		return nil
+	}
+
+	ui.PrintSectionHeader("", fmt.Sprintf("Ports of %s", ws.Name), len(ports))
+	tbl := ui.NewTable("NAME", "PORT", "ENV")
+	for _, name := range workspace.PortNames(ports) {
+		tbl.AddRow(name, ports[name], workspace.PortEnvName(name))
+	}
+	tbl.Print()
+
+	return nil
+}
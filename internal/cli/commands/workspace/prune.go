@@ -1,19 +1,46 @@
 package workspace
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	sessioncmd "github.com/aki/amux/internal/cli/commands/session"
 	"github.com/aki/amux/internal/cli/ui"
 	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/session"
 	"github.com/aki/amux/internal/workspace"
 )
 
 var pruneWorkspaceCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "Remove old idle workspaces",
-	RunE:  runPruneWorkspace,
+	Long: `Remove workspaces that meet all of the given criteria.
+
+By default, workspaces idle for more than 7 days are pruned. Further criteria
+narrow this down:
+  --merged          the branch is merged into the base branch (squash merges count)
+  --no-ahead        the branch has no commits ahead of the base branch
+  --session-idle N  no session activity in the last N days
+  --auto-created    the workspace was created automatically for a session
+  --status S        the workspace has status S (repeatable)
+
+Use --days 0 to ignore idle time. --merged and --no-ahead keep workspaces with
+uncommitted changes. Workspaces with running sessions are never pruned. The plan is printed before anything is removed.
+
+With --archive, or with 'prune: archive' under workspace in .amux/config.yaml,
+workspaces are archived instead and can be brought back with 'amux ws restore'.
+
+Examples:
+  # Clean up workspaces whose work has landed
+  amux ws prune --days 0 --merged
+
+  # See which session workspaces have been abandoned for two weeks
+  amux ws prune --auto-created --session-idle 14 --dry-run`,
+	RunE: runPruneWorkspace,
 }
 
 func runPruneWorkspace(cmd *cobra.Command, args []string) error {
@@ -26,30 +53,136 @@ func runPruneWorkspace(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	archive := pruneArchive
+	if !cmd.Flags().Changed("archive") {
+		archive = manager.ArchiveOnPrune()
+	}
+
 	opts := workspace.CleanupOptions{
-		Days:   pruneDays,
-		DryRun: pruneDryRun,
+		Days:            pruneDays,
+		DryRun:          pruneDryRun,
+		Archive:         archive,
+		Merged:          pruneMerged,
+		NoAhead:         pruneNoAhead,
+		SessionIdleDays: pruneSessionIdle,
+		AutoCreatedOnly: pruneAutoCreated,
+	}
+	for _, s := range pruneStatus {
+		status, err := workspace.ParseConsistencyStatus(s)
+		if err != nil {
+			return err
+		}
+		opts.Statuses = append(opts.Statuses, status)
+	}
+	opts.Sessions, err = sessionActivity(cmd.Context(), config.NewManager(projectRoot), manager)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	removed, err := manager.Cleanup(cmd.Context(), opts)
+	plan, err := manager.PlanCleanup(cmd.Context(), opts)
 	if err != nil {
 		return fmt.Errorf("failed to prune workspaces: %w", err)
 	}
 
-	if len(removed) == 0 {
+	if pruneDryRun && ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(plan)
+	}
+	if len(plan) == 0 {
+		if ui.GlobalFormatter.IsJSON() {
+			return ui.GlobalFormatter.Output([]string{})
+		}
 		ui.OutputLine("No workspaces to prune")
 		return nil
 	}
 
+	if !ui.GlobalFormatter.IsJSON() {
+		printPrunePlan(plan)
+	}
 	if pruneDryRun {
-		ui.OutputLine("Would remove %d workspace(s):", len(removed))
-	} else {
-		ui.OutputLine("Removed %d workspace(s)", len(removed))
+		return nil
 	}
 
-	for _, id := range removed {
-		ui.OutputLine("  - %s", id)
+	done := manager.ApplyCleanup(cmd.Context(), plan)
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(done)
 	}
 
+	ui.OutputLine("")
+	ui.Success("Pruned %d workspace(s)", len(done))
 	return nil
 }
+
+// sessionActivity summarizes the sessions of each workspace
+func sessionActivity(ctx context.Context, configMgr *config.Manager, manager *workspace.Manager) (map[string]workspace.SessionActivity, error) {
+	if !configMgr.IsInitialized() {
+		return nil, nil
+	}
+	sessions, err := sessioncmd.SetupManager(configMgr).List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := manager.List(ctx, workspace.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return summarizeSessions(sessions, workspaces), nil
+}
+
+// summarizeSessions sums up session activity by workspace ID. Sessions record
+// the workspace as given when they were started, which may be its name or
+// index.
+func summarizeSessions(sessions []*session.Session, workspaces []*workspace.Workspace) map[string]workspace.SessionActivity {
+	// Later identifiers win, matching the precedence of ResolveWorkspace
+	ids := make(map[string]string)
+	for _, identifier := range []func(*workspace.Workspace) string{
+		func(ws *workspace.Workspace) string { return ws.Name },
+		func(ws *workspace.Workspace) string { return ws.Index },
+		func(ws *workspace.Workspace) string { return ws.ID },
+	} {
+		for _, ws := range workspaces {
+			if id := identifier(ws); id != "" {
+				ids[id] = ws.ID
+			}
+		}
+	}
+
+	activity := make(map[string]workspace.SessionActivity)
+	for _, s := range sessions {
+		id, ok := ids[s.WorkspaceID]
+		if !ok {
+			// Sessions of removed workspaces
+			id = s.WorkspaceID
+		}
+		a := activity[id]
+		if s.Status == session.StatusRunning || s.Status == session.StatusStarting {
+			a.Running++
+		}
+		for _, t := range []time.Time{s.StartedAt, s.LastActivityAt} {
+			if t.After(a.LastActive) {
+				a.LastActive = t
+			}
+		}
+		if s.StoppedAt != nil && s.StoppedAt.After(a.LastActive) {
+			a.LastActive = *s.StoppedAt
+		}
+		activity[id] = a
+	}
+	return activity
+}
+
+// printPrunePlan shows what prune will do with each workspace
+func printPrunePlan(plan []*workspace.PruneCandidate) {
+	tbl := ui.NewTable("NAME", "BRANCH", "STATUS", "ACTION", "REASONS")
+	pending := 0
+	for _, c := range plan {
+		action := c.Action
+		if c.Blocked != "" {
+			action = "skip (" + c.Blocked + ")"
+		} else {
+			pending++
+		}
+		tbl.AddRow(c.Workspace.Name, c.Workspace.BranchLabel(), c.Workspace.Status.String(), action, strings.Join(c.Reasons, ", "))
+	}
+	ui.PrintSectionHeader("🧹", "Prune plan", pending)
+	tbl.Print()
+}
@@ -0,0 +1,56 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestSummarizeSessions(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	require.NoError(t, configManager.Save(config.DefaultConfig()))
+	manager, err := workspace.NewManager(configManager)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "feature"})
+	require.NoError(t, err)
+	workspaces, err := manager.List(ctx, workspace.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, workspaces, 1)
+
+	started := time.Now().Add(-time.Hour)
+	stopped := time.Now().Add(-time.Minute)
+	sessions := []*session.Session{
+		// Started with 'session run -w feature'
+		{ID: "s1", WorkspaceID: "feature", Status: session.StatusRunning, StartedAt: started},
+		// Started with the workspace index
+		{ID: "s2", WorkspaceID: workspaces[0].Index, Status: session.StatusStopped, StartedAt: started, StoppedAt: &stopped},
+		{ID: "s3", WorkspaceID: ws.ID, Status: session.StatusRunning, StartedAt: started},
+		{ID: "s4", WorkspaceID: "removed", Status: session.StatusStopped, StartedAt: started},
+	}
+
+	activity := summarizeSessions(sessions, workspaces)
+	assert.Equal(t, 2, activity[ws.ID].Running)
+	assert.Equal(t, stopped, activity[ws.ID].LastActive)
+	assert.NotContains(t, activity, "feature")
+	assert.Contains(t, activity, "removed")
+
+	// Running sessions started by name keep the workspace from being pruned
+	plan, err := manager.PlanCleanup(ctx, workspace.CleanupOptions{
+		NoAhead:  true,
+		Sessions: summarizeSessions(sessions[:1], workspaces),
+	})
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	assert.Equal(t, "1 running session(s)", plan[0].Blocked)
+}
@@ -37,6 +37,13 @@ func runRemoveWorkspace(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to resolve workspace: %w", err)
 	}
 
+	if ws.Adopted && !removeForce {
+		ui.Error("Workspace '%s' was adopted from a worktree created outside amux: %s", ws.Name, ws.Path)
+		ui.OutputLine("Use 'amux ws detach %s' to forget it and keep the worktree,", ws.Name)
+		ui.OutputLine("or --force to delete the worktree with everything in it")
+		return fmt.Errorf("workspace is adopted")
+	}
+
 	// Check for active sessions
 	sessionIDs, err := ws.SessionIDs()
 	if err != nil {
@@ -64,7 +71,11 @@ func runRemoveWorkspace(cmd *cobra.Command, args []string) error {
 	}
 
 	if !removeForce {
-		ui.Warning("This will remove workspace '%s' (%s) and its branch '%s'", ws.Name, ws.ID, ws.Branch)
+		if ws.Detached {
+			ui.Warning("This will remove workspace '%s' (%s)", ws.Name, ws.ID)
+		} else {
+			ui.Warning("This will remove workspace '%s' (%s) and its branch '%s'", ws.Name, ws.ID, ws.Branch)
+		}
 		response := ui.Prompt("Are you sure? (y/N): ")
 		if response != "y" && response != "Y" {
 			ui.OutputLine("Removal cancelled")
@@ -75,6 +86,7 @@ func runRemoveWorkspace(cmd *cobra.Command, args []string) error {
 	if err := manager.Remove(cmd.Context(), workspace.Identifier(ws.ID), workspace.RemoveOptions{
 		NoHooks:    removeNoHooks,
 		CurrentDir: cwd,
+		Force:      removeForce,
 	}); err != nil {
 		return fmt.Errorf("failed to remove workspace: %w", err)
 	}
@@ -0,0 +1,95 @@
+package workspace
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var repairWorkspaceCmd = &cobra.Command{
+	Use:   "repair [workspace-name-or-id]",
+	Short: "Repair inconsistent workspaces",
+	Long: `Bring workspaces flagged by 'amux ws list' back to a consistent state.
+
+  folder-missing    the worktree is checked out again from the recorded branch
+                    (or commit, when detached); files from workspace.copy are
+                    brought in again
+  worktree-missing  the folder is registered with git again, first with
+                    'git worktree repair'; if git has no record of it left,
+                    the folder is attached to its branch and its files kept,
+                    with differences showing as uncommitted changes
+  orphaned          the workspace metadata and storage are dropped; the
+                    branch is kept
+
+Workspaces without an index get one, and index entries of workspaces that no
+longer exist are removed.
+
+Examples:
+  # See what would be done for all workspaces
+  amux ws repair --all --dry-run
+
+  # Repair one workspace
+  amux ws repair feature-auth`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRepairWorkspace,
+}
+
+func runRepairWorkspace(cmd *cobra.Command, args []string) error {
+	if (len(args) == 0) == !repairAll {
+		return fmt.Errorf("specify a workspace or --all")
+	}
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	var identifier workspace.Identifier
+	if len(args) == 1 {
+		identifier = workspace.Identifier(args[0])
+	}
+	actions, err := manager.Repair(cmd.Context(), identifier, workspace.RepairOptions{DryRun: repairDryRun})
+	if err != nil {
+		return fmt.Errorf("failed to repair workspaces: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(actions)
+	}
+	if len(actions) == 0 {
+		ui.OutputLine("Nothing to repair")
+		return nil
+	}
+
+	if repairDryRun {
+		tbl := ui.NewTable("NAME", "STATUS", "ACTION", "DETAILS")
+		for _, a := range actions {
+			tbl.AddRow(a.Workspace.Name, a.Workspace.Status.String(), a.Action, a.Description)
+		}
+		ui.PrintSectionHeader("🔧", "Repair plan", len(actions))
+		tbl.Print()
+		return nil
+	}
+
+	failed := 0
+	for _, a := range actions {
+		if a.Error != "" {
+			failed++
+			ui.Error("%s: failed to %s: %s", a.Workspace.Name, a.Description, a.Error)
+			continue
+		}
+		ui.Success("%s: %s", a.Workspace.Name, a.Description)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repair(s) failed", failed, len(actions))
+	}
+	return nil
+}
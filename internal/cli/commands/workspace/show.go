@@ -35,6 +35,17 @@ func runShowWorkspace(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to resolve workspace: %w", err)
 	}
 
+	// Show the policy in effect and who holds the workspace's slots
+	if ws.Concurrency == "" {
+		ws.Concurrency = manager.DefaultConcurrency()
+	}
+	if ws.Holders, err = ws.SessionHolders(); err != nil {
+		return fmt.Errorf("failed to read workspace holders: %w", err)
+	}
+	if err := measureUsage(manager, projectRoot, []*workspace.Workspace{ws}); err != nil {
+		return err
+	}
+
 	// Handle JSON output
 	if ui.GlobalFormatter.IsJSON() {
 		return ui.GlobalFormatter.Output(ws)
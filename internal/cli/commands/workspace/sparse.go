@@ -0,0 +1,106 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var sparseWorkspaceCmd = &cobra.Command{
+	Use:   "sparse <workspace-name-or-id>",
+	Short: "Show or change the directories of a sparse workspace",
+	Long: `Show the directories checked out in a workspace created with --sparse, or
+change them with the add and remove subcommands.
+
+Sparse workspaces use git sparse-checkout in cone mode: the listed directories
+are checked out with everything below them, along with the files at the top of
+the repository and directly in the parents of the listed directories.
+
+Examples:
+  # Show what is checked out
+  amux ws sparse api-fix
+
+  # Also check out the shared protobuf definitions
+  amux ws sparse add api-fix proto/api
+
+  # Stop checking out a directory
+  amux ws sparse remove api-fix libs/common`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSparseWorkspace,
+}
+
+var sparseAddCmd = &cobra.Command{
+	Use:   "add <workspace-name-or-id> <dir>...",
+	Short: "Check out more directories in a sparse workspace",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateSparse(cmd, args[0], args[1:], nil)
+	},
+}
+
+var sparseRemoveCmd = &cobra.Command{
+	Use:   "remove <workspace-name-or-id> <dir>...",
+	Short: "Stop checking out directories in a sparse workspace",
+	Long: `Stop checking out directories in a sparse workspace. Files with local
+changes in those directories are left in place.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateSparse(cmd, args[0], nil, args[1:])
+	},
+}
+
+func runSparseWorkspace(cmd *cobra.Command, args []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(cmd.Context(), workspace.Identifier(args[0]))
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+	return printSparse(ws)
+}
+
+// updateSparse adds and removes directories of a sparse workspace
+func updateSparse(cmd *cobra.Command, identifier string, add, remove []string) error {
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.UpdateSparse(cmd.Context(), workspace.Identifier(identifier), add, remove)
+	if err != nil {
+		return fmt.Errorf("failed to update sparse checkout: %w", err)
+	}
+	if !ui.GlobalFormatter.IsJSON() {
+		ui.Success("Sparse checkout of workspace '%s' updated", ws.Name)
+	}
+	return printSparse(ws)
+}
+
+// printSparse prints the directories checked out in a workspace
+func printSparse(ws *workspace.Workspace) error {
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(ws.Sparse)
+	}
+	if len(ws.Sparse) == 0 {
+		ui.OutputLine("Workspace '%s' is a full checkout", ws.Name)
+		return nil
+	}
+	ui.PrintKeyValue("Sparse", strings.Join(ws.Sparse, ", "))
+	return nil
+}
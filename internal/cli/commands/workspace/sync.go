@@ -0,0 +1,139 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var syncWorkspaceCmd = &cobra.Command{
+	Use:   "sync <workspace-name-or-id>",
+	Short: "Update a workspace branch from its base branch",
+	Long: `Update a workspace branch from the base branch it was created from.
+
+By default the branch is rebased onto the local base branch and nothing is
+fetched. Use --merge to merge the base branch instead, and --fetch to fetch the
+base branch's remote first and sync with its upstream.
+
+If the sync stops on conflicts, the conflicting files are listed and the
+rebase or merge is left in progress. Resolve the conflicts in the worktree and
+continue with git, or undo the sync with --abort.
+
+Examples:
+  # Rebase onto the base branch
+  amux ws sync feature-auth
+
+  # Merge the base branch instead
+  amux ws sync feature-auth --merge
+
+  # Give up on a sync that stopped on conflicts
+  amux ws sync feature-auth --abort`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSyncWorkspace,
+}
+
+func runSyncWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	identifier := workspace.Identifier(args[0])
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+
+	ws, err := manager.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+
+	if syncAbort {
+		strategy, err := manager.AbortSync(ctx, identifier)
+		if err != nil {
+			return fmt.Errorf("failed to abort sync: %w", err)
+		}
+		if ui.GlobalFormatter.IsJSON() {
+			return ui.GlobalFormatter.Output(map[string]interface{}{
+				"workspace": ws.ID,
+				"aborted":   strategy,
+			})
+		}
+		ui.Success("Aborted %s in workspace %s", strategy, ws.Name)
+		return nil
+	}
+
+	opts := workspace.SyncOptions{Strategy: git.SyncRebase, Fetch: syncFetch}
+	if syncMerge {
+		opts.Strategy = git.SyncMerge
+	}
+
+	result, err := manager.Sync(ctx, identifier, opts)
+	if err != nil {
+		if errors.Is(err, git.ErrUncommittedChanges) {
+			return fmt.Errorf("failed to sync workspace %s: %w (commit or stash them first)", ws.Name, err)
+		}
+		return fmt.Errorf("failed to sync workspace: %w", err)
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		if err := ui.GlobalFormatter.Output(result); err != nil {
+			return err
+		}
+	} else {
+		printSyncResult(ws, result)
+	}
+
+	if len(result.Conflicts) > 0 {
+		return fmt.Errorf("%s stopped with %d conflict(s)", result.Strategy, len(result.Conflicts))
+	}
+	return nil
+}
+
+// printSyncResult reports the outcome of a sync
+func printSyncResult(ws *workspace.Workspace, result *git.SyncResult) {
+	switch {
+	case result.UpToDate:
+		ui.Success("Workspace %s is already up to date with %s", ws.Name, result.Base)
+		return
+	case len(result.Conflicts) == 0 && result.Strategy == git.SyncMerge:
+		ui.Success("Merged %s into workspace %s (%d new commit(s))", result.Base, ws.Name, result.Commits)
+		return
+	case len(result.Conflicts) == 0:
+		ui.Success("Rebased workspace %s onto %s (%d new commit(s))", ws.Name, result.Base, result.Commits)
+		return
+	}
+
+	ui.Warning("The %s of %s with %s stopped with conflicts", result.Strategy, ws.Name, result.Base)
+	ui.OutputLine("")
+	tbl := ui.NewTable("FILE", "CONFLICT", "MARKERS")
+	for _, c := range result.Conflicts {
+		tbl.AddRow(c.Path, c.Kind, formatMarkerLines(c.Markers))
+	}
+	tbl.Print()
+	ui.OutputLine("")
+	ui.Info("Resolve the conflicts in %s, then run 'git %s --continue'", ws.Path, result.Strategy)
+	ui.Info("To undo the sync, run 'amux ws sync %s --abort'", ws.Name)
+}
+
+// formatMarkerLines formats the lines of conflict markers (e.g., "line 3, 40")
+func formatMarkerLines(lines []int) string {
+	if len(lines) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = strconv.Itoa(line)
+	}
+	return "line " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,176 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	sessioncmd "github.com/aki/amux/internal/cli/commands/session"
+	"github.com/aki/amux/internal/cli/ui"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/workspace"
+)
+
+var unlockWorkspaceCmd = &cobra.Command{
+	Use:   "unlock [workspace-name-or-id]",
+	Short: "Free workspace slots held by sessions that are gone",
+	Long: `Free the slots that sessions hold in a workspace, so new sessions can start.
+
+Sessions free their slot when they stop. A session that crashed, or whose
+record was removed by hand, may keep it: the workspace then looks busy to
+'amux run'. Slots are checked whenever a session starts, but only slots held
+for more than a minute are freed, as sessions take theirs before they are
+recorded.
+
+With --stale, slots of sessions that are no longer running are freed in the
+given workspace, or in all workspaces. With --session, the slot of a session
+is freed whether or not it still runs.
+
+Examples:
+  # Free what crashed sessions left behind
+  amux ws unlock --stale
+
+  # Let another session into an exclusive workspace
+  amux ws unlock migration --session session-12`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUnlockWorkspace,
+}
+
+// unlockResult lists the slots freed in a workspace
+type unlockResult struct {
+	Workspace string                    `json:"workspace"`
+	Freed     []workspace.SessionHolder `json:"freed"`
+}
+
+func runUnlockWorkspace(cmd *cobra.Command, args []string) error {
+	if unlockStale == (len(unlockSessions) > 0) {
+		return fmt.Errorf("specify either --stale or --session")
+	}
+	if len(unlockSessions) > 0 && len(args) == 0 {
+		return fmt.Errorf("--session requires a workspace")
+	}
+
+	projectRoot, err := config.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	manager, err := workspace.SetupManager(projectRoot)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	var workspaces []*workspace.Workspace
+	if len(args) > 0 {
+		ws, err := manager.ResolveWorkspace(ctx, workspace.Identifier(args[0]))
+		if err != nil {
+			return fmt.Errorf("failed to resolve workspace: %w", err)
+		}
+		workspaces = append(workspaces, ws)
+	} else {
+		workspaces, err = manager.List(ctx, workspace.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list workspaces: %w", err)
+		}
+	}
+
+	var results []unlockResult
+	if unlockStale {
+		results, err = unlockStaleSessions(ctx, manager, config.NewManager(projectRoot), workspaces)
+	} else {
+		results, err = unlockSessionSlots(ctx, manager, workspaces[0], unlockSessions)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ui.GlobalFormatter.IsJSON() {
+		return ui.GlobalFormatter.Output(results)
+	}
+	if len(results) == 0 {
+		ui.Info("No slots to free")
+		return nil
+	}
+	for _, r := range results {
+		ui.Success("Freed %d slot(s) in workspace '%s'", len(r.Freed), r.Workspace)
+		for _, h := range r.Freed {
+			ui.OutputLine("  - %s %s", h.SessionID, ui.DimStyle.Render(fmt.Sprintf("(since %s)", ui.FormatTime(h.AcquiredAt))))
+		}
+	}
+	return nil
+}
+
+// unlockStaleSessions frees the slots of sessions that are no longer running
+func unlockStaleSessions(ctx context.Context, manager *workspace.Manager, configMgr *config.Manager, workspaces []*workspace.Workspace) ([]unlockResult, error) {
+	// Sessions found to have ended while listing them free their own slots,
+	// so look at the holders first to report those too
+	before := make([][]workspace.SessionHolder, len(workspaces))
+	for i, ws := range workspaces {
+		holders, err := ws.SessionHolders()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions of workspace '%s': %w", ws.Name, err)
+		}
+		before[i] = holders
+	}
+
+	sessions, err := sessioncmd.SetupManager(configMgr).List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	running := make(map[string]bool)
+	for _, s := range sessions {
+		if s.Status != session.StatusStopped && s.Status != session.StatusFailed {
+			running[s.ID] = true
+		}
+	}
+	manager.SetSessionCheck(func(sessionID string) bool { return running[sessionID] })
+
+	var results []unlockResult
+	for i, ws := range workspaces {
+		if len(before[i]) == 0 {
+			continue
+		}
+		if _, err := manager.UnlockStale(ctx, workspace.Identifier(ws.ID)); err != nil {
+			return nil, fmt.Errorf("failed to unlock workspace '%s': %w", ws.Name, err)
+		}
+		after, err := ws.SessionHolders()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions of workspace '%s': %w", ws.Name, err)
+		}
+
+		var freed []workspace.SessionHolder
+		for _, h := range before[i] {
+			if !slices.ContainsFunc(after, func(a workspace.SessionHolder) bool { return a.SessionID == h.SessionID }) {
+				freed = append(freed, h)
+			}
+		}
+		if len(freed) > 0 {
+			results = append(results, unlockResult{Workspace: ws.Name, Freed: freed})
+		}
+	}
+	return results, nil
+}
+
+// unlockSessionSlots frees the slots of the given sessions in a workspace
+func unlockSessionSlots(ctx context.Context, manager *workspace.Manager, ws *workspace.Workspace, sessionIDs []string) ([]unlockResult, error) {
+	holders, err := ws.SessionHolders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions of workspace '%s': %w", ws.Name, err)
+	}
+
+	var freed []workspace.SessionHolder
+	for _, id := range sessionIDs {
+		i := slices.IndexFunc(holders, func(h workspace.SessionHolder) bool { return h.SessionID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("session %s holds no slot in workspace '%s'", id, ws.Name)
+		}
+		if err := manager.ReleaseSession(ctx, workspace.Identifier(ws.ID), id); err != nil {
+			return nil, fmt.Errorf("failed to unlock workspace '%s': %w", ws.Name, err)
+		}
+		freed = append(freed, holders[i])
+	}
+	return []unlockResult{{Workspace: ws.Name, Freed: freed}}, nil
+}
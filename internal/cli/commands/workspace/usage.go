@@ -0,0 +1,19 @@
+package workspace
+
+import (
+	"fmt"
+
+	sessioncmd "github.com/aki/amux/internal/cli/commands/session"
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// measureUsage fills in the disk usage of workspaces, counting the logs of
+// the sessions run in them
+func measureUsage(manager *workspace.Manager, projectRoot string, workspaces []*workspace.Workspace) error {
+	manager.SetLogUsage(sessioncmd.SetupManager(config.NewManager(projectRoot)).LogUsage)
+	if err := manager.MeasureUsage(workspaces); err != nil {
+		return fmt.Errorf("failed to measure disk usage: %w", err)
+	}
+	return nil
+}
@@ -3,27 +3,101 @@ package workspace
 
 import (
 	"github.com/aki/amux/internal/cli/commands/workspace/storage"
+	"github.com/aki/amux/internal/workspace"
 	"github.com/spf13/cobra"
 )
 
 var (
+	// Adopt flags
+	adoptName        string
+	adoptBaseBranch  string
+	adoptDescription string
+	adoptNoHooks     bool
+
+	// Archive flags
+	archiveForce   bool
+	archiveNoHooks bool
+	restoreNoHooks bool
+
+	// Checkpoint flags
+	checkpointMessage string
+	checkpointAuto    bool
+	rollbackForce     bool
+
 	// Create flags
 	createBaseBranch  string
 	createBranch      string // Create new branch with specified name
 	createCheckout    string // Checkout existing branch
 	createDescription string
 	createNoHooks     bool
+	createDryRun      bool
+	createFrom        string // Commit, tag or remote branch to start from
+	createDetach      bool
+	createLabels      []string
+	createMeta        []string
+	createConcurrency string
+	createSparse      []string
+
+	// Detach flags
+	detachForce   bool
+	detachNoHooks bool
+
+	// Diff flags
+	diffStat     bool
+	diffNameOnly bool
+	diffPatch    bool
+
+	// Label flags
+	labelRemove []string
 
 	// List flags
-	listOneline bool
+	listOneline   bool
+	listLabels    []string
+	listMeta      []string
+	listStatus    []string
+	listBranch    string
+	listOlderThan string
+	listNewerThan string
+	listInUse     bool
+	listSort      string
+	listReverse   bool
+
+	// Merge flags
+	mergeSquash  bool
+	mergeFFOnly  bool
+	mergeNoFF    bool
+	mergeMessage string
+	mergeRemove  bool
+	mergeForce   bool
+	mergeNoHooks bool
 
 	// Prune flags
-	pruneDays   int
-	pruneDryRun bool
+	pruneDays        int
+	pruneDryRun      bool
+	pruneArchive     bool
+	pruneMerged      bool
+	pruneNoAhead     bool
+	pruneSessionIdle int
+	pruneAutoCreated bool
+	pruneStatus      []string
+
+	// Repair flags
+	repairAll    bool
+	repairDryRun bool
 
 	// Remove flags
 	removeForce   bool
 	removeNoHooks bool
+
+	// Sync flags
+	syncRebase bool
+	syncMerge  bool
+	syncAbort  bool
+	syncFetch  bool
+
+	// Unlock flags
+	unlockStale    bool
+	unlockSessions []string
 )
 
 var workspaceCmd = &cobra.Command{
@@ -38,28 +112,125 @@ func init() {
 	workspaceCmd.AddCommand(createWorkspaceCmd)
 	workspaceCmd.AddCommand(listWorkspaceCmd)
 	workspaceCmd.AddCommand(showWorkspaceCmd)
+	workspaceCmd.AddCommand(labelWorkspaceCmd)
+	workspaceCmd.AddCommand(annotateWorkspaceCmd)
 	workspaceCmd.AddCommand(removeWorkspaceCmd)
 	workspaceCmd.AddCommand(pruneWorkspaceCmd)
+	workspaceCmd.AddCommand(repairWorkspaceCmd)
+	workspaceCmd.AddCommand(adoptWorkspaceCmd)
+	workspaceCmd.AddCommand(detachWorkspaceCmd)
+	workspaceCmd.AddCommand(unlockWorkspaceCmd)
+	workspaceCmd.AddCommand(sparseWorkspaceCmd)
+	sparseWorkspaceCmd.AddCommand(sparseAddCmd)
+	sparseWorkspaceCmd.AddCommand(sparseRemoveCmd)
 	workspaceCmd.AddCommand(cdWorkspaceCmd)
+	workspaceCmd.AddCommand(portsWorkspaceCmd)
+	workspaceCmd.AddCommand(diffWorkspaceCmd)
+	workspaceCmd.AddCommand(syncWorkspaceCmd)
+	workspaceCmd.AddCommand(mergeWorkspaceCmd)
+	workspaceCmd.AddCommand(checkpointWorkspaceCmd)
+	workspaceCmd.AddCommand(checkpointsWorkspaceCmd)
+	workspaceCmd.AddCommand(rollbackWorkspaceCmd)
+	workspaceCmd.AddCommand(archiveWorkspaceCmd)
+	workspaceCmd.AddCommand(archivesWorkspaceCmd)
+	workspaceCmd.AddCommand(restoreWorkspaceCmd)
 	workspaceCmd.AddCommand(storage.Command())
 
+	// Adopt command flags
+	adoptWorkspaceCmd.Flags().StringVarP(&adoptName, "name", "n", "", "Workspace name (default: the worktree folder name)")
+	adoptWorkspaceCmd.Flags().StringVar(&adoptBaseBranch, "base", "", "Base branch (default: the repository's default branch)")
+	adoptWorkspaceCmd.Flags().StringVarP(&adoptDescription, "description", "d", "", "Description of the workspace")
+	adoptWorkspaceCmd.Flags().BoolVar(&adoptNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+
+	// Archive command flags
+	archiveWorkspaceCmd.Flags().BoolVarP(&archiveForce, "force", "f", false, "Archive even if sessions are using the workspace")
+	archiveWorkspaceCmd.Flags().BoolVar(&archiveNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+	restoreWorkspaceCmd.Flags().BoolVar(&restoreNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+
+	// Checkpoint command flags
+	checkpointWorkspaceCmd.Flags().StringVarP(&checkpointMessage, "message", "m", "", "Note describing the checkpoint")
+	checkpointWorkspaceCmd.Flags().BoolVar(&checkpointAuto, "auto", false, "Also checkpoint before each session starts in the workspace (--auto=false to stop)")
+	rollbackWorkspaceCmd.Flags().BoolVarP(&rollbackForce, "force", "f", false, "Roll back even if sessions are using the workspace")
+
 	// Create command flags
 	createWorkspaceCmd.Flags().StringVar(&createBaseBranch, "base", "", "Base branch for new branches")
 	createWorkspaceCmd.Flags().StringVarP(&createBranch, "branch", "b", "", "Create new branch with specified name")
 	createWorkspaceCmd.Flags().StringVarP(&createCheckout, "checkout", "c", "", "Use existing branch")
 	createWorkspaceCmd.Flags().StringVarP(&createDescription, "description", "d", "", "Description of the workspace")
 	createWorkspaceCmd.Flags().BoolVar(&createNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+	createWorkspaceCmd.Flags().StringVar(&createFrom, "from", "", "Start from a commit, tag or remote branch (e.g. origin/fix) instead of the base branch")
+	createWorkspaceCmd.Flags().BoolVar(&createDetach, "detach", false, "Check out without a branch (detached HEAD), e.g. for read-only investigation")
+	createWorkspaceCmd.Flags().BoolVar(&createDryRun, "dry-run", false, "Show what would be created and copied without creating anything")
+	createWorkspaceCmd.Flags().StringSliceVarP(&createLabels, "label", "l", nil, "Label the workspace (repeatable)")
+	createWorkspaceCmd.Flags().StringArrayVar(&createMeta, "meta", nil, "Set a metadata entry as key=value (repeatable)")
+	createWorkspaceCmd.Flags().StringSliceVar(&createSparse, "sparse", nil, "Check out only these directories, relative to the repository root (sparse checkout in cone mode)")
+	createWorkspaceCmd.Flags().StringVar(&createConcurrency, "concurrency", "", "Sessions allowed at once: exclusive, shared, shared:N or unlimited (default: workspace.concurrency in config)")
+
+	// Label command flags
+	labelWorkspaceCmd.Flags().StringSliceVarP(&labelRemove, "remove", "r", nil, "Remove a label (repeatable)")
+
+	// Detach command flags
+	detachWorkspaceCmd.Flags().BoolVarP(&detachForce, "force", "f", false, "Detach even if sessions are using the workspace")
+	detachWorkspaceCmd.Flags().BoolVar(&detachNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+
+	// Diff command flags
+	diffWorkspaceCmd.Flags().BoolVar(&diffStat, "stat", false, "Show changed files with line counts (default)")
+	diffWorkspaceCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "Show only the names of changed files")
+	diffWorkspaceCmd.Flags().BoolVar(&diffPatch, "patch", false, "Show the full patch")
+	diffWorkspaceCmd.MarkFlagsMutuallyExclusive("stat", "name-only", "patch")
 
 	// List command flags
 	listWorkspaceCmd.Flags().BoolVar(&listOneline, "oneline", false, "Show one workspace per line (for use with fzf)")
+	listWorkspaceCmd.Flags().StringSliceVarP(&listLabels, "label", "l", nil, "Only workspaces with this label (repeatable, all must match)")
+	listWorkspaceCmd.Flags().StringArrayVar(&listMeta, "meta", nil, "Only workspaces with this metadata, as key=value or key (repeatable)")
+	listWorkspaceCmd.Flags().StringSliceVar(&listStatus, "status", nil, "Only workspaces with this status (consistent, folder-missing, worktree-missing, orphaned)")
+	listWorkspaceCmd.Flags().StringVar(&listBranch, "branch", "", "Only workspaces whose branch matches this glob pattern (e.g. 'feature/*')")
+	listWorkspaceCmd.Flags().StringVar(&listOlderThan, "older-than", "", "Only workspaces last modified longer ago than this (e.g. 7d, 12h)")
+	listWorkspaceCmd.Flags().StringVar(&listNewerThan, "newer-than", "", "Only workspaces last modified within this time (e.g. 7d, 12h)")
+	listWorkspaceCmd.Flags().BoolVar(&listInUse, "in-use", false, "Only workspaces with running sessions (--in-use=false for those without)")
+	listWorkspaceCmd.Flags().StringVar(&listSort, "sort", workspace.SortByCreated, "Sort by created, updated, name or branch")
+	listWorkspaceCmd.Flags().BoolVar(&listReverse, "reverse", false, "Reverse the sort order")
+
+	// Merge command flags
+	mergeWorkspaceCmd.Flags().BoolVar(&mergeSquash, "squash", false, "Squash the workspace's commits into one commit")
+	mergeWorkspaceCmd.Flags().BoolVar(&mergeFFOnly, "ff-only", false, "Only merge if the base branch can be fast-forwarded")
+	mergeWorkspaceCmd.Flags().BoolVar(&mergeNoFF, "no-ff", false, "Always create a merge commit")
+	mergeWorkspaceCmd.Flags().StringVarP(&mergeMessage, "message", "m", "", "Commit message for the merge or squash commit")
+	mergeWorkspaceCmd.Flags().BoolVar(&mergeRemove, "remove", false, "Remove the workspace and delete its branch after merging")
+	mergeWorkspaceCmd.Flags().BoolVarP(&mergeForce, "force", "f", false, "Merge even if sessions are using the workspace")
+	mergeWorkspaceCmd.Flags().BoolVar(&mergeNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+	mergeWorkspaceCmd.MarkFlagsMutuallyExclusive("squash", "ff-only", "no-ff")
 
 	// Prune command flags
-	pruneWorkspaceCmd.Flags().IntVarP(&pruneDays, "days", "d", 7, "Remove workspaces idle for more than N days")
+	pruneWorkspaceCmd.Flags().IntVarP(&pruneDays, "days", "d", 7, "Remove workspaces idle for more than N days (0 to ignore idle time)")
+	pruneWorkspaceCmd.Flags().BoolVar(&pruneMerged, "merged", false, "Only workspaces whose branch is merged into the base branch")
+	pruneWorkspaceCmd.Flags().BoolVar(&pruneNoAhead, "no-ahead", false, "Only workspaces with no commits ahead of the base branch")
+	pruneWorkspaceCmd.Flags().IntVar(&pruneSessionIdle, "session-idle", 0, "Only workspaces without session activity in the last N days")
+	pruneWorkspaceCmd.Flags().BoolVar(&pruneAutoCreated, "auto-created", false, "Only workspaces created automatically for sessions")
+	pruneWorkspaceCmd.Flags().StringSliceVar(&pruneStatus, "status", nil, "Only workspaces with this status (consistent, folder-missing, worktree-missing, orphaned)")
 	pruneWorkspaceCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be removed without removing")
+	pruneWorkspaceCmd.Flags().BoolVar(&pruneArchive, "archive", false, "Archive workspaces instead of removing them (default: workspace.prune in config)")
+
+	// Repair command flags
+	repairWorkspaceCmd.Flags().BoolVar(&repairAll, "all", false, "Repair all workspaces")
+	repairWorkspaceCmd.Flags().BoolVar(&repairDryRun, "dry-run", false, "Explain what would be done without changing anything")
 
 	// Remove command flags
-	removeWorkspaceCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Force removal without confirmation")
+	removeWorkspaceCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Force removal without confirmation, also deleting adopted worktrees")
 	removeWorkspaceCmd.Flags().BoolVar(&removeNoHooks, "no-hooks", false, "Skip running hooks for this operation")
+
+	// Sync command flags
+	syncWorkspaceCmd.Flags().BoolVar(&syncRebase, "rebase", false, "Rebase onto the base branch (default)")
+	syncWorkspaceCmd.Flags().BoolVar(&syncMerge, "merge", false, "Merge the base branch instead of rebasing")
+	syncWorkspaceCmd.Flags().BoolVar(&syncAbort, "abort", false, "Abort a rebase or merge that stopped on conflicts")
+	syncWorkspaceCmd.Flags().BoolVar(&syncFetch, "fetch", false, "Fetch the base branch's remote and sync with its upstream")
+	syncWorkspaceCmd.MarkFlagsMutuallyExclusive("rebase", "merge", "abort")
+	syncWorkspaceCmd.MarkFlagsMutuallyExclusive("fetch", "abort")
+
+	// Unlock command flags
+	unlockWorkspaceCmd.Flags().BoolVar(&unlockStale, "stale", false, "Free the slots of sessions that are no longer running")
+	unlockWorkspaceCmd.Flags().StringSliceVar(&unlockSessions, "session", nil, "Free the slot of this session (repeatable)")
+	unlockWorkspaceCmd.MarkFlagsMutuallyExclusive("stale", "session")
 }
 
 // Command returns the workspace command
@@ -14,6 +14,8 @@ const (
 	FormatPretty OutputFormat = "pretty"
 	// FormatJSON represents JSON output format
 	FormatJSON OutputFormat = "json"
+	// FormatWide represents human-readable output with extra columns, such as disk usage
+	FormatWide OutputFormat = "wide"
 )
 
 // ParseFormat converts a string to OutputFormat
@@ -23,6 +25,8 @@ func ParseFormat(s string) (OutputFormat, error) {
 		return FormatPretty, nil
 	case "json":
 		return FormatJSON, nil
+	case "wide":
+		return FormatWide, nil
 	default:
 		return "", fmt.Errorf("unsupported format: %s", s)
 	}
@@ -38,10 +42,15 @@ type Formatter interface {
 
 	// IsJSON returns true if this formatter outputs JSON
 	IsJSON() bool
+
+	// IsWide returns true if human-readable output should show extra columns
+	IsWide() bool
 }
 
 // prettyFormatter implements Formatter for human-readable output
-type prettyFormatter struct{}
+type prettyFormatter struct {
+	wide bool
+}
 
 // NewPrettyFormatter creates a new pretty formatter
 func NewPrettyFormatter() Formatter {
@@ -71,6 +80,10 @@ func (f *prettyFormatter) IsJSON() bool {
 	return false
 }
 
+func (f *prettyFormatter) IsWide() bool {
+	return f.wide
+}
+
 // jsonFormatter implements Formatter for JSON output
 type jsonFormatter struct {
 	encoder *json.Encoder
@@ -98,6 +111,10 @@ func (f *jsonFormatter) IsJSON() bool {
 	return true
 }
 
+func (f *jsonFormatter) IsWide() bool {
+	return false
+}
+
 // GlobalFormatter is the global formatter instance
 var GlobalFormatter Formatter = NewPrettyFormatter()
 
@@ -108,6 +125,8 @@ func SetGlobalFormatter(format OutputFormat) error {
 		GlobalFormatter = NewPrettyFormatter()
 	case FormatJSON:
 		GlobalFormatter = NewJSONFormatter()
+	case FormatWide:
+		GlobalFormatter = &prettyFormatter{wide: true}
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
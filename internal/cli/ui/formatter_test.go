@@ -30,6 +30,11 @@ func TestParseFormat(t *testing.T) {
 			input: "json",
 			want:  FormatJSON,
 		},
+		{
+			name:  "wide format",
+			input: "wide",
+			want:  FormatWide,
+		},
 		{
 			name:      "invalid format",
 			input:     "xml",
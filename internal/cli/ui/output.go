@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -98,12 +99,35 @@ func PrintWorkspace(w *workspace.Workspace) {
 		OutputLine("   %s", w.Description)
 	}
 
-	OutputLine("   %s %s", DimStyle.Render("Branch:"), w.Branch)
-	OutputLine("   %s %s", DimStyle.Render("Path:"), w.Path)
+	OutputLine("   %s %s", DimStyle.Render("Branch:"), w.BranchLabel())
+	if w.Adopted {
+		OutputLine("   %s %s %s", DimStyle.Render("Path:"), w.Path, DimStyle.Render("(adopted)"))
+	} else {
+		OutputLine("   %s %s", DimStyle.Render("Path:"), w.Path)
+	}
+
+	if len(w.Labels) > 0 {
+		OutputLine("   %s %s", DimStyle.Render("Labels:"), strings.Join(w.Labels, ", "))
+	}
+	if len(w.Metadata) > 0 {
+		OutputLine("   %s", DimStyle.Render("Metadata:"))
+		keys := make([]string, 0, len(w.Metadata))
+		for key := range w.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			OutputLine("     - %s: %s", key, w.Metadata[key])
+		}
+	}
 
 	if w.StoragePath != "" {
 		OutputLine("   %s %s", DimStyle.Render("Storage:"), w.StoragePath)
 	}
+	if u := w.Usage; u != nil {
+		OutputLine("   %s %s %s", DimStyle.Render("Disk usage:"), FormatSize(u.Total()),
+			DimStyle.Render(fmt.Sprintf("(worktree %s, storage %s, logs %s)", FormatSize(u.Worktree), FormatSize(u.Storage), FormatSize(u.Logs))))
+	}
 
 	OutputLine("   %s %s", DimStyle.Render("Created:"), FormatTime(w.CreatedAt))
 	OutputLine("   %s %s", DimStyle.Render("Updated:"), FormatTime(w.UpdatedAt))
@@ -114,19 +138,44 @@ func PrintWorkspace(w *workspace.Workspace) {
 	case workspace.StatusConsistent:
 		statusStr = SuccessStyle.Render("✓ Consistent")
 	case workspace.StatusFolderMissing:
-		statusStr = WarningStyle.Render("⚠ Folder missing (run 'amux ws rm' to clean up)")
+		statusStr = WarningStyle.Render("⚠ Folder missing (run 'amux ws repair' to check it out again)")
 	case workspace.StatusWorktreeMissing:
-		statusStr = WarningStyle.Render("⚠ Git worktree missing (run 'amux ws rm' to clean up)")
+		statusStr = WarningStyle.Render("⚠ Git worktree missing (run 'amux ws repair' to register it again)")
 	case workspace.StatusOrphaned:
-		statusStr = ErrorStyle.Render("✗ Orphaned (both folder and worktree missing)")
+		statusStr = ErrorStyle.Render("✗ Orphaned (both folder and worktree missing, run 'amux ws repair' to clean up)")
 	default:
 		statusStr = DimStyle.Render("Unknown")
 	}
 	OutputLine("   %s %s", DimStyle.Render("Status:"), statusStr)
 
-	// Show active sessions
+	// Show allocated ports
+	if len(w.Ports) > 0 {
+		OutputLine("   %s", DimStyle.Render("Ports:"))
+		for _, name := range workspace.PortNames(w.Ports) {
+			OutputLine("     - %s: %d", name, w.Ports[name])
+		}
+	}
+
+	if w.AutoCheckpoint {
+		OutputLine("   %s %s", DimStyle.Render("Checkpoints:"), "automatic before each session")
+	}
+
+	if w.Concurrency != "" {
+		OutputLine("   %s %s", DimStyle.Render("Concurrency:"), w.Concurrency)
+	}
+
+	if len(w.Sparse) > 0 {
+		OutputLine("   %s %s", DimStyle.Render("Sparse:"), strings.Join(w.Sparse, ", "))
+	}
+
+	// Show active sessions, with when they took their slot if known
 	sessionCount := w.SessionCount()
-	if sessionCount > 0 {
+	if len(w.Holders) > 0 {
+		OutputLine("   %s %d active session(s):", DimStyle.Render("Sessions:"), len(w.Holders))
+		for _, h := range w.Holders {
+			OutputLine("     - %s %s", h.SessionID, DimStyle.Render(fmt.Sprintf("(since %s)", FormatTime(h.AcquiredAt))))
+		}
+	} else if sessionCount > 0 {
 		sessionIDs, err := w.SessionIDs()
 		if err == nil && len(sessionIDs) > 0 {
 			OutputLine("   %s %d active session(s):", DimStyle.Render("Sessions:"), sessionCount)
@@ -155,6 +204,13 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dd", int(d.Hours()/24))
 }
 
+// FormatChanges formats inserted and deleted line counts (e.g., "+12 -3")
+func FormatChanges(insertions, deletions int) string {
+	return fmt.Sprintf("%s %s",
+		SuccessStyle.Render(fmt.Sprintf("+%d", insertions)),
+		ErrorStyle.Render(fmt.Sprintf("-%d", deletions)))
+}
+
 // PrintWorkspaceList displays a list of workspaces using a table
 func PrintWorkspaceList(workspaces []*workspace.Workspace) {
 	if len(workspaces) == 0 {
@@ -162,8 +218,23 @@ func PrintWorkspaceList(workspaces []*workspace.Workspace) {
 		return
 	}
 
+	// Only show the labels column when some workspace has labels, and the
+	// size column when disk usage was measured
+	showLabels, showSize := false, false
+	for _, w := range workspaces {
+		showLabels = showLabels || len(w.Labels) > 0
+		showSize = showSize || w.Usage != nil
+	}
+
 	// Create table
-	tbl := NewTable("ID", "NAME", "BRANCH", "AGE", "STATUS", "DESCRIPTION")
+	headers := []interface{}{"ID", "NAME", "BRANCH", "AGE", "STATUS", "CHANGES", "AHEAD/BEHIND"}
+	if showSize {
+		headers = append(headers, "SIZE")
+	}
+	if showLabels {
+		headers = append(headers, "LABELS")
+	}
+	tbl := NewTable(append(headers, "DESCRIPTION")...)
 
 	// Add rows
 	for _, w := range workspaces {
@@ -205,7 +276,34 @@ func PrintWorkspaceList(workspaces []*workspace.Workspace) {
 			status = fmt.Sprintf("%s • %s", status, sessionInfo)
 		}
 
-		tbl.AddRow(id, w.Name, w.Branch, age, status, description)
+		changes, aheadBehind := "-", "-"
+		if c := w.Changes; c != nil {
+			changes = DimStyle.Render("clean")
+			if c.Files > 0 {
+				changes = fmt.Sprintf("%d files %s", c.Files, FormatChanges(c.Insertions, c.Deletions))
+				if c.UncommittedFiles > 0 {
+					changes += WarningStyle.Render(fmt.Sprintf(" (%d uncommitted)", c.UncommittedFiles))
+				}
+			}
+			aheadBehind = fmt.Sprintf("↑%d ↓%d", c.Ahead, c.Behind)
+		}
+
+		row := []interface{}{id, w.Name, w.BranchLabel(), age, status, changes, aheadBehind}
+		if showSize {
+			size := "-"
+			if w.Usage != nil {
+				size = FormatSize(w.Usage.Total())
+			}
+			row = append(row, size)
+		}
+		if showLabels {
+			labels := strings.Join(w.Labels, ",")
+			if labels == "" {
+				labels = "-"
+			}
+			row = append(row, labels)
+		}
+		tbl.AddRow(append(row, description)...)
 	}
 
 	// Print with header
@@ -292,6 +390,12 @@ func FormatSize(bytes int64) string {
 	}
 }
 
+// FormatQuotaExceeded describes an exceeded disk quota with readable sizes
+func FormatQuotaExceeded(e *workspace.ErrQuotaExceeded) string {
+	return fmt.Sprintf("%s uses %s of disk space, over its quota of %s (run 'amux du' to see what takes it)",
+		e.Scope(), FormatSize(e.Used), FormatSize(e.Limit))
+}
+
 // Confirm asks the user for confirmation
 func Confirm(prompt string) bool {
 	reader := bufio.NewReader(os.Stdin)
@@ -2,6 +2,8 @@ package ui
 
 import (
 	"testing"
+
+	"github.com/aki/amux/internal/workspace"
 )
 
 func TestFormatSize(t *testing.T) {
@@ -31,3 +33,11 @@ func TestFormatSize(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatQuotaExceeded(t *testing.T) {
+	got := FormatQuotaExceeded(&workspace.ErrQuotaExceeded{Workspace: "big", Used: 2 << 30, Limit: 1 << 30})
+	want := "workspace 'big' uses 2.0GB of disk space, over its quota of 1.0GB (run 'amux du' to see what takes it)"
+	if got != want {
+		t.Errorf("FormatQuotaExceeded() = %q, want %q", got, want)
+	}
+}
@@ -7,6 +7,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aki/amux/internal/runtime/tmux"
 )
 
 func TestConfigWithTasks(t *testing.T) {
@@ -64,6 +67,26 @@ tasks:
 				assert.Equal(t, []string{"prepare"}, cfg.Tasks[1].DependsOn)
 			},
 		},
+		{
+			name: "task and agent with ports",
+			content: `version: "1.0"
+agents:
+  claude:
+    name: Claude
+    runtime: local
+    ports: [preview]
+tasks:
+  - name: dev
+    command: npm run dev
+    lifecycle: daemon
+    ports: [web, api]`,
+			wantErr: false,
+			check: func(t *testing.T, cfg *Config) {
+				require.Len(t, cfg.Tasks, 1)
+				assert.Equal(t, []string{"web", "api"}, cfg.Tasks[0].Ports)
+				assert.Equal(t, []string{"preview"}, cfg.Agents["claude"].Ports)
+			},
+		},
 		{
 			name: "task with invalid dependency",
 			content: `version: "1.0"
@@ -217,3 +240,31 @@ tasks:
 	assert.Equal(t, "build", buildTask.Name)
 	assert.Equal(t, "go build", buildTask.Command)
 }
+
+func TestAgentTmuxRuntimeOptions(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`version: "1.0"
+agents:
+  claude:
+    name: Claude
+    runtime: tmux
+    runtimeOptions:
+      historyLimit: 50000
+      statusLine: true
+      mouse: true
+      group: best-of-3
+      layout: even-horizontal
+`), &cfg)
+	require.NoError(t, err)
+
+	agent := cfg.Agents["claude"]
+	spec := agent.ToExecutionSpec()
+	opts, ok := spec.Options.(tmux.Options)
+	require.True(t, ok, "expected tmux options, got %T", spec.Options)
+	assert.Equal(t, 50000, opts.OutputHistory)
+	assert.True(t, opts.StatusLine)
+	assert.True(t, opts.Mouse)
+	assert.Equal(t, "best-of-3", opts.Group)
+	assert.Equal(t, "even-horizontal", opts.Layout)
+	assert.False(t, opts.RemainOnExit)
+}
@@ -89,6 +89,11 @@ func (m *Manager) GetWorkspacesDir() string {
 	return filepath.Join(m.projectRoot, AmuxDir, "workspaces")
 }
 
+// GetCacheDir returns the directory of the dependency caches shared by workspaces
+func (m *Manager) GetCacheDir() string {
+	return filepath.Join(m.projectRoot, AmuxDir, "cache")
+}
+
 // GetAgent retrieves an agent configuration by ID
 func (m *Manager) GetAgent(agentID string) (*Agent, error) {
 	cfg, err := m.Load()
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"gopkg.in/yaml.v3"
+
 	"github.com/aki/amux/internal/runtime"
 	"github.com/aki/amux/internal/runtime/tmux"
 )
@@ -27,11 +29,25 @@ func (a *Agent) convertRuntimeOptions() runtime.RuntimeOptions {
 		}
 	}
 
-	// Return default options based on runtime type
+	// Decode options loaded from config based on runtime type
 	switch a.GetRuntimeType() {
 	case "tmux":
-		return tmux.Options{}
+		opts := tmux.Options{}
+		decodeRuntimeOptions(a.GetRuntimeOptions(), &opts)
+		return opts
 	default:
 		return nil
 	}
 }
+
+// decodeRuntimeOptions decodes raw options loaded from YAML into a typed struct
+func decodeRuntimeOptions(raw interface{}, out interface{}) {
+	if raw == nil {
+		return
+	}
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return
+	}
+	_ = yaml.Unmarshal(data, out)
+}
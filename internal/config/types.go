@@ -8,10 +8,74 @@ import (
 
 // Config represents the main Amux configuration
 type Config struct {
-	Version string           `yaml:"version"`
-	MCP     MCPConfig        `yaml:"mcp"`
-	Agents  map[string]Agent `yaml:"agents"`
-	Tasks   []*task.Task     `yaml:"tasks,omitempty"`
+	Version   string           `yaml:"version"`
+	MCP       MCPConfig        `yaml:"mcp"`
+	Agents    map[string]Agent `yaml:"agents"`
+	Tasks     []*task.Task     `yaml:"tasks,omitempty"`
+	Workspace WorkspaceConfig  `yaml:"workspace,omitempty"`
+	Caches    []CacheConfig    `yaml:"caches,omitempty"`
+}
+
+// WorkspaceConfig represents workspace configuration
+type WorkspaceConfig struct {
+	// Copy lists files from the main checkout to bring into each new workspace
+	Copy []CopyRule `yaml:"copy,omitempty"`
+	// Prune is what 'amux ws prune' does with old workspaces: remove (default) or archive
+	Prune string `yaml:"prune,omitempty"`
+	// WorktreePath is where new worktrees are created, as a template relative
+	// to the project root (default: .amux/workspaces/{{id}}/worktree)
+	WorktreePath string `yaml:"worktreePath,omitempty"`
+	// BranchName names the branches of new workspaces created without one (default: amux/{{id}})
+	BranchName string `yaml:"branchName,omitempty"`
+	// Concurrency limits how many sessions run in a workspace at once, for
+	// workspaces that don't set their own: exclusive, shared, shared:N or unlimited (default)
+	Concurrency string `yaml:"concurrency,omitempty"`
+	// Quota limits the disk space of workspaces and of the whole project
+	Quota QuotaConfig `yaml:"quota,omitempty"`
+}
+
+// QuotaConfig sets disk space limits such as 5GB, checked when sessions
+// start. Limits that are not set are not checked.
+type QuotaConfig struct {
+	Workspace string `yaml:"workspace,omitempty"` // Worktree, storage and session logs of each workspace
+	Project   string `yaml:"project,omitempty"`   // All workspaces and shared caches together
+	Action    string `yaml:"action,omitempty"`    // warn (default) or block new sessions when a limit is exceeded
+}
+
+// Prune actions for WorkspaceConfig
+const (
+	PruneRemove  = "remove"
+	PruneArchive = "archive"
+)
+
+// Copy modes for CopyRule
+const (
+	CopyModeCopy     = "copy"
+	CopyModeSymlink  = "symlink"
+	CopyModeHardlink = "hardlink"
+	CopyModeReflink  = "reflink"
+)
+
+// CopyRule selects files that are not tracked by git, such as .env or
+// node_modules, to copy or link into new workspaces
+type CopyRule struct {
+	Pattern string `yaml:"pattern"`        // Glob pattern relative to the project root
+	Mode    string `yaml:"mode,omitempty"` // copy (default), symlink, hardlink or reflink
+}
+
+// GetMode returns the copy mode, defaulting to copy
+func (r CopyRule) GetMode() string {
+	if r.Mode == "" {
+		return CopyModeCopy
+	}
+	return r.Mode
+}
+
+// CacheConfig shares a dependency cache directory, such as the Go module
+// cache, between all workspaces of the project
+type CacheConfig struct {
+	Name string `yaml:"name"`          // Directory under .amux/cache; go, npm and pip also imply Env
+	Env  string `yaml:"env,omitempty"` // Environment variable set to the directory
 }
 
 // MCPConfig represents MCP server configuration
@@ -51,6 +115,8 @@ type Agent struct {
 	Tags           []string          `yaml:"tags,omitempty"`
 	RuntimeOptions interface{}       `yaml:"runtimeOptions,omitempty"` // Runtime-specific options
 	Command        []string          `yaml:"command,omitempty"`        // Command to execute
+	Ports          []string          `yaml:"ports,omitempty"`          // Named ports allocated per workspace
+	Sparse         []string          `yaml:"sparse,omitempty"`         // Directories checked out in workspaces created for the agent
 }
 
 // GetRuntimeType returns the runtime type for this agent
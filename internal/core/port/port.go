@@ -0,0 +1,43 @@
+// Package port provides the naming rules for the named ports of workspaces,
+// shared by workspaces and the tasks that ask for them
+package port
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namePattern restricts port names to ones usable in environment variable names
+var namePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// ValidateName checks that a port name can be turned into an environment variable
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid port name %q: must start with a letter and contain only letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// EnvName returns the environment variable holding a named port (e.g., web -> AMUX_PORT_WEB)
+func EnvName(name string) string {
+	return "AMUX_PORT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// ValidateNames checks a set of port names, refusing different names that
+// would share an environment variable (e.g., web-api and web_api). The same
+// name may appear more than once.
+func ValidateNames(names []string) error {
+	byEnv := make(map[string]string, len(names))
+	for _, name := range names {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+		env := EnvName(name)
+		if other, ok := byEnv[env]; ok && other != name {
+			return fmt.Errorf("port names %q and %q both map to %s", other, name, env)
+		}
+		byEnv[env] = name
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// runGit runs a git command in the repository and returns its standard output
+func (o *Operations) runGit(args ...string) ([]byte, error) {
+	return o.runGitEnv(nil, args...)
+}
+
+// runGitEnv runs a git command with additional environment variables
+func (o *Operations) runGitEnv(env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = o.repoPath
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return output, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return output, nil
+}
+
+// MergeBase returns the best common ancestor of HEAD and base
+func (o *Operations) MergeBase(base string) (string, error) {
+	output, err := o.runGit("merge-base", base, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to find merge base with %s: %w", base, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AheadBehind returns how many commits HEAD is ahead of and behind base
+func (o *Operations) AheadBehind(base string) (ahead, behind int, err error) {
+	return o.divergence("HEAD", base)
+}
+
+// divergence counts the commits only on rev and the commits only on other
+func (o *Operations) divergence(rev, other string) (only, otherOnly int, err error) {
+	output, err := o.runGit("rev-list", "--left-right", "--count", rev+"..."+other)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare with %s: %w", other, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", output)
+	}
+	only, _ = strconv.Atoi(fields[0])
+	otherOnly, _ = strconv.Atoi(fields[1])
+	return only, otherOnly, nil
+}
+
+// CommitsAhead counts the commits on rev that are not on base
+func (o *Operations) CommitsAhead(rev, base string) (int, error) {
+	only, _, err := o.divergence(rev, base)
+	return only, err
+}
+
+// IsMerged reports whether the changes of rev are already in base: either rev
+// is an ancestor of base, or merging rev into base would not change base's
+// tree, as after a squash or rebase merge.
+func (o *Operations) IsMerged(rev, base string) (bool, error) {
+	if _, err := o.runGit("merge-base", "--is-ancestor", rev, base); err == nil {
+		return true, nil
+	}
+
+	baseTree, err := o.revParse(base + "^{tree}")
+	if err != nil {
+		return false, err
+	}
+	// merge-tree fails when the merge conflicts, which means rev has changes base lacks
+	output, err := o.runGit("merge-tree", "--write-tree", base, rev)
+	if err != nil {
+		return false, nil
+	}
+	tree, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimSpace(tree) == baseTree, nil
+}
+
+// Diff summarizes the changes of the working tree relative to base. Files are
+// compared against the merge base, so changes made on base after branching
+// are not included.
+func (o *Operations) Diff(base string) (*DiffSummary, error) {
+	mergeBase, err := o.MergeBase(base)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &DiffSummary{Base: base, MergeBase: mergeBase}
+	summary.Ahead, summary.Behind, err = o.AheadBehind(base)
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes committed on the branch
+	committed, err := o.diffFiles(mergeBase, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	// Staged and unstaged changes on top of HEAD
+	uncommitted, err := o.diffFiles("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := o.untrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// All changes from the merge base to the working tree
+	files, err := o.diffFiles(mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, untracked...)
+
+	committedPaths := make(map[string]bool, len(committed))
+	for _, f := range committed {
+		committedPaths[f.Path] = true
+	}
+	uncommittedPaths := make(map[string]bool, len(uncommitted)+len(untracked))
+	for _, f := range uncommitted {
+		uncommittedPaths[f.Path] = true
+	}
+	for _, f := range untracked {
+		uncommittedPaths[f.Path] = true
+	}
+
+	for i := range files {
+		f := &files[i]
+		f.Committed = committedPaths[f.Path]
+		f.Uncommitted = uncommittedPaths[f.Path]
+		summary.Insertions += f.Insertions
+		summary.Deletions += f.Deletions
+		if f.Committed {
+			summary.CommittedFiles++
+		}
+		if f.Uncommitted {
+			summary.UncommittedFiles++
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	summary.Files = files
+
+	return summary, nil
+}
+
+// Filter limits the summary to changes of the given paths and the files below
+// them, recomputing the totals. Without paths the summary is left unchanged.
+func (s *DiffSummary) Filter(paths ...string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	files := make([]FileChange, 0, len(s.Files))
+	for _, f := range s.Files {
+		for _, p := range paths {
+			if matchesPath(f.Path, p) || (f.OldPath != "" && matchesPath(f.OldPath, p)) {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+
+	s.Files = files
+	s.Insertions, s.Deletions, s.CommittedFiles, s.UncommittedFiles = 0, 0, 0, 0
+	for _, f := range files {
+		s.Insertions += f.Insertions
+		s.Deletions += f.Deletions
+		if f.Committed {
+			s.CommittedFiles++
+		}
+		if f.Uncommitted {
+			s.UncommittedFiles++
+		}
+	}
+}
+
+// matchesPath reports whether file is path or lies below it
+func matchesPath(file, path string) bool {
+	path = strings.TrimRight(path, "/")
+	return file == path || strings.HasPrefix(file, path+"/")
+}
+
+// DiffPatch returns the patch of the working tree relative to the merge base
+// with base, including untracked files. Paths limit the patch to these files.
+func (o *Operations) DiffPatch(base string, paths ...string) (string, error) {
+	mergeBase, err := o.MergeBase(base)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"diff", "-M", mergeBase}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	output, err := o.runGit(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff: %w", err)
+	}
+
+	var patch bytes.Buffer
+	patch.Write(output)
+
+	untracked, err := o.untrackedFiles(paths...)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range untracked {
+		// git diff --no-index exits with 1 when the files differ
+		cmd := exec.Command("git", "diff", "--no-index", "--", os.DevNull, f.Path)
+		cmd.Dir = o.repoPath
+		out, err := cmd.Output()
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			return "", fmt.Errorf("failed to diff %s: %w", f.Path, err)
+		}
+		patch.Write(out)
+	}
+
+	return patch.String(), nil
+}
+
+// diffFiles lists the files changed between revisions (or the working tree
+// when only one is given) with their line counts
+func (o *Operations) diffFiles(revs ...string) ([]FileChange, error) {
+	args := append([]string{"diff", "-M", "-z", "--name-status"}, revs...)
+	nameStatus, err := o.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff: %w", err)
+	}
+	args = append([]string{"diff", "-M", "-z", "--numstat"}, revs...)
+	numstat, err := o.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff: %w", err)
+	}
+
+	files := parseNameStatus(nameStatus)
+	counts := parseNumstat(numstat)
+	for i := range files {
+		if c, ok := counts[files[i].Path]; ok {
+			files[i].Insertions = c.Insertions
+			files[i].Deletions = c.Deletions
+			files[i].Binary = c.Binary
+		}
+	}
+	return files, nil
+}
+
+// untrackedFiles lists files not known to git and not ignored
+func (o *Operations) untrackedFiles(paths ...string) ([]FileChange, error) {
+	args := []string{"ls-files", "--others", "--exclude-standard", "-z"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	output, err := o.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var files []FileChange
+	for _, path := range splitNull(output) {
+		f := FileChange{Path: path, Status: FileUntracked}
+		if data, err := os.ReadFile(filepath.Join(o.repoPath, path)); err == nil {
+			if bytes.IndexByte(data, 0) >= 0 {
+				f.Binary = true
+			} else {
+				f.Insertions = bytes.Count(data, []byte("\n"))
+				if len(data) > 0 && data[len(data)-1] != '\n' {
+					f.Insertions++
+				}
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// TrackedFiles lists the files under the given paths that are tracked by git
+func (o *Operations) TrackedFiles(paths ...string) ([]string, error) {
+	args := []string{"ls-files", "-z"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	output, err := o.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked files: %w", err)
+	}
+	return splitNull(output), nil
+}
+
+// parseNameStatus parses the output of 'git diff --name-status -z'
+func parseNameStatus(output []byte) []FileChange {
+	fields := splitNull(output)
+	var files []FileChange
+	for i := 0; i < len(fields); i++ {
+		code := fields[i]
+		if code == "" {
+			continue
+		}
+		f := FileChange{Status: fileStatus(code[0])}
+		if (code[0] == 'R' || code[0] == 'C') && i+2 < len(fields) {
+			f.OldPath = fields[i+1]
+			f.Path = fields[i+2]
+			i += 2
+		} else if i+1 < len(fields) {
+			f.Path = fields[i+1]
+			i++
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// parseNumstat parses the output of 'git diff --numstat -z' into line counts by path
+func parseNumstat(output []byte) map[string]FileChange {
+	fields := splitNull(output)
+	counts := make(map[string]FileChange)
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+		if path == "" && i+2 < len(fields) {
+			// Renames are followed by the old and new path
+			path = fields[i+2]
+			i += 2
+		}
+
+		var c FileChange
+		if parts[0] == "-" && parts[1] == "-" {
+			c.Binary = true
+		} else {
+			c.Insertions, _ = strconv.Atoi(parts[0])
+			c.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		counts[path] = c
+	}
+	return counts
+}
+
+// fileStatus maps a git status letter to a FileStatus
+func fileStatus(code byte) FileStatus {
+	switch code {
+	case 'A':
+		return FileAdded
+	case 'D':
+		return FileDeleted
+	case 'R':
+		return FileRenamed
+	case 'C':
+		return FileCopied
+	case 'T':
+		return FileTypeChanged
+	default:
+		return FileModified
+	}
+}
+
+// splitNull splits NUL-separated output, dropping the trailing empty field
+func splitNull(output []byte) []string {
+	s := strings.TrimSuffix(string(output), "\x00")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\x00")
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/tests/helpers"
+)
+
+func TestParseNameStatus(t *testing.T) {
+	output := []byte("M\x00a.go\x00R100\x00old.go\x00new.go\x00A\x00b.go\x00D\x00c.go\x00")
+	files := parseNameStatus(output)
+	require.Len(t, files, 4)
+	assert.Equal(t, FileChange{Path: "a.go", Status: FileModified}, files[0])
+	assert.Equal(t, FileChange{Path: "new.go", OldPath: "old.go", Status: FileRenamed}, files[1])
+	assert.Equal(t, FileAdded, files[2].Status)
+	assert.Equal(t, FileDeleted, files[3].Status)
+}
+
+func TestParseNumstat(t *testing.T) {
+	output := []byte("3\t1\ta.go\x001\t0\t\x00old.go\x00new.go\x00-\t-\timage.png\x00")
+	counts := parseNumstat(output)
+	assert.Equal(t, 3, counts["a.go"].Insertions)
+	assert.Equal(t, 1, counts["a.go"].Deletions)
+	assert.Equal(t, 1, counts["new.go"].Insertions)
+	assert.True(t, counts["image.png"].Binary)
+}
+
+func TestOperations_Diff(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	gitCmd := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644))
+	}
+
+	gitCmd("checkout", "-b", "feature")
+	writeFile("committed.txt", "one\ntwo\n")
+	gitCmd("add", "committed.txt")
+	gitCmd("commit", "-m", "Add committed.txt")
+
+	// Advance main so the branch is behind
+	gitCmd("checkout", "main")
+	writeFile("main.txt", "main\n")
+	gitCmd("add", "main.txt")
+	gitCmd("commit", "-m", "Add main.txt")
+	gitCmd("checkout", "feature")
+
+	writeFile("README.md", "# Changed\n")
+	writeFile("new.txt", "a\nb\nc")
+
+	ops := NewOperations(repoDir)
+	summary, err := ops.Diff("main")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, summary.Ahead)
+	assert.Equal(t, 1, summary.Behind)
+	assert.Equal(t, 1, summary.CommittedFiles)
+	assert.Equal(t, 2, summary.UncommittedFiles)
+	require.Len(t, summary.Files, 3)
+
+	byPath := make(map[string]FileChange)
+	for _, f := range summary.Files {
+		byPath[f.Path] = f
+	}
+	assert.Equal(t, FileChange{Path: "README.md", Status: FileModified, Insertions: 1, Deletions: 1, Uncommitted: true}, byPath["README.md"])
+	assert.Equal(t, FileChange{Path: "committed.txt", Status: FileAdded, Insertions: 2, Committed: true}, byPath["committed.txt"])
+	assert.Equal(t, FileChange{Path: "new.txt", Status: FileUntracked, Insertions: 3, Uncommitted: true}, byPath["new.txt"])
+	assert.Equal(t, 6, summary.Insertions)
+	assert.Equal(t, 1, summary.Deletions)
+
+	// Changes on main after branching are not part of the diff
+	_, ok := byPath["main.txt"]
+	assert.False(t, ok)
+
+	patch, err := ops.DiffPatch("main")
+	require.NoError(t, err)
+	assert.Contains(t, patch, "+# Changed")
+	assert.Contains(t, patch, "+++ b/new.txt")
+	assert.NotContains(t, patch, "main.txt")
+
+	patch, err = ops.DiffPatch("main", "new.txt")
+	require.NoError(t, err)
+	assert.Contains(t, patch, "new.txt")
+	assert.NotContains(t, patch, "README.md")
+}
+
+func TestDiffSummary_Filter(t *testing.T) {
+	summary := &DiffSummary{
+		Insertions: 6,
+		Deletions:  1,
+		Files: []FileChange{
+			{Path: "docs/guide.md", Insertions: 4, Committed: true},
+			{Path: "docs.go", Insertions: 1, Deletions: 1, Uncommitted: true},
+			{Path: "src/new.go", OldPath: "docs/old.go", Status: FileRenamed, Insertions: 1, Uncommitted: true},
+		},
+	}
+
+	summary.Filter()
+	assert.Len(t, summary.Files, 3)
+
+	summary.Filter("docs/")
+	require.Len(t, summary.Files, 2)
+	assert.Equal(t, "docs/guide.md", summary.Files[0].Path)
+	assert.Equal(t, "src/new.go", summary.Files[1].Path)
+	assert.Equal(t, 5, summary.Insertions)
+	assert.Equal(t, 0, summary.Deletions)
+	assert.Equal(t, 1, summary.CommittedFiles)
+	assert.Equal(t, 1, summary.UncommittedFiles)
+}
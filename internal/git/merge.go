@@ -0,0 +1,118 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Merge merges branch into the branch checked out in this worktree. When the
+// merge conflicts it is aborted, leaving the worktree as it was, and the
+// conflicts are returned in the result. An empty message keeps git's default.
+func (o *Operations) Merge(branch string, mode MergeMode, message string) (*MergeResult, error) {
+	head, err := o.runGit("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if o.SyncInProgress() != "" {
+		return nil, fmt.Errorf("a rebase or merge is already in progress in %s", o.repoPath)
+	}
+	dirty, err := o.HasTrackedChanges()
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("%s: %w", o.repoPath, ErrUncommittedChanges)
+	}
+
+	result := &MergeResult{Branch: branch, Base: strings.TrimSpace(string(head)), Mode: mode}
+	var behind int
+	result.Commits, behind, err = o.divergence(branch, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if result.Commits == 0 {
+		return nil, fmt.Errorf("branch %s has no commits to merge into %s", branch, result.Base)
+	}
+	result.FastForward = behind == 0 && mode != MergeNoFastForward && mode != MergeSquash
+
+	args := []string{"merge", "--no-edit"}
+	switch mode {
+	case MergeDefault:
+	case MergeFastForwardOnly:
+		if behind > 0 {
+			return nil, fmt.Errorf("cannot fast-forward %s to %s: %s has %d commit(s) not on %s", result.Base, branch, result.Base, behind, branch)
+		}
+		args = append(args, "--ff-only")
+	case MergeNoFastForward:
+		args = append(args, "--no-ff")
+	case MergeSquash:
+		args = append(args, "--squash")
+	default:
+		return nil, fmt.Errorf("unknown merge mode: %s", mode)
+	}
+	if message != "" && mode != MergeSquash {
+		args = append(args, "-m", message)
+	}
+	args = append(args, branch)
+
+	if _, mergeErr := o.runGit(args...); mergeErr != nil {
+		conflicts, err := o.Conflicts()
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) == 0 {
+			return nil, fmt.Errorf("failed to merge %s: %w", branch, mergeErr)
+		}
+		result.Conflicts = conflicts
+		// A squash merge does not record MERGE_HEAD, so reset instead of aborting
+		if mode == MergeSquash {
+			_, err = o.runGit("reset", "--merge")
+		} else {
+			_, err = o.runGit("merge", "--abort")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to undo conflicting merge: %w", err)
+		}
+		return result, nil
+	}
+
+	if mode == MergeSquash {
+		commitArgs := []string{"commit", "--no-edit"}
+		if message != "" {
+			commitArgs = []string{"commit", "-m", message}
+		}
+		if _, err := o.runGit(commitArgs...); err != nil {
+			_, _ = o.runGit("reset", "--merge")
+			return nil, fmt.Errorf("failed to commit squash merge: %w", err)
+		}
+	}
+
+	result.Commit, err = o.revParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WorktreeOf returns the path of the worktree where branch is checked out,
+// or an empty string if it is not checked out
+func (o *Operations) WorktreeOf(branch string) (string, error) {
+	worktrees, err := o.ListWorktrees()
+	if err != nil {
+		return "", err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return wt.Path, nil
+		}
+	}
+	return "", nil
+}
+
+// AddWorktree checks out an existing branch in a new worktree at path
+func (o *Operations) AddWorktree(path, branch string) error {
+	if _, err := o.runGit("worktree", "add", path, branch); err != nil {
+		return fmt.Errorf("failed to add worktree: %w", err)
+	}
+	return nil
+}
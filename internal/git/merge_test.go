@@ -0,0 +1,124 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/tests/helpers"
+)
+
+func TestOperations_Merge(t *testing.T) {
+	// setup creates a feature branch with two commits and checks out main,
+	// optionally advancing main so it cannot be fast-forwarded
+	setup := func(t *testing.T, diverged bool) (string, func(args ...string) string) {
+		repoDir := helpers.CreateTestRepo(t)
+		gitCmd := func(args ...string) string {
+			t.Helper()
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repoDir
+			output, err := cmd.CombinedOutput()
+			require.NoError(t, err, "git %v: %s", args, output)
+			return strings.TrimSpace(string(output))
+		}
+		commitFile := func(name, content string) {
+			require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644))
+			gitCmd("add", name)
+			gitCmd("commit", "-m", "Update "+name)
+		}
+
+		gitCmd("checkout", "-b", "feature")
+		commitFile("one.txt", "one\n")
+		commitFile("two.txt", "two\n")
+		gitCmd("checkout", "main")
+		if diverged {
+			commitFile("main.txt", "main\n")
+		}
+		return repoDir, gitCmd
+	}
+
+	t.Run("fast-forward", func(t *testing.T) {
+		repoDir, gitCmd := setup(t, false)
+		result, err := NewOperations(repoDir).Merge("feature", MergeDefault, "")
+		require.NoError(t, err)
+		assert.Equal(t, "main", result.Base)
+		assert.Equal(t, 2, result.Commits)
+		assert.True(t, result.FastForward)
+		assert.Equal(t, gitCmd("rev-parse", "feature"), result.Commit)
+	})
+
+	t.Run("fast-forward only refuses diverged base", func(t *testing.T) {
+		repoDir, gitCmd := setup(t, true)
+		before := gitCmd("rev-parse", "HEAD")
+		_, err := NewOperations(repoDir).Merge("feature", MergeFastForwardOnly, "")
+		assert.Error(t, err)
+		assert.Equal(t, before, gitCmd("rev-parse", "HEAD"))
+	})
+
+	t.Run("no fast-forward with message", func(t *testing.T) {
+		repoDir, gitCmd := setup(t, false)
+		result, err := NewOperations(repoDir).Merge("feature", MergeNoFastForward, "Merge feature work")
+		require.NoError(t, err)
+		assert.False(t, result.FastForward)
+		assert.Equal(t, "Merge feature work", gitCmd("log", "-1", "--format=%s"))
+		assert.Len(t, strings.Fields(gitCmd("log", "-1", "--format=%P")), 2)
+	})
+
+	t.Run("squash", func(t *testing.T) {
+		repoDir, gitCmd := setup(t, true)
+		result, err := NewOperations(repoDir).Merge("feature", MergeSquash, "Add feature")
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Commits)
+		assert.Equal(t, "Add feature", gitCmd("log", "-1", "--format=%s"))
+		assert.Len(t, strings.Fields(gitCmd("log", "-1", "--format=%P")), 1)
+		assert.FileExists(t, filepath.Join(repoDir, "two.txt"))
+	})
+
+	t.Run("nothing to merge", func(t *testing.T) {
+		repoDir, gitCmd := setup(t, false)
+		gitCmd("merge", "--ff-only", "feature")
+		_, err := NewOperations(repoDir).Merge("feature", MergeDefault, "")
+		assert.Error(t, err)
+	})
+
+	for _, mode := range []MergeMode{MergeDefault, MergeSquash} {
+		t.Run("conflict "+string(mode), func(t *testing.T) {
+			repoDir, gitCmd := setup(t, false)
+			require.NoError(t, os.WriteFile(filepath.Join(repoDir, "two.txt"), []byte("main\n"), 0o644))
+			gitCmd("add", "two.txt")
+			gitCmd("commit", "-m", "Conflicting two.txt")
+			before := gitCmd("rev-parse", "HEAD")
+
+			ops := NewOperations(repoDir)
+			result, err := ops.Merge("feature", mode, "")
+			require.NoError(t, err)
+			require.Len(t, result.Conflicts, 1)
+			assert.Equal(t, "two.txt", result.Conflicts[0].Path)
+			assert.Empty(t, result.Commit)
+
+			// The conflicting merge is undone
+			assert.Empty(t, ops.SyncInProgress())
+			assert.Equal(t, before, gitCmd("rev-parse", "HEAD"))
+			assert.Empty(t, gitCmd("status", "--porcelain", "--untracked-files=no"))
+		})
+	}
+}
+
+func TestOperations_WorktreeOf(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	ops := NewOperations(repoDir)
+
+	path, err := ops.WorktreeOf("main")
+	require.NoError(t, err)
+	resolved, _ := filepath.EvalSymlinks(repoDir)
+	assert.Contains(t, []string{repoDir, resolved}, path)
+
+	path, err = ops.WorktreeOf("missing")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+}
@@ -102,6 +102,61 @@ func (o *Operations) CreateWorktreeFromExistingBranch(path, branch string) error
 	return nil
 }
 
+// CreateDetachedWorktree creates a worktree with a detached HEAD at a commit
+func (o *Operations) CreateDetachedWorktree(path, rev string) error {
+	if _, err := o.runGit("worktree", "add", "--detach", path, rev); err != nil {
+		return fmt.Errorf("failed to create detached worktree at %s: %w", rev, err)
+	}
+	return nil
+}
+
+// ResolveCommit returns the commit a branch, tag, remote branch or SHA points to
+func (o *Operations) ResolveCommit(rev string) (string, error) {
+	output, err := o.runGit("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit, tag or branch", rev)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RemoteBranch splits a ref such as origin/feature into a configured remote
+// and a branch name
+func (o *Operations) RemoteBranch(ref string) (remote, branch string, ok bool) {
+	remotes, err := o.runGit("remote")
+	if err != nil {
+		return "", "", false
+	}
+	ref = strings.TrimPrefix(ref, "refs/remotes/")
+	for _, name := range strings.Fields(string(remotes)) {
+		if rest, found := strings.CutPrefix(ref, name+"/"); found && rest != "" {
+			return name, rest, true
+		}
+	}
+	return "", "", false
+}
+
+// IsRemoteBranch reports whether a ref names a fetched remote-tracking branch
+func (o *Operations) IsRemoteBranch(ref string) bool {
+	_, err := o.runGit("rev-parse", "--verify", "--quiet", "refs/remotes/"+strings.TrimPrefix(ref, "refs/remotes/"))
+	return err == nil
+}
+
+// FetchBranch fetches a single branch from a remote
+func (o *Operations) FetchBranch(remote, branch string) error {
+	if _, err := o.runGit("fetch", remote, branch); err != nil {
+		return fmt.Errorf("failed to fetch %s from %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// SetUpstream makes a local branch track a remote branch such as origin/feature
+func (o *Operations) SetUpstream(branch, upstream string) error {
+	if _, err := o.runGit("branch", "--set-upstream-to="+upstream, branch); err != nil {
+		return fmt.Errorf("failed to set upstream of %s to %s: %w", branch, upstream, err)
+	}
+	return nil
+}
+
 // RemoveWorktree removes a git worktree
 func (o *Operations) RemoveWorktree(path string) error {
 	cmd := exec.Command("git", "worktree", "remove", "--force", path)
@@ -128,6 +183,68 @@ func (o *Operations) PruneWorktrees() error {
 	return nil
 }
 
+// RepairWorktree restores the link between the repository and a worktree
+// folder, e.g. after either of them was moved
+func (o *Operations) RepairWorktree(path string) error {
+	if worktreeLinkTaken(path) {
+		return fmt.Errorf("failed to repair worktree: %s links to the record of another worktree", path)
+	}
+	if _, err := o.runGit("worktree", "repair", path); err != nil {
+		return fmt.Errorf("failed to repair worktree: %w", err)
+	}
+	return nil
+}
+
+// worktreeLinkTaken reports whether the .git link of the folder at path points
+// to git's record of a different worktree that still exists, as happens when
+// git reuses the name after losing track of the folder. Repairing the link
+// would then take that worktree's record over.
+func worktreeLinkTaken(path string) bool {
+	link := filepath.Join(path, ".git")
+	data, err := os.ReadFile(link)
+	if err != nil {
+		return false
+	}
+	record := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+	owner, err := os.ReadFile(filepath.Join(record, "gitdir"))
+	if err != nil {
+		return false
+	}
+	ownerInfo, err := os.Stat(strings.TrimSpace(string(owner)))
+	if err != nil {
+		return false
+	}
+	linkInfo, err := os.Stat(link)
+	return err == nil && !os.SameFile(ownerInfo, linkInfo)
+}
+
+// ReattachWorktree registers an existing folder as a worktree of branch after
+// git has lost its record of it. The files in the folder are left as they
+// are, so differences from the branch show up as uncommitted changes.
+func (o *Operations) ReattachWorktree(path, branch string) error {
+	// Register a placeholder worktree for the branch and move its .git link
+	// into the folder
+	placeholder := path + ".reattach"
+	if _, err := o.runGit("worktree", "add", "--no-checkout", placeholder, branch); err != nil {
+		return fmt.Errorf("failed to register worktree for %s: %w", branch, err)
+	}
+	defer func() { _ = os.RemoveAll(placeholder) }()
+
+	if err := os.Rename(filepath.Join(placeholder, ".git"), filepath.Join(path, ".git")); err != nil {
+		_ = o.RemoveWorktree(placeholder)
+		return fmt.Errorf("failed to link worktree: %w", err)
+	}
+	if err := o.RepairWorktree(path); err != nil {
+		return err
+	}
+
+	// The placeholder was never checked out, so build the index from HEAD
+	if _, err := NewOperations(path).runGit("reset", "--quiet"); err != nil {
+		return fmt.Errorf("failed to reset worktree index: %w", err)
+	}
+	return nil
+}
+
 // CreateBranch creates a new branch from a base branch
 func (o *Operations) CreateBranch(branch, baseBranch string) error {
 	cmd := exec.Command("git", "branch", branch, baseBranch)
@@ -190,6 +307,14 @@ func (o *Operations) BranchExists(branch string) (bool, error) {
 	return false, nil
 }
 
+// ValidateBranchName checks that a name can be used for a new branch
+func (o *Operations) ValidateBranchName(branch string) error {
+	if _, err := o.runGit("check-ref-format", "--branch", branch); err != nil {
+		return fmt.Errorf("invalid branch name '%s'", branch)
+	}
+	return nil
+}
+
 // GetDefaultBranch returns the default branch name (main or master)
 func (o *Operations) GetDefaultBranch() (string, error) {
 	// Try to get the default branch from remote
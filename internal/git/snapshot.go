@@ -0,0 +1,244 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snapshot records the full state of the worktree, including untracked files
+// that are not ignored, as a commit whose parent is HEAD. The index, HEAD and
+// the branch are left untouched; the commit is only reachable through refs
+// the caller creates.
+func (o *Operations) Snapshot(message string) (string, error) {
+	head, err := o.revParse("HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	// Stage everything into a scratch index so the real index is not touched
+	index, err := os.CreateTemp("", "amux-snapshot-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	indexPath := index.Name()
+	defer func() { _ = os.Remove(indexPath) }()
+	env := []string{"GIT_INDEX_FILE=" + indexPath}
+
+	// Start from a copy of the real index, so that skip-worktree entries
+	// (e.g. outside a sparse checkout) keep their content rather than being
+	// recorded as deleted
+	seeded, err := o.copyIndex(index)
+	_ = index.Close()
+	if err != nil {
+		return "", err
+	}
+	if !seeded {
+		_ = os.Remove(indexPath) // git refuses to read an empty index file
+		if _, err := o.runGitEnv(env, "read-tree", "HEAD"); err != nil {
+			return "", fmt.Errorf("failed to read HEAD tree: %w", err)
+		}
+	}
+	if _, err := o.runGitEnv(env, "add", "--all"); err != nil {
+		return "", fmt.Errorf("failed to stage worktree: %w", err)
+	}
+	tree, err := o.runGitEnv(env, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree: %w", err)
+	}
+
+	commit, err := o.runGit("commit-tree", strings.TrimSpace(string(tree)), "-p", head, "-m", message)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot commit: %w", err)
+	}
+	return strings.TrimSpace(string(commit)), nil
+}
+
+// copyIndex copies the index of the worktree to w. It reports false when the
+// worktree has no index yet.
+func (o *Operations) copyIndex(w io.Writer) (bool, error) {
+	output, err := o.runGit("rev-parse", "--git-path", "index")
+	if err != nil {
+		return false, err
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(o.repoPath, path)
+	}
+
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read index: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+	if _, err := io.Copy(w, src); err != nil {
+		return false, fmt.Errorf("failed to copy index: %w", err)
+	}
+	return true, nil
+}
+
+// RestoreSnapshot puts the worktree back into the state recorded by Snapshot.
+// HEAD (and the branch) are reset to the commit that was checked out when the
+// snapshot was taken, and changes that were uncommitted at the time are
+// uncommitted again. Untracked files created since then are removed; ignored
+// files are kept.
+func (o *Operations) RestoreSnapshot(snapshot string) error {
+	parent, err := o.revParse(snapshot + "^")
+	if err != nil {
+		return err
+	}
+	if o.SyncInProgress() != "" {
+		if _, err := o.AbortSync(); err != nil {
+			return err
+		}
+	}
+
+	steps := [][]string{
+		{"reset", "--hard", "--quiet", parent},
+		{"clean", "-d", "--force", "--quiet"},
+		// Update the index and worktree to the snapshot, removing files it does not have
+		{"read-tree", "-u", "--reset", snapshot},
+		// Back to the snapshot's HEAD in the index so its changes show as uncommitted
+		{"reset", "--quiet", parent},
+	}
+	for _, args := range steps {
+		if _, err := o.runGit(args...); err != nil {
+			return fmt.Errorf("failed to restore snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateRef points ref at commit, creating it if needed
+func (o *Operations) UpdateRef(ref, commit string) error {
+	if _, err := o.runGit("update-ref", ref, commit); err != nil {
+		return fmt.Errorf("failed to update %s: %w", ref, err)
+	}
+	return nil
+}
+
+// DeleteRefs deletes all refs under prefix
+func (o *Operations) DeleteRefs(prefix string) error {
+	refs, err := o.ListRefs(prefix)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, err := o.runGit("update-ref", "-d", ref.Name); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", ref.Name, err)
+		}
+	}
+	return nil
+}
+
+// ListRefs lists the refs under prefix with the commits they point at, oldest first
+func (o *Operations) ListRefs(prefix string) ([]RefInfo, error) {
+	output, err := o.runGit("for-each-ref", "--sort=committerdate",
+		"--format=%(refname)%00%(objectname)%00%(parent)%00%(committerdate:unix)%00%(subject)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	var refs []RefInfo
+	for _, line := range bytes.Split(bytes.TrimSpace(output), []byte("\n")) {
+		fields := strings.SplitN(string(line), "\x00", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		ref := RefInfo{Name: fields[0], Commit: fields[1], Subject: fields[4]}
+		if parents := strings.Fields(fields[2]); len(parents) > 0 {
+			ref.Parent = parents[0]
+		}
+		if unix, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			ref.Date = time.Unix(unix, 0)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// DiffSnapshots lists the files that differ between two snapshots with their
+// line counts, sorted by path. Paths limit the result to these files and the
+// files below them.
+func (o *Operations) DiffSnapshots(from, to string, paths ...string) ([]FileChange, error) {
+	changes, err := o.diffFiles(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]FileChange, 0, len(changes))
+	for _, f := range changes {
+		if len(paths) == 0 {
+			files = append(files, f)
+			continue
+		}
+		for _, p := range paths {
+			if matchesPath(f.Path, p) || (f.OldPath != "" && matchesPath(f.OldPath, p)) {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// SnapshotPatch returns the patch between two snapshots. Paths limit the patch
+// to these files.
+func (o *Operations) SnapshotPatch(from, to string, paths ...string) (string, error) {
+	args := []string{"diff", "-M", from, to}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	output, err := o.runGit(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff: %w", err)
+	}
+	return string(output), nil
+}
+
+// UncommittedPatch returns a binary patch of the uncommitted changes in the
+// worktree relative to HEAD, including untracked files that are not ignored.
+// The patch is empty for a clean worktree.
+func (o *Operations) UncommittedPatch() (string, error) {
+	snapshot, err := o.Snapshot("uncommitted changes")
+	if err != nil {
+		return "", err
+	}
+	output, err := o.runGit("diff", "--binary", snapshot+"^", snapshot)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff uncommitted changes: %w", err)
+	}
+	return string(output), nil
+}
+
+// ApplyPatch applies a patch such as one from UncommittedPatch to the worktree
+// without staging it
+func (o *Operations) ApplyPatch(patch string) error {
+	file, err := os.CreateTemp("", "amux-patch-")
+	if err != nil {
+		return fmt.Errorf("failed to create patch file: %w", err)
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	if _, err := file.WriteString(patch); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write patch file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write patch file: %w", err)
+	}
+
+	if _, err := o.runGit("apply", "--binary", "--whitespace=nowarn", file.Name()); err != nil {
+		return fmt.Errorf("failed to apply patch: %w", err)
+	}
+	return nil
+}
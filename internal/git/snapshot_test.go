@@ -0,0 +1,182 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/tests/helpers"
+)
+
+func TestOperations_Snapshot(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	gitCmd := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+		return strings.TrimSpace(string(output))
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644))
+	}
+	readFile := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(repoDir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	writeFile(".gitignore", "*.log\n")
+	writeFile("tracked.txt", "v1\n")
+	gitCmd("add", ".gitignore", "tracked.txt")
+	gitCmd("commit", "-m", "Add files")
+	head := gitCmd("rev-parse", "HEAD")
+
+	// Uncommitted and untracked changes are part of the snapshot
+	writeFile("tracked.txt", "v2\n")
+	writeFile("untracked.txt", "new\n")
+	writeFile("debug.log", "ignored\n")
+
+	ops := NewOperations(repoDir)
+	snapshot, err := ops.Snapshot("before refactor")
+	require.NoError(t, err)
+	require.NoError(t, ops.UpdateRef("refs/amux/test/1", snapshot))
+
+	// The snapshot leaves HEAD, index and worktree alone
+	assert.Equal(t, head, gitCmd("rev-parse", "HEAD"))
+	assert.Equal(t, "M tracked.txt\n?? untracked.txt", gitCmd("status", "--porcelain"))
+
+	// Wreck the worktree and commit on top
+	writeFile("tracked.txt", "broken\n")
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "untracked.txt")))
+	writeFile("garbage.txt", "garbage\n")
+	gitCmd("add", "--all")
+	gitCmd("commit", "-m", "Broken")
+
+	require.NoError(t, ops.RestoreSnapshot(snapshot))
+	assert.Equal(t, head, gitCmd("rev-parse", "HEAD"))
+	assert.Equal(t, "v2\n", readFile("tracked.txt"))
+	assert.Equal(t, "new\n", readFile("untracked.txt"))
+	assert.Equal(t, "ignored\n", readFile("debug.log"))
+	assert.NoFileExists(t, filepath.Join(repoDir, "garbage.txt"))
+	assert.Equal(t, "M tracked.txt\n?? untracked.txt", gitCmd("status", "--porcelain"))
+
+	refs, err := ops.ListRefs("refs/amux/test/")
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	assert.Equal(t, "refs/amux/test/1", refs[0].Name)
+	assert.Equal(t, snapshot, refs[0].Commit)
+	assert.Equal(t, head, refs[0].Parent)
+	assert.Equal(t, "before refactor", refs[0].Subject)
+	assert.False(t, refs[0].Date.IsZero())
+
+	require.NoError(t, ops.DeleteRefs("refs/amux/test/"))
+	refs, err = ops.ListRefs("refs/amux/test/")
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+}
+
+func TestOperations_SnapshotSparse(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	gitCmd := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+		return strings.TrimSpace(string(output))
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644))
+	}
+
+	writeFile("app/main.go", "package main\n")
+	writeFile("docs/guide.md", "guide\n")
+	gitCmd("add", "--all")
+	gitCmd("commit", "-m", "Add app and docs")
+	head := gitCmd("rev-parse", "HEAD")
+
+	ops := NewOperations(repoDir)
+	require.NoError(t, ops.SetSparseCheckout([]string{"app"}))
+	require.NoFileExists(t, filepath.Join(repoDir, "docs", "guide.md"))
+
+	writeFile("app/main.go", "package main\n\nfunc main() {}\n")
+	writeFile("app/new.go", "package main\n")
+
+	// Files outside the sparse cone are not recorded as deleted
+	snapshot, err := ops.Snapshot("sparse")
+	require.NoError(t, err)
+	assert.Equal(t, "M\tapp/main.go\nA\tapp/new.go", gitCmd("diff", "--name-status", snapshot+"^", snapshot))
+
+	patch, err := ops.UncommittedPatch()
+	require.NoError(t, err)
+	assert.Contains(t, patch, "app/new.go")
+	assert.NotContains(t, patch, "docs/guide.md")
+
+	// Restoring keeps the worktree sparse
+	writeFile("app/main.go", "broken\n")
+	require.NoError(t, ops.RestoreSnapshot(snapshot))
+	assert.Equal(t, head, gitCmd("rev-parse", "HEAD"))
+	assert.Equal(t, "M app/main.go\n?? app/new.go", gitCmd("status", "--porcelain"))
+	assert.NoFileExists(t, filepath.Join(repoDir, "docs", "guide.md"))
+
+	// The patch of uncommitted changes applies back onto a clean worktree
+	gitCmd("reset", "--hard", "--quiet")
+	gitCmd("clean", "-d", "--force", "--quiet")
+	require.NoError(t, ops.ApplyPatch(patch))
+	assert.Equal(t, "M app/main.go\n?? app/new.go", gitCmd("status", "--porcelain"))
+	assert.NoFileExists(t, filepath.Join(repoDir, "docs", "guide.md"))
+
+	// Skip-worktree entries the sparse patterns do not cover are kept as well
+	gitCmd("update-index", "--skip-worktree", "README.md")
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "README.md")))
+	snapshot, err = ops.Snapshot("skip-worktree")
+	require.NoError(t, err)
+	assert.Equal(t, "M\tapp/main.go\nA\tapp/new.go", gitCmd("diff", "--name-status", snapshot+"^", snapshot))
+}
+
+func TestOperations_DiffSnapshots(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	writeFile := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoDir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644))
+	}
+
+	ops := NewOperations(repoDir)
+	before, err := ops.Snapshot("before")
+	require.NoError(t, err)
+
+	writeFile("src/app.go", "package app\n")
+	writeFile("notes.txt", "one\ntwo\n")
+	after, err := ops.Snapshot("after")
+	require.NoError(t, err)
+
+	files, err := ops.DiffSnapshots(before, after)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "notes.txt", files[0].Path)
+	assert.Equal(t, FileAdded, files[0].Status)
+	assert.Equal(t, 2, files[0].Insertions)
+	assert.Equal(t, "src/app.go", files[1].Path)
+
+	files, err = ops.DiffSnapshots(before, after, "src")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "src/app.go", files[0].Path)
+
+	patch, err := ops.SnapshotPatch(before, after, "notes.txt")
+	require.NoError(t, err)
+	assert.Contains(t, patch, "+++ b/notes.txt")
+	assert.NotContains(t, patch, "src/app.go")
+}
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// CreateSparseWorktree creates a worktree for an existing branch, or for a
+// commit when detach is set, that only checks out the given directories
+// (sparse checkout in cone mode). Files at the top level and directly in the
+// parents of the directories are always checked out. The sparse settings
+// apply to the new worktree only.
+func (o *Operations) CreateSparseWorktree(worktreePath, rev string, detach bool, dirs []string) error {
+	args := []string{"worktree", "add", "--no-checkout"}
+	if detach {
+		args = append(args, "--detach")
+	}
+	if _, err := o.runGit(append(args, worktreePath, rev)...); err != nil {
+		return fmt.Errorf("failed to create worktree: %w", err)
+	}
+
+	wt := NewOperations(worktreePath)
+	err := wt.SetSparseCheckout(dirs)
+	if err == nil {
+		// The worktree was added without a checkout; fill it in now that
+		// only the sparse directories are wanted
+		if _, err = wt.runGit("checkout"); err != nil {
+			err = fmt.Errorf("failed to check out sparse worktree: %w", err)
+		}
+	}
+	if err != nil {
+		_ = o.RemoveWorktree(worktreePath)
+		return err
+	}
+	return nil
+}
+
+// SetSparseCheckout changes the directories checked out in a sparse
+// worktree. Files in directories that are dropped are removed unless they
+// have local changes.
+func (o *Operations) SetSparseCheckout(dirs []string) error {
+	args := append([]string{"sparse-checkout", "set", "--cone", "--"}, dirs...)
+	if _, err := o.runGit(args...); err != nil {
+		return fmt.Errorf("failed to set sparse checkout: %w", err)
+	}
+	return nil
+}
+
+// IsDirectory reports whether path is a directory in the tree of rev
+func (o *Operations) IsDirectory(rev, dir string) bool {
+	output, err := o.runGit("cat-file", "-t", rev+":"+dir)
+	return err == nil && strings.TrimSpace(string(output)) == "tree"
+}
+
+// ListTree lists the entries directly in a directory of the tree of rev
+// ("" or "." for the top level)
+func (o *Operations) ListTree(rev, dir string) ([]TreeEntry, error) {
+	args := []string{"ls-tree", "-z", rev}
+	if dir != "" && dir != "." {
+		args = append(args, "--", strings.TrimSuffix(dir, "/")+"/")
+	}
+	output, err := o.runGit(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree: %w", err)
+	}
+
+	var entries []TreeEntry
+	for _, line := range splitNull(output) {
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		entries = append(entries, TreeEntry{
+			Name: path.Base(name),
+			Dir:  len(fields) > 1 && fields[1] == "tree",
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,236 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUncommittedChanges is returned when a sync would touch a dirty worktree
+var ErrUncommittedChanges = errors.New("worktree has uncommitted changes")
+
+// conflictKinds maps the porcelain status of unmerged files to a description
+var conflictKinds = map[string]string{
+	"DD": "both deleted",
+	"AU": "added by us",
+	"UD": "deleted by them",
+	"UA": "added by them",
+	"DU": "deleted by us",
+	"AA": "both added",
+	"UU": "both modified",
+}
+
+// Sync brings HEAD up to date with base using the given strategy. When the
+// sync stops on conflicts, the rebase or merge is left in progress and the
+// conflicts are returned in the result; it can be finished with git or undone
+// with AbortSync.
+func (o *Operations) Sync(base string, strategy SyncStrategy) (*SyncResult, error) {
+	if inProgress := o.SyncInProgress(); inProgress != "" {
+		return nil, fmt.Errorf("a %s is already in progress", inProgress)
+	}
+	dirty, err := o.HasTrackedChanges()
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, ErrUncommittedChanges
+	}
+
+	before, err := o.revParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	_, behind, err := o.AheadBehind(base)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{Strategy: strategy, Base: base, Before: before, Commits: behind}
+	if behind == 0 {
+		result.UpToDate = true
+		result.After = before
+		return result, nil
+	}
+
+	var args []string
+	switch strategy {
+	case SyncRebase:
+		args = []string{"rebase", base}
+	case SyncMerge:
+		args = []string{"merge", "--no-edit", base}
+	default:
+		return nil, fmt.Errorf("unknown sync strategy: %s", strategy)
+	}
+
+	if _, syncErr := o.runGit(args...); syncErr != nil {
+		conflicts, err := o.Conflicts()
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) == 0 {
+			return nil, fmt.Errorf("failed to %s onto %s: %w", strategy, base, syncErr)
+		}
+		result.Conflicts = conflicts
+		return result, nil
+	}
+
+	result.After, err = o.revParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SyncInProgress returns the strategy of an unfinished rebase or merge, or an
+// empty string if there is none
+func (o *Operations) SyncInProgress() SyncStrategy {
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if o.gitPathExists(dir) {
+			return SyncRebase
+		}
+	}
+	if o.gitPathExists("MERGE_HEAD") {
+		return SyncMerge
+	}
+	return ""
+}
+
+// AbortSync undoes an unfinished rebase or merge and returns its strategy
+func (o *Operations) AbortSync() (SyncStrategy, error) {
+	strategy := o.SyncInProgress()
+	if strategy == "" {
+		return "", fmt.Errorf("no rebase or merge in progress")
+	}
+	if _, err := o.runGit(string(strategy), "--abort"); err != nil {
+		return "", fmt.Errorf("failed to abort %s: %w", strategy, err)
+	}
+	return strategy, nil
+}
+
+// Conflicts lists the unmerged files of the worktree with the lines where
+// their conflict markers start
+func (o *Operations) Conflicts() ([]Conflict, error) {
+	output, err := o.runGit("status", "--porcelain", "-z", "--untracked-files=no")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	var conflicts []Conflict
+	fields := splitNull(output)
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		if code[0] == 'R' || code[0] == 'C' {
+			// Renames and copies are followed by the original path
+			i++
+		}
+		kind, ok := conflictKinds[code]
+		if !ok {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			Path:    path,
+			Kind:    kind,
+			Markers: o.conflictMarkers(path),
+		})
+	}
+	return conflicts, nil
+}
+
+// FetchUpstream fetches the remote that base tracks and returns the remote
+// ref to sync with. Base may be a local branch with an upstream or a remote
+// branch like origin/main.
+func (o *Operations) FetchUpstream(base string) (string, error) {
+	remote, ref := "", ""
+	if upstream, err := o.runGit("rev-parse", "--abbrev-ref", "--symbolic-full-name", base+"@{upstream}"); err == nil {
+		ref = strings.TrimSpace(string(upstream))
+		if name, err := o.runGit("config", "branch."+base+".remote"); err == nil {
+			remote = strings.TrimSpace(string(name))
+		}
+	} else if prefix, _, found := strings.Cut(base, "/"); found {
+		if remotes, err := o.runGit("remote"); err == nil {
+			for _, name := range strings.Fields(string(remotes)) {
+				if name == prefix {
+					remote, ref = prefix, base
+				}
+			}
+		}
+	}
+	if remote == "" {
+		return "", fmt.Errorf("base branch %s does not track a remote", base)
+	}
+
+	if _, err := o.runGit("fetch", remote); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", remote, err)
+	}
+	return ref, nil
+}
+
+// conflictMarkers returns the 1-based lines where conflict markers start
+func (o *Operations) conflictMarkers(path string) []int {
+	file, err := os.Open(filepath.Join(o.repoPath, path))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if bytes.HasPrefix(scanner.Bytes(), []byte("<<<<<<< ")) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// HasTrackedChanges reports whether tracked files are modified or staged
+func (o *Operations) HasTrackedChanges() (bool, error) {
+	output, err := o.runGit("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, fmt.Errorf("failed to get status: %w", err)
+	}
+	return len(bytes.TrimSpace(output)) > 0, nil
+}
+
+// HasUncommittedChanges reports whether tracked files are modified or staged,
+// or untracked files that are not ignored exist
+func (o *Operations) HasUncommittedChanges() (bool, error) {
+	output, err := o.runGit("status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("failed to get status: %w", err)
+	}
+	return len(bytes.TrimSpace(output)) > 0, nil
+}
+
+// revParse resolves a revision to a commit hash
+func (o *Operations) revParse(rev string) (string, error) {
+	output, err := o.runGit("rev-parse", rev)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitPathExists reports whether a path inside the git directory exists. In a
+// worktree this resolves to the worktree's own git directory.
+func (o *Operations) gitPathExists(name string) bool {
+	output, err := o.runGit("rev-parse", "--git-path", name)
+	if err != nil {
+		return false
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(o.repoPath, path)
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
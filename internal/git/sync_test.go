@@ -0,0 +1,120 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/tests/helpers"
+)
+
+// setupSyncRepo creates a repository with a feature branch that is one commit
+// behind main. When conflicting, both branches change README.md.
+func setupSyncRepo(t *testing.T, conflicting bool) string {
+	t.Helper()
+	repoDir := helpers.CreateTestRepo(t)
+	gitCmd := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+	}
+	commitFile := func(name, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0o644))
+		gitCmd("add", name)
+		gitCmd("commit", "-m", "Update "+name)
+	}
+
+	gitCmd("checkout", "-b", "feature")
+	if conflicting {
+		commitFile("README.md", "# Feature\n")
+	} else {
+		commitFile("feature.txt", "feature\n")
+	}
+
+	gitCmd("checkout", "main")
+	if conflicting {
+		commitFile("README.md", "# Main\n")
+	} else {
+		commitFile("main.txt", "main\n")
+	}
+	gitCmd("checkout", "feature")
+
+	return repoDir
+}
+
+func TestOperations_Sync(t *testing.T) {
+	for _, strategy := range []SyncStrategy{SyncRebase, SyncMerge} {
+		t.Run(string(strategy), func(t *testing.T) {
+			repoDir := setupSyncRepo(t, false)
+			ops := NewOperations(repoDir)
+
+			result, err := ops.Sync("main", strategy)
+			require.NoError(t, err)
+			assert.False(t, result.UpToDate)
+			assert.Equal(t, 1, result.Commits)
+			assert.Empty(t, result.Conflicts)
+			assert.NotEqual(t, result.Before, result.After)
+			assert.FileExists(t, filepath.Join(repoDir, "main.txt"))
+
+			_, behind, err := ops.AheadBehind("main")
+			require.NoError(t, err)
+			assert.Equal(t, 0, behind)
+
+			result, err = ops.Sync("main", strategy)
+			require.NoError(t, err)
+			assert.True(t, result.UpToDate)
+		})
+	}
+}
+
+func TestOperations_SyncConflicts(t *testing.T) {
+	for _, strategy := range []SyncStrategy{SyncRebase, SyncMerge} {
+		t.Run(string(strategy), func(t *testing.T) {
+			repoDir := setupSyncRepo(t, true)
+			ops := NewOperations(repoDir)
+
+			result, err := ops.Sync("main", strategy)
+			require.NoError(t, err)
+			require.Len(t, result.Conflicts, 1)
+			assert.Equal(t, Conflict{Path: "README.md", Kind: "both modified", Markers: []int{1}}, result.Conflicts[0])
+			assert.Equal(t, strategy, ops.SyncInProgress())
+
+			_, err = ops.Sync("main", strategy)
+			assert.Error(t, err, "sync while one is in progress")
+
+			aborted, err := ops.AbortSync()
+			require.NoError(t, err)
+			assert.Equal(t, strategy, aborted)
+			assert.Empty(t, ops.SyncInProgress())
+
+			content, err := os.ReadFile(filepath.Join(repoDir, "README.md"))
+			require.NoError(t, err)
+			assert.Equal(t, "# Feature\n", string(content))
+
+			_, err = ops.AbortSync()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOperations_SyncUncommittedChanges(t *testing.T) {
+	repoDir := setupSyncRepo(t, false)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "feature.txt"), []byte("dirty\n"), 0o644))
+
+	_, err := NewOperations(repoDir).Sync("main", SyncRebase)
+	assert.ErrorIs(t, err, ErrUncommittedChanges)
+}
+
+func TestOperations_FetchUpstreamWithoutRemote(t *testing.T) {
+	repoDir := setupSyncRepo(t, false)
+
+	_, err := NewOperations(repoDir).FetchUpstream("main")
+	assert.Error(t, err)
+}
@@ -1,5 +1,7 @@
 package git
 
+import "time"
+
 // WorktreeInfo represents information about a git worktree
 type WorktreeInfo struct {
 	Path   string
@@ -7,6 +9,12 @@ type WorktreeInfo struct {
 	Commit string
 }
 
+// TreeEntry is a file or directory in a git tree
+type TreeEntry struct {
+	Name string
+	Dir  bool
+}
+
 // RepositoryInfo represents information about a git repository
 type RepositoryInfo struct {
 	Path          string
@@ -14,3 +22,111 @@ type RepositoryInfo struct {
 	RemoteURL     string
 	IsClean       bool
 }
+
+// FileStatus describes how a file changed
+type FileStatus string
+
+const (
+	// FileAdded indicates a new file
+	FileAdded FileStatus = "added"
+	// FileModified indicates a changed file
+	FileModified FileStatus = "modified"
+	// FileDeleted indicates a removed file
+	FileDeleted FileStatus = "deleted"
+	// FileRenamed indicates a moved file
+	FileRenamed FileStatus = "renamed"
+	// FileCopied indicates a copied file
+	FileCopied FileStatus = "copied"
+	// FileTypeChanged indicates a file whose type changed (e.g., to a symlink)
+	FileTypeChanged FileStatus = "typechange"
+	// FileUntracked indicates a new file not yet added to git
+	FileUntracked FileStatus = "untracked"
+)
+
+// FileChange represents a file changed relative to a base
+type FileChange struct {
+	Path        string     `json:"path"`
+	OldPath     string     `json:"oldPath,omitempty"` // Previous path of renamed or copied files
+	Status      FileStatus `json:"status"`
+	Insertions  int        `json:"insertions"`
+	Deletions   int        `json:"deletions"`
+	Binary      bool       `json:"binary,omitempty"`
+	Committed   bool       `json:"committed"`   // Changed in commits on the branch
+	Uncommitted bool       `json:"uncommitted"` // Changed in the working tree or index
+}
+
+// DiffSummary summarizes the changes of a branch and its working tree
+// relative to a base branch
+type DiffSummary struct {
+	Base             string       `json:"base"`
+	MergeBase        string       `json:"mergeBase"`
+	Ahead            int          `json:"ahead"`  // Commits on the branch not on base
+	Behind           int          `json:"behind"` // Commits on base not on the branch
+	Insertions       int          `json:"insertions"`
+	Deletions        int          `json:"deletions"`
+	CommittedFiles   int          `json:"committedFiles"`
+	UncommittedFiles int          `json:"uncommittedFiles"`
+	Files            []FileChange `json:"files"`
+}
+
+// SyncStrategy selects how a branch is brought up to date with its base
+type SyncStrategy string
+
+const (
+	// SyncRebase replays the branch's commits on top of the base
+	SyncRebase SyncStrategy = "rebase"
+	// SyncMerge merges the base into the branch
+	SyncMerge SyncStrategy = "merge"
+)
+
+// Conflict describes a file left unmerged by a rebase or merge
+type Conflict struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`              // How the sides disagree (e.g., "both modified")
+	Markers []int  `json:"markers,omitempty"` // Lines where conflict markers (<<<<<<<) start
+}
+
+// SyncResult describes the outcome of bringing a branch up to date with its base
+type SyncResult struct {
+	Strategy  SyncStrategy `json:"strategy"`
+	Base      string       `json:"base"`
+	Before    string       `json:"before"`          // HEAD before the sync
+	After     string       `json:"after,omitempty"` // HEAD after a completed sync
+	Commits   int          `json:"commits"`         // Commits taken from base
+	UpToDate  bool         `json:"upToDate"`
+	Conflicts []Conflict   `json:"conflicts,omitempty"`
+}
+
+// MergeMode selects how a branch is merged into its base
+type MergeMode string
+
+const (
+	// MergeDefault fast-forwards when possible and creates a merge commit otherwise
+	MergeDefault MergeMode = ""
+	// MergeFastForwardOnly refuses to merge unless the base can be fast-forwarded
+	MergeFastForwardOnly MergeMode = "ff-only"
+	// MergeNoFastForward always creates a merge commit
+	MergeNoFastForward MergeMode = "no-ff"
+	// MergeSquash combines the branch's changes into a single commit
+	MergeSquash MergeMode = "squash"
+)
+
+// MergeResult describes the outcome of merging a branch into its base
+type MergeResult struct {
+	Branch      string     `json:"branch"`
+	Base        string     `json:"base"`
+	Mode        MergeMode  `json:"mode,omitempty"`
+	Commits     int        `json:"commits"`          // Commits on the branch that were merged
+	Commit      string     `json:"commit,omitempty"` // Tip of base after the merge
+	FastForward bool       `json:"fastForward"`
+	Conflicts   []Conflict `json:"conflicts,omitempty"`
+}
+
+// RefInfo describes a ref pointing at a commit
+type RefInfo struct {
+	Name    string    `json:"name"`
+	Commit  string    `json:"commit"`
+	Parent  string    `json:"parent,omitempty"` // First parent of the commit
+	Subject string    `json:"subject"`
+	Date    time.Time `json:"date"` // Commit date
+}
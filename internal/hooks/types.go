@@ -40,6 +40,10 @@ const (
 	EventWorkspaceCreate Event = "workspace_create"
 	// EventWorkspaceRemove fires before workspace removal
 	EventWorkspaceRemove Event = "workspace_remove"
+	// EventWorkspacePreMerge fires before a workspace is merged into its base branch
+	EventWorkspacePreMerge Event = "workspace_pre_merge"
+	// EventWorkspacePostMerge fires after a workspace is merged into its base branch
+	EventWorkspacePostMerge Event = "workspace_post_merge"
 	// EventSessionStart fires when session starts
 	EventSessionStart Event = "session_start"
 	// EventSessionStop fires when session stops
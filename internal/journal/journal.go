@@ -0,0 +1,302 @@
+// Package journal records the files that change in a session's workspace
+// while the session runs
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aki/amux/internal/semaphore"
+)
+
+const (
+	// FileName is the name of the journal in a session directory
+	FileName = "files.jsonl"
+
+	// ConflictsFileName is the name of the file in a session directory listing
+	// the files the session changed while other sessions were active
+	ConflictsFileName = "conflicts.jsonl"
+
+	// markerName marks a session directory whose workspace is being watched
+	markerName = "journal.yaml"
+
+	// coalesceWindow is how close together repeated events for a file must be
+	// to be recorded once (e.g., a create followed by the write closing it)
+	coalesceWindow = time.Second
+
+	// activeRefresh is how long the list of active sessions is cached
+	activeRefresh = 2 * time.Second
+)
+
+// ErrUnsupported is returned by Watch on platforms without file change notifications
+var ErrUnsupported = errors.New("file journal is not supported on this platform")
+
+// Op is the kind of change made to a file
+type Op string
+
+const (
+	// OpCreate indicates a file was created or moved into place
+	OpCreate Op = "create"
+	// OpModify indicates a file was written
+	OpModify Op = "modify"
+	// OpDelete indicates a file was deleted or moved away
+	OpDelete Op = "delete"
+)
+
+// Entry is a change to a file in a watched worktree
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Op       Op        `json:"op"`
+	Path     string    `json:"path"`     // Relative to the worktree
+	Sessions []string  `json:"sessions"` // Sessions active in the worktree at the time
+}
+
+// File summarizes the changes recorded for one file
+type File struct {
+	Path    string    `json:"path"`
+	Op      Op        `json:"op"` // Last change
+	Changes int       `json:"changes"`
+	FirstAt time.Time `json:"first_at"`
+	LastAt  time.Time `json:"last_at"`
+	Shared  []string  `json:"shared,omitempty"` // Other sessions active during a change
+}
+
+// Conflict is the first change a session made to a file while other
+// sessions were active in the same worktree
+type Conflict struct {
+	Time     time.Time `json:"time"`
+	Path     string    `json:"path"`
+	Sessions []string  `json:"sessions"` // The other sessions
+}
+
+// Read reads the journal in a session directory. A session without a journal
+// has no entries.
+func Read(sessionDir string) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(sessionDir, FileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open file journal: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip a line cut short by a crash
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file journal: %w", err)
+	}
+	return entries, nil
+}
+
+// ReadConflicts reads the conflicts recorded in a session directory
+func ReadConflicts(sessionDir string) ([]Conflict, error) {
+	data, err := os.ReadFile(filepath.Join(sessionDir, ConflictsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read conflicts: %w", err)
+	}
+
+	var conflicts []Conflict
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		var c Conflict
+		if len(line) == 0 || json.Unmarshal(line, &c) != nil {
+			continue
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, nil
+}
+
+// Summarize groups a session's journal entries by file, sorted by path. Files
+// that were created and deleted again (such as editor swap files) are left out.
+func Summarize(sessionID string, entries []Entry) []File {
+	byPath := make(map[string]*File)
+	created := make(map[string]bool)
+	shared := make(map[string]map[string]bool)
+	for _, e := range entries {
+		f, ok := byPath[e.Path]
+		if !ok {
+			f = &File{Path: e.Path, FirstAt: e.Time}
+			byPath[e.Path] = f
+			created[e.Path] = e.Op == OpCreate
+			shared[e.Path] = make(map[string]bool)
+		}
+		f.Op = e.Op
+		f.LastAt = e.Time
+		f.Changes++
+		for _, s := range e.Sessions {
+			if s != sessionID {
+				shared[e.Path][s] = true
+			}
+		}
+	}
+
+	files := make([]File, 0, len(byPath))
+	for path, f := range byPath {
+		if created[path] && f.Op == OpDelete {
+			continue
+		}
+		for s := range shared[path] {
+			f.Shared = append(f.Shared, s)
+		}
+		sort.Strings(f.Shared)
+		files = append(files, *f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// marker records which worktree a session is journaling, and by which process
+type marker struct {
+	Root string `yaml:"root"`
+	PID  int    `yaml:"pid"`
+}
+
+// writer appends changes to a session's journal, attributing them to the
+// sessions active in the same worktree
+type writer struct {
+	root       string
+	sessionDir string
+	sessionID  string
+	file       *os.File
+	last       map[string]Entry
+	conflicts  map[string]bool // Files and other sessions already recorded as conflicts
+
+	active   []string
+	activeAt time.Time
+}
+
+// newWriter opens the journal of a session directory and marks the session as
+// watching root
+func newWriter(root, sessionDir string) (*writer, error) {
+	data, err := yaml.Marshal(marker{Root: root, PID: os.Getpid()})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(sessionDir, markerName), data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write journal marker: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(sessionDir, FileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		_ = os.Remove(filepath.Join(sessionDir, markerName))
+		return nil, fmt.Errorf("failed to open file journal: %w", err)
+	}
+
+	return &writer{
+		root:       root,
+		sessionDir: sessionDir,
+		sessionID:  filepath.Base(sessionDir),
+		file:       file,
+		last:       make(map[string]Entry),
+		conflicts:  make(map[string]bool),
+	}, nil
+}
+
+// record appends a change unless it repeats the previous change of the file
+func (w *writer) record(op Op, path string, now time.Time) error {
+	if prev, ok := w.last[path]; ok && now.Sub(prev.Time) < coalesceWindow {
+		if prev.Op == op || (prev.Op == OpCreate && op == OpModify) {
+			return nil
+		}
+	}
+
+	e := Entry{Time: now, Op: op, Path: path, Sessions: w.activeSessions(now)}
+	w.last[path] = e
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write file journal: %w", err)
+	}
+	return w.recordConflict(e)
+}
+
+// recordConflict notes a change made while other sessions were active, once
+// per file and set of sessions, so it can be reported while the session runs
+func (w *writer) recordConflict(e Entry) error {
+	var others []string
+	for _, s := range e.Sessions {
+		if s != w.sessionID {
+			others = append(others, s)
+		}
+	}
+	key := e.Path + "\x00" + strings.Join(others, ",")
+	if len(others) == 0 || w.conflicts[key] {
+		return nil
+	}
+	w.conflicts[key] = true
+
+	data, err := json.Marshal(Conflict{Time: e.Time, Path: e.Path, Sessions: others})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(w.sessionDir, ConflictsFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to record conflict: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to record conflict: %w", err)
+	}
+	return nil
+}
+
+// activeSessions lists the sessions whose proxies are watching the same
+// worktree, looking at the markers next to this session's directory
+func (w *writer) activeSessions(now time.Time) []string {
+	if w.active != nil && now.Sub(w.activeAt) < activeRefresh {
+		return w.active
+	}
+
+	active := []string{w.sessionID}
+	dirs, _ := os.ReadDir(filepath.Dir(w.sessionDir))
+	for _, dir := range dirs {
+		if !dir.IsDir() || dir.Name() == w.sessionID {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(w.sessionDir), dir.Name(), markerName))
+		if err != nil {
+			continue
+		}
+		var m marker
+		if yaml.Unmarshal(data, &m) != nil || filepath.Clean(m.Root) != filepath.Clean(w.root) {
+			continue
+		}
+		if semaphore.ProcessAlive(m.PID) {
+			active = append(active, dir.Name())
+		}
+	}
+	sort.Strings(active)
+
+	w.active, w.activeAt = active, now
+	return active
+}
+
+// close closes the journal and removes the session's marker
+func (w *writer) close() error {
+	_ = os.Remove(filepath.Join(w.sessionDir, markerName))
+	return w.file.Close()
+}
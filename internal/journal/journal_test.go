@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_Record(t *testing.T) {
+	root := t.TempDir()
+	sessionsDir := t.TempDir()
+	mine := filepath.Join(sessionsDir, "session-1")
+	other := filepath.Join(sessionsDir, "session-2")
+	elsewhere := filepath.Join(sessionsDir, "session-3")
+	for _, dir := range []string{mine, other, elsewhere} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+
+	w, err := newWriter(root, mine)
+	require.NoError(t, err)
+
+	// Another session watching the same worktree, and one watching another
+	otherWriter, err := newWriter(root, other)
+	require.NoError(t, err)
+	elsewhereWriter, err := newWriter(t.TempDir(), elsewhere)
+	require.NoError(t, err)
+	defer func() { _ = elsewhereWriter.close() }()
+
+	now := time.Now()
+	require.NoError(t, w.record(OpCreate, "a.txt", now))
+	// The write closing a new file is part of creating it
+	require.NoError(t, w.record(OpModify, "a.txt", now.Add(100*time.Millisecond)))
+	require.NoError(t, w.record(OpModify, "a.txt", now.Add(2*time.Second)))
+
+	// Once the other session is gone, changes are only attributed to this one
+	require.NoError(t, otherWriter.close())
+	require.NoError(t, w.record(OpDelete, "a.txt", now.Add(5*time.Second)))
+	require.NoError(t, w.close())
+
+	_, err = os.Stat(filepath.Join(mine, markerName))
+	assert.True(t, os.IsNotExist(err), "marker should be removed on close")
+
+	entries, err := Read(mine)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, OpCreate, entries[0].Op)
+	assert.Equal(t, []string{"session-1", "session-2"}, entries[0].Sessions)
+	assert.Equal(t, OpModify, entries[1].Op)
+	assert.Equal(t, OpDelete, entries[2].Op)
+	assert.Equal(t, []string{"session-1"}, entries[2].Sessions)
+
+	// The change made while the other session was active is a conflict,
+	// recorded once for the file
+	conflicts, err := ReadConflicts(mine)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "a.txt", conflicts[0].Path)
+	assert.Equal(t, []string{"session-2"}, conflicts[0].Sessions)
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Now()
+	entries := []Entry{
+		{Time: now, Op: OpModify, Path: "b.go", Sessions: []string{"s1"}},
+		{Time: now.Add(time.Second), Op: OpModify, Path: "a.go", Sessions: []string{"s1", "s2"}},
+		{Time: now.Add(2 * time.Second), Op: OpCreate, Path: ".a.go.swp", Sessions: []string{"s1"}},
+		{Time: now.Add(3 * time.Second), Op: OpModify, Path: "b.go", Sessions: []string{"s1"}},
+		{Time: now.Add(4 * time.Second), Op: OpDelete, Path: ".a.go.swp", Sessions: []string{"s1"}},
+	}
+
+	files := Summarize("s1", entries)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "a.go", files[0].Path)
+	assert.Equal(t, []string{"s2"}, files[0].Shared)
+
+	assert.Equal(t, "b.go", files[1].Path)
+	assert.Equal(t, 2, files[1].Changes)
+	assert.Equal(t, now, files[1].FirstAt)
+	assert.Equal(t, now.Add(3*time.Second), files[1].LastAt)
+	assert.Empty(t, files[1].Shared)
+}
+
+func TestRead_Missing(t *testing.T) {
+	entries, err := Read(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
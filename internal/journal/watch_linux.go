@@ -0,0 +1,191 @@
+//go:build linux
+
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// watchMask selects the inotify events that are journaled
+	watchMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_DELETE |
+		syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+	// drainTimeout is how long events still queued are read after ctx is done
+	drainTimeout = 100 * time.Millisecond
+)
+
+// Watch records the files that change below root into the journal in
+// sessionDir until ctx is done. The .git directory and directories ignored by
+// git are not watched.
+func Watch(ctx context.Context, root, sessionDir string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	// A non-blocking descriptor is read through the runtime poller, so reads
+	// honor deadlines
+	events := os.NewFile(uintptr(fd), "inotify")
+	defer func() { _ = events.Close() }()
+
+	w, err := newWriter(root, sessionDir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.close() }()
+
+	iw := &inotifyWatcher{
+		fd:      fd,
+		root:    root,
+		dirs:    make(map[int]string),
+		ignored: ignoredDirs(root),
+		writer:  w,
+	}
+	if err := iw.addTree("", false); err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = events.SetReadDeadline(time.Now().Add(drainTimeout))
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := events.Read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil
+			}
+			return fmt.Errorf("failed to read file events: %w", err)
+		}
+		iw.handle(buf[:n])
+	}
+}
+
+// inotifyWatcher tracks the watched directories of a worktree
+type inotifyWatcher struct {
+	fd      int
+	root    string
+	dirs    map[int]string // Watch descriptor to directory relative to root
+	ignored map[string]bool
+	writer  *writer
+}
+
+// addTree watches a directory and the directories below it. Files found in
+// directories that appeared while watching are recorded as created, since
+// they may have been written before the watch was in place.
+func (iw *inotifyWatcher) addTree(rel string, recordFiles bool) error {
+	return filepath.WalkDir(filepath.Join(iw.root, rel), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory may be gone already
+			return nil
+		}
+		relPath, _ := filepath.Rel(iw.root, path)
+		relPath = filepath.ToSlash(relPath)
+		if relPath == "." {
+			relPath = ""
+		}
+
+		if !d.IsDir() {
+			if recordFiles {
+				_ = iw.writer.record(OpCreate, relPath, time.Now())
+			}
+			return nil
+		}
+		if relPath == ".git" || iw.ignored[relPath] || (recordFiles && isIgnored(iw.root, relPath)) {
+			return filepath.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(iw.fd, path, watchMask)
+		if err != nil {
+			if relPath == "" {
+				return fmt.Errorf("failed to watch %s: %w", iw.root, err)
+			}
+			// Out of watches or a vanished directory; keep watching the rest
+			return filepath.SkipDir
+		}
+		iw.dirs[wd] = relPath
+		return nil
+	})
+}
+
+// handle journals a buffer of inotify events
+func (iw *inotifyWatcher) handle(buf []byte) {
+	now := time.Now()
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+		mask := binary.NativeEndian.Uint32(buf[offset+4:])
+		nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+		start := offset + syscall.SizeofInotifyEvent
+		if start+nameLen > len(buf) {
+			return
+		}
+		name := string(bytes.TrimRight(buf[start:start+nameLen], "\x00"))
+		offset = start + nameLen
+
+		if mask&syscall.IN_IGNORED != 0 {
+			delete(iw.dirs, wd)
+			continue
+		}
+		dir, ok := iw.dirs[wd]
+		if !ok || name == "" {
+			continue
+		}
+		rel := name
+		if dir != "" {
+			rel = dir + "/" + name
+		}
+
+		if mask&syscall.IN_ISDIR != 0 {
+			if mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				_ = iw.addTree(rel, true)
+			}
+			continue
+		}
+
+		switch {
+		case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			_ = iw.writer.record(OpCreate, rel, now)
+		case mask&syscall.IN_CLOSE_WRITE != 0:
+			_ = iw.writer.record(OpModify, rel, now)
+		case mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+			_ = iw.writer.record(OpDelete, rel, now)
+		}
+	}
+}
+
+// ignoredDirs lists the directories of a worktree ignored by git
+func ignoredDirs(root string) map[string]bool {
+	ignored := make(map[string]bool)
+	cmd := exec.Command("git", "ls-files", "--others", "--ignored", "--exclude-standard", "--directory", "-z")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return ignored
+	}
+	for _, path := range strings.Split(string(output), "\x00") {
+		if strings.HasSuffix(path, "/") {
+			ignored[strings.TrimSuffix(path, "/")] = true
+		}
+	}
+	return ignored
+}
+
+// isIgnored reports whether git ignores a path of the worktree
+func isIgnored(root, rel string) bool {
+	cmd := exec.Command("git", "check-ignore", "-q", "--", rel)
+	cmd.Dir = root
+	return cmd.Run() == nil
+}
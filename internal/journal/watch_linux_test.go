@@ -0,0 +1,62 @@
+//go:build linux
+
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/tests/helpers"
+)
+
+func TestWatch(t *testing.T) {
+	root := helpers.CreateTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("build/\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "build"), 0o755))
+	sessionDir := filepath.Join(t.TempDir(), "session-1")
+	require.NoError(t, os.MkdirAll(sessionDir, 0o755))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, root, sessionDir) }()
+
+	// Wait for the watches to be in place
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(sessionDir, markerName))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "README.md"), []byte("changed\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src", "pkg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "pkg", "new.go"), []byte("package pkg\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "build", "out.bin"), []byte("ignored\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(root, ".gitignore")))
+
+	require.Eventually(t, func() bool {
+		entries, _ := Read(sessionDir)
+		return len(entries) >= 3
+	}, 5*time.Second, 20*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	entries, err := Read(sessionDir)
+	require.NoError(t, err)
+	files := Summarize("session-1", entries)
+	paths := make(map[string]Op)
+	for _, f := range files {
+		paths[f.Path] = f.Op
+	}
+	assert.Equal(t, OpModify, paths["README.md"])
+	assert.Equal(t, OpCreate, paths["src/pkg/new.go"])
+	assert.Equal(t, OpDelete, paths[".gitignore"])
+	assert.NotContains(t, paths, "build/out.bin")
+	for _, e := range entries {
+		assert.Equal(t, []string{"session-1"}, e.Sessions)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package journal
+
+import "context"
+
+// Watch records the files that change below root into the journal in
+// sessionDir until ctx is done. File change notifications are only
+// available on Linux.
+func Watch(ctx context.Context, root, sessionDir string) error {
+	return ErrUnsupported
+}
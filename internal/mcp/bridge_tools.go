@@ -34,8 +34,8 @@ func (s *ServerV2) registerBridgeTools() error {
 }
 
 // Shared logic for getting workspace list
-func (s *ServerV2) getWorkspaceList(ctx context.Context) ([]workspaceInfo, error) {
-	workspaces, err := s.workspaceManager.List(ctx, workspace.ListOptions{})
+func (s *ServerV2) getWorkspaceList(ctx context.Context, opts workspace.ListOptions) ([]workspaceInfo, error) {
+	workspaces, err := s.workspaceManager.List(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list workspaces: %w", err)
 	}
@@ -49,6 +49,8 @@ func (s *ServerV2) getWorkspaceList(ctx context.Context) ([]workspaceInfo, error
 			Branch:      ws.Branch,
 			BaseBranch:  ws.BaseBranch,
 			Description: ws.Description,
+			Labels:      ws.Labels,
+			Metadata:    ws.Metadata,
 			StoragePath: ws.StoragePath,
 			CreatedAt:   ws.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:   ws.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -75,10 +77,16 @@ func (s *ServerV2) getWorkspaceDetail(ctx context.Context, workspaceID string) (
 		Name:        ws.Name,
 		Branch:      ws.Branch,
 		BaseBranch:  ws.BaseBranch,
+		From:        ws.From,
+		Detached:    ws.Detached,
 		Description: ws.Description,
+		Labels:      ws.Labels,
+		Metadata:    ws.Metadata,
 		Path:        ws.Path,
 		CreatedAt:   ws.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   ws.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Ports:       ws.Ports,
+		Sparse:      ws.Sparse,
 	}
 
 	// Add paths
@@ -88,6 +96,8 @@ func (s *ServerV2) getWorkspaceDetail(ctx context.Context, workspaceID string) (
 	// Add resource URIs
 	detail.Resources.Files = fmt.Sprintf("amux://workspace/%s/files", ws.ID)
 	detail.Resources.Context = fmt.Sprintf("amux://workspace/%s/context", ws.ID)
+	detail.Resources.Ports = fmt.Sprintf("amux://workspace/%s/ports", ws.ID)
+	detail.Resources.Diff = fmt.Sprintf("amux://workspace/%s/diff", ws.ID)
 
 	return detail, nil
 }
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/aki/amux/internal/workspace"
+)
+
+// WorkspaceCheckpointParams defines parameters for checkpointing a workspace
+type WorkspaceCheckpointParams struct {
+	WorkspaceID string `json:"workspace_identifier" mcp:"required" description:"Workspace ID, index, or name"`
+
+	Message string `json:"message,omitempty" description:"Note describing the checkpoint (optional)"`
+}
+
+// WorkspaceRollbackParams defines parameters for rolling a workspace back to a checkpoint
+type WorkspaceRollbackParams struct {
+	WorkspaceID string `json:"workspace_identifier" mcp:"required" description:"Workspace ID, index, or name"`
+
+	Checkpoint string `json:"checkpoint" mcp:"required" description:"Checkpoint ID (from workspace_checkpoint_list) or commit"`
+
+	Force bool `json:"force,omitempty" description:"Roll back even if sessions are using the workspace (optional)"`
+}
+
+// registerCheckpointTools registers the workspace checkpoint tools
+func (s *ServerV2) registerCheckpointTools() error {
+	checkpointOpts, err := WithStructOptions(GetEnhancedDescription("workspace_checkpoint"), WorkspaceCheckpointParams{})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_checkpoint options: %w", err)
+	}
+	s.mcpServer.AddTool(mcp.NewTool("workspace_checkpoint", checkpointOpts...), s.handleWorkspaceCheckpoint)
+
+	listOpts, err := WithStructOptions(GetEnhancedDescription("workspace_checkpoint_list"), WorkspaceIDParams{})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_checkpoint_list options: %w", err)
+	}
+	s.mcpServer.AddTool(mcp.NewTool("workspace_checkpoint_list", listOpts...), s.handleWorkspaceCheckpointList)
+
+	rollbackOpts, err := WithStructOptions(GetEnhancedDescription("workspace_rollback"), WorkspaceRollbackParams{})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_rollback options: %w", err)
+	}
+	s.mcpServer.AddTool(mcp.NewTool("workspace_rollback", rollbackOpts...), s.handleWorkspaceRollback)
+
+	return nil
+}
+
+// handleWorkspaceCheckpoint records the current state of a workspace
+func (s *ServerV2) handleWorkspaceCheckpoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params WorkspaceCheckpointParams
+	if err := UnmarshalArgs(request, &params); err != nil {
+		return nil, err
+	}
+	ws, err := s.resolveToolWorkspace(ctx, params.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cp, err := s.workspaceManager.Checkpoint(ctx, workspace.Identifier(ws.ID), params.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint workspace: %w", err)
+	}
+
+	return createEnhancedResult("workspace_checkpoint", cp, nil)
+}
+
+// handleWorkspaceCheckpointList lists the checkpoints of a workspace
+func (s *ServerV2) handleWorkspaceCheckpointList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params WorkspaceIDParams
+	if err := UnmarshalArgs(request, &params); err != nil {
+		return nil, err
+	}
+	ws, err := s.resolveToolWorkspace(ctx, params.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := s.workspaceManager.ListCheckpoints(ctx, workspace.Identifier(ws.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	return createEnhancedResult("workspace_checkpoint_list", checkpoints, nil)
+}
+
+// handleWorkspaceRollback restores a workspace to a checkpoint
+func (s *ServerV2) handleWorkspaceRollback(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params WorkspaceRollbackParams
+	if err := UnmarshalArgs(request, &params); err != nil {
+		return nil, err
+	}
+	if params.Checkpoint == "" {
+		return nil, fmt.Errorf("invalid or missing checkpoint argument")
+	}
+	ws, err := s.resolveToolWorkspace(ctx, params.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.workspaceManager.Rollback(ctx, workspace.Identifier(ws.ID), params.Checkpoint, params.Force)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back workspace: %w", err)
+	}
+
+	return createEnhancedResult("workspace_rollback", map[string]interface{}{
+		"workspace_id": ws.ID,
+		"checkpoint":   params.Checkpoint,
+		"saved":        saved,
+		"message":      fmt.Sprintf("Rolled back workspace %s to checkpoint %s; the previous state is checkpoint %s", ws.Name, params.Checkpoint, saved.ID),
+	}, nil)
+}
+
+// resolveToolWorkspace resolves the workspace named in a tool call
+func (s *ServerV2) resolveToolWorkspace(ctx context.Context, identifier string) (*workspace.Workspace, error) {
+	if identifier == "" {
+		return nil, fmt.Errorf("invalid or missing workspace_identifier argument")
+	}
+	ws, err := s.workspaceManager.ResolveWorkspace(ctx, workspace.Identifier(identifier))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, WorkspaceNotFoundError(identifier)
+		}
+		return nil, fmt.Errorf("failed to resolve workspace: %w", err)
+	}
+	return ws, nil
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/aki/amux/internal/git"
 	"github.com/aki/amux/internal/workspace"
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -41,6 +44,33 @@ func (s *ServerV2) registerResourceTemplates() error {
 	)
 	s.mcpServer.AddResourceTemplate(workspaceContextTemplate, s.handleWorkspaceContextResource)
 
+	// Register workspace ports template
+	workspacePortsTemplate := mcp.NewResourceTemplate(
+		"amux://workspace/{id}/ports",
+		"Workspace Ports",
+		mcp.WithTemplateDescription("Get the named ports allocated to a workspace and their AMUX_PORT_<NAME> variables"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.mcpServer.AddResourceTemplate(workspacePortsTemplate, s.handleWorkspacePortsResource)
+
+	// Register workspace diff template
+	workspaceDiffTemplate := mcp.NewResourceTemplate(
+		"amux://workspace/{id}/diff",
+		"Workspace Diff",
+		mcp.WithTemplateDescription("Get the files changed in a workspace relative to its base branch"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.mcpServer.AddResourceTemplate(workspaceDiffTemplate, s.handleWorkspaceDiffResource)
+
+	// Register session files template
+	sessionFilesTemplate := mcp.NewResourceTemplate(
+		"amux://session/{id}/files",
+		"Session Files",
+		mcp.WithTemplateDescription("Get the files changed in a session's workspace while the session ran, flagging files changed while other sessions were active"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.mcpServer.AddResourceTemplate(sessionFilesTemplate, s.handleSessionFilesResource)
+
 	return nil
 }
 
@@ -54,6 +84,8 @@ type workspacePaths struct {
 type workspaceResources struct {
 	Files   string `json:"files"`
 	Context string `json:"context"`
+	Ports   string `json:"ports"`
+	Diff    string `json:"diff"`
 }
 
 // workspaceDetail is the common structure for detailed workspace information
@@ -63,10 +95,16 @@ type workspaceDetail struct {
 	Name        string             `json:"name"`
 	Branch      string             `json:"branch"`
 	BaseBranch  string             `json:"baseBranch"`
+	From        string             `json:"from,omitempty"`
+	Detached    bool               `json:"detached,omitempty"`
 	Path        string             `json:"path"`
 	Description string             `json:"description,omitempty"`
+	Labels      []string           `json:"labels,omitempty"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
 	CreatedAt   string             `json:"createdAt"`
 	UpdatedAt   string             `json:"updatedAt"`
+	Ports       map[string]int     `json:"ports,omitempty"`
+	Sparse      []string           `json:"sparse,omitempty"`
 	Paths       workspacePaths     `json:"paths"`
 	Resources   workspaceResources `json:"resources"`
 }
@@ -122,6 +160,25 @@ func (s *ServerV2) handleWorkspaceDetailResource(ctx context.Context, request mc
 	}, nil
 }
 
+// outsideSparse reports whether a path that is missing from a sparse
+// workspace is in git but left out of its checkout
+func outsideSparse(ws *workspace.Workspace, subPath string) bool {
+	if len(ws.Sparse) == 0 {
+		return false
+	}
+	relPath := filepath.ToSlash(subPath)
+	tree, err := git.NewOperations(ws.Path).ListTree("HEAD", path.Dir(relPath))
+	if err != nil {
+		return false
+	}
+	for _, entry := range tree {
+		if entry.Name == path.Base(relPath) {
+			return !ws.IsMaterialized(relPath, entry.Dir)
+		}
+	}
+	return false
+}
+
 // handleWorkspaceFilesResource lists files in a workspace directory
 func (s *ServerV2) handleWorkspaceFilesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 	workspaceID, subPath, err := parseWorkspaceURI(request.Params.URI)
@@ -155,6 +212,9 @@ func (s *ServerV2) handleWorkspaceFilesResource(ctx context.Context, request mcp
 	// Check if path exists and is a directory
 	info, err := os.Stat(fullPath)
 	if err != nil {
+		if os.IsNotExist(err) && outsideSparse(ws, subPath) {
+			return nil, fmt.Errorf("%s is outside the sparse checkout of workspace '%s' (add it with 'amux ws sparse add %s <dir>')", subPath, ws.Name, ws.Name)
+		}
 		return nil, fmt.Errorf("failed to stat path: %w", err)
 	}
 
@@ -190,12 +250,15 @@ func (s *ServerV2) handleWorkspaceFilesResource(ctx context.Context, request mcp
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
 
+	// In sparse workspaces, entries say whether they are checked out
 	type fileInfo struct {
-		Name string `json:"name"`
-		Type string `json:"type"`
-		Size int64  `json:"size"`
+		Name         string `json:"name"`
+		Type         string `json:"type"`
+		Size         int64  `json:"size"`
+		Materialized *bool  `json:"materialized,omitempty"`
 	}
 
+	materialized := true
 	files := make([]fileInfo, 0, len(entries))
 	for _, entry := range entries {
 		// Skip hidden files
@@ -213,11 +276,35 @@ func (s *ServerV2) handleWorkspaceFilesResource(ctx context.Context, request mcp
 			fileType = "directory"
 		}
 
-		files = append(files, fileInfo{
+		file := fileInfo{
 			Name: entry.Name(),
 			Type: fileType,
 			Size: info.Size(),
-		})
+		}
+		if len(ws.Sparse) > 0 {
+			file.Materialized = &materialized
+		}
+		files = append(files, file)
+	}
+
+	// Also list what git has but the sparse checkout leaves out
+	if len(ws.Sparse) > 0 {
+		tree, err := git.NewOperations(ws.Path).ListTree("HEAD", filepath.ToSlash(subPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sparse directory: %w", err)
+		}
+		notMaterialized := false
+		for _, entry := range tree {
+			if strings.HasPrefix(entry.Name, ".") || slices.ContainsFunc(entries, func(e os.DirEntry) bool { return e.Name() == entry.Name }) {
+				continue
+			}
+			fileType := "file"
+			if entry.Dir {
+				fileType = "directory"
+			}
+			files = append(files, fileInfo{Name: entry.Name, Type: fileType, Materialized: &notMaterialized})
+		}
+		slices.SortFunc(files, func(a, b fileInfo) int { return strings.Compare(a.Name, b.Name) })
 	}
 
 	jsonData, err := json.MarshalIndent(files, "", "  ")
@@ -274,3 +361,97 @@ func (s *ServerV2) handleWorkspaceContextResource(ctx context.Context, request m
 		},
 	}, nil
 }
+
+// workspacePorts is the content of the workspace ports resource
+type workspacePorts struct {
+	WorkspaceID string            `json:"workspaceId"`
+	Ports       map[string]int    `json:"ports"`
+	Env         map[string]string `json:"env"`
+}
+
+// handleWorkspacePortsResource returns the ports allocated to a workspace
+func (s *ServerV2) handleWorkspacePortsResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	workspaceID, _, err := parseWorkspaceURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := s.workspaceManager.ResolveWorkspace(ctx, workspace.Identifier(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	ports := ws.Ports
+	if ports == nil {
+		ports = map[string]int{}
+	}
+	jsonData, err := json.MarshalIndent(workspacePorts{
+		WorkspaceID: ws.ID,
+		Ports:       ports,
+		Env:         workspace.PortEnvironment(ports),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workspace ports: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		&mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
+
+// handleWorkspaceDiffResource returns the changes of a workspace relative to its base branch
+func (s *ServerV2) handleWorkspaceDiffResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	workspaceID, _, err := parseWorkspaceURI(request.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := s.getWorkspaceDiff(ctx, workspaceID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workspace diff: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		&mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
+
+// handleSessionFilesResource returns the files changed in a session's workspace while it ran
+func (s *ServerV2) handleSessionFilesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	path := strings.TrimPrefix(request.Params.URI, "amux://session/")
+	sessionID := strings.TrimSuffix(path, "/files")
+	if sessionID == "" || sessionID == path || strings.Contains(sessionID, "/") {
+		return nil, fmt.Errorf("invalid session files URI: %s", request.Params.URI)
+	}
+
+	activity, err := s.getSessionManager().Files(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session files: %w", err)
+	}
+
+	jsonData, err := json.MarshalIndent(activity, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session files: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		&mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(jsonData),
+		},
+	}, nil
+}
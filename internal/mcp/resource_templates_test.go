@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aki/amux/internal/journal"
+	"github.com/aki/amux/internal/session"
 	"github.com/aki/amux/internal/workspace"
 )
 
@@ -121,6 +125,8 @@ func TestHandleWorkspaceDetailResource(t *testing.T) {
 	require.True(t, ok, "resources field should be present")
 	assert.Equal(t, fmt.Sprintf("amux://workspace/%s/files", ws.ID), resources["files"])
 	assert.Equal(t, fmt.Sprintf("amux://workspace/%s/context", ws.ID), resources["context"])
+	assert.Equal(t, fmt.Sprintf("amux://workspace/%s/ports", ws.ID), resources["ports"])
+	assert.Equal(t, fmt.Sprintf("amux://workspace/%s/diff", ws.ID), resources["diff"])
 }
 
 func TestHandleWorkspaceFilesResource(t *testing.T) {
@@ -202,6 +208,64 @@ func TestHandleWorkspaceFilesResource(t *testing.T) {
 	})
 }
 
+func TestHandleWorkspaceFilesResource_Sparse(t *testing.T) {
+	s := setupTestServer(t)
+	ctx := context.Background()
+
+	// Commit a few directories on a branch to check out sparsely
+	seed, err := s.workspaceManager.Create(ctx, workspace.CreateOptions{Name: "seed"})
+	require.NoError(t, err)
+	for _, file := range []string{"api/main.go", "web/index.html"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(seed.Path, filepath.Dir(file)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(seed.Path, file), []byte(file), 0o644))
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "Add directories"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = seed.Path
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+	}
+
+	ws, err := s.workspaceManager.Create(ctx, workspace.CreateOptions{
+		Name:   "test-sparse",
+		From:   seed.Branch,
+		Sparse: []string{"api"},
+	})
+	require.NoError(t, err)
+
+	read := func(subPath string) ([]mcp.ResourceContents, error) {
+		return s.handleWorkspaceFilesResource(ctx, mcp.ReadResourceRequest{
+			Params: mcp.ReadResourceParams{
+				URI: fmt.Sprintf("amux://workspace/%s/files%s", ws.ID, subPath),
+			},
+		})
+	}
+
+	contents, err := read("")
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	textContent, ok := contents[0].(*mcp.TextResourceContents)
+	require.True(t, ok)
+
+	var files []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(textContent.Text), &files))
+	materialized := make(map[string]interface{})
+	for _, f := range files {
+		materialized[f["name"].(string)] = f["materialized"]
+	}
+	assert.Equal(t, true, materialized["api"])
+	assert.Equal(t, true, materialized["README.md"])
+	assert.Equal(t, false, materialized["web"], "web is in git but not checked out")
+
+	_, err = read("/web/index.html")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the sparse checkout")
+
+	contents, err = read("/api/main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "api/main.go", contents[0].(*mcp.TextResourceContents).Text)
+}
+
 func TestHandleWorkspaceContextResource(t *testing.T) {
 	s := setupTestServer(t)
 
@@ -254,6 +318,115 @@ func TestHandleWorkspaceContextResource(t *testing.T) {
 	})
 }
 
+func TestHandleWorkspacePortsResource(t *testing.T) {
+	s := setupTestServer(t)
+
+	ws, err := s.workspaceManager.Create(context.Background(), workspace.CreateOptions{
+		Name: "test-ports",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	request := mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{
+			URI: fmt.Sprintf("amux://workspace/%s/ports", ws.ID),
+		},
+	}
+
+	t.Run("no ports", func(t *testing.T) {
+		contents, err := s.handleWorkspacePortsResource(ctx, request)
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+
+		textContent, ok := contents[0].(*mcp.TextResourceContents)
+		require.True(t, ok)
+		assert.Equal(t, "application/json", textContent.MIMEType)
+
+		var result workspacePorts
+		require.NoError(t, json.Unmarshal([]byte(textContent.Text), &result))
+		assert.Equal(t, ws.ID, result.WorkspaceID)
+		assert.Empty(t, result.Ports)
+	})
+
+	t.Run("with ports", func(t *testing.T) {
+		ports, err := s.workspaceManager.AllocatePorts(ctx, workspace.Identifier(ws.ID), []string{"web"})
+		require.NoError(t, err)
+
+		contents, err := s.handleWorkspacePortsResource(ctx, request)
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+
+		textContent, ok := contents[0].(*mcp.TextResourceContents)
+		require.True(t, ok)
+
+		var result workspacePorts
+		require.NoError(t, json.Unmarshal([]byte(textContent.Text), &result))
+		assert.Equal(t, ports["web"], result.Ports["web"])
+		assert.Equal(t, fmt.Sprint(ports["web"]), result.Env["AMUX_PORT_WEB"])
+	})
+}
+
+func TestHandleWorkspaceDiff(t *testing.T) {
+	s := setupTestServer(t)
+
+	ctx := context.Background()
+	ws, err := s.workspaceManager.Create(ctx, workspace.CreateOptions{
+		Name: "test-diff",
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(ws.Path, "new.txt"), []byte("one\ntwo\n"), 0o644))
+
+	t.Run("resource", func(t *testing.T) {
+		contents, err := s.handleWorkspaceDiffResource(ctx, mcp.ReadResourceRequest{
+			Params: mcp.ReadResourceParams{
+				URI: fmt.Sprintf("amux://workspace/%s/diff", ws.ID),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+
+		textContent, ok := contents[0].(*mcp.TextResourceContents)
+		require.True(t, ok)
+
+		var result workspaceDiff
+		require.NoError(t, json.Unmarshal([]byte(textContent.Text), &result))
+		assert.Equal(t, ws.ID, result.WorkspaceID)
+		require.Len(t, result.Files, 1)
+		assert.Equal(t, "new.txt", result.Files[0].Path)
+		assert.Equal(t, 2, result.Files[0].Insertions)
+		assert.True(t, result.Files[0].Uncommitted)
+		assert.Empty(t, result.Patch)
+	})
+
+	t.Run("tool with patch", func(t *testing.T) {
+		result, err := s.handleWorkspaceDiff(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "workspace_diff",
+				Arguments: map[string]interface{}{
+					"workspace_identifier": ws.Name,
+					"patch":                true,
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Content, 1)
+
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "+one")
+	})
+
+	t.Run("unknown workspace", func(t *testing.T) {
+		_, err := s.handleWorkspaceDiff(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "workspace_diff",
+				Arguments: map[string]interface{}{"workspace_identifier": "missing"},
+			},
+		})
+		assert.Error(t, err)
+	})
+}
+
 func TestRegisterResourceTemplates(t *testing.T) {
 	s := setupTestServer(t)
 
@@ -299,3 +472,46 @@ func TestRegisterResourceTemplates(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, contents)
 }
+
+func TestHandleSessionFilesResource(t *testing.T) {
+	s := setupTestServer(t)
+	ctx := context.Background()
+
+	// A stopped session whose proxy journaled a change
+	amuxDir := s.configManager.GetAmuxDir()
+	stoppedAt := time.Now()
+	sess := &session.Session{
+		ID:          "session-files",
+		WorkspaceID: "ws-1",
+		Runtime:     "local",
+		Status:      session.StatusStopped,
+		StartedAt:   stoppedAt.Add(-time.Minute),
+		StoppedAt:   &stoppedAt,
+	}
+	require.NoError(t, session.NewFileStore(amuxDir).Save(ctx, sess))
+	sessionDir := filepath.Join(amuxDir, "sessions", sess.ID)
+	require.NoError(t, os.MkdirAll(sessionDir, 0o755))
+	entry := fmt.Sprintf(`{"time":%q,"op":"modify","path":"main.go","sessions":["session-files","session-other"]}`+"\n",
+		stoppedAt.Format(time.RFC3339Nano))
+	require.NoError(t, os.WriteFile(filepath.Join(sessionDir, journal.FileName), []byte(entry), 0o644))
+
+	contents, err := s.handleSessionFilesResource(ctx, mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{URI: "amux://session/session-files/files"},
+	})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	textContent, ok := contents[0].(*mcp.TextResourceContents)
+	require.True(t, ok)
+
+	var activity session.FileActivity
+	require.NoError(t, json.Unmarshal([]byte(textContent.Text), &activity))
+	require.Len(t, activity.Files, 1)
+	assert.Equal(t, "main.go", activity.Files[0].Path)
+	assert.Equal(t, []string{"session-other"}, activity.Files[0].Shared)
+	assert.Equal(t, []string{"main.go"}, activity.Conflicts)
+
+	_, err = s.handleSessionFilesResource(ctx, mcp.ReadResourceRequest{
+		Params: mcp.ReadResourceParams{URI: "amux://session/session-files"},
+	})
+	assert.Error(t, err)
+}
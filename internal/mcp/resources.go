@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/aki/amux/internal/workspace"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -24,15 +25,17 @@ func (s *ServerV2) registerResources() error {
 
 // workspaceInfo is the common structure for workspace information
 type workspaceInfo struct {
-	ID          string `json:"id"`
-	Index       string `json:"index"`
-	Name        string `json:"name"`
-	Branch      string `json:"branch"`
-	BaseBranch  string `json:"baseBranch"`
-	Description string `json:"description,omitempty"`
-	StoragePath string `json:"storagePath,omitempty"`
-	CreatedAt   string `json:"createdAt"`
-	UpdatedAt   string `json:"updatedAt"`
+	ID          string            `json:"id"`
+	Index       string            `json:"index"`
+	Name        string            `json:"name"`
+	Branch      string            `json:"branch"`
+	BaseBranch  string            `json:"baseBranch"`
+	Description string            `json:"description,omitempty"`
+	Labels      []string          `json:"labels,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	StoragePath string            `json:"storagePath,omitempty"`
+	CreatedAt   string            `json:"createdAt"`
+	UpdatedAt   string            `json:"updatedAt"`
 	Resources   struct {
 		Detail  string `json:"detail"`
 		Files   string `json:"files"`
@@ -42,7 +45,7 @@ type workspaceInfo struct {
 
 // handleWorkspaceListResource returns a list of all workspaces
 func (s *ServerV2) handleWorkspaceListResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-	workspaceList, err := s.getWorkspaceList(ctx)
+	workspaceList, err := s.getWorkspaceList(ctx, workspace.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
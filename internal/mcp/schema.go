@@ -185,9 +185,62 @@ type WorkspaceCreateParams struct {
 	Branch string `json:"branch,omitempty" description:"Use existing branch (optional)"`
 
 	Description string `json:"description,omitempty" description:"Description (optional)"`
+
+	From string `json:"from,omitempty" description:"Commit SHA, tag or remote branch such as origin/fix to start from instead of the base branch (optional). A remote branch gets a local branch tracking it"`
+
+	Detached bool `json:"detached,omitempty" description:"Check out without a branch, e.g. for read-only investigation (optional)"`
+
+	Labels string `json:"labels,omitempty" description:"Comma-separated labels such as bug,needs-review (optional)"`
+
+	Metadata string `json:"metadata,omitempty" description:"Comma-separated key=value metadata such as issue=142,agent=claude (optional)"`
+
+	Concurrency string `json:"concurrency,omitempty" description:"How many sessions may run in the workspace at once: exclusive, shared, shared:N or unlimited (optional, default from config)"`
+
+	Sparse string `json:"sparse,omitempty" description:"Comma-separated directories such as services/api,libs/common to check out instead of the whole repository (optional). Files outside them are not materialized"`
+}
+
+// WorkspaceListParams defines parameters for filtering and sorting the workspace list
+type WorkspaceListParams struct {
+	Labels string `json:"labels,omitempty" description:"Only workspaces with all of these comma-separated labels (optional)"`
+
+	Metadata string `json:"metadata,omitempty" description:"Only workspaces with this comma-separated metadata, as key=value or key (optional)"`
+
+	Status string `json:"status,omitempty" description:"Only workspaces with this status: consistent, folder-missing, worktree-missing or orphaned (optional)"`
+
+	Branch string `json:"branch,omitempty" description:"Only workspaces whose branch matches this glob pattern, e.g. feature/* (optional)"`
+
+	OlderThan string `json:"older_than,omitempty" description:"Only workspaces last modified longer ago than this, e.g. 7d or 12h (optional)"`
+
+	NewerThan string `json:"newer_than,omitempty" description:"Only workspaces last modified within this time, e.g. 7d or 12h (optional)"`
+
+	InUse bool `json:"in_use,omitempty" description:"true for workspaces with running sessions, false for those without (optional)"`
+
+	Sort string `json:"sort,omitempty" description:"Sort by created (default, newest first), updated, name or branch (optional)"`
+
+	Reverse bool `json:"reverse,omitempty" description:"Reverse the sort order (optional)"`
 }
 
 // WorkspaceIDParams defines parameters for workspace operations requiring an ID
 type WorkspaceIDParams struct {
 	WorkspaceID string `json:"workspace_identifier" mcp:"required" description:"Workspace ID, index, or name"`
 }
+
+// WorkspaceDiffParams defines parameters for diffing a workspace against its base branch
+type WorkspaceDiffParams struct {
+	WorkspaceID string `json:"workspace_identifier" mcp:"required" description:"Workspace ID, index, or name"`
+
+	Patch bool `json:"patch,omitempty" description:"Include the full patch (optional)"`
+
+	Paths []string `json:"paths,omitempty" description:"Limit the diff to these paths (optional)"`
+}
+
+// WorkspaceSyncParams defines parameters for syncing a workspace with its base branch
+type WorkspaceSyncParams struct {
+	WorkspaceID string `json:"workspace_identifier" mcp:"required" description:"Workspace ID, index, or name"`
+
+	Strategy string `json:"strategy,omitempty" description:"How to sync: rebase (default) or merge (optional)"`
+
+	Fetch bool `json:"fetch,omitempty" description:"Fetch the base branch's remote and sync with its upstream (optional)"`
+
+	Abort bool `json:"abort,omitempty" description:"Abort a rebase or merge that stopped on conflicts (optional)"`
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
 	"github.com/aki/amux/internal/workspace"
 )
 
@@ -105,6 +106,29 @@ func (s *ServerV2) registerTools() error {
 
 	s.mcpServer.AddTool(mcp.NewTool("workspace_remove", removeOpts...), s.handleWorkspaceRemove)
 
+	// workspace_diff tool
+
+	diffOpts, err := WithStructOptions(GetEnhancedDescription("workspace_diff"), WorkspaceDiffParams{})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_diff options: %w", err)
+	}
+
+	s.mcpServer.AddTool(mcp.NewTool("workspace_diff", diffOpts...), s.handleWorkspaceDiff)
+
+	// workspace_sync tool
+
+	syncOpts, err := WithStructOptions(GetEnhancedDescription("workspace_sync"), WorkspaceSyncParams{})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_sync options: %w", err)
+	}
+
+	s.mcpServer.AddTool(mcp.NewTool("workspace_sync", syncOpts...), s.handleWorkspaceSync)
+
+	// Register checkpoint tools
+	if err := s.registerCheckpointTools(); err != nil {
+		return fmt.Errorf("failed to register checkpoint tools: %w", err)
+	}
+
 	// Register storage tools
 	if err := s.registerStorageTools(); err != nil {
 		return fmt.Errorf("failed to register storage tools: %w", err)
@@ -142,6 +166,37 @@ func (s *ServerV2) handleWorkspaceCreate(ctx context.Context, request mcp.CallTo
 		opts.Description = description
 	}
 
+	if from, ok := args["from"].(string); ok {
+		opts.From = from
+	}
+
+	if detached, ok := args["detached"].(bool); ok && detached {
+		opts.BranchMode = workspace.BranchModeDetach
+	}
+
+	if labels, ok := args["labels"].(string); ok {
+		opts.Labels = splitList(labels)
+	}
+
+	if metadata, ok := args["metadata"].(string); ok && metadata != "" {
+		set, unset, err := workspace.ParseMetadata(splitList(metadata))
+		if err != nil {
+			return nil, err
+		}
+		if len(unset) > 0 {
+			return nil, fmt.Errorf("metadata expects key=value entries, got '%s-'", unset[0])
+		}
+		opts.Metadata = set
+	}
+
+	if concurrency, ok := args["concurrency"].(string); ok {
+		opts.Concurrency = concurrency
+	}
+
+	if sparse, ok := args["sparse"].(string); ok {
+		opts.Sparse = splitList(sparse)
+	}
+
 	ws, err := s.workspaceManager.Create(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
@@ -352,3 +407,93 @@ func (s *ServerV2) authMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// workspaceDiff is the result of the workspace_diff tool and resource
+type workspaceDiff struct {
+	WorkspaceID string `json:"workspaceId"`
+	*git.DiffSummary
+	Patch string `json:"patch,omitempty"`
+}
+
+// handleWorkspaceDiff summarizes the changes of a workspace relative to its base branch
+func (s *ServerV2) handleWorkspaceDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params WorkspaceDiffParams
+	if err := UnmarshalArgs(request, &params); err != nil {
+		return nil, err
+	}
+	result, err := s.getWorkspaceDiff(ctx, params.WorkspaceID, params.Patch, params.Paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return createEnhancedResult("workspace_diff", result, nil)
+}
+
+// getWorkspaceDiff builds the diff of a workspace, optionally with its patch
+func (s *ServerV2) getWorkspaceDiff(ctx context.Context, workspaceID string, withPatch bool, paths ...string) (*workspaceDiff, error) {
+	ws, err := s.resolveToolWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.workspaceManager.Diff(ctx, workspace.Identifier(ws.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff workspace: %w", err)
+	}
+	summary.Filter(paths...)
+
+	result := &workspaceDiff{WorkspaceID: ws.ID, DiffSummary: summary}
+	if withPatch {
+		result.Patch, err = s.workspaceManager.DiffPatch(ctx, workspace.Identifier(ws.ID), paths...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff workspace: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// handleWorkspaceSync brings a workspace branch up to date with its base branch
+func (s *ServerV2) handleWorkspaceSync(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params WorkspaceSyncParams
+	if err := UnmarshalArgs(request, &params); err != nil {
+		return nil, err
+	}
+	ws, err := s.resolveToolWorkspace(ctx, params.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Abort {
+		strategy, err := s.workspaceManager.AbortSync(ctx, workspace.Identifier(ws.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to abort sync: %w", err)
+		}
+		return createEnhancedResult("workspace_sync", map[string]interface{}{
+			"workspace_id": ws.ID,
+			"aborted":      strategy,
+			"message":      fmt.Sprintf("Aborted %s in workspace %s", strategy, ws.Name),
+		}, nil)
+	}
+
+	strategy := git.SyncStrategy(params.Strategy)
+	switch strategy {
+	case "":
+		strategy = git.SyncRebase
+	case git.SyncRebase, git.SyncMerge:
+	default:
+		return nil, fmt.Errorf("invalid strategy %q: must be rebase or merge", params.Strategy)
+	}
+
+	result, err := s.workspaceManager.Sync(ctx, workspace.Identifier(ws.ID), workspace.SyncOptions{
+		Strategy: strategy,
+		Fetch:    params.Fetch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync workspace: %w", err)
+	}
+
+	return createEnhancedResult("workspace_sync", struct {
+		WorkspaceID string `json:"workspaceId"`
+		*git.SyncResult
+	}{ws.ID, result}, nil)
+}
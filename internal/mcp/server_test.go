@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestHandleWorkspaceSync(t *testing.T) {
+	s := setupTestServer(t)
+	ctx := context.Background()
+
+	ws, err := s.workspaceManager.Create(ctx, workspace.CreateOptions{
+		Name:       "test-sync",
+		BaseBranch: "main",
+	})
+	require.NoError(t, err)
+
+	// Advance the base branch in the main repository
+	repoDir := s.configManager.GetProjectRoot()
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.txt"), []byte("main\n"), 0o644))
+	for _, args := range [][]string{{"add", "main.txt"}, {"commit", "-m", "Add main.txt"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, output)
+	}
+
+	call := func(args map[string]interface{}) (map[string]interface{}, error) {
+		result, err := s.handleWorkspaceSync(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: "workspace_sync", Arguments: args},
+		})
+		if err != nil {
+			return nil, err
+		}
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(textContent.Text), &response))
+		return response["result"].(map[string]interface{}), nil
+	}
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, err := call(map[string]interface{}{"workspace_identifier": ws.Name, "strategy": "squash"})
+		assert.Error(t, err)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		result, err := call(map[string]interface{}{"workspace_identifier": ws.Name, "strategy": "merge"})
+		require.NoError(t, err)
+		assert.Equal(t, ws.ID, result["workspaceId"])
+		assert.Equal(t, "merge", result["strategy"])
+		assert.Equal(t, float64(1), result["commits"])
+		assert.FileExists(t, filepath.Join(ws.Path, "main.txt"))
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		result, err := call(map[string]interface{}{"workspace_identifier": ws.Name})
+		require.NoError(t, err)
+		assert.Equal(t, true, result["upToDate"])
+	})
+
+	t.Run("abort without sync in progress", func(t *testing.T) {
+		_, err := call(map[string]interface{}{"workspace_identifier": ws.Name, "abort": true})
+		assert.Error(t, err)
+	})
+}
+
+func TestHandleWorkspaceCheckpoint(t *testing.T) {
+	s := setupTestServer(t)
+	ctx := context.Background()
+
+	ws, err := s.workspaceManager.Create(ctx, workspace.CreateOptions{
+		Name:       "test-checkpoint",
+		BaseBranch: "main",
+	})
+	require.NoError(t, err)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) (interface{}, error) {
+		result, err := handler(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Arguments: args},
+		})
+		if err != nil {
+			return nil, err
+		}
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(textContent.Text), &response))
+		return response["result"], nil
+	}
+
+	notes := filepath.Join(ws.Path, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("before\n"), 0o644))
+
+	result, err := call(s.handleWorkspaceCheckpoint, map[string]interface{}{
+		"workspace_identifier": ws.Name,
+		"message":              "before refactor",
+	})
+	require.NoError(t, err)
+	cp := result.(map[string]interface{})
+	assert.Equal(t, "1", cp["id"])
+	assert.Equal(t, "before refactor", cp["message"])
+
+	require.NoError(t, os.WriteFile(notes, []byte("after\n"), 0o644))
+
+	result, err = call(s.handleWorkspaceRollback, map[string]interface{}{
+		"workspace_identifier": ws.Name,
+		"checkpoint":           "1",
+	})
+	require.NoError(t, err)
+	saved := result.(map[string]interface{})["saved"].(map[string]interface{})
+	assert.Equal(t, "2", saved["id"])
+
+	content, err := os.ReadFile(notes)
+	require.NoError(t, err)
+	assert.Equal(t, "before\n", string(content))
+
+	result, err = call(s.handleWorkspaceCheckpointList, map[string]interface{}{
+		"workspace_identifier": ws.Name,
+	})
+	require.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	t.Run("unknown checkpoint", func(t *testing.T) {
+		_, err := call(s.handleWorkspaceRollback, map[string]interface{}{
+			"workspace_identifier": ws.Name,
+			"checkpoint":           "99",
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown workspace", func(t *testing.T) {
+		_, err := call(s.handleWorkspaceCheckpoint, map[string]interface{}{
+			"workspace_identifier": "does-not-exist",
+		})
+		assert.Error(t, err)
+	})
+}
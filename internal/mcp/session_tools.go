@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
@@ -17,12 +18,16 @@ type SessionRunParams struct {
 	AutoCreateWorkspace bool              `json:"auto_create_workspace,omitempty" jsonschema:"description=Auto-create workspace if not specified,default=true"`
 	Name                string            `json:"name,omitempty" jsonschema:"description=Human-readable name for the session"`
 	Description         string            `json:"description,omitempty" jsonschema:"description=Description of session purpose"`
+	AgentID             string            `json:"agent_id,omitempty" jsonschema:"description=Agent from the configuration to run, with its command, runtime and runtime options"`
 	TaskName            string            `json:"task_name,omitempty" jsonschema:"description=Name of a predefined task to run"`
 	Command             []string          `json:"command,omitempty" jsonschema:"description=Command and arguments to run (if no task specified)"`
 	Runtime             string            `json:"runtime,omitempty" jsonschema:"description=Runtime to use (local, tmux),default=local"`
 	Environment         map[string]string `json:"environment,omitempty" jsonschema:"description=Additional environment variables"`
 	WorkingDir          string            `json:"working_dir,omitempty" jsonschema:"description=Working directory override"`
 	EnableLog           bool              `json:"enable_log,omitempty" jsonschema:"description=Enable logging to file,default=false"`
+	Ports               []string          `json:"ports,omitempty" jsonschema:"description=Named workspace ports to allocate and pass as AMUX_PORT_<NAME>"`
+	WaitForWorkspace    bool              `json:"wait_for_workspace,omitempty" jsonschema:"description=Wait for a free slot when the workspace's concurrency policy has none instead of failing,default=false"`
+	WaitTimeout         string            `json:"wait_timeout,omitempty" jsonschema:"description=Give up waiting after this long (e.g. 10m; default: no limit)"`
 }
 
 // SessionListParams defines parameters for session_list tool
@@ -127,6 +132,9 @@ func (s *ServerV2) handleSessionRun(ctx context.Context, request mcp.CallToolReq
 	if description, ok := args["description"].(string); ok {
 		opts.Description = description
 	}
+	if agentID, ok := args["agent_id"].(string); ok {
+		opts.Agent = agentID
+	}
 	if taskName, ok := args["task_name"].(string); ok {
 		opts.TaskName = taskName
 	}
@@ -157,6 +165,24 @@ func (s *ServerV2) handleSessionRun(ctx context.Context, request mcp.CallToolReq
 	if enableLog, ok := args["enable_log"].(bool); ok {
 		opts.EnableLog = enableLog
 	}
+	if portsInterface, ok := args["ports"].([]interface{}); ok {
+		for _, v := range portsInterface {
+			if name, ok := v.(string); ok {
+				opts.Ports = append(opts.Ports, name)
+			}
+		}
+	}
+
+	if wait, ok := args["wait_for_workspace"].(bool); ok {
+		opts.WaitForWorkspace = wait
+	}
+	if timeout, ok := args["wait_timeout"].(string); ok && timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wait_timeout: %w", err)
+		}
+		opts.WaitTimeout = d
+	}
 
 	// Create session manager
 	sessionMgr := s.getSessionManager()
@@ -182,6 +208,12 @@ func (s *ServerV2) handleSessionRun(ctx context.Context, request mcp.CallToolReq
 	if sess.Description != "" {
 		result["description"] = sess.Description
 	}
+	if len(sess.Warnings) > 0 {
+		result["warnings"] = sess.Warnings
+	}
+	if sess.QuotaExceeded != nil {
+		result["quota_exceeded"] = sess.QuotaExceeded
+	}
 
 	return createEnhancedResult("session_run", result, nil)
 }
@@ -358,9 +390,11 @@ func (s *ServerV2) getSessionManager() session.Manager {
 		}
 	}
 
-	// Create task manager
-	taskMgr := task.NewManager()
-	// TODO: Load tasks from config
+	// Create task manager with the tasks defined in the config
+	taskMgr, err := s.configManager.GetTaskManager()
+	if err != nil {
+		taskMgr = task.NewManager()
+	}
 
 	// Create session store
 	store := session.NewFileStore(s.configManager.GetAmuxDir())
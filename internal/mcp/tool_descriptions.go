@@ -19,11 +19,15 @@ var toolDescriptions = map[string]ToolDescription{
 			"When asked to 'work on issue #X' or 'implement feature Y'",
 			"Before making any code changes to the repository",
 			"When you want to experiment without affecting the main branch",
+			"When you need to inspect or test an older release, a commit or someone else's remote branch",
 		},
 		Examples: []string{
 			`workspace_create(name: "fix-issue-30") → {id: "workspace-fix-issue-30-...", name: "fix-issue-30", branch: "fix-issue-30"}`,
 			`workspace_create(name: "feat-api", description: "New API endpoints") → {id: "workspace-feat-api-...", name: "feat-api", description: "New API endpoints"}`,
 			`workspace_create(name: "hotfix", baseBranch: "release/v2") → {id: "workspace-hotfix-...", branch: "hotfix", base_branch: "release/v2"}`,
+			`workspace_create(name: "review-pr", from: "origin/feature/login") → {id: "workspace-review-pr-...", branch: "feature/login", from: "origin/feature/login"}`,
+			`workspace_create(name: "bisect", from: "v1.4.0", detached: true) → {id: "workspace-bisect-...", detached: true, from: "v1.4.0"}`,
+			`workspace_create(name: "fix-login", labels: "bug", metadata: "issue=142,agent=claude") → {id: "workspace-fix-login-...", labels: ["bug"], metadata: {issue: "142", agent: "claude"}}`,
 		},
 		NextTools: []string{
 			"resource_workspace_browse - Explore the workspace structure",
@@ -50,6 +54,89 @@ var toolDescriptions = map[string]ToolDescription{
 		},
 	},
 
+	"workspace_diff": {
+		Description: "Show what changed in a workspace relative to its base branch: changed files with status and line counts, whether each change is committed or uncommitted, and how far the branch is ahead of or behind the base",
+		WhenToUse: []string{
+			"Before opening a pull request or merging a workspace",
+			"To review what an agent changed in a workspace",
+			"To check whether a workspace has uncommitted work before removing it",
+			"When asked 'what changed in workspace X?'",
+		},
+		Examples: []string{
+			`workspace_diff(workspace_identifier: "fix-auth") → {base: "main", ahead: 2, behind: 0, files: [{path: "auth.go", status: "modified", insertions: 12, deletions: 3, committed: true}]}`,
+			`workspace_diff(workspace_identifier: "1", patch: true, paths: ["src/"]) → {files: [...], patch: "diff --git a/src/..."}`,
+		},
+		NextTools: []string{
+			"resource_workspace_browse - Inspect the changed files",
+			"session_run - Run tests against the changes",
+			"workspace_remove - Remove the workspace once its changes are merged",
+		},
+	},
+
+	"workspace_sync": {
+		Description: "Bring a workspace branch up to date with its base branch by rebasing (default) or merging. Nothing is fetched unless fetch is set. If the sync stops on conflicts, the conflicting files and the lines of their conflict markers are returned and the rebase or merge is left in progress",
+		WhenToUse: []string{
+			"Before opening a pull request from a long-lived workspace",
+			"When workspace_diff shows the workspace is behind its base branch",
+			"To abort a sync that stopped on conflicts (abort: true)",
+		},
+		Examples: []string{
+			`workspace_sync(workspace_identifier: "fix-auth") → {strategy: "rebase", base: "main", commits: 3}`,
+			`workspace_sync(workspace_identifier: "1", strategy: "merge") → {strategy: "merge", conflicts: [{path: "auth.go", kind: "both modified", markers: [42]}]}`,
+			`workspace_sync(workspace_identifier: "1", abort: true) → {aborted: "merge"}`,
+		},
+		NextTools: []string{
+			"workspace_diff - Review the workspace changes after syncing",
+			"session_run - Run tests against the updated branch",
+		},
+	},
+
+	"workspace_checkpoint": {
+		Description: "Record the full state of a workspace worktree, including uncommitted and untracked files, as a checkpoint. The branch, index and files are left untouched, so checkpoints are cheap to take before risky changes",
+		WhenToUse: []string{
+			"Before a large or risky refactor",
+			"Before letting another agent work in the same workspace",
+			"To mark a known-good state you may want to return to",
+		},
+		Examples: []string{
+			`workspace_checkpoint(workspace_identifier: "fix-auth") → {id: "1", commit: "3f2a…", head: "9c1d…"}`,
+			`workspace_checkpoint(workspace_identifier: "1", message: "tests passing") → {id: "2", message: "tests passing"}`,
+		},
+		NextTools: []string{
+			"workspace_rollback - Return to the checkpoint if the changes go wrong",
+			"workspace_checkpoint_list - See all checkpoints of the workspace",
+		},
+	},
+
+	"workspace_checkpoint_list": {
+		Description: "List the checkpoints of a workspace, oldest first, with their IDs, notes and the branch commit they were taken on",
+		WhenToUse: []string{
+			"To find the checkpoint to roll back to",
+			"To see whether a checkpoint was taken automatically before a session",
+		},
+		Examples: []string{
+			`workspace_checkpoint_list(workspace_identifier: "fix-auth") → [{id: "1", message: "Before session a1b2"}, {id: "2", message: "tests passing"}]`,
+		},
+		NextTools: []string{
+			"workspace_rollback - Restore one of the checkpoints",
+		},
+	},
+
+	"workspace_rollback": {
+		Description: "Restore a workspace worktree to a checkpoint, including untracked files. The current state is saved as a new checkpoint first, so a rollback can itself be undone. Refuses while sessions use the workspace unless force is set",
+		WhenToUse: []string{
+			"When a refactor went wrong and you want to start over",
+			"To undo the changes made by a session",
+		},
+		Examples: []string{
+			`workspace_rollback(workspace_identifier: "fix-auth", checkpoint: "2") → {checkpoint: "2", saved: {id: "3"}}`,
+		},
+		NextTools: []string{
+			"workspace_diff - Review the restored workspace",
+			"workspace_rollback - Roll back to the saved checkpoint to undo the rollback",
+		},
+	},
+
 	"resource_workspace_browse": {
 		Description: "Browse files in a workspace (replaces ls, find, tree commands). Returns directory listings or file contents. FASTER than bash commands and provides better context",
 		WhenToUse: []string{
@@ -211,15 +298,19 @@ var toolDescriptions = map[string]ToolDescription{
 	},
 
 	"resource_workspace_list": {
-		Description: "List all workspaces. Shows ID, name, branch, and other details",
+		Description: "List workspaces. Shows ID, name, branch, labels, metadata and other details. Filter by labels, metadata, status, branch pattern, age or running sessions, and sort by created, updated, name or branch",
 		WhenToUse: []string{
 			"To see all available workspaces",
 			"When starting work to choose or create a workspace",
 			"To find a specific workspace by name or ID",
+			"To find workspaces for an issue, owner or agent via labels and metadata",
 			"To check workspace status before operations",
 		},
 		Examples: []string{
 			`resource_workspace_list() → [{id: "workspace-fix-auth-...", name: "fix-auth", branch: "fix-auth"}, {id: "workspace-feat-api-...", name: "feat-api", branch: "feat-api"}]`,
+			`resource_workspace_list(labels: "bug", in_use: false) → [{id: "workspace-fix-auth-...", name: "fix-auth", labels: ["bug"]}]`,
+			`resource_workspace_list(metadata: "issue=142") → [{id: "workspace-fix-login-...", name: "fix-login", metadata: {issue: "142"}}]`,
+			`resource_workspace_list(branch: "feature/*", sort: "updated") → [...]`,
 		},
 		NextTools: []string{
 			"resource_workspace_show - Get details of a specific workspace",
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aki/amux/internal/workspace"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
@@ -19,7 +20,7 @@ func (s *ServerV2) registerWorkspaceBridgeTools() error {
 	// resource_workspace_list - Bridge to amux://workspace
 	listOpts, err := WithStructOptions(
 		GetEnhancedDescription("resource_workspace_list"),
-		struct{}{},
+		WorkspaceListParams{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create resource_workspace_list options: %w", err)
@@ -54,8 +55,13 @@ func (s *ServerV2) registerWorkspaceBridgeTools() error {
 // Bridge tool handlers for workspace resources
 
 func (s *ServerV2) handleResourceWorkspaceList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	opts, err := workspaceListOptions(request.GetArguments())
+	if err != nil {
+		return nil, err
+	}
+
 	// Use shared logic with resource handler
-	workspaceList, err := s.getWorkspaceList(ctx)
+	workspaceList, err := s.getWorkspaceList(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +69,68 @@ func (s *ServerV2) handleResourceWorkspaceList(ctx context.Context, request mcp.
 	return createEnhancedResult("resource_workspace_list", workspaceList, nil)
 }
 
+// workspaceListOptions builds list filters from resource_workspace_list arguments
+func workspaceListOptions(args map[string]interface{}) (workspace.ListOptions, error) {
+	var opts workspace.ListOptions
+
+	if labels, ok := args["labels"].(string); ok {
+		opts.Labels = splitList(labels)
+	}
+	if metadata, ok := args["metadata"].(string); ok && metadata != "" {
+		opts.Metadata = make(map[string]string)
+		for _, entry := range splitList(metadata) {
+			key, value, _ := strings.Cut(entry, "=")
+			opts.Metadata[key] = value
+		}
+	}
+	if status, ok := args["status"].(string); ok && status != "" {
+		parsed, err := workspace.ParseConsistencyStatus(status)
+		if err != nil {
+			return opts, err
+		}
+		opts.Statuses = []workspace.ConsistencyStatus{parsed}
+	}
+	if branch, ok := args["branch"].(string); ok {
+		opts.Branch = branch
+	}
+
+	var err error
+	if olderThan, ok := args["older_than"].(string); ok && olderThan != "" {
+		if opts.OlderThan, err = workspace.ParseAge(olderThan); err != nil {
+			return opts, err
+		}
+	}
+	if newerThan, ok := args["newer_than"].(string); ok && newerThan != "" {
+		if opts.NewerThan, err = workspace.ParseAge(newerThan); err != nil {
+			return opts, err
+		}
+	}
+
+	// Only filter on sessions when in_use is given, whichever way
+	if inUse, ok := args["in_use"].(bool); ok {
+		opts.InUse = &inUse
+	}
+	if sortBy, ok := args["sort"].(string); ok {
+		opts.SortBy = sortBy
+	}
+	if reverse, ok := args["reverse"].(bool); ok {
+		opts.Reverse = reverse
+	}
+
+	return opts, nil
+}
+
+// splitList splits a comma-separated list, dropping blank entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (s *ServerV2) handleResourceWorkspaceShow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 	workspaceID, ok := args["workspace_identifier"].(string)
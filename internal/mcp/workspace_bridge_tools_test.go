@@ -69,6 +69,35 @@ func TestWorkspaceBridgeTools(t *testing.T) {
 		}
 	})
 
+	t.Run("resource_workspace_list filters by label", func(t *testing.T) {
+		if _, err := testServer.workspaceManager.SetLabels(context.Background(), workspace.Identifier(ws2.ID), []string{"bug"}, nil); err != nil {
+			t.Fatalf("failed to label workspace: %v", err)
+		}
+
+		req := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "resource_workspace_list",
+				Arguments: map[string]interface{}{"labels": "bug", "in_use": false},
+			},
+		}
+
+		result, err := testServer.handleResourceWorkspaceList(context.Background(), req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var enhancedResult struct {
+			Result []map[string]interface{} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &enhancedResult); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+
+		if len(enhancedResult.Result) != 1 || enhancedResult.Result[0]["id"] != ws2.ID {
+			t.Errorf("expected only %s, got %v", ws2.ID, enhancedResult.Result)
+		}
+	})
+
 	t.Run("resource_workspace_show returns workspace details", func(t *testing.T) {
 		req := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
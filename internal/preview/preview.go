@@ -0,0 +1,374 @@
+// Package preview provides a local reverse proxy that serves the dev servers
+// of workspaces under <workspace>.localhost hostnames.
+package preview
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// DefaultPortName is the port served under the bare workspace hostname when
+// a workspace has several ports
+const DefaultPortName = "web"
+
+// dialTimeout bounds the liveness check of a dev server
+const dialTimeout = 300 * time.Millisecond
+
+// WorkspaceLister lists the workspaces of a project
+type WorkspaceLister interface {
+	List(ctx context.Context, opts workspace.ListOptions) ([]*workspace.Workspace, error)
+}
+
+// SessionLister lists the sessions of a project
+type SessionLister interface {
+	List(ctx context.Context, workspaceID string) ([]*session.Session, error)
+}
+
+// Preview is a dev server port that can be reached through the proxy
+type Preview struct {
+	Workspace string `json:"workspace"`
+	Host      string `json:"host"`
+	PortName  string `json:"port_name"`
+	Port      int    `json:"port"`
+	Default   bool   `json:"default"`
+	SessionID string `json:"session_id,omitempty"` // Running session the port is registered by
+	Up        bool   `json:"up"`
+}
+
+// Server routes requests for <workspace>.localhost to the dev servers of
+// workspaces. A specific port is reached with <port-name>.<workspace>.localhost.
+type Server struct {
+	workspaces WorkspaceLister
+	sessions   SessionLister
+	listenPort string
+}
+
+// NewServer creates a preview server. listenPort is the port the server
+// listens on, used to build links on the status page.
+func NewServer(workspaces WorkspaceLister, sessions SessionLister, listenPort int) *Server {
+	return &Server{
+		workspaces: workspaces,
+		sessions:   sessions,
+		listenPort: strconv.Itoa(listenPort),
+	}
+}
+
+// HostLabel returns the hostname label of a workspace name
+// (e.g., "Feature Auth" -> "feature-auth")
+func HostLabel(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// parseHost splits a request host into the port name and workspace label.
+// ok is false for hosts that do not name a workspace (e.g., localhost).
+func parseHost(host string) (portName, label string, ok bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	rest, found := strings.CutSuffix(host, ".localhost")
+	if !found || rest == "" {
+		return "", "", false
+	}
+
+	labels := strings.Split(rest, ".")
+	switch len(labels) {
+	case 1:
+		return "", labels[0], true
+	case 2:
+		return labels[0], labels[1], true
+	default:
+		return "", "", false
+	}
+}
+
+// defaultPortName picks the port served under the bare workspace hostname
+func defaultPortName(ports map[string]int) string {
+	if _, ok := ports[DefaultPortName]; ok {
+		return DefaultPortName
+	}
+	names := workspace.PortNames(ports)
+	if len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	portName, label, ok := parseHost(r.Host)
+	if !ok {
+		s.serveStatus(w, r)
+		return
+	}
+
+	ws, err := s.findWorkspace(r.Context(), label)
+	if err != nil {
+		s.serveError(w, http.StatusNotFound, "Unknown workspace", err.Error())
+		return
+	}
+
+	if len(ws.Ports) == 0 {
+		s.serveError(w, http.StatusNotFound, "No ports",
+			fmt.Sprintf("Workspace %s has no ports. Add ports: [web] to a task, or run 'amux ws ports %s web'.", ws.Name, ws.Name))
+		return
+	}
+	if portName == "" {
+		portName = defaultPortName(ws.Ports)
+	}
+	port, ok := lookupPort(ws.Ports, portName)
+	if !ok {
+		s.serveError(w, http.StatusNotFound, "Unknown port",
+			fmt.Sprintf("Workspace %s has no port named %s (ports: %s).", ws.Name, portName, strings.Join(workspace.PortNames(ws.Ports), ", ")))
+		return
+	}
+
+	sess, err := s.registeredSession(r.Context(), ws, portName, port)
+	if err != nil {
+		s.serveError(w, http.StatusBadGateway, "Server unavailable", err.Error())
+		return
+	}
+	if sess == nil {
+		s.serveError(w, http.StatusBadGateway, "Server not running",
+			fmt.Sprintf("No running session in workspace %s uses port %s (%d). Start its dev server, e.g. 'amux run -w %s --task <task>'.", ws.Name, portName, port, ws.Name))
+		return
+	}
+
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort("127.0.0.1", strconv.Itoa(port))}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		s.serveError(w, http.StatusBadGateway, "Server down",
+			fmt.Sprintf("The dev server of workspace %s on port %d (session %s) is not responding: %v", ws.Name, port, sess.ID, err))
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// lookupPort finds a port by name, accepting the hostname form of the name
+func lookupPort(ports map[string]int, name string) (int, bool) {
+	if port, ok := ports[name]; ok {
+		return port, true
+	}
+	for n, port := range ports {
+		if HostLabel(n) == name {
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+// findWorkspace resolves a hostname label to a workspace
+func (s *Server) findWorkspace(ctx context.Context, label string) (*workspace.Workspace, error) {
+	workspaces, err := s.workspaces.List(ctx, workspace.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	var matches []*workspace.Workspace
+	for _, ws := range workspaces {
+		if HostLabel(ws.Name) == label || ws.Index == label || strings.ToLower(ws.ID) == label {
+			matches = append(matches, ws)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no workspace matches %s.localhost", label)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("several workspaces match %s.localhost; use the workspace index instead (e.g. %s.localhost)", label, matches[0].Index)
+	}
+}
+
+// registeredSession returns the running session of the workspace that was
+// given the port, or nil if there is none
+func (s *Server) registeredSession(ctx context.Context, ws *workspace.Workspace, portName string, port int) (*session.Session, error) {
+	sessions, err := s.sessions.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	envName := workspace.PortEnvName(portName)
+	value := strconv.Itoa(port)
+	for _, sess := range sessions {
+		if sess.Status != session.StatusRunning || !inWorkspace(sess, ws) {
+			continue
+		}
+		if sess.Environment[envName] == value {
+			return sess, nil
+		}
+	}
+	return nil, nil
+}
+
+// inWorkspace reports whether a session runs in a workspace. Sessions record
+// the workspace as given when they were started.
+func inWorkspace(sess *session.Session, ws *workspace.Workspace) bool {
+	if sess.WorkspaceID == "" {
+		return false
+	}
+	return sess.WorkspaceID == ws.ID || sess.WorkspaceID == ws.Name || sess.WorkspaceID == ws.Index
+}
+
+// Previews returns all ports of all workspaces with their state
+func (s *Server) Previews(ctx context.Context) ([]Preview, error) {
+	workspaces, err := s.workspaces.List(ctx, workspace.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	sessions, err := s.sessions.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var previews []Preview
+	for _, ws := range workspaces {
+		defaultName := defaultPortName(ws.Ports)
+		label := HostLabel(ws.Name)
+		for _, name := range workspace.PortNames(ws.Ports) {
+			p := Preview{
+				Workspace: ws.Name,
+				Host:      HostLabel(name) + "." + label + ".localhost",
+				PortName:  name,
+				Port:      ws.Ports[name],
+				Default:   name == defaultName,
+			}
+			if p.Default {
+				p.Host = label + ".localhost"
+			}
+
+			envName := workspace.PortEnvName(name)
+			for _, sess := range sessions {
+				if sess.Status == session.StatusRunning && inWorkspace(sess, ws) &&
+					sess.Environment[envName] == strconv.Itoa(p.Port) {
+					p.SessionID = sess.ID
+					break
+				}
+			}
+			if p.SessionID != "" {
+				p.Up = portUp(p.Port)
+			}
+			previews = append(previews, p)
+		}
+	}
+
+	sort.SliceStable(previews, func(i, j int) bool {
+		if previews[i].Workspace != previews[j].Workspace {
+			return previews[i].Workspace < previews[j].Workspace
+		}
+		return previews[i].Default && !previews[j].Default
+	})
+	return previews, nil
+}
+
+// portUp reports whether something accepts connections on a local port
+func portUp(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), dialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// url returns the address of a host through the proxy
+func (s *Server) url(host string) string {
+	return "http://" + net.JoinHostPort(host, s.listenPort) + "/"
+}
+
+var statusTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>amux previews</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.3rem 1rem 0.3rem 0; }
+.up { color: #1a7f37; } .down { color: #cf222e; } .idle { color: #888; }
+</style>
+</head>
+<body>
+<h1>amux previews</h1>
+{{if .Error}}<p class="down">{{.Error}}</p>{{end}}
+{{if .Previews}}
+<table>
+<tr><th>Workspace</th><th>Port</th><th>Address</th><th>State</th></tr>
+{{range .Previews}}
+<tr>
+<td>{{.Workspace}}</td>
+<td>{{.PortName}} ({{.Port}})</td>
+<td><a href="{{index $.URLs .Host}}">{{index $.URLs .Host}}</a></td>
+<td>{{if not .SessionID}}<span class="idle">not running</span>{{else if .Up}}<span class="up">up</span> ({{.SessionID}}){{else}}<span class="down">down</span> ({{.SessionID}}){{end}}</td>
+</tr>
+{{end}}
+</table>
+{{else if not .Error}}
+<p>No workspace has ports yet. Add <code>ports: [web]</code> to a task and start it in a workspace.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// serveStatus renders the list of available previews
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Previews []Preview
+		URLs     map[string]string
+		Error    string
+	}{URLs: make(map[string]string)}
+
+	previews, err := s.Previews(r.Context())
+	if err != nil {
+		data.Error = err.Error()
+	}
+	data.Previews = previews
+	for _, p := range previews {
+		data.URLs[p.Host] = s.url(p.Host)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = statusTemplate.Execute(w, data)
+}
+
+var errorTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: system-ui, sans-serif; margin: 2rem;">
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+<p><a href="{{.StatusURL}}">All previews</a></p>
+</body>
+</html>
+`))
+
+// serveError renders an error page
+func (s *Server) serveError(w http.ResponseWriter, code int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	_ = errorTemplate.Execute(w, map[string]string{
+		"Title":     title,
+		"Message":   message,
+		"StatusURL": s.url("localhost"),
+	})
+}
@@ -0,0 +1,168 @@
+package preview
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/session"
+	"github.com/aki/amux/internal/workspace"
+)
+
+type fakeWorkspaces []*workspace.Workspace
+
+func (f fakeWorkspaces) List(ctx context.Context, opts workspace.ListOptions) ([]*workspace.Workspace, error) {
+	return f, nil
+}
+
+type fakeSessions []*session.Session
+
+func (f fakeSessions) List(ctx context.Context, workspaceID string) ([]*session.Session, error) {
+	return f, nil
+}
+
+// freeLocalPort returns a port nothing listens on
+func freeLocalPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return port
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		portName string
+		label    string
+		ok       bool
+	}{
+		{"feature-auth.localhost:8080", "", "feature-auth", true},
+		{"api.feature-auth.localhost:8080", "api", "feature-auth", true},
+		{"Feature-Auth.LOCALHOST", "", "feature-auth", true},
+		{"localhost:8080", "", "", false},
+		{"127.0.0.1:8080", "", "", false},
+		{"a.b.c.localhost", "", "", false},
+		{"example.com", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			portName, label, ok := parseHost(tt.host)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.portName, portName)
+			assert.Equal(t, tt.label, label)
+		})
+	}
+}
+
+func TestHostLabel(t *testing.T) {
+	assert.Equal(t, "feature-auth", HostLabel("feature-auth"))
+	assert.Equal(t, "feature-auth", HostLabel("Feature Auth"))
+	assert.Equal(t, "fix-123", HostLabel("fix_123"))
+}
+
+func TestServer(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from "+r.URL.Path)
+	}))
+	defer backend.Close()
+	webPort := backend.Listener.Addr().(*net.TCPAddr).Port
+	downPort := freeLocalPort(t)
+
+	workspaces := fakeWorkspaces{
+		{ID: "workspace-feature-auth-1", Index: "1", Name: "feature-auth", Ports: map[string]int{"web": webPort, "api": downPort}},
+		{ID: "workspace-idle-2", Index: "2", Name: "idle", Ports: map[string]int{"web": freeLocalPort(t)}},
+		{ID: "workspace-bare-3", Index: "3", Name: "bare"},
+	}
+	sessions := fakeSessions{
+		{ID: "session-1", WorkspaceID: "feature-auth", Status: session.StatusRunning, Environment: map[string]string{
+			"AMUX_PORT_WEB": strconv.Itoa(webPort),
+			"AMUX_PORT_API": strconv.Itoa(downPort),
+		}},
+		{ID: "session-2", WorkspaceID: "workspace-idle-2", Status: session.StatusStopped, Environment: map[string]string{
+			"AMUX_PORT_WEB": strconv.Itoa(workspaces[1].Ports["web"]),
+		}},
+	}
+	server := NewServer(workspaces, sessions, 8080)
+
+	get := func(host, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+path, nil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("proxies default port", func(t *testing.T) {
+		rec := get("feature-auth.localhost:8080", "/app")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "hello from /app", rec.Body.String())
+	})
+
+	t.Run("proxies by index", func(t *testing.T) {
+		rec := get("1.localhost:8080", "/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("server down", func(t *testing.T) {
+		rec := get("api.feature-auth.localhost:8080", "/")
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		assert.Contains(t, rec.Body.String(), "not responding")
+	})
+
+	t.Run("no running session", func(t *testing.T) {
+		rec := get("idle.localhost:8080", "/")
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		assert.Contains(t, rec.Body.String(), "No running session")
+	})
+
+	t.Run("unknown workspace", func(t *testing.T) {
+		rec := get("nope.localhost:8080", "/")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("unknown port", func(t *testing.T) {
+		rec := get("db.feature-auth.localhost:8080", "/")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), "api, web")
+	})
+
+	t.Run("workspace without ports", func(t *testing.T) {
+		rec := get("bare.localhost:8080", "/")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("status page", func(t *testing.T) {
+		rec := get("localhost:8080", "/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "http://feature-auth.localhost:8080/")
+		assert.Contains(t, body, "http://api.feature-auth.localhost:8080/")
+		assert.Contains(t, body, "not running")
+	})
+}
+
+func TestPreviews(t *testing.T) {
+	workspaces := fakeWorkspaces{
+		{ID: "ws-1", Name: "one", Ports: map[string]int{"api": 4001, "web": 4000}},
+		{ID: "ws-2", Name: "two", Ports: map[string]int{"api": 4002}},
+	}
+	server := NewServer(workspaces, fakeSessions{}, 8080)
+
+	previews, err := server.Previews(context.Background())
+	require.NoError(t, err)
+	require.Len(t, previews, 3)
+
+	assert.Equal(t, "one.localhost", previews[0].Host)
+	assert.Equal(t, "web", previews[0].PortName)
+	assert.True(t, previews[0].Default)
+	assert.Equal(t, "api.one.localhost", previews[1].Host)
+	assert.Equal(t, "two.localhost", previews[2].Host)
+	assert.Equal(t, "api", previews[2].PortName)
+}
@@ -41,3 +41,31 @@ var Default = &DefaultChecker{}
 func HasChildren(pid int) (bool, error) {
 	return Default.HasChildren(pid)
 }
+
+// Info describes a running process
+type Info struct {
+	PID  int
+	Args []string
+}
+
+// List returns all running processes visible to the current user
+func List() ([]Info, error) {
+	output, err := exec.Command("ps", "-eo", "pid=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var infos []Info
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		infos = append(infos, Info{PID: pid, Args: fields[1:]})
+	}
+	return infos, nil
+}
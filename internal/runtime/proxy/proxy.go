@@ -19,6 +19,9 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/journal"
 )
 
 // Status represents the status information that is periodically written
@@ -30,16 +33,20 @@ type Status struct {
 	StartedAt      time.Time `yaml:"started_at"`
 	EndedAt        time.Time `yaml:"ended_at,omitempty"`
 	LastActivityAt time.Time `yaml:"last_activity_at,omitempty"`
+	EndSnapshot    string    `yaml:"end_snapshot,omitempty"` // Snapshot commit of the watched worktree when the command exited
 }
 
 // Options configures the proxy behavior
 type Options struct {
-	SessionDir string   // Directory to store run-specific data
-	StatusPath string   // Path to status file
-	LogPath    string   // Path to log file (empty if logging disabled)
-	SocketPath string   // Unix socket path for output streaming
-	Command    []string // Command to execute
-	Foreground bool     // If true, run in foreground mode (direct I/O, no pipes)
+	SessionDir string    // Directory to store run-specific data
+	StatusPath string    // Path to status file
+	LogPath    string    // Path to log file (empty if logging disabled)
+	SocketPath string    // Unix socket path for output streaming
+	Command    []string  // Command to execute
+	Foreground bool      // If true, run in foreground mode (direct I/O, no pipes)
+	Tap        bool      // If true, proxy output read from Input instead of running Command
+	Input      io.Reader // Source of tapped output in tap mode (default: os.Stdin)
+	WatchDir   string    // Worktree whose file changes are journaled and whose state is snapshotted on exit (optional)
 }
 
 // BuildProxyCommand builds command arguments for running amux proxy
@@ -57,40 +64,17 @@ func BuildProxyCommandWithOptions(sessionID string, command []string, enableLog
 		return nil, fmt.Errorf("failed to find amux binary: %w", err)
 	}
 
-	// Determine paths based on environment variable or find project root
-	amuxDir := os.Getenv("AMUX_DIR")
-	if amuxDir == "" {
-		// If AMUX_DIR is not set, find project root and .amux directory
-		// This happens when BuildProxyCommand is called before environment is set
-		cwd, _ := os.Getwd()
-		dir := cwd
-		for {
-			configPath := filepath.Join(dir, ".amux", "config.yaml")
-			if _, err := os.Stat(configPath); err == nil {
-				amuxDir = filepath.Join(dir, ".amux")
-				break
-			}
-
-			parent := filepath.Dir(dir)
-			if parent == dir {
-				return nil, fmt.Errorf("could not find .amux directory")
-			}
-			dir = parent
-		}
-	}
-
 	// Session directory
-	sessionDir := filepath.Join(amuxDir, "sessions", sessionID)
+	sessionDir, err := SessionDir(sessionID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Status file path
 	statusPath := filepath.Join(sessionDir, "status.yaml")
 
 	// Socket path (in temp directory for shorter path)
-	tmpDir := os.Getenv("TMPDIR")
-	if tmpDir == "" {
-		tmpDir = "/tmp"
-	}
-	socketPath := filepath.Join(tmpDir, fmt.Sprintf("amux-%s.sock", sessionID))
+	socketPath := SocketPath(sessionID)
 
 	// Build proxy command arguments
 	args := []string{
@@ -119,6 +103,61 @@ func BuildProxyCommandWithOptions(sessionID string, command []string, enableLog
 	return args, nil
 }
 
+// BuildTapCommand builds command arguments for running amux proxy in tap mode.
+// A tap proxy does not start a process; it consumes output piped to its stdin
+// (e.g. from tmux pipe-pane) and provides status, logging and streaming for it.
+func BuildTapCommand(sessionID string, enableLog bool) ([]string, error) {
+	args, err := BuildProxyCommandWithOptions(sessionID, nil, enableLog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the trailing "--" separator with the tap flag
+	args[len(args)-1] = "--tap"
+	return args, nil
+}
+
+// SessionDir returns the directory holding run data for a session
+func SessionDir(sessionID string) (string, error) {
+	// Determine paths based on environment variable or find project root
+	amuxDir := os.Getenv("AMUX_DIR")
+	if amuxDir == "" {
+		// If AMUX_DIR is not set, find project root and .amux directory
+		// This happens when BuildProxyCommand is called before environment is set
+		cwd, _ := os.Getwd()
+		dir := cwd
+		for {
+			configPath := filepath.Join(dir, ".amux", "config.yaml")
+			if _, err := os.Stat(configPath); err == nil {
+				amuxDir = filepath.Join(dir, ".amux")
+				break
+			}
+
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return "", fmt.Errorf("could not find .amux directory")
+			}
+			dir = parent
+		}
+	}
+
+	return filepath.Join(amuxDir, "sessions", sessionID), nil
+}
+
+// SocketDir returns the directory holding session output sockets
+func SocketDir() string {
+	tmpDir := os.Getenv("TMPDIR")
+	if tmpDir == "" {
+		tmpDir = "/tmp"
+	}
+	return tmpDir
+}
+
+// SocketPath returns the output socket path for a session
+func SocketPath(sessionID string) string {
+	return filepath.Join(SocketDir(), fmt.Sprintf("amux-%s.sock", sessionID))
+}
+
 // GetShell returns the appropriate shell for the current platform
 func GetShell() string {
 	shell := os.Getenv("SHELL")
@@ -157,7 +196,7 @@ type Proxy struct {
 	statusMu   sync.RWMutex
 	ringBuffer *ring.Ring
 	bufferMu   sync.RWMutex
-	clients    map[net.Conn]struct{}
+	clients    map[*client]struct{}
 	clientsMu  sync.RWMutex
 	listener   net.Listener
 }
@@ -174,16 +213,19 @@ func New(opts Options) (*Proxy, error) {
 	if opts.SocketPath == "" {
 		return nil, fmt.Errorf("socket path is required")
 	}
-	if len(opts.Command) == 0 {
+	if len(opts.Command) == 0 && !opts.Tap {
 		return nil, fmt.Errorf("command is required")
 	}
+	if opts.Tap && opts.Input == nil {
+		opts.Input = os.Stdin
+	}
 
 	// Create ring buffer (50KB / ~50 bytes per line = ~1000 lines)
 	ringSize := 1000
 	p := &Proxy{
 		opts:       opts,
 		ringBuffer: ring.New(ringSize),
-		clients:    make(map[net.Conn]struct{}),
+		clients:    make(map[*client]struct{}),
 	}
 
 	return p, nil
@@ -191,75 +233,17 @@ func New(opts Options) (*Proxy, error) {
 
 // Run executes the proxied command
 func (p *Proxy) Run() error {
-	// Ensure session directory exists
-	if err := os.MkdirAll(p.opts.SessionDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create session directory: %w", err)
-	}
-
-	// Read current status to get run_id
-	var currentRunID int
-	if data, err := os.ReadFile(p.opts.StatusPath); err == nil {
-		var status Status
-		if err := yaml.Unmarshal(data, &status); err == nil {
-			currentRunID = status.RunID
-		}
-	}
-
-	// Next run ID
-	nextRunID := currentRunID + 1
-
-	// Create run directory
-	runDir := filepath.Join(p.opts.SessionDir, fmt.Sprintf("%d", nextRunID))
-	if err := os.MkdirAll(runDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create run directory: %w", err)
-	}
-
-	// Open log file if path is provided
-	var logFile *os.File
-	if p.opts.LogPath != "" {
-		var err error
-		// If LogPath ends with "/" or is a directory, create console.log in run directory
-		logPath := p.opts.LogPath
-		if strings.HasSuffix(logPath, "/") || strings.HasSuffix(logPath, string(os.PathSeparator)) {
-			logPath = filepath.Join(runDir, "console.log")
-		} else if info, err := os.Stat(logPath); err == nil && info.IsDir() {
-			logPath = filepath.Join(runDir, "console.log")
-		}
-		logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-		if err != nil {
-			return fmt.Errorf("failed to open log file: %w", err)
-		}
-		defer func() { _ = logFile.Close() }()
+	nextRunID, logFile, cleanup, err := p.prepareRun()
+	if err != nil {
+		return err
 	}
+	defer cleanup()
 
-	// Start Unix socket server if socket path provided
-	if p.opts.SocketPath != "" {
-		// Remove existing socket file
-		_ = os.Remove(p.opts.SocketPath)
-
-		// Try to use relative path if absolute path is too long
-		socketPath := p.opts.SocketPath
-		if len(socketPath) > 100 { // Leave some margin for safety
-			// Try relative path from current directory
-			cwd, _ := os.Getwd()
-			if rel, err := filepath.Rel(cwd, socketPath); err == nil && len(rel) < len(socketPath) {
-				socketPath = rel
-			}
-		}
-
-		// Create socket
-		listener, err := net.Listen("unix", socketPath)
-		if err != nil {
-			return fmt.Errorf("failed to create unix socket: %w", err)
-		}
-		p.listener = listener
-		defer func() {
-			_ = listener.Close()
-			_ = os.Remove(p.opts.SocketPath)
-		}()
+	stopJournal := p.startJournal()
+	defer stopJournal()
 
-		// Start accepting connections
-		go p.acceptConnections()
+	if p.opts.Tap {
+		return p.runTap(nextRunID, logFile)
 	}
 
 	// Create the command
@@ -351,7 +335,7 @@ func (p *Proxy) Run() error {
 	}()
 
 	// Wait for completion (no timeout in foreground mode)
-	err := <-waitDone
+	err = <-waitDone
 
 	// Stop background tasks
 	cancel()
@@ -368,6 +352,149 @@ func (p *Proxy) Run() error {
 	return nil
 }
 
+// startJournal records the files that change in the watched worktree into the
+// session directory until the returned function is called. Journaling is best
+// effort; the session runs the same without it.
+func (p *Proxy) startJournal() func() {
+	if p.opts.WatchDir == "" {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = journal.Watch(ctx, p.opts.WatchDir, p.opts.SessionDir)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// prepareRun creates the run directory, opens the log file and starts the
+// socket server. The returned cleanup function releases those resources.
+func (p *Proxy) prepareRun() (int, *os.File, func(), error) {
+	var cleanups []func()
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+
+	// Ensure session directory exists
+	if err := os.MkdirAll(p.opts.SessionDir, 0o755); err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	// Read current status to get run_id
+	var currentRunID int
+	if data, err := os.ReadFile(p.opts.StatusPath); err == nil {
+		var status Status
+		if err := yaml.Unmarshal(data, &status); err == nil {
+			currentRunID = status.RunID
+		}
+	}
+
+	// Next run ID
+	nextRunID := currentRunID + 1
+
+	// Create run directory
+	runDir := filepath.Join(p.opts.SessionDir, fmt.Sprintf("%d", nextRunID))
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	// Open log file if path is provided
+	var logFile *os.File
+	if p.opts.LogPath != "" {
+		var err error
+		// If LogPath ends with "/" or is a directory, create console.log in run directory
+		logPath := p.opts.LogPath
+		if strings.HasSuffix(logPath, "/") || strings.HasSuffix(logPath, string(os.PathSeparator)) {
+			logPath = filepath.Join(runDir, "console.log")
+		} else if info, err := os.Stat(logPath); err == nil && info.IsDir() {
+			logPath = filepath.Join(runDir, "console.log")
+		}
+		logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		cleanups = append(cleanups, func() { _ = logFile.Close() })
+	}
+
+	// Start Unix socket server if socket path provided
+	if p.opts.SocketPath != "" {
+		// Remove existing socket file
+		_ = os.Remove(p.opts.SocketPath)
+
+		// Try to use relative path if absolute path is too long
+		socketPath := p.opts.SocketPath
+		if len(socketPath) > 100 { // Leave some margin for safety
+			// Try relative path from current directory
+			cwd, _ := os.Getwd()
+			if rel, err := filepath.Rel(cwd, socketPath); err == nil && len(rel) < len(socketPath) {
+				socketPath = rel
+			}
+		}
+
+		// Create socket
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			cleanup()
+			return 0, nil, nil, fmt.Errorf("failed to create unix socket: %w", err)
+		}
+		p.listener = listener
+		cleanups = append(cleanups, func() {
+			_ = listener.Close()
+			_ = os.Remove(p.opts.SocketPath)
+		})
+
+		// Start accepting connections
+		go p.acceptConnections()
+	}
+
+	return nextRunID, logFile, cleanup, nil
+}
+
+// runTap consumes output from the tap input until it is closed
+func (p *Proxy) runTap(runID int, logFile *os.File) error {
+	// Initialize status
+	now := time.Now()
+	p.statusMu.Lock()
+	p.status = &Status{
+		RunID:          runID,
+		PID:            os.Getpid(),
+		Status:         "running",
+		ExitCode:       -1, // Initialize with -1 for running process
+		StartedAt:      now,
+		LastActivityAt: now,
+	}
+	p.statusMu.Unlock()
+
+	// Write initial status
+	if err := p.writeStatus(); err != nil {
+		return fmt.Errorf("failed to write initial status: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start status updates
+	statusDone := p.startStatusUpdates(ctx)
+
+	// The tapped output is already visible in its own terminal
+	p.copyOutput(io.Discard, p.opts.Input, logFile)
+
+	// Stop background tasks
+	cancel()
+	<-statusDone
+
+	// The input closes when the tapped process goes away
+	p.updateFinalStatus(nil)
+	return nil
+}
+
 // startIOCopying starts goroutines to copy stdout and stderr
 func (p *Proxy) startIOCopying(stdout, stderr io.Reader, logFile *os.File) *sync.WaitGroup {
 	var wg sync.WaitGroup
@@ -422,9 +549,13 @@ func (p *Proxy) handleSignals(ctx context.Context, sigChan <-chan os.Signal, cmd
 
 // updateFinalStatus updates and writes the final status
 func (p *Proxy) updateFinalStatus(err error) {
+	endedAt := time.Now()
+	snapshot := p.snapshotWorktree()
+
 	p.statusMu.Lock()
 	p.status.Status = "exited"
-	p.status.EndedAt = time.Now()
+	p.status.EndedAt = endedAt
+	p.status.EndSnapshot = snapshot
 	if exitErr, ok := err.(*exec.ExitError); ok {
 		p.status.ExitCode = exitErr.ExitCode()
 	} else if err == nil {
@@ -436,6 +567,22 @@ func (p *Proxy) updateFinalStatus(err error) {
 	_ = p.writeStatus()
 }
 
+// snapshotWorktree records the state of the watched worktree as the command
+// exits, so edits made after the session ended aren't charged to it. It
+// returns the snapshot commit, or "" when there is nothing to snapshot.
+func (p *Proxy) snapshotWorktree() string {
+	if p.opts.WatchDir == "" {
+		return ""
+	}
+	sessionID := filepath.Base(p.opts.SessionDir)
+	commit, err := git.NewOperations(p.opts.WatchDir).Snapshot(fmt.Sprintf("Session %s end", sessionID))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to snapshot workspace: %v\n", err)
+		return ""
+	}
+	return commit
+}
+
 func (p *Proxy) copyOutput(dst io.Writer, src io.Reader, logFile *os.File) {
 	// Buffer for reading
 	buf := make([]byte, 4096)
@@ -477,8 +624,7 @@ func (p *Proxy) copyOutput(dst io.Writer, src io.Reader, logFile *os.File) {
 
 	// Handle any remaining data in line buffer
 	if len(lineBuffer) > 0 {
-		p.addToRingBuffer(lineBuffer)
-		p.broadcastData(lineBuffer)
+		p.publish(lineBuffer)
 	}
 }
 
@@ -520,6 +666,22 @@ func (p *Proxy) writeStatus() error {
 	return nil
 }
 
+// clientBacklog is how many lines a client may fall behind before it is
+// disconnected as too slow
+const clientBacklog = 1024
+
+// client is a connection receiving the proxied output
+type client struct {
+	conn      net.Conn
+	out       chan []byte
+	closeOnce sync.Once
+}
+
+// close disconnects the client
+func (c *client) close() {
+	c.closeOnce.Do(func() { _ = c.conn.Close() })
+}
+
 // acceptConnections handles incoming socket connections
 func (p *Proxy) acceptConnections() {
 	for {
@@ -529,45 +691,63 @@ func (p *Proxy) acceptConnections() {
 			return
 		}
 
-		// Add client
+		// Register the client together with a snapshot of the ring buffer,
+		// so that every line is sent exactly once and in order
+		c := &client{conn: conn, out: make(chan []byte, clientBacklog)}
+		p.bufferMu.RLock()
+		backlog := make([][]byte, 0)
+		p.ringBuffer.Do(func(value interface{}) {
+			if d, ok := value.([]byte); ok && len(d) > 0 {
+				backlog = append(backlog, d)
+			}
+		})
 		p.clientsMu.Lock()
-		p.clients[conn] = struct{}{}
+		p.clients[c] = struct{}{}
 		p.clientsMu.Unlock()
+		p.bufferMu.RUnlock()
 
-		// Send ring buffer content to new client
-		go p.sendBufferToClient(conn)
+		go p.serveClient(c, backlog)
 	}
 }
 
-// sendBufferToClient sends the current ring buffer content to a new client
-func (p *Proxy) sendBufferToClient(conn net.Conn) {
-	defer func() {
-		// Remove client on disconnect
-		p.clientsMu.Lock()
-		delete(p.clients, conn)
-		p.clientsMu.Unlock()
-		_ = conn.Close()
+// serveClient writes the buffered and subsequent output to a client
+func (p *Proxy) serveClient(c *client, backlog [][]byte) {
+	defer p.removeClient(c)
+
+	// Clients only read, so reading detects a disconnect even while no
+	// output arrives
+	go func() {
+		_, _ = io.Copy(io.Discard, c.conn)
+		p.removeClient(c)
 	}()
 
 	// Send current buffer content
-	p.bufferMu.RLock()
-	data := make([][]byte, 0)
-	p.ringBuffer.Do(func(value interface{}) {
-		if d, ok := value.([]byte); ok && len(d) > 0 {
-			data = append(data, d)
+	for _, d := range backlog {
+		if _, err := c.conn.Write(d); err != nil {
+			return
 		}
-	})
-	p.bufferMu.RUnlock()
+	}
 
-	// Write data to client
-	for _, d := range data {
-		if _, err := conn.Write(d); err != nil {
+	// Send new output as it arrives, until the client is removed
+	for d := range c.out {
+		// Set write deadline to avoid blocking on slow clients
+		_ = c.conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, err := c.conn.Write(d); err != nil {
 			return
 		}
 	}
+}
 
-	// Keep connection open for future broadcasts
-	select {}
+// removeClient unregisters a client, closes its output channel and
+// disconnects it. It is safe to call more than once.
+func (p *Proxy) removeClient(c *client) {
+	p.clientsMu.Lock()
+	if _, ok := p.clients[c]; ok {
+		delete(p.clients, c)
+		close(c.out)
+	}
+	p.clientsMu.Unlock()
+	c.close()
 }
 
 // processDataForBuffer processes raw data, splitting on newlines for ring buffer
@@ -588,36 +768,30 @@ func (p *Proxy) processDataForBuffer(data []byte, lineBuffer *[]byte) {
 		*lineBuffer = (*lineBuffer)[idx+1:]
 
 		// Add to ring buffer and broadcast
-		p.addToRingBuffer(line)
-		p.broadcastData(line)
+		p.publish(line)
 	}
 }
 
-// addToRingBuffer adds data to the ring buffer
-func (p *Proxy) addToRingBuffer(data []byte) {
+// publish adds data to the ring buffer and sends it to all connected clients
+func (p *Proxy) publish(data []byte) {
 	p.bufferMu.Lock()
 	defer p.bufferMu.Unlock()
 
 	// Store a copy of the data
-	p.ringBuffer.Value = append([]byte(nil), data...)
+	d := append([]byte(nil), data...)
+	p.ringBuffer.Value = d
 	p.ringBuffer = p.ringBuffer.Next()
-}
 
-// broadcastData sends data to all connected clients
-func (p *Proxy) broadcastData(data []byte) {
 	p.clientsMu.RLock()
 	defer p.clientsMu.RUnlock()
-
-	for conn := range p.clients {
-		// Non-blocking write
-		go func(c net.Conn, d []byte) {
-			// Set write deadline to avoid blocking on slow clients
-			_ = c.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
-			if _, err := c.Write(d); err != nil {
-				// Client is slow or disconnected, will be cleaned up
-				_ = c.Close()
-			}
-		}(conn, data)
+	for c := range p.clients {
+		select {
+		case c.out <- d:
+		default:
+			// Client is too slow; disconnecting it makes its reader
+			// remove it
+			c.close()
+		}
 	}
 }
 
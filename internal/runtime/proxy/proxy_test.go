@@ -3,12 +3,17 @@ package proxy
 import (
 	"context"
 	"io"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/aki/amux/internal/tests/helpers"
 )
 
 func TestProxy_Run(t *testing.T) {
@@ -390,3 +395,177 @@ func TestProxy_ActivityTracking(t *testing.T) {
 		t.Error("Proxy did not complete in time")
 	}
 }
+
+func TestProxy_Tap(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionDir := filepath.Join(tmpDir, "sessions", "test-session")
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := New(Options{
+		SessionDir: sessionDir,
+		StatusPath: filepath.Join(sessionDir, "status.yaml"),
+		LogPath:    sessionDir + "/",
+		SocketPath: filepath.Join(t.TempDir(), "test.sock"),
+		Tap:        true,
+		Input:      strings.NewReader("line one\nline two\n"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tap proxy: %v", err)
+	}
+
+	if err := p.Run(); err != nil {
+		t.Fatalf("Tap proxy failed: %v", err)
+	}
+
+	logData, err := os.ReadFile(filepath.Join(sessionDir, "1", "console.log"))
+	if err != nil {
+		t.Fatalf("Failed to read console log: %v", err)
+	}
+	if string(logData) != "line one\nline two\n" {
+		t.Errorf("Unexpected log content: %q", string(logData))
+	}
+
+	statusData, err := os.ReadFile(filepath.Join(sessionDir, "status.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	if !strings.Contains(string(statusData), "status: exited") {
+		t.Errorf("Expected exited status, got:\n%s", string(statusData))
+	}
+}
+
+func TestProxy_ClientDisconnect(t *testing.T) {
+	sessionDir := filepath.Join(t.TempDir(), "sessions", "test-session")
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+
+	// Keep the tap input open and idle while clients come and go
+	input, output := io.Pipe()
+	p, err := New(Options{
+		SessionDir: sessionDir,
+		StatusPath: filepath.Join(sessionDir, "status.yaml"),
+		SocketPath: socketPath,
+		Tap:        true,
+		Input:      input,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tap proxy: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- p.Run()
+	}()
+
+	clientCount := func() int {
+		p.clientsMu.RLock()
+		defer p.clientsMu.RUnlock()
+		return len(p.clients)
+	}
+	waitFor := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for clientCount() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d clients, got %d", want, clientCount())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	var conns []net.Conn
+	for len(conns) < 3 {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			// The listener may not be up yet
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	waitFor(3)
+
+	// Disconnected clients are removed without any output being sent
+	for _, conn := range conns[:2] {
+		_ = conn.Close()
+	}
+	waitFor(1)
+
+	// The remaining client still receives output
+	if _, err := output.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conns[2].SetReadDeadline(time.Now().Add(3 * time.Second))
+	buf := make([]byte, len("hello\n"))
+	if _, err := io.ReadFull(conns[2], buf); err != nil {
+		t.Fatalf("Failed to read from socket: %v", err)
+	}
+	if string(buf) != "hello\n" {
+		t.Errorf("Unexpected output: %q", buf)
+	}
+	_ = conns[2].Close()
+	waitFor(0)
+
+	_ = output.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Tap proxy failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Proxy did not complete in time")
+	}
+}
+
+func TestProxy_EndSnapshot(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	sessionDir := filepath.Join(t.TempDir(), "sessions", "test-session")
+	if err := os.MkdirAll(sessionDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	notes := filepath.Join(repoDir, "notes.txt")
+
+	p, err := New(Options{
+		SessionDir: sessionDir,
+		StatusPath: filepath.Join(sessionDir, "status.yaml"),
+		SocketPath: filepath.Join(t.TempDir(), "test.sock"),
+		Command:    []string{"sh", "-c", "echo session > " + notes},
+		WatchDir:   repoDir,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create proxy: %v", err)
+	}
+	if err := p.Run(); err != nil {
+		t.Fatalf("Proxy failed: %v", err)
+	}
+
+	// Edits made after the session exited are not part of its end state
+	if err := os.WriteFile(notes, []byte("later\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(sessionDir, "status.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	var status Status
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		t.Fatalf("Failed to parse status: %v", err)
+	}
+	if status.EndSnapshot == "" {
+		t.Fatal("Expected an end snapshot")
+	}
+	cmd := exec.Command("git", "show", status.EndSnapshot+":notes.txt")
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if string(output) != "session\n" {
+		t.Errorf("Expected the worktree as the command exited, got %q", output)
+	}
+}
@@ -49,6 +49,12 @@ type InputSendingRuntime interface {
 	SendInput(ctx context.Context, sessionID string, input string) error
 }
 
+// AdoptableRuntime is a runtime that can take over processes it did not start
+type AdoptableRuntime interface {
+	Runtime
+	Adopt(ctx context.Context, spec AdoptSpec) (Process, error)
+}
+
 // Process represents a running or completed process
 type Process interface {
 	// ID returns the unique identifier for this process
@@ -95,6 +101,19 @@ type ExecutionSpec struct {
 	Options RuntimeOptions
 }
 
+// AdoptSpec describes an existing process to bring under amux management
+type AdoptSpec struct {
+	// Session information
+	SessionID string // Session ID for tracking
+
+	// Target is a runtime-specific reference to the existing process
+	// (e.g., a tmux session name or pane ID)
+	Target string
+
+	// Logging configuration
+	EnableLog bool // Enable logging to file
+}
+
 // RuntimeOptions is implemented by runtime-specific option types
 //
 //nolint:revive // RuntimeOptions is a clearer name than Options in this context
@@ -0,0 +1,299 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/aki/amux/internal/runtime"
+)
+
+const (
+	// defaultGridName is the tmux session assembled by Grid when no name is given
+	defaultGridName = "amux-grid"
+
+	// defaultLayout is the layout of shared windows when none is configured
+	defaultLayout = "tiled"
+)
+
+// layouts lists the preset layouts understood by tmux select-layout
+var layouts = []string{"even-horizontal", "even-vertical", "main-horizontal", "main-vertical", "tiled"}
+
+// validateLayout checks that a layout is one of the tmux preset layouts
+func validateLayout(layout string) error {
+	for _, l := range layouts {
+		if l == layout {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown tmux layout %q (valid: %s)", layout, strings.Join(layouts, ", "))
+}
+
+// GridSpec describes a set of sessions to show side by side in one tmux window
+type GridSpec struct {
+	Name     string        // Tmux session holding the grid (default: "amux-grid")
+	Window   string        // Window name (default: "amux")
+	Layout   string        // Preset tmux layout (default: "tiled")
+	Sessions []GridSession // Sessions to show, in order
+}
+
+// GridSession is an amux session shown in a grid
+type GridSession struct {
+	ID    string // Amux session ID
+	Title string // Pane title (default: the session ID)
+}
+
+// Grid assembles running sessions into a single tmux window by moving their
+// panes into it. Sessions keep running in their panes, so stop, kill and
+// input keep working; they follow the panes through the amux pane tags.
+// The tmux session name of the grid is returned.
+func (r *Runtime) Grid(ctx context.Context, spec GridSpec) (string, error) {
+	if spec.Name == "" {
+		spec.Name = defaultGridName
+	}
+	if spec.Window == "" {
+		spec.Window = "amux"
+	}
+	if spec.Layout == "" {
+		spec.Layout = defaultLayout
+	}
+	if err := validateLayout(spec.Layout); err != nil {
+		return "", err
+	}
+	if len(spec.Sessions) == 0 {
+		return "", fmt.Errorf("no sessions to show")
+	}
+
+	procs := make([]*Process, len(spec.Sessions))
+	for i, s := range spec.Sessions {
+		proc, err := r.FindBySessionID(ctx, s.ID)
+		if err != nil {
+			return "", fmt.Errorf("session not found: %s", s.ID)
+		}
+		if proc.State() != runtime.StateRunning || !proc.alive() {
+			return "", fmt.Errorf("session %s is not running", s.ID)
+		}
+		if i > 0 && proc.opts.SocketPath != procs[0].opts.SocketPath {
+			return "", fmt.Errorf("session %s runs on a different tmux server", s.ID)
+		}
+		procs[i] = proc
+	}
+	socketPath := procs[0].opts.SocketPath
+
+	windowID, placeholder, err := r.ensureWindow(socketPath, spec.Name, spec.Window)
+	if err != nil {
+		return "", err
+	}
+
+	// Each pane joins after the previous one so that the grid keeps the
+	// order of the sessions
+	joinTarget := windowID
+	for i, proc := range procs {
+		sessionName, paneID := proc.location()
+		if paneID == "" {
+			// Processes owning a whole tmux session move its only pane
+			output, err := r.tmuxCmd(socketPath, "list-panes", "-s", "-t", "="+sessionName, "-F", "#{pane_id}").Output()
+			if err != nil {
+				return "", fmt.Errorf("failed to list panes of tmux session %s: %w", sessionName, err)
+			}
+			panes := strings.Fields(string(output))
+			if len(panes) != 1 {
+				return "", fmt.Errorf("tmux session %s has %d panes; only single-pane sessions can be shown in a grid", sessionName, len(panes))
+			}
+			paneID = panes[0]
+		}
+
+		// Bind the session to its pane before moving it, so that monitors
+		// woken by the old tmux session closing can find it again
+		title := firstNonEmpty(spec.Sessions[i].Title, spec.Sessions[i].ID)
+		args := paneSetupArgs(proc, spec.Sessions[i].ID, paneID, windowID, title)
+		if out, err := r.tmuxCmd(socketPath, args...).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to set up pane of session %s: %s", spec.Sessions[i].ID, strings.TrimSpace(string(out)))
+		}
+		proc.moveTo(spec.Name, spec.Window, paneID)
+
+		current, _ := r.tmuxCmd(socketPath, "display-message", "-p", "-t", paneID, "#{window_id}").Output()
+		if strings.TrimSpace(string(current)) != windowID {
+			// Re-apply the layout after each move to make room for the next pane
+			if out, err := r.tmuxCmd(socketPath, "join-pane", "-d", "-s", paneID, "-t", joinTarget,
+				";", "select-layout", "-t", windowID, spec.Layout).CombinedOutput(); err != nil {
+				return "", fmt.Errorf("failed to move session %s into grid: %s", spec.Sessions[i].ID, strings.TrimSpace(string(out)))
+			}
+		}
+		joinTarget = paneID
+
+		if placeholder != "" {
+			_ = r.tmuxCmd(socketPath, "kill-pane", "-t", placeholder).Run()
+			placeholder = ""
+		}
+	}
+
+	if out, err := r.tmuxCmd(socketPath, "select-layout", "-t", windowID, spec.Layout,
+		";", "set-option", "-w", "-t", windowID, "pane-border-status", "top",
+		";", "set-option", "-w", "-t", windowID, "pane-border-format", " #{pane_title} ").CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to apply grid layout: %s", strings.TrimSpace(string(out)))
+	}
+
+	return spec.Name, nil
+}
+
+// ensureWindow makes sure a shared window exists and returns its ID. A
+// window created here holds a placeholder pane which should be killed once
+// another pane has joined it.
+func (r *Runtime) ensureWindow(socketPath, sessionName, windowName string) (windowID, placeholder string, err error) {
+	var args []string
+	switch {
+	case r.tmuxCmd(socketPath, "has-session", "-t", "="+sessionName).Run() != nil:
+		args = []string{"new-session", "-d", "-s", sessionName, "-n", windowName}
+	case r.tmuxCmd(socketPath, "has-session", "-t", "="+sessionName+":"+windowName).Run() != nil:
+		args = []string{"new-window", "-d", "-t", "=" + sessionName + ":", "-n", windowName}
+	}
+
+	if args != nil {
+		args = append(args, "-P", "-F", "#{pane_id}\t#{window_id}")
+		output, err := r.tmuxCmd(socketPath, args...).CombinedOutput()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create tmux window %s:%s: %s", sessionName, windowName, strings.TrimSpace(string(output)))
+		}
+		fields := strings.Split(strings.TrimSpace(string(output)), "\t")
+		if len(fields) != 2 {
+			return "", "", fmt.Errorf("unexpected tmux output: %q", output)
+		}
+		placeholder, windowID = fields[0], fields[1]
+	} else {
+		output, err := r.tmuxCmd(socketPath, "display-message", "-p", "-t", "="+sessionName+":"+windowName, "#{window_id}").Output()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to find tmux window %s:%s: %w", sessionName, windowName, err)
+		}
+		windowID = strings.TrimSpace(string(output))
+	}
+
+	// Keep dead panes so that their pane-died hooks can record the exit status
+	if err := r.tmuxCmd(socketPath, "set-option", "-w", "-t", windowID, "remain-on-exit", "on").Run(); err != nil {
+		return "", "", fmt.Errorf("failed to configure tmux window %s:%s: %w", sessionName, windowName, err)
+	}
+	return windowID, placeholder, nil
+}
+
+// executeInGroup starts a process as a new pane in the shared tmux session
+// named by its Group option
+func (r *Runtime) executeInGroup(ctx context.Context, proc *Process, proxyArgs []string) (runtime.Process, error) {
+	opts, spec := proc.opts, proc.spec
+
+	paneArgs := []string{"-P", "-F", "#{pane_id}\t#{window_id}"}
+	if spec.WorkingDir != "" {
+		paneArgs = append(paneArgs, "-c", spec.WorkingDir)
+	}
+	for k, v := range spec.Environment {
+		paneArgs = append(paneArgs, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+	paneArgs = append(paneArgs, proxyArgs...)
+
+	paneID, windowID, err := r.addGroupPane(ctx, opts, paneArgs)
+	if err != nil && strings.Contains(err.Error(), "duplicate session") {
+		// Another amux process created the group first
+		paneID, windowID, err = r.addGroupPane(ctx, opts, paneArgs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	proc.paneID = paneID
+
+	title := firstNonEmpty(spec.Environment["AMUX_SESSION_NAME"], spec.SessionID, paneID)
+	proc.hooked = true
+	args := paneSetupArgs(proc, spec.SessionID, paneID, windowID, title)
+	args = append(args, ";", "select-layout", "-t", windowID, opts.Layout)
+	if opts.StatusLine {
+		args = append(args,
+			";", "set-option", "-w", "-t", windowID, "pane-border-status", "top",
+			";", "set-option", "-w", "-t", windowID, "pane-border-format", " #{pane_title} ")
+	}
+	if out, err := r.tmuxCmd(opts.SocketPath, args...).CombinedOutput(); err != nil {
+		_ = r.killPane(opts.SocketPath, paneID)
+		return nil, fmt.Errorf("failed to set up tmux pane: %s", strings.TrimSpace(string(out)))
+	}
+
+	proc.setState(runtime.StateRunning)
+
+	// A process exiting before its hook was installed is cleaned up here
+	// the way the hook would have done it
+	if dead, err := proc.isPaneDead(); err == nil && dead {
+		_ = r.tmuxCmd(opts.SocketPath, "wait-for", "-S", proc.exitChannel()).Run()
+		if !opts.RemainOnExit {
+			_ = r.killPane(opts.SocketPath, paneID)
+		}
+	}
+
+	r.store(proc)
+	go proc.monitor(ctx)
+
+	return proc, nil
+}
+
+// addGroupPane creates a pane for a process in its group's shared window,
+// creating the tmux session and window as needed
+func (r *Runtime) addGroupPane(ctx context.Context, opts Options, paneArgs []string) (paneID, windowID string, err error) {
+	history := strconv.Itoa(opts.OutputHistory)
+	window := "=" + opts.Group + ":" + opts.WindowName
+
+	var args []string
+	switch {
+	case r.tmuxCmd(opts.SocketPath, "has-session", "-t", "="+opts.Group).Run() != nil:
+		// Replace the placeholder window once history-limit is set, as the
+		// limit only applies to panes created afterwards
+		args = []string{
+			"new-session", "-d", "-s", opts.Group, "-n", opts.WindowName,
+			";", "set-option", "-t", opts.Group, "history-limit", history,
+		}
+		if opts.Mouse {
+			args = append(args, ";", "set-option", "-t", opts.Group, "mouse", "on")
+		}
+		args = append(args, ";", "new-window", "-k", "-t", opts.Group+":^", "-n", opts.WindowName)
+	case r.tmuxCmd(opts.SocketPath, "has-session", "-t", window).Run() != nil:
+		args = []string{
+			"set-option", "-t", opts.Group, "history-limit", history,
+			";", "new-window", "-d", "-t", "=" + opts.Group + ":", "-n", opts.WindowName,
+		}
+	default:
+		// Split the last pane so that panes stay in creation order
+		args = []string{
+			"set-option", "-t", opts.Group, "history-limit", history,
+			";", "split-window", "-d", "-t", window + ".{bottom-right}",
+		}
+	}
+	args = append(args, paneArgs...)
+
+	if opts.SocketPath != "" {
+		args = append([]string{"-S", opts.SocketPath}, args...)
+	}
+	output, err := exec.CommandContext(ctx, r.executable, args...).CombinedOutput()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create tmux pane: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	fields := strings.Split(strings.TrimSpace(string(output)), "\t")
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("unexpected tmux output: %q", output)
+	}
+	paneID, windowID = fields[0], fields[1]
+
+	// Keep dead panes so that their pane-died hooks can record the exit status
+	_ = r.tmuxCmd(opts.SocketPath, "set-option", "-w", "-t", windowID, "remain-on-exit", "on").Run()
+	return paneID, windowID, nil
+}
+
+// paneSetupArgs builds the tmux command list binding an amux session to a
+// pane of a shared window: the pane tag, its title and, for processes
+// started by amux, the pane-died hook
+func paneSetupArgs(proc *Process, sessionID, paneID, windowID, title string) []string {
+	args := []string{"select-pane", "-t", paneID, "-T", title}
+	if sessionID != "" {
+		args = append(args, ";", "set-option", "-p", "-t", paneID, paneSessionIDOption, sessionID)
+	}
+	if proc.hooked {
+		hook := sharedPaneDiedHook(firstNonEmpty(sessionID, paneID), paneID, windowID, proc.opts.RemainOnExit, exitStatusPath(sessionID))
+		args = append(args, ";", "set-hook", "-p", "-t", paneID, "pane-died", hook)
+	}
+	return args
+}
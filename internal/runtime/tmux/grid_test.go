@@ -0,0 +1,215 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aki/amux/internal/runtime"
+)
+
+// Tests in this file run against a real tmux server private to the test
+
+// newTestRuntime returns a runtime talking to a private tmux server. Sessions
+// run without the amux proxy, which is replaced by a script running the
+// wrapped command directly.
+func newTestRuntime(t *testing.T) *Runtime {
+	t.Helper()
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not available")
+	}
+
+	// Keep the socket path short, as unix sockets are limited in length
+	tmpDir, err := os.MkdirTemp("", "amux-tmux")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	proxyBin := filepath.Join(tmpDir, "amux")
+	script := "#!/bin/sh\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift\nexec \"$@\"\n"
+	require.NoError(t, os.WriteFile(proxyBin, []byte(script), 0o755))
+
+	t.Setenv("TMUX", "")
+	t.Setenv("TMUX_TMPDIR", tmpDir)
+	t.Setenv("AMUX_BIN", proxyBin)
+	t.Setenv("AMUX_DIR", filepath.Join(tmpDir, ".amux"))
+
+	return newTestRuntimeIn(t, tmpDir)
+}
+
+// newTestRuntimeIn returns another runtime for the tmux server of a test.
+// Monitors of its processes are stopped with the server, so that they do not
+// act on panes of servers started by later tests.
+func newTestRuntimeIn(t *testing.T, baseDir string) *Runtime {
+	t.Helper()
+	r, err := New(baseDir)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = r.tmuxCmd("", "kill-server").Run()
+		r.processes.Range(func(_, proc any) bool {
+			select {
+			case <-proc.(*Process).done:
+			case <-time.After(2 * hookCheckInterval):
+				t.Errorf("process %s did not stop with the tmux server", proc.(*Process).ID())
+			}
+			return true
+		})
+	})
+	return r
+}
+
+// startSessions starts cat in a tmux pane for each session ID
+func startSessions(t *testing.T, r *Runtime, opts Options, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		_, err := r.Execute(context.Background(), runtime.ExecutionSpec{
+			SessionID: id,
+			Command:   []string{"cat"},
+			Options:   opts,
+		})
+		require.NoError(t, err)
+	}
+}
+
+// windowPanes lists the amux session tag of each pane in a tmux window
+func windowPanes(t *testing.T, r *Runtime, window string) []string {
+	t.Helper()
+	output, err := r.tmuxCmd("", "list-panes", "-t", window, "-F", "#{"+paneSessionIDOption+"}").Output()
+	require.NoError(t, err)
+	return strings.Fields(string(output))
+}
+
+// paneContent captures the visible content of the pane of a session
+func paneContent(t *testing.T, r *Runtime, sessionID string) string {
+	t.Helper()
+	info, ok := r.lookup("", sessionID)
+	require.True(t, ok, "pane of %s not found", sessionID)
+	output, err := r.tmuxCmd("", "capture-pane", "-p", "-t", info.paneID).Output()
+	require.NoError(t, err)
+	return string(output)
+}
+
+// assertTargeted checks that input sent to one session reaches its pane only
+func assertTargeted(t *testing.T, r *Runtime, target string, ids ...string) {
+	t.Helper()
+	input := fmt.Sprintf("input-for-%s", target)
+	require.NoError(t, r.SendInput(context.Background(), target, input))
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(paneContent(t, r, target), input)
+	}, 5*time.Second, 50*time.Millisecond)
+	for _, id := range ids {
+		if id != target {
+			assert.NotContains(t, paneContent(t, r, id), input, "input for %s reached %s", target, id)
+		}
+	}
+}
+
+func TestGrid(t *testing.T) {
+	r := newTestRuntime(t)
+	ctx := context.Background()
+	ids := []string{"session-1", "session-2", "session-3"}
+	startSessions(t, r, Options{}, ids...)
+
+	name, err := r.Grid(ctx, GridSpec{
+		Sessions: []GridSession{{ID: ids[0]}, {ID: ids[1], Title: "second"}, {ID: ids[2]}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, defaultGridName, name)
+
+	// The panes joined the grid window in order, replacing the placeholder
+	window := "=" + defaultGridName + ":amux"
+	assert.Equal(t, ids, windowPanes(t, r, window))
+	for _, id := range ids {
+		info, ok := r.lookup("", id)
+		require.True(t, ok)
+		assert.True(t, info.paneScoped, "%s is not bound to its pane", id)
+		assert.Equal(t, defaultGridName, info.sessionName)
+	}
+	title, err := r.tmuxCmd("", "display-message", "-p", "-t", window+".1", "#{pane_title}").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "second", strings.TrimSpace(string(title)))
+
+	// The original tmux sessions closed once their panes moved out
+	output, err := r.tmuxCmd("", "list-sessions", "-F", "#{session_name}").Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{defaultGridName}, strings.Fields(string(output)))
+
+	// Commands on a session only affect its own pane
+	assertTargeted(t, r, ids[1], ids...)
+
+	require.NoError(t, r.Kill(ctx, ids[0]))
+	assert.Equal(t, ids[1:], windowPanes(t, r, window))
+
+	require.NoError(t, r.Stop(ctx, ids[2]))
+	assert.Eventually(t, func() bool {
+		return r.tmuxCmd("", "list-panes", "-t", window).Run() == nil &&
+			assert.ObjectsAreEqual(ids[1:2], windowPanes(t, r, window))
+	}, 5*time.Second, 50*time.Millisecond)
+
+	proc, err := r.FindBySessionID(ctx, ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, runtime.StateRunning, proc.State())
+}
+
+func TestGrid_Errors(t *testing.T) {
+	r := newTestRuntime(t)
+	ctx := context.Background()
+	startSessions(t, r, Options{}, "session-1")
+
+	_, err := r.Grid(ctx, GridSpec{Sessions: []GridSession{{ID: "session-1"}}, Layout: "spiral"})
+	assert.Error(t, err)
+
+	_, err = r.Grid(ctx, GridSpec{})
+	assert.Error(t, err)
+
+	_, err = r.Grid(ctx, GridSpec{Sessions: []GridSession{{ID: "session-1"}, {ID: "missing"}}})
+	assert.Error(t, err)
+
+	// A failed grid leaves the session where it was
+	info, ok := r.lookup("", "session-1")
+	require.True(t, ok)
+	assert.False(t, info.paneScoped)
+}
+
+func TestExecuteInGroup(t *testing.T) {
+	r := newTestRuntime(t)
+	ctx := context.Background()
+	ids := []string{"session-1", "session-2", "session-3"}
+	startSessions(t, r, Options{Group: "work", Layout: "even-vertical"}, ids...)
+
+	// All sessions share one window of the group session, in creation order
+	window := "=work:amux"
+	assert.Equal(t, ids, windowPanes(t, r, window))
+	output, err := r.tmuxCmd("", "list-sessions", "-F", "#{session_name}").Output()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work"}, strings.Fields(string(output)))
+
+	// A runtime without in-memory state finds the panes through their tags
+	other := newTestRuntimeIn(t, r.baseDir)
+	for _, id := range ids {
+		proc, err := other.FindBySessionID(ctx, id)
+		require.NoError(t, err)
+		_, paneID := proc.location()
+		assert.NotEmpty(t, paneID, "%s is not bound to its pane", id)
+	}
+
+	assertTargeted(t, other, ids[1], ids...)
+
+	require.NoError(t, other.Kill(ctx, ids[1]))
+	assert.Equal(t, []string{ids[0], ids[2]}, windowPanes(t, r, window))
+
+	require.NoError(t, r.Stop(ctx, ids[0]))
+	assert.Equal(t, ids[2:], windowPanes(t, r, window))
+
+	// Killing the last pane closes the group session
+	require.NoError(t, r.Kill(ctx, ids[2]))
+	assert.Error(t, r.tmuxCmd("", "has-session", "-t", "=work").Run())
+}
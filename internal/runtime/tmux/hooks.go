@@ -0,0 +1,254 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aki/amux/internal/runtime"
+	"github.com/aki/amux/internal/runtime/proxy"
+)
+
+const (
+	// exitStatusFile is written to the session directory by the pane-died hook
+	exitStatusFile = "exit_status"
+
+	// exitChannelPrefix names the tmux wait-for channels signalled by hooks
+	exitChannelPrefix = "amux-exit-"
+
+	// hookCheckInterval is how often hooked processes double-check their
+	// session, in case a hook could not run (e.g. the tmux server was killed)
+	hookCheckInterval = 5 * time.Second
+)
+
+// exitChannel returns the wait-for channel signalled when a tmux session, or
+// the pane of a pane-scoped amux session, ends. Pane-scoped channels are keyed
+// by amux session ID (or pane ID) as the pane may move between tmux sessions.
+func exitChannel(name string) string {
+	return exitChannelPrefix + name
+}
+
+// paneDiedHook builds the pane-died hook for a session started by amux.
+// It records the exit status, wakes the monitor and cleans up the session
+// unless it should remain after exit.
+func paneDiedHook(opts Options, exitFile string) string {
+	var cleanup []string
+	if !opts.RemainOnExit {
+		cleanup = append(cleanup, "kill-session -t "+tmuxQuote(opts.SessionName))
+	}
+	return exitHookCommands(exitFile, exitChannel(opts.SessionName), cleanup)
+}
+
+// sharedPaneDiedHook builds the pane-died hook for an amux session living in a
+// single pane of a shared window. Cleanup removes the pane and re-applies the
+// window layout to the remaining panes.
+func sharedPaneDiedHook(channelKey, paneID, windowID string, remainOnExit bool, exitFile string) string {
+	var cleanup []string
+	if !remainOnExit {
+		cleanup = append(cleanup, "kill-pane -t "+tmuxQuote(paneID))
+		if windowID != "" {
+			cleanup = append(cleanup, "select-layout -t "+tmuxQuote(windowID))
+		}
+	}
+	return exitHookCommands(exitFile, exitChannel(channelKey), cleanup)
+}
+
+// exitHookCommands joins the commands of a pane-died hook
+func exitHookCommands(exitFile, channel string, cleanup []string) string {
+	var cmds []string
+	if exitFile != "" {
+		shellCmd := "printf %s #{pane_dead_status} > " + shellJoin([]string{strings.ReplaceAll(exitFile, "#", "##")})
+		cmds = append(cmds, "run-shell "+tmuxQuote(shellCmd))
+	}
+	cmds = append(cmds, "wait-for -S "+tmuxQuote(channel))
+	cmds = append(cmds, cleanup...)
+	return strings.Join(cmds, " ; ")
+}
+
+// exitStatusPath returns the file the pane-died hook records the exit status in
+func exitStatusPath(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	dir, err := proxy.SessionDir(sessionID)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, exitStatusFile)
+}
+
+// ensureClosedHook installs a global session-closed hook which signals the
+// exit channel of the closed session. Session-scoped session-closed hooks
+// never fire, as the session is gone by the time tmux runs them.
+func (r *Runtime) ensureClosedHook(socketPath string) error {
+	output, err := r.tmuxCmd(socketPath, "show-hooks", "-g", "session-closed").Output()
+	if err == nil && strings.Contains(string(output), exitChannelPrefix) {
+		return nil
+	}
+
+	shellCmd := shellJoin([]string{r.executable, "-S", "#{socket_path}", "wait-for", "-S", exitChannelPrefix + "#{hook_session_name}"})
+	if out, err := r.tmuxCmd(socketPath, "set-hook", "-ga", "session-closed", "run-shell "+tmuxQuote(shellCmd)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to install session-closed hook: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// exitHook returns the amux pane-died hook of a tmux session, if it has one
+func (r *Runtime) exitHook(socketPath, sessionName string) (string, bool) {
+	output, err := r.tmuxCmd(socketPath, "show-hooks", "-t", sessionName, "pane-died").Output()
+	if err != nil || !strings.Contains(string(output), exitChannel(sessionName)) {
+		return "", false
+	}
+	return string(output), true
+}
+
+// paneExitHook returns the amux pane-died hook of a pane-scoped session, if it has one
+func (r *Runtime) paneExitHook(socketPath, paneID, sessionID string) (string, bool) {
+	output, err := r.tmuxCmd(socketPath, "show-hooks", "-p", "-t", paneID, "pane-died").Output()
+	if err != nil || !strings.Contains(string(output), exitChannel(sessionID)) {
+		return "", false
+	}
+	return string(output), true
+}
+
+// waitForExit blocks until tmux hooks report that the session has ended
+func (p *Process) waitForExit(ctx context.Context) {
+	channel := p.exitChannel()
+	woken := p.waitForSignal(ctx, channel)
+
+	ticker := time.NewTicker(hookCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.setState(runtime.StateFailed)
+			p.doneOnce.Do(func() {
+				close(p.done)
+			})
+			return
+		case <-woken:
+			// Guard against wait-for failing for other reasons, and follow
+			// panes moved to another tmux session (e.g. into a grid)
+			if p.alive() {
+				woken = nil
+				if next := p.exitChannel(); next != channel {
+					channel = next
+					woken = p.waitForSignal(ctx, channel)
+				}
+				continue
+			}
+			if p.relocate() {
+				channel = p.exitChannel()
+				woken = p.waitForSignal(ctx, channel)
+				continue
+			}
+		case <-ticker.C:
+			// Hooks do not run if tmux misses the process exit
+			if p.alive() {
+				continue
+			}
+			if p.relocate() {
+				channel = p.exitChannel()
+				woken = p.waitForSignal(ctx, channel)
+				continue
+			}
+		}
+
+		code := p.getExitStatus()
+		p.mu.Lock()
+		p.state = runtime.StateStopped
+		p.exitCode = code
+		p.mu.Unlock()
+
+		// Finish the hook's cleanup in case it did not run
+		if !p.opts.RemainOnExit && p.sessionExists() {
+			p.cleanup()
+		}
+
+		p.doneOnce.Do(func() {
+			close(p.done)
+		})
+		return
+	}
+}
+
+// waitForSignal waits on an exit channel in the background. The
+// returned channel is closed when a hook signals it, or when wait-for fails
+// (e.g. the tmux server went away).
+func (p *Process) waitForSignal(ctx context.Context, channel string) <-chan struct{} {
+	woken := make(chan struct{})
+	go func() {
+		defer close(woken)
+		cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "wait-for", channel)
+		if err := cmd.Start(); err != nil {
+			return
+		}
+		exited := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = cmd.Process.Kill()
+			case <-exited:
+			}
+		}()
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	return woken
+}
+
+// exitChannel returns the wait-for channel hooks signal when the process ends
+func (p *Process) exitChannel() string {
+	sessionName, paneID := p.location()
+	if paneID != "" {
+		return exitChannel(firstNonEmpty(p.spec.SessionID, paneID))
+	}
+	return exitChannel(sessionName)
+}
+
+// alive reports whether the process's pane still exists and has not exited
+func (p *Process) alive() bool {
+	if !p.sessionExists() {
+		return false
+	}
+	dead, err := p.isPaneDead()
+	return err == nil && !dead
+}
+
+// readExitStatus reads the exit status recorded for a session
+func readExitStatus(sessionID string) (int, bool) {
+	path := exitStatusPath(sessionID)
+	if path == "" {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if code, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return code, true
+		}
+	}
+
+	// Fall back to the status recorded by the proxy running in the pane
+	data, err = os.ReadFile(filepath.Join(filepath.Dir(path), "status.yaml"))
+	if err != nil {
+		return 0, false
+	}
+	var status proxy.Status
+	if err := yaml.Unmarshal(data, &status); err != nil || status.Status != "exited" {
+		return 0, false
+	}
+	return status.ExitCode, true
+}
+
+// tmuxQuote quotes a string for use as a single argument in a tmux command
+func tmuxQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,11 +23,24 @@ import (
 	"github.com/aki/amux/internal/runtime/proxy"
 )
 
+const (
+	// sessionIDOption is the tmux session option recording the amux session ID
+	sessionIDOption = "@amux_session_id"
+	// paneSessionIDOption is the tmux pane option recording the amux session ID
+	// for sessions bound to a single pane rather than a whole tmux session
+	paneSessionIDOption = "@amux_pane_session_id"
+
+	// paneInfoFormat is the tmux format used to identify panes and their amux tags
+	paneInfoFormat = "#{session_name}\t#{window_name}\t#{pane_id}\t#{pane_dead}\t#{" +
+		sessionIDOption + "}\t#{" + paneSessionIDOption + "}"
+)
+
 // Runtime implements the tmux-based process runtime
 type Runtime struct {
 	executable string   // tmux binary path
 	baseDir    string   // base directory for sockets
 	processes  sync.Map // map[string]*Process
+	sessions   sync.Map // map[sessionID]processID
 }
 
 // New creates a new tmux runtime
@@ -76,6 +90,15 @@ func (r *Runtime) Execute(ctx context.Context, spec runtime.ExecutionSpec) (runt
 	if opts.OutputHistory == 0 {
 		opts.OutputHistory = 10000
 	}
+	if opts.Group != "" {
+		opts.SessionName = opts.Group
+		if opts.Layout == "" {
+			opts.Layout = defaultLayout
+		}
+		if err := validateLayout(opts.Layout); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create process
 	proc := &Process{
@@ -100,24 +123,33 @@ func (r *Runtime) Execute(ctx context.Context, spec runtime.ExecutionSpec) (runt
 		return nil, fmt.Errorf("failed to build proxy command: %w", err)
 	}
 
-	// Build tmux command
+	// Validate working directory
+	if spec.WorkingDir != "" {
+		if _, err := os.Stat(spec.WorkingDir); err != nil {
+			return nil, fmt.Errorf("working directory does not exist: %w", err)
+		}
+	}
+
+	if opts.Group != "" {
+		return r.executeInGroup(ctx, proc, proxyArgs)
+	}
+
+	// Build tmux command. All setup runs as a single tmux command list.
+	// The session starts with a placeholder window which is replaced by the
+	// real one once history-limit is set, as the limit only applies to
+	// panes created afterwards.
+	target := opts.SessionName + ":^"
 	args := []string{
 		"new-session",
 		"-d", // detached
 		"-s", opts.SessionName,
 		"-n", opts.WindowName,
 	}
+	args = append(args, ";", "set-option", "-t", opts.SessionName, "history-limit", strconv.Itoa(opts.OutputHistory))
 
-	// Add socket path if specified
-	if opts.SocketPath != "" {
-		args = append([]string{"-S", opts.SocketPath}, args...)
-	}
-
-	// Set working directory
+	// Replace the placeholder window with the proxied command
+	args = append(args, ";", "new-window", "-k", "-t", target, "-n", opts.WindowName)
 	if spec.WorkingDir != "" {
-		if _, err := os.Stat(spec.WorkingDir); err != nil {
-			return nil, fmt.Errorf("working directory does not exist: %w", err)
-		}
 		args = append(args, "-c", spec.WorkingDir)
 	}
 
@@ -126,24 +158,61 @@ func (r *Runtime) Execute(ctx context.Context, spec runtime.ExecutionSpec) (runt
 		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
 	}
 
-	// Set remain-on-exit if requested
-	if opts.RemainOnExit {
-		args = append(args, "-x") // remain-on-exit
-	}
-
 	// Add the proxy command
 	args = append(args, proxyArgs...)
 
+	// Record the amux session ID on the tmux session so that other amux
+	// processes can find it later
+	if spec.SessionID != "" {
+		args = append(args, ";", "set-option", "-t", opts.SessionName, sessionIDOption, spec.SessionID)
+	}
+
+	// Apply user interface options
+	args = append(args, opts.uiArgs(spec)...)
+
+	// Keep the pane around when the process exits so that pane-died fires
+	// and can record the exit status before the session is cleaned up.
+	// Both apply to the current window, i.e. the one just created.
+	args = append(args, ";", "set-option", "-w", "-t", target, "remain-on-exit", "on")
+	exitFile := ""
+	if spec.SessionID != "" {
+		if dir, err := proxy.SessionDir(spec.SessionID); err == nil {
+			exitFile = filepath.Join(dir, exitStatusFile)
+		}
+	}
+	args = append(args, ";", "set-hook", "-t", opts.SessionName, "pane-died", paneDiedHook(opts, exitFile))
+
+	// Add socket path if specified
+	if opts.SocketPath != "" {
+		args = append([]string{"-S", opts.SocketPath}, args...)
+	}
+
 	// Create session with command
 	cmd := exec.CommandContext(ctx, r.executable, args...)
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to create tmux session: %w", err)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to create tmux session: %w: %s", err, strings.TrimSpace(string(output)))
 	}
 
 	proc.setState(runtime.StateRunning)
+	proc.hooked = true
+
+	// pane-died hooks belong to the window, so they can only be installed
+	// once it exists. A process exiting before that is cleaned up here the
+	// way the hook would have done it.
+	if dead, err := proc.isPaneDead(); err == nil && dead {
+		_ = r.tmuxCmd(opts.SocketPath, "wait-for", "-S", exitChannel(opts.SessionName)).Run()
+		if !opts.RemainOnExit {
+			_ = r.killSession(opts.SocketPath, opts.SessionName)
+		}
+	}
+
+	// Wake monitors when sessions are closed from outside amux
+	if err := r.ensureClosedHook(opts.SocketPath); err != nil {
+		proc.hooked = false
+	}
 
 	// Store process
-	r.processes.Store(proc.id, proc)
+	r.store(proc)
 
 	// Monitor process
 	go proc.monitor(ctx)
@@ -151,12 +220,234 @@ func (r *Runtime) Execute(ctx context.Context, spec runtime.ExecutionSpec) (runt
 	return proc, nil
 }
 
-// Find locates an existing process by ID
+// Adopt brings an existing tmux session or pane under amux management.
+// The target may be a tmux session name or a pane reference (e.g. "%3" or
+// "work:1.2"). Output of the target pane is tapped into an amux proxy so that
+// activity tracking, logs and watch work as for sessions started by amux.
+func (r *Runtime) Adopt(ctx context.Context, spec runtime.AdoptSpec) (runtime.Process, error) {
+	if spec.Target == "" {
+		return nil, fmt.Errorf("tmux target is required")
+	}
+	if spec.SessionID == "" {
+		return nil, fmt.Errorf("session ID is required")
+	}
+
+	// display-message falls back to the current pane for unknown targets,
+	// so check the target exists first
+	if err := r.tmuxCmd("", "has-session", "-t", spec.Target).Run(); err != nil {
+		return nil, fmt.Errorf("tmux target not found: %s", spec.Target)
+	}
+
+	// Resolve target to its session and pane
+	output, err := r.tmuxCmd("", "display-message", "-p", "-t", spec.Target, paneInfoFormat).Output()
+	if err != nil {
+		return nil, fmt.Errorf("tmux target not found: %s", spec.Target)
+	}
+	fields := strings.Split(strings.TrimRight(string(output), "\n"), "\t")
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("unexpected tmux output for target %s: %q", spec.Target, output)
+	}
+	sessionName, windowName, paneID := fields[0], fields[1], fields[2]
+	if fields[3] == "1" {
+		return nil, fmt.Errorf("tmux pane %s is dead", paneID)
+	}
+	if existing := firstNonEmpty(fields[5], fields[4]); existing != "" {
+		return nil, fmt.Errorf("tmux target %s is already managed by amux session %s", spec.Target, existing)
+	}
+
+	// A bare session name adopts the whole session, anything else adopts a single pane
+	paneScoped := strings.TrimPrefix(spec.Target, "=") != sessionName
+
+	proc := &Process{
+		id:          uuid.New().String(),
+		sessionName: sessionName,
+		spec:        runtime.ExecutionSpec{SessionID: spec.SessionID, EnableLog: spec.EnableLog},
+		state:       runtime.StateRunning,
+		startTime:   time.Now(),
+		opts:        Options{SessionName: sessionName, WindowName: windowName},
+		runtime:     r,
+		done:        make(chan struct{}),
+		adopted:     true,
+	}
+	if paneScoped {
+		proc.paneID = paneID
+	}
+
+	// Tag the target with the session ID
+	var tagCmd *exec.Cmd
+	if paneScoped {
+		tagCmd = r.tmuxCmd("", "set-option", "-p", "-t", paneID, paneSessionIDOption, spec.SessionID)
+	} else {
+		tagCmd = r.tmuxCmd("", "set-option", "-t", sessionName, sessionIDOption, spec.SessionID)
+	}
+	if out, err := tagCmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to tag tmux target: %s", out)
+	}
+
+	// Tap pane output into an amux proxy for activity tracking
+	tapArgs, err := proxy.BuildTapCommand(spec.SessionID, spec.EnableLog)
+	if err != nil {
+		proc.untag()
+		return nil, fmt.Errorf("failed to build proxy command: %w", err)
+	}
+	if out, err := r.tmuxCmd("", "pipe-pane", "-o", "-t", paneID, shellJoin(tapArgs)).CombinedOutput(); err != nil {
+		proc.untag()
+		return nil, fmt.Errorf("failed to attach activity tracking: %s", out)
+	}
+
+	r.store(proc)
+	go proc.monitor(context.WithoutCancel(ctx))
+
+	return proc, nil
+}
+
+// Find locates an existing process by process ID or amux session ID
 func (r *Runtime) Find(ctx context.Context, id string) (runtime.Process, error) {
 	if proc, ok := r.processes.Load(id); ok {
 		return proc.(*Process), nil
 	}
-	return nil, runtime.ErrProcessNotFound
+	return r.FindBySessionID(ctx, id)
+}
+
+// FindBySessionID locates a process by amux session ID. Sessions started or
+// adopted by another amux process are discovered through their tmux tags.
+func (r *Runtime) FindBySessionID(ctx context.Context, sessionID string) (*Process, error) {
+	if processID, ok := r.sessions.Load(sessionID); ok {
+		if proc, ok := r.processes.Load(processID); ok {
+			p := proc.(*Process)
+			// Follow panes moved by another amux process
+			if p.State() == runtime.StateRunning && !p.sessionExists() {
+				p.relocate()
+			}
+			return p, nil
+		}
+	}
+
+	info, ok := r.lookup("", sessionID)
+	if !ok {
+		return nil, runtime.ErrProcessNotFound
+	}
+
+	// Sessions without amux hooks were adopted rather than started by amux
+	var hook string
+	var hooked bool
+	if info.paneScoped {
+		hook, hooked = r.paneExitHook("", info.paneID, sessionID)
+	} else {
+		hook, hooked = r.exitHook("", info.sessionName)
+	}
+
+	proc := &Process{
+		id:          uuid.New().String(),
+		sessionName: info.sessionName,
+		spec:        runtime.ExecutionSpec{SessionID: sessionID},
+		state:       runtime.StateRunning,
+		startTime:   time.Now(),
+		opts: Options{
+			SessionName:  info.sessionName,
+			WindowName:   info.windowName,
+			RemainOnExit: hooked && !strings.Contains(hook, "kill-"),
+		},
+		runtime: r,
+		done:    make(chan struct{}),
+		adopted: !hooked,
+		hooked:  hooked,
+	}
+	if info.paneScoped {
+		proc.paneID = info.paneID
+	}
+	if info.dead {
+		proc.state = runtime.StateStopped
+		proc.exitCode = proc.getExitStatus()
+		proc.doneOnce.Do(func() { close(proc.done) })
+	} else {
+		go proc.monitor(context.WithoutCancel(ctx))
+	}
+
+	r.store(proc)
+	return proc, nil
+}
+
+// paneInfo describes a tmux pane as reported with paneInfoFormat
+type paneInfo struct {
+	sessionName string
+	windowName  string
+	paneID      string
+	dead        bool
+	paneScoped  bool // Tagged as a single-pane amux session
+}
+
+// lookup finds the pane of an amux session through its tmux tags
+func (r *Runtime) lookup(socketPath, sessionID string) (paneInfo, bool) {
+	output, err := r.tmuxCmd(socketPath, "list-panes", "-a", "-F", paneInfoFormat).Output()
+	if err != nil {
+		// No tmux server running
+		return paneInfo{}, false
+	}
+
+	// Trailing tabs are significant, as the last fields are often empty
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 6 {
+			continue
+		}
+		paneScoped := fields[5] == sessionID
+		if !paneScoped && fields[4] != sessionID {
+			continue
+		}
+		return paneInfo{
+			sessionName: fields[0],
+			windowName:  fields[1],
+			paneID:      fields[2],
+			dead:        fields[3] == "1",
+			paneScoped:  paneScoped,
+		}, true
+	}
+	return paneInfo{}, false
+}
+
+// Stop gracefully stops a session
+func (r *Runtime) Stop(ctx context.Context, sessionID string) error {
+	proc, err := r.FindBySessionID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return proc.Stop(ctx)
+}
+
+// Kill forcefully terminates a session
+func (r *Runtime) Kill(ctx context.Context, sessionID string) error {
+	proc, err := r.FindBySessionID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return proc.Kill(ctx)
+}
+
+// Attach attaches the current terminal to a session
+func (r *Runtime) Attach(ctx context.Context, sessionID string) error {
+	proc, err := r.FindBySessionID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return proc.Attach()
+}
+
+// SendInput sends input to a session
+func (r *Runtime) SendInput(ctx context.Context, sessionID string, input string) error {
+	proc, err := r.FindBySessionID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return proc.SendInput(input)
+}
+
+// store registers a process and its session mapping
+func (r *Runtime) store(proc *Process) {
+	r.processes.Store(proc.id, proc)
+	if proc.spec.SessionID != "" {
+		r.sessions.Store(proc.spec.SessionID, proc.id)
+	}
 }
 
 // List returns all processes managed by this runtime
@@ -199,6 +490,19 @@ func (r *Runtime) killSession(socketPath, sessionName string) error {
 	return cmd.Run()
 }
 
+// killPane kills a tmux pane and re-applies the layout of the window it was in
+func (r *Runtime) killPane(socketPath, paneID string) error {
+	windowID, _ := r.tmuxCmd(socketPath, "display-message", "-p", "-t", paneID, "#{window_id}").Output()
+	if err := r.tmuxCmd(socketPath, "kill-pane", "-t", paneID).Run(); err != nil {
+		return err
+	}
+	if id := strings.TrimSpace(string(windowID)); id != "" {
+		// Without a layout name the last preset layout is re-applied
+		_ = r.tmuxCmd(socketPath, "select-layout", "-t", id).Run()
+	}
+	return nil
+}
+
 // RunCommand executes a tmux command and returns output
 func (r *Runtime) RunCommand(args ...string) (string, error) {
 	cmd := exec.Command(r.executable, args...)
@@ -230,6 +534,9 @@ type Process struct {
 	done        chan struct{}
 	doneOnce    sync.Once
 	exitCode    int
+	paneID      string // Pane the process is bound to (empty means the whole session)
+	adopted     bool   // Process was not started by amux
+	hooked      bool   // tmux hooks report exit, so the process needs no polling
 }
 
 // ID returns the unique identifier for this process
@@ -266,7 +573,7 @@ func (p *Process) Stop(ctx context.Context) error {
 	p.mu.Unlock()
 
 	// Send SIGTERM via tmux
-	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "send-keys", "-t", p.sessionName, "C-c")
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "send-keys", "-t", p.target(), "C-c")
 	cmd = exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to send interrupt: %w", err)
@@ -294,8 +601,13 @@ func (p *Process) Kill(ctx context.Context) error {
 	}
 	p.mu.Unlock()
 
-	// Kill the tmux session
-	if err := p.runtime.killSession(p.opts.SocketPath, p.sessionName); err != nil {
+	// Kill the tmux pane or session
+	sessionName, paneID := p.location()
+	if paneID != "" {
+		if err := p.runtime.killPane(p.opts.SocketPath, paneID); err != nil {
+			return fmt.Errorf("failed to kill pane: %w", err)
+		}
+	} else if err := p.runtime.killSession(p.opts.SocketPath, sessionName); err != nil {
 		return fmt.Errorf("failed to kill session: %w", err)
 	}
 
@@ -344,10 +656,12 @@ func (p *Process) StartTime() time.Time {
 
 // Metadata returns runtime-specific metadata
 func (p *Process) Metadata() runtime.Metadata {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return &Metadata{
 		SessionName: p.sessionName,
 		WindowName:  p.opts.WindowName,
-		// PaneID could be retrieved dynamically if needed, but for now leave empty
+		PaneID:      p.paneID,
 	}
 }
 
@@ -367,19 +681,27 @@ func (p *Process) Attach() error {
 		return fmt.Errorf("tmux session no longer exists")
 	}
 
+	sessionName, paneID := p.location()
+
 	// Get current terminal size
 	width, height, err := term.GetSize(os.Stdout.Fd())
 	if err == nil && width > 0 && height > 0 {
 		// Try to resize tmux window to match terminal
 		// This is best-effort, so we ignore errors
-		resizeCmd := p.runtime.tmuxCmd(p.opts.SocketPath, "resize-window", "-t", p.sessionName,
+		resizeCmd := p.runtime.tmuxCmd(p.opts.SocketPath, "resize-window", "-t", sessionName,
 			"-x", fmt.Sprintf("%d", width),
 			"-y", fmt.Sprintf("%d", height))
 		_ = resizeCmd.Run() // Ignore errors as resize is not critical
 	}
 
+	// Focus the pane before attaching so the client lands on it
+	if paneID != "" {
+		_ = p.runtime.tmuxCmd(p.opts.SocketPath, "select-window", "-t", paneID).Run()
+		_ = p.runtime.tmuxCmd(p.opts.SocketPath, "select-pane", "-t", paneID).Run()
+	}
+
 	// Create attach command
-	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "attach-session", "-t", p.sessionName)
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "attach-session", "-t", sessionName)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -394,17 +716,91 @@ func (p *Process) setState(state runtime.ProcessState) {
 	p.state = state
 }
 
-// sessionExists checks if the tmux session still exists
+// location returns the tmux session and pane the process lives in
+func (p *Process) location() (sessionName, paneID string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.sessionName, p.paneID
+}
+
+// moveTo records that the process now lives in the given pane
+func (p *Process) moveTo(sessionName, windowName, paneID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessionName = sessionName
+	p.paneID = paneID
+	p.opts.SessionName = sessionName
+	p.opts.WindowName = windowName
+}
+
+// relocate looks the process up by its tmux tags after it disappeared from
+// its last known location, e.g. because its pane was joined into a grid.
+// It reports whether the process was found alive elsewhere.
+func (p *Process) relocate() bool {
+	if p.spec.SessionID == "" {
+		return false
+	}
+	info, ok := p.runtime.lookup(p.opts.SocketPath, p.spec.SessionID)
+	if !ok || info.dead {
+		return false
+	}
+	newPaneID := ""
+	if info.paneScoped {
+		newPaneID = info.paneID
+	}
+	if sessionName, paneID := p.location(); info.sessionName == sessionName && newPaneID == paneID {
+		return false
+	}
+	p.moveTo(info.sessionName, info.windowName, newPaneID)
+	return true
+}
+
+// target returns the tmux target for pane-level commands
+func (p *Process) target() string {
+	sessionName, paneID := p.location()
+	if paneID != "" {
+		return paneID
+	}
+	return sessionName
+}
+
+// sessionExists checks if the tmux session (or pane) still exists
 func (p *Process) sessionExists() bool {
-	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "has-session", "-t", p.sessionName)
+	sessionName, paneID := p.location()
+	if paneID != "" {
+		// has-session accepts pane targets without falling back to the current pane
+		return p.runtime.tmuxCmd(p.opts.SocketPath, "has-session", "-t", paneID).Run() == nil
+	}
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "has-session", "-t", sessionName)
 	return cmd.Run() == nil
 }
 
+// cleanup removes the tmux session, or the pane of a pane-scoped process
+// together with re-applying the layout of the window it was in
+func (p *Process) cleanup() {
+	sessionName, paneID := p.location()
+	if paneID == "" {
+		_ = p.runtime.killSession(p.opts.SocketPath, sessionName)
+		return
+	}
+	_ = p.runtime.killPane(p.opts.SocketPath, paneID)
+}
+
+// untag removes the amux session ID from the tmux target
+func (p *Process) untag() {
+	sessionName, paneID := p.location()
+	if paneID != "" {
+		_ = p.runtime.tmuxCmd(p.opts.SocketPath, "set-option", "-p", "-u", "-t", paneID, paneSessionIDOption).Run()
+		return
+	}
+	_ = p.runtime.tmuxCmd(p.opts.SocketPath, "set-option", "-u", "-t", sessionName, sessionIDOption).Run()
+}
+
 // capturePane captures the pane content
 func (p *Process) capturePane() (string, error) {
 	// Use -S to capture from the beginning of the history
 	// and -E to capture to the end
-	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "capture-pane", "-t", p.sessionName, "-p", "-S", "-", "-E", "-")
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "capture-pane", "-t", p.target(), "-p", "-S", "-", "-E", "-")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to capture pane: %w", err)
@@ -432,7 +828,7 @@ func (p *Process) CaptureOutput(lines int) ([]byte, error) {
 	}
 
 	// Capture the specified number of lines from the bottom
-	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "capture-pane", "-t", p.sessionName,
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "capture-pane", "-t", p.target(),
 		"-p",                            // print to stdout
 		"-e",                            // include escape sequences
 		"-S", fmt.Sprintf("-%d", lines), // start from N lines up
@@ -518,7 +914,7 @@ func (p *Process) StreamOutput(ctx context.Context, w io.Writer, opts runtime.St
 
 // isPaneDead checks if the pane is dead
 func (p *Process) isPaneDead() (bool, error) {
-	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "list-panes", "-t", p.sessionName, "-F", "#{pane_dead}")
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "display-message", "-p", "-t", p.target(), "#{pane_dead}")
 	output, err := cmd.Output()
 	if err != nil {
 		if strings.Contains(err.Error(), "session not found") {
@@ -530,15 +926,33 @@ func (p *Process) isPaneDead() (bool, error) {
 	return strings.TrimSpace(string(output)) == "1", nil
 }
 
-// getExitStatus tries to get the exit status from the pane
+// getExitStatus returns the exit status of the process in the pane
 func (p *Process) getExitStatus() int {
-	// This is a limitation of tmux - it doesn't directly expose exit codes
-	// We assume success (0) for dead panes unless we know otherwise
-	return 0
+	// Recorded by the pane-died hook for sessions started by amux
+	if code, ok := readExitStatus(p.spec.SessionID); ok {
+		return code
+	}
+
+	// Dead panes kept by remain-on-exit still know their status
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "display-message", "-p", "-t", p.target(), "#{pane_dead_status}")
+	if output, err := cmd.Output(); err == nil {
+		if code, err := strconv.Atoi(strings.TrimSpace(string(output))); err == nil {
+			return code
+		}
+	}
+
+	// Assume failure if the session disappeared without a status
+	return 1
 }
 
 // monitor watches the tmux session for completion
 func (p *Process) monitor(ctx context.Context) {
+	if p.hooked {
+		p.waitForExit(ctx)
+		return
+	}
+
+	// Without amux hooks (e.g. adopted targets) poll for exit
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -553,9 +967,13 @@ func (p *Process) monitor(ctx context.Context) {
 		case <-ticker.C:
 			// Check if session still exists
 			if !p.sessionExists() {
+				if p.relocate() {
+					continue
+				}
+				code := p.getExitStatus()
 				p.mu.Lock()
 				p.state = runtime.StateStopped
-				p.exitCode = 1 // Assume failure if session disappeared
+				p.exitCode = code
 				p.mu.Unlock()
 				p.doneOnce.Do(func() {
 					close(p.done)
@@ -566,14 +984,16 @@ func (p *Process) monitor(ctx context.Context) {
 			// Check if pane is dead
 			dead, err := p.isPaneDead()
 			if err == nil && dead {
+				code := p.getExitStatus()
 				p.mu.Lock()
 				p.state = runtime.StateStopped
-				p.exitCode = p.getExitStatus()
+				p.exitCode = code
 				p.mu.Unlock()
 
-				// Kill session if remain-on-exit is not set
-				if !p.opts.RemainOnExit {
-					_ = p.runtime.killSession(p.opts.SocketPath, p.sessionName)
+				// Kill session if remain-on-exit is not set. Adopted
+				// targets belong to the user and are left alone.
+				if !p.opts.RemainOnExit && !p.adopted {
+					p.cleanup()
 				}
 
 				p.doneOnce.Do(func() {
@@ -587,12 +1007,40 @@ func (p *Process) monitor(ctx context.Context) {
 
 // Options implements runtime.RuntimeOptions for tmux processes
 type Options struct {
-	SessionName   string // Tmux session name (generated if empty)
-	WindowName    string // Window name (default: "amux")
-	SocketPath    string // Custom socket path (generated if empty)
-	RemainOnExit  bool   // Keep pane open after process exits
-	CaptureOutput bool   // Capture pane output
-	OutputHistory int    // Lines of history to keep (default: 10000)
+	SessionName   string `yaml:"sessionName,omitempty"`   // Tmux session name (generated if empty)
+	WindowName    string `yaml:"windowName,omitempty"`    // Window name (default: "amux")
+	SocketPath    string `yaml:"socketPath,omitempty"`    // Custom socket path (generated if empty)
+	RemainOnExit  bool   `yaml:"remainOnExit,omitempty"`  // Keep pane open after process exits
+	CaptureOutput bool   `yaml:"captureOutput,omitempty"` // Capture pane output
+	OutputHistory int    `yaml:"historyLimit,omitempty"`  // Lines of history to keep (default: 10000)
+	StatusLine    bool   `yaml:"statusLine,omitempty"`    // Show amux session and workspace in the status line
+	Mouse         bool   `yaml:"mouse,omitempty"`         // Enable mouse support
+	Group         string `yaml:"group,omitempty"`         // Shared tmux session to add the process to as a pane
+	Layout        string `yaml:"layout,omitempty"`        // Layout of the shared window (default: "tiled")
+}
+
+// uiArgs returns the tmux commands applying user interface options
+func (o Options) uiArgs(spec runtime.ExecutionSpec) []string {
+	var args []string
+	if o.Mouse {
+		args = append(args, ";", "set-option", "-t", o.SessionName, "mouse", "on")
+	}
+	if o.StatusLine {
+		name := firstNonEmpty(spec.Environment["AMUX_SESSION_NAME"], spec.SessionID, o.SessionName)
+		left := fmt.Sprintf(" amux: %s ", escapeFormat(name))
+		right := ""
+		if ws := spec.Environment["AMUX_WORKSPACE_ID"]; ws != "" {
+			right = fmt.Sprintf(" workspace: %s ", escapeFormat(ws))
+		}
+		args = append(args,
+			";", "set-option", "-t", o.SessionName, "status", "on",
+			";", "set-option", "-t", o.SessionName, "status-left-length", strconv.Itoa(len(left)),
+			";", "set-option", "-t", o.SessionName, "status-left", left,
+			";", "set-option", "-t", o.SessionName, "status-right-length", strconv.Itoa(len(right)),
+			";", "set-option", "-t", o.SessionName, "status-right", right,
+		)
+	}
+	return args
 }
 
 // IsRuntimeOptions implements the RuntimeOptions interface
@@ -602,18 +1050,18 @@ func (Options) IsRuntimeOptions() {}
 func (p *Process) SendInput(input string) error {
 	// Check if session still exists
 	if !p.sessionExists() {
-		return fmt.Errorf("tmux session not found: %s", p.sessionName)
+		return fmt.Errorf("tmux session not found: %s", p.target())
 	}
 
 	// Use tmux send-keys to send input
 	// -l flag sends the input literally (without interpreting keys like Enter)
-	cmd := exec.Command("tmux", "send-keys", "-t", p.sessionName, "-l", input)
+	cmd := p.runtime.tmuxCmd(p.opts.SocketPath, "send-keys", "-t", p.target(), "-l", input)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to send input: %w", err)
 	}
 
 	// Send Enter key to execute the command
-	cmd = exec.Command("tmux", "send-keys", "-t", p.sessionName, "Enter")
+	cmd = p.runtime.tmuxCmd(p.opts.SocketPath, "send-keys", "-t", p.target(), "Enter")
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to send Enter key: %w", err)
 	}
@@ -657,3 +1105,27 @@ func (p *Process) GetLastActivityAt() (time.Time, error) {
 
 	return status.LastActivityAt, nil
 }
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// escapeFormat escapes text for literal use in a tmux format
+func escapeFormat(s string) string {
+	return strings.ReplaceAll(s, "#", "##")
+}
+
+// shellJoin quotes arguments into a single shell command line
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
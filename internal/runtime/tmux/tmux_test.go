@@ -48,3 +48,49 @@ func TestTmuxRuntime_NotAvailable(t *testing.T) {
 	err := r.Validate()
 	assert.Error(t, err)
 }
+
+func TestPaneDiedHook(t *testing.T) {
+	hook := paneDiedHook(Options{SessionName: "amux-1234"}, "/tmp/it's/exit_status")
+	assert.Equal(t,
+		`run-shell "printf %s #{pane_dead_status} > '/tmp/it'\\''s/exit_status'" ; `+
+			`wait-for -S "amux-exit-amux-1234" ; kill-session -t "amux-1234"`,
+		hook)
+
+	// Sessions that remain after exit are not killed, and no status is
+	// recorded without a session directory
+	hook = paneDiedHook(Options{SessionName: "amux-1234", RemainOnExit: true}, "")
+	assert.Equal(t, `wait-for -S "amux-exit-amux-1234"`, hook)
+}
+
+func TestSharedPaneDiedHook(t *testing.T) {
+	hook := sharedPaneDiedHook("session-1", "%3", "@1", false, "")
+	assert.Equal(t, `wait-for -S "amux-exit-session-1" ; kill-pane -t "%3" ; select-layout -t "@1"`, hook)
+
+	hook = sharedPaneDiedHook("session-1", "%3", "@1", true, "")
+	assert.Equal(t, `wait-for -S "amux-exit-session-1"`, hook)
+}
+
+func TestValidateLayout(t *testing.T) {
+	assert.NoError(t, validateLayout("tiled"))
+	assert.NoError(t, validateLayout("even-horizontal"))
+	assert.Error(t, validateLayout("grid"))
+}
+
+func TestTmuxQuote(t *testing.T) {
+	assert.Equal(t, `"plain"`, tmuxQuote("plain"))
+	assert.Equal(t, `"a \"b\" \$HOME \\n"`, tmuxQuote(`a "b" $HOME \n`))
+}
+
+func TestOptions_UIArgs(t *testing.T) {
+	spec := runtime.ExecutionSpec{
+		SessionID:   "session-1",
+		Environment: map[string]string{"AMUX_WORKSPACE_ID": "workspace-#1"},
+	}
+
+	assert.Empty(t, Options{SessionName: "s"}.uiArgs(spec))
+
+	args := Options{SessionName: "s", Mouse: true, StatusLine: true}.uiArgs(spec)
+	assert.Contains(t, args, "mouse")
+	assert.Contains(t, args, " amux: session-1 ")
+	assert.Contains(t, args, " workspace: workspace-##1 ")
+}
@@ -124,3 +124,18 @@ func runHelperProcess() {
 
 	os.Exit(0)
 }
+
+func TestProcessAlive(t *testing.T) {
+	assert.True(t, ProcessAlive(os.Getpid()))
+	assert.False(t, ProcessAlive(0))
+	assert.False(t, ProcessAlive(-1))
+
+	// Processes owned by other users count as alive
+	if os.Getuid() != 0 {
+		assert.True(t, ProcessAlive(1))
+	}
+
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	assert.False(t, ProcessAlive(cmd.Process.Pid))
+}
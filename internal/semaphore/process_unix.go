@@ -0,0 +1,23 @@
+//go:build !windows
+
+package semaphore
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ProcessAlive reports whether a process with the given PID exists
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// EPERM means the process exists but belongs to another user
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package semaphore
+
+import (
+	"os"
+)
+
+// ProcessAlive reports whether a process with the given PID exists
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	// FindProcess opens a handle to the process, which fails once it is gone
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
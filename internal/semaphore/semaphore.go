@@ -18,10 +18,39 @@ type Holder interface {
 	ID() string
 }
 
+// ProcessHolder is a holder tied to an OS process. The PID is recorded on
+// Acquire, and the holder is evicted once the process is gone.
+type ProcessHolder interface {
+	Holder
+	PID() int
+}
+
 // holderEntry represents a holder with metadata
 type holderEntry struct {
 	ID         string    `json:"id"`
 	AcquiredAt time.Time `json:"acquired_at"`
+	PID        int       `json:"pid,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitzero"`
+}
+
+// HolderInfo describes a current holder of the semaphore
+type HolderInfo struct {
+	ID         string    `json:"id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	PID        int       `json:"pid,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitzero"`
+}
+
+// Options configures how a semaphore detects stale holders
+type Options struct {
+	// LeaseTTL makes holders expire this long after they acquire the
+	// semaphore or last renew it. Zero means holders never expire.
+	LeaseTTL time.Duration
+
+	// IsAlive reports whether a holder still exists. It is called with the
+	// file lock held, so it must not use the same semaphore. Holders it
+	// returns false for are evicted.
+	IsAlive func(HolderInfo) bool
 }
 
 // semaphoreData represents the persistent state of a semaphore
@@ -34,6 +63,7 @@ type semaphoreData struct {
 type FileSemaphore struct {
 	path     string
 	capacity int
+	opts     Options
 	mu       sync.Mutex
 	lock     *fileLock
 }
@@ -47,6 +77,12 @@ var (
 
 // New creates a new file-based semaphore
 func New(path string, capacity int) (*FileSemaphore, error) {
+	return NewWithOptions(path, capacity, Options{})
+}
+
+// NewWithOptions creates a new file-based semaphore that evicts stale
+// holders as configured by opts
+func NewWithOptions(path string, capacity int, opts Options) (*FileSemaphore, error) {
 	if capacity < 1 {
 		capacity = 1
 	}
@@ -60,6 +96,7 @@ func New(path string, capacity int) (*FileSemaphore, error) {
 	return &FileSemaphore{
 		path:     path,
 		capacity: capacity,
+		opts:     opts,
 		lock:     lock,
 	}, nil
 }
@@ -77,7 +114,7 @@ func (s *FileSemaphore) Acquire(holder Holder) error {
 		_ = s.lock.unlock()
 	}()
 
-	data, err := s.load()
+	data, err := s.loadLive()
 	if err != nil {
 		return fmt.Errorf("failed to load semaphore data: %w", err)
 	}
@@ -96,14 +133,73 @@ func (s *FileSemaphore) Acquire(holder Holder) error {
 	}
 
 	// Add holder
-	data.Holders = append(data.Holders, holderEntry{
+	entry := holderEntry{
 		ID:         holderID,
 		AcquiredAt: time.Now(),
-	})
+	}
+	if p, ok := holder.(ProcessHolder); ok {
+		entry.PID = p.PID()
+	}
+	if s.opts.LeaseTTL > 0 {
+		entry.ExpiresAt = entry.AcquiredAt.Add(s.opts.LeaseTTL)
+	}
+	data.Holders = append(data.Holders, entry)
 
 	return s.save(data)
 }
 
+// Renew extends the lease of a holder by the semaphore's LeaseTTL
+func (s *FileSemaphore) Renew(holderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Acquire file lock for cross-process synchronization
+	if err := s.lock.lock(); err != nil {
+		return fmt.Errorf("failed to acquire file lock: %w", err)
+	}
+	defer func() {
+		_ = s.lock.unlock()
+	}()
+
+	data, err := s.loadLive()
+	if err != nil {
+		return fmt.Errorf("failed to load semaphore data: %w", err)
+	}
+
+	for i := range data.Holders {
+		if data.Holders[i].ID != holderID {
+			continue
+		}
+		if s.opts.LeaseTTL <= 0 {
+			return nil
+		}
+		data.Holders[i].ExpiresAt = time.Now().Add(s.opts.LeaseTTL)
+		return s.save(data)
+	}
+	return ErrNotHeld
+}
+
+// Prune evicts stale holders: those whose process is gone, whose lease
+// expired or that IsAlive rejects. It returns the evicted holders.
+func (s *FileSemaphore) Prune() ([]HolderInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Acquire file lock for cross-process synchronization
+	if err := s.lock.lock(); err != nil {
+		return nil, fmt.Errorf("failed to acquire file lock: %w", err)
+	}
+	defer func() {
+		_ = s.lock.unlock()
+	}()
+
+	data, err := s.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load semaphore data: %w", err)
+	}
+	return s.evictStale(data)
+}
+
 // Release releases the semaphore for a specific holder ID
 func (s *FileSemaphore) Release(holderID string) error {
 	s.mu.Lock()
@@ -194,7 +290,7 @@ func (s *FileSemaphore) Holders() []string {
 		_ = s.lock.unlock()
 	}()
 
-	data, err := s.load()
+	data, err := s.loadLive()
 	if err != nil {
 		return nil
 	}
@@ -206,6 +302,31 @@ func (s *FileSemaphore) Holders() []string {
 	return ids
 }
 
+// HolderInfos returns the current holders along with when they acquired the semaphore
+func (s *FileSemaphore) HolderInfos() []HolderInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Acquire file lock for cross-process synchronization
+	if err := s.lock.lock(); err != nil {
+		return nil
+	}
+	defer func() {
+		_ = s.lock.unlock()
+	}()
+
+	data, err := s.loadLive()
+	if err != nil {
+		return nil
+	}
+
+	infos := make([]HolderInfo, len(data.Holders))
+	for i, h := range data.Holders {
+		infos[i] = HolderInfo(h)
+	}
+	return infos
+}
+
 // Count returns the number of current holders
 func (s *FileSemaphore) Count() int {
 	s.mu.Lock()
@@ -219,7 +340,7 @@ func (s *FileSemaphore) Count() int {
 		_ = s.lock.unlock()
 	}()
 
-	data, err := s.load()
+	data, err := s.loadLive()
 	if err != nil {
 		return 0
 	}
@@ -240,7 +361,7 @@ func (s *FileSemaphore) Available() int {
 		_ = s.lock.unlock()
 	}()
 
-	data, err := s.load()
+	data, err := s.loadLive()
 	if err != nil {
 		return s.capacity
 	}
@@ -290,6 +411,52 @@ func (s *FileSemaphore) load() (*semaphoreData, error) {
 	return data, nil
 }
 
+// loadLive loads the semaphore data and evicts stale holders
+func (s *FileSemaphore) loadLive() (*semaphoreData, error) {
+	data, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.evictStale(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// evictStale removes stale holders from data, saving it if any were found
+func (s *FileSemaphore) evictStale(data *semaphoreData) ([]HolderInfo, error) {
+	now := time.Now()
+	var evicted []HolderInfo
+	live := make([]holderEntry, 0, len(data.Holders))
+	for _, h := range data.Holders {
+		if s.isStale(HolderInfo(h), now) {
+			evicted = append(evicted, HolderInfo(h))
+			continue
+		}
+		live = append(live, h)
+	}
+	if len(evicted) == 0 {
+		return nil, nil
+	}
+
+	data.Holders = live
+	if err := s.save(data); err != nil {
+		return nil, err
+	}
+	return evicted, nil
+}
+
+// isStale reports whether a holder should be evicted
+func (s *FileSemaphore) isStale(h HolderInfo, now time.Time) bool {
+	if !h.ExpiresAt.IsZero() && now.After(h.ExpiresAt) {
+		return true
+	}
+	if h.PID > 0 && !ProcessAlive(h.PID) {
+		return true
+	}
+	return s.opts.IsAlive != nil && !s.opts.IsAlive(h)
+}
+
 // save saves the semaphore data to disk atomically
 func (s *FileSemaphore) save(data *semaphoreData) error {
 	// Ensure directory exists
@@ -3,6 +3,7 @@ package semaphore
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -281,6 +282,88 @@ func TestRemove(t *testing.T) {
 	})
 }
 
+// processHolder is a holder tied to a process, for testing
+type processHolder struct {
+	testHolder
+	pid int
+}
+
+func (h *processHolder) PID() int {
+	return h.pid
+}
+
+func TestStaleHolders(t *testing.T) {
+	t.Run("expired lease", func(t *testing.T) {
+		tempDir := t.TempDir()
+		semPath := filepath.Join(tempDir, "test.lock")
+		sem, err := NewWithOptions(semPath, 1, Options{LeaseTTL: 50 * time.Millisecond})
+		require.NoError(t, err)
+		defer sem.Close()
+
+		require.NoError(t, sem.Acquire(&testHolder{id: "holder1"}))
+		assert.ErrorIs(t, sem.Acquire(&testHolder{id: "holder2"}), ErrNoCapacity)
+
+		// Renewing keeps the holder past its original lease
+		time.Sleep(30 * time.Millisecond)
+		require.NoError(t, sem.Renew("holder1"))
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, []string{"holder1"}, sem.Holders())
+
+		time.Sleep(60 * time.Millisecond)
+		require.NoError(t, sem.Acquire(&testHolder{id: "holder2"}))
+		assert.Equal(t, []string{"holder2"}, sem.Holders())
+		assert.ErrorIs(t, sem.Renew("holder1"), ErrNotHeld)
+	})
+
+	t.Run("dead process", func(t *testing.T) {
+		tempDir := t.TempDir()
+		semPath := filepath.Join(tempDir, "test.lock")
+		sem, err := New(semPath, 2)
+		require.NoError(t, err)
+		defer sem.Close()
+
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		require.NoError(t, cmd.Run())
+
+		require.NoError(t, sem.Acquire(&processHolder{testHolder: testHolder{id: "self"}, pid: os.Getpid()}))
+		require.NoError(t, sem.Acquire(&processHolder{testHolder: testHolder{id: "gone"}, pid: cmd.Process.Pid}))
+
+		assert.Equal(t, []string{"self"}, sem.Holders())
+		infos := sem.HolderInfos()
+		require.Len(t, infos, 1)
+		assert.Equal(t, os.Getpid(), infos[0].PID)
+	})
+
+	t.Run("liveness check", func(t *testing.T) {
+		tempDir := t.TempDir()
+		semPath := filepath.Join(tempDir, "test.lock")
+
+		// Holders acquired without a check are kept until one is given
+		sem1, err := New(semPath, 3)
+		require.NoError(t, err)
+		for _, id := range []string{"holder1", "holder2", "holder3"} {
+			require.NoError(t, sem1.Acquire(&testHolder{id: id}))
+		}
+		sem1.Close()
+
+		sem2, err := NewWithOptions(semPath, 3, Options{
+			IsAlive: func(h HolderInfo) bool { return h.ID != "holder2" },
+		})
+		require.NoError(t, err)
+		defer sem2.Close()
+
+		evicted, err := sem2.Prune()
+		require.NoError(t, err)
+		require.Len(t, evicted, 1)
+		assert.Equal(t, "holder2", evicted[0].ID)
+		assert.Equal(t, []string{"holder1", "holder3"}, sem2.Holders())
+
+		evicted, err = sem2.Prune()
+		require.NoError(t, err)
+		assert.Empty(t, evicted)
+	})
+}
+
 func TestPersistence(t *testing.T) {
 	t.Run("persist and reload", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/runtime"
+	"github.com/aki/amux/internal/runtime/tmux"
+)
+
+// applyAgent fills in what the session options leave unset from the agent
+// configuration: its command, runtime, runtime options, environment and
+// working directory. Options given for the session win over the agent's.
+// The agent's ports are allocated along with the session's.
+func (m *manager) applyAgent(opts *CreateOptions) (*config.Agent, error) {
+	if opts.Agent == "" {
+		return nil, nil
+	}
+	if m.configManager == nil {
+		return nil, fmt.Errorf("agent %q needs a project configuration", opts.Agent)
+	}
+	agent, err := m.configManager.GetAgent(opts.Agent)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TaskName == "" && len(opts.Command) == 0 {
+		opts.Command = agent.GetCommand()
+	}
+	if opts.Runtime == "" {
+		opts.Runtime = agent.GetRuntimeType()
+	}
+	// Runtime options only apply to the runtime the agent is configured for
+	if opts.Runtime == agent.GetRuntimeType() {
+		opts.RuntimeOptions = mergeRuntimeOptions(agent.ToExecutionSpec().Options, opts.RuntimeOptions)
+	}
+	if opts.WorkingDir == "" {
+		opts.WorkingDir = agent.WorkingDir
+	}
+	opts.Ports = append(opts.Ports, agent.Ports...)
+	if len(agent.Environment) > 0 {
+		env := make(map[string]string, len(agent.Environment)+len(opts.Environment))
+		for k, v := range agent.Environment {
+			env[k] = v
+		}
+		for k, v := range opts.Environment {
+			env[k] = v
+		}
+		opts.Environment = env
+	}
+	return agent, nil
+}
+
+// mergeRuntimeOptions lays the options given for a session over those of its
+// agent. Only the shared tmux window of a session is set per session; other
+// runtimes take the session's options as a whole.
+func mergeRuntimeOptions(agent, session runtime.RuntimeOptions) runtime.RuntimeOptions {
+	if session == nil {
+		return agent
+	}
+	agentOpts, ok := agent.(tmux.Options)
+	sessionOpts, sessionTmux := session.(tmux.Options)
+	if !ok || !sessionTmux {
+		return session
+	}
+	if sessionOpts.Group != "" {
+		agentOpts.Group = sessionOpts.Group
+	}
+	if sessionOpts.Layout != "" {
+		agentOpts.Layout = sessionOpts.Layout
+	}
+	return agentOpts
+}
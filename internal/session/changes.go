@@ -0,0 +1,152 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// Changes describes what a session changed in its workspace's worktree
+type Changes struct {
+	SessionID   string              `json:"session_id"`
+	WorkspaceID string              `json:"workspace_id"`
+	From        *workspace.Snapshot `json:"from"`
+	To          *workspace.Snapshot `json:"to,omitempty"` // Unset when compared with the current worktree
+	Insertions  int                 `json:"insertions"`
+	Deletions   int                 `json:"deletions"`
+	Files       []git.FileChange    `json:"files"`
+	Patch       string              `json:"patch,omitempty"`
+}
+
+// DiffOptions defines options for showing what a session changed
+type DiffOptions struct {
+	Patch bool     // Include the full patch
+	Paths []string // Limit the changes to these paths
+}
+
+// Diff returns what a session changed in its workspace: the difference
+// between the worktree when the session started and when it exited, or the
+// current worktree if it is still running
+func (m *manager) Diff(ctx context.Context, id string, opts DiffOptions) (*Changes, error) {
+	session, snapshotter, err := m.snapshottedSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changes{
+		SessionID:   session.ID,
+		WorkspaceID: session.WorkspaceID,
+		From:        session.StartSnapshot,
+		To:          session.EndSnapshot,
+	}
+	identifier := workspace.Identifier(session.WorkspaceID)
+	var to string
+	if changes.To != nil {
+		to = changes.To.Commit
+	}
+
+	changes.Files, err = snapshotter.DiffSnapshots(ctx, identifier, changes.From.Commit, to, opts.Paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff session changes: %w", err)
+	}
+	for _, f := range changes.Files {
+		changes.Insertions += f.Insertions
+		changes.Deletions += f.Deletions
+	}
+
+	if opts.Patch {
+		changes.Patch, err = snapshotter.SnapshotPatch(ctx, identifier, changes.From.Commit, to, opts.Paths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session patch: %w", err)
+		}
+	}
+
+	return changes, nil
+}
+
+// Revert restores a session's workspace to the state it was in when the
+// session started. This also undoes anything done in the workspace by others
+// since then; the state before the revert is saved as a workspace checkpoint,
+// which is returned. Running sessions, or sessions whose workspace is used by
+// other running sessions, are only reverted with force.
+func (m *manager) Revert(ctx context.Context, id string, force bool) (*workspace.Checkpoint, error) {
+	session, snapshotter, err := m.snapshottedSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		if session.Status == StatusRunning {
+			return nil, fmt.Errorf("session %s is still running - stop it first", session.ID)
+		}
+		sessions, err := m.List(ctx, session.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		running := 0
+		for _, s := range sessions {
+			if s.ID != session.ID && s.Status == StatusRunning {
+				running++
+			}
+		}
+		if running > 0 {
+			return nil, fmt.Errorf("cannot revert session %s - workspace in use by %d other running session(s)", session.ID, running)
+		}
+	}
+
+	checkpoint, err := snapshotter.RestoreSnapshot(ctx, workspace.Identifier(session.WorkspaceID),
+		session.StartSnapshot.Commit, fmt.Sprintf("reverting session %s", session.ID), force)
+	if err != nil {
+		return checkpoint, fmt.Errorf("failed to revert session: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// snapshottedSession returns a session whose workspace state was recorded
+// when it started, along with the workspace manager holding the snapshots
+func (m *manager) snapshottedSession(ctx context.Context, id string) (*Session, Snapshotter, error) {
+	session, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshotter, ok := m.workspaceManager.(Snapshotter)
+	if !ok {
+		return nil, nil, fmt.Errorf("workspace snapshots are not available")
+	}
+	if session.StartSnapshot == nil {
+		return nil, nil, fmt.Errorf("no workspace state was recorded when session %s started", session.ID)
+	}
+	return session, snapshotter, nil
+}
+
+// recordEndSnapshot records the workspace state of a session whose start
+// state was recorded once it is seen to have exited. The proxy snapshots the
+// worktree as the command exits; only when it couldn't (e.g., it was killed)
+// is the worktree snapshotted now. Failures leave the end state unrecorded,
+// and the session's changes are then compared with the current worktree.
+func (m *manager) recordEndSnapshot(ctx context.Context, session *Session) {
+	if session.StartSnapshot == nil || session.EndSnapshot != nil {
+		return
+	}
+	if session.Status != StatusStopped && session.Status != StatusFailed {
+		return
+	}
+	snapshotter, ok := m.workspaceManager.(Snapshotter)
+	if !ok {
+		return
+	}
+
+	identifier := workspace.Identifier(session.WorkspaceID)
+	var snapshot *workspace.Snapshot
+	var err error
+	if status, ok := m.readProxyStatus(session.ID); ok && status.EndSnapshot != "" {
+		snapshot, err = snapshotter.RecordSessionSnapshot(ctx, identifier, session.ID, workspace.SnapshotEnd, status.EndSnapshot)
+	} else {
+		snapshot, err = snapshotter.SnapshotSession(ctx, identifier, session.ID, workspace.SnapshotEnd)
+	}
+	if err == nil {
+		session.EndSnapshot = snapshot
+	}
+}
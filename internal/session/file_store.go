@@ -65,7 +65,7 @@ func (s *FileStore) Load(ctx context.Context, id string) (*Session, error) {
 	data, err := os.ReadFile(file)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("session not found: %s", id)
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to read session file: %w", err)
 	}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aki/amux/internal/journal"
+)
+
+// FileActivity lists the files that changed in a session's workspace while
+// the session ran
+type FileActivity struct {
+	SessionID   string         `json:"session_id"`
+	WorkspaceID string         `json:"workspace_id"`
+	Files       []journal.File `json:"files"`
+	// Files that changed while other sessions were active in the workspace,
+	// so they may have been edited by more than one session
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// Files returns the files that changed in a session's workspace while it ran,
+// as recorded in the session's file journal. Each change is attributed to all
+// sessions active in the workspace at the time.
+func (m *manager) Files(ctx context.Context, id string) (*FileActivity, error) {
+	session, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.WorkspaceID == "" {
+		return nil, fmt.Errorf("session %s does not run in a workspace", session.ID)
+	}
+	if m.configManager == nil {
+		return nil, fmt.Errorf("session directory not available")
+	}
+
+	entries, err := journal.Read(m.sessionDir(session.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	activity := &FileActivity{
+		SessionID:   session.ID,
+		WorkspaceID: session.WorkspaceID,
+		Files:       journal.Summarize(session.ID, entries),
+	}
+	for _, f := range activity.Files {
+		if len(f.Shared) > 0 {
+			activity.Conflicts = append(activity.Conflicts, f.Path)
+		}
+	}
+	return activity, nil
+}
+
+// warnConflicts adds a warning to a session for each file it changed while
+// other sessions were active in its workspace, as recorded by its proxy. It
+// reports whether a warning was added.
+func (m *manager) warnConflicts(session *Session) bool {
+	if session.WorkspaceID == "" || m.configManager == nil {
+		return false
+	}
+	conflicts, err := journal.ReadConflicts(m.sessionDir(session.ID))
+	if err != nil {
+		return false
+	}
+
+	warned := false
+	for _, c := range conflicts {
+		warning := fmt.Sprintf("%s changed while session %s was active in the same workspace", c.Path, strings.Join(c.Sessions, ", "))
+		if !slices.Contains(session.Warnings, warning) {
+			session.Warnings = append(session.Warnings, warning)
+			warned = true
+		}
+	}
+	return warned
+}
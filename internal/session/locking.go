@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aki/amux/internal/runtime"
+	"github.com/aki/amux/internal/semaphore"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// workspaceWaitInterval is how often a session waiting for a busy workspace
+// tries again
+var workspaceWaitInterval = 500 * time.Millisecond
+
+// acquireWorkspace takes a slot in the session's workspace. Sessions that
+// ended without anyone noticing are found first, so their slots are free.
+// With WaitForWorkspace, a busy workspace is tried again until a slot frees
+// up, the wait times out or the context is canceled.
+func (m *manager) acquireWorkspace(ctx context.Context, opts CreateOptions, sessionID string) error {
+	locker, ok := m.workspaceManager.(WorkspaceLocker)
+	if !ok || opts.WorkspaceID == "" {
+		return nil
+	}
+
+	var deadline time.Time
+	if opts.WaitTimeout > 0 {
+		deadline = time.Now().Add(opts.WaitTimeout)
+	}
+	for {
+		// Listing updates the status of running sessions, freeing the
+		// slots of those that ended
+		_, _ = m.List(ctx, "")
+
+		err := locker.AcquireSession(ctx, workspace.Identifier(opts.WorkspaceID), sessionID)
+		var busy *workspace.ErrWorkspaceBusy
+		if err == nil || !errors.As(err, &busy) || !opts.WaitForWorkspace {
+			return err
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("gave up waiting after %s: %w", opts.WaitTimeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(workspaceWaitInterval):
+		}
+	}
+}
+
+// releaseWorkspace frees the slot a session holds in its workspace
+func (m *manager) releaseWorkspace(ctx context.Context, workspaceID, sessionID string) {
+	locker, ok := m.workspaceManager.(WorkspaceLocker)
+	if !ok || workspaceID == "" {
+		return
+	}
+	if err := locker.ReleaseSession(ctx, workspace.Identifier(workspaceID), sessionID); err != nil {
+		// The workspace may already be gone
+		slog.Debug("failed to release workspace", "workspace", workspaceID, "session", sessionID, "error", err)
+	}
+}
+
+// sessionRunning reports whether a session holding a workspace slot is still
+// running. Sessions that are gone or recorded as ended are stale, and so are
+// sessions whose process is gone while their record says they run, such as
+// ones that crashed. Nothing is recorded here, since that may release slots
+// while the workspace is locked.
+func (m *manager) sessionRunning(sessionID string) bool {
+	ctx := context.Background()
+	session, err := m.store.Load(ctx, sessionID)
+	if err != nil {
+		return !errors.Is(err, ErrSessionNotFound)
+	}
+	if session.Status == StatusStopped || session.Status == StatusFailed {
+		return false
+	}
+
+	// The proxy records the exit of what it runs, and its PID while it runs
+	status, ok := m.readProxyStatus(session.ID)
+	if ok && (status.Status == "exited" || (status.PID > 0 && !semaphore.ProcessAlive(status.PID))) {
+		return false
+	}
+	if session.Runtime == "local" || session.Runtime == "local-detached" {
+		return ok || m.configManager == nil
+	}
+
+	rt, found := m.runtimes[session.Runtime]
+	if !found {
+		return true
+	}
+	proc, err := rt.Find(ctx, session.ID)
+	if err != nil {
+		return false
+	}
+	state := proc.State()
+	return state != runtime.StateStopped && state != runtime.StateFailed
+}
+
+// sessionEnded records the end snapshot of a session that stopped or failed
+// and frees its slot in the workspace
+func (m *manager) sessionEnded(ctx context.Context, session *Session) {
+	if session.Status != StatusStopped && session.Status != StatusFailed {
+		return
+	}
+	m.recordEndSnapshot(ctx, session)
+	m.releaseWorkspace(ctx, session.WorkspaceID, session.ID)
+}
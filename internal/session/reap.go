@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aki/amux/internal/process"
+	"github.com/aki/amux/internal/runtime/proxy"
+)
+
+// ReapKind identifies the kind of resource found by Reap
+type ReapKind string
+
+const (
+	// ReapKindProcess is an amux proxy process without a session record
+	ReapKindProcess ReapKind = "process"
+	// ReapKindSocket is an output socket nobody is listening on
+	ReapKindSocket ReapKind = "socket"
+)
+
+// ReapOptions defines options for reaping orphaned session resources
+type ReapOptions struct {
+	DryRun bool // Only report what would be reaped
+}
+
+// ReapEntry describes an orphaned resource found by Reap
+type ReapEntry struct {
+	Kind      ReapKind `json:"kind"`
+	SessionID string   `json:"session_id"`
+	PID       int      `json:"pid,omitempty"`
+	Path      string   `json:"path,omitempty"`
+	Reason    string   `json:"reason"`
+	Reaped    bool     `json:"reaped"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Reap finds and cleans up proxy processes and sockets left behind by
+// sessions that no longer have a record
+func (m *manager) Reap(ctx context.Context, opts ReapOptions) ([]*ReapEntry, error) {
+	if m.configManager == nil {
+		return nil, fmt.Errorf("config manager is required to reap sessions")
+	}
+
+	sessions, err := m.store.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	known := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		known[s.ID] = true
+	}
+
+	processes, err := process.List()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsDir := filepath.Join(m.configManager.GetAmuxDir(), "sessions")
+	var entries []*ReapEntry
+
+	// Proxy processes whose session record is gone
+	for _, p := range processes {
+		sessionID, ok := proxySessionID(p.Args, sessionsDir)
+		if !ok || known[sessionID] {
+			continue
+		}
+		entry := &ReapEntry{
+			Kind:      ReapKindProcess,
+			SessionID: sessionID,
+			PID:       p.PID,
+			Reason:    "no session record",
+		}
+		if !opts.DryRun {
+			if err := terminate(p.PID); err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Reaped = true
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	// Sockets are shared by all projects in the temp directory, so only
+	// remove the ones nobody is listening on any more
+	sockets, err := filepath.Glob(filepath.Join(proxy.SocketDir(), "amux-*.sock"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sockets: %w", err)
+	}
+	for _, path := range sockets {
+		sessionID := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "amux-"), ".sock")
+		if known[sessionID] || socketAlive(path) {
+			continue
+		}
+		entry := &ReapEntry{
+			Kind:      ReapKindSocket,
+			SessionID: sessionID,
+			Path:      path,
+			Reason:    "no listener",
+		}
+		if !opts.DryRun {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				entry.Error = err.Error()
+			} else {
+				entry.Reaped = true
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// proxySessionID extracts the session ID from the arguments of an amux proxy
+// process belonging to the given sessions directory
+func proxySessionID(args []string, sessionsDir string) (string, bool) {
+	if len(args) < 2 || args[1] != "proxy" {
+		return "", false
+	}
+	for i := 2; i < len(args)-1; i++ {
+		if args[i] != "--session-dir" {
+			continue
+		}
+		dir := filepath.Clean(args[i+1])
+		if filepath.Dir(dir) != filepath.Clean(sessionsDir) {
+			return "", false
+		}
+		return filepath.Base(dir), true
+	}
+	return "", false
+}
+
+// terminate asks a proxy to shut down; the proxy forwards the signal to its child
+func terminate(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to signal process %d: %w", pid, err)
+	}
+	return nil
+}
+
+// socketAlive reports whether a process is accepting connections on a socket
+func socketAlive(path string) bool {
+	conn, err := net.DialTimeout("unix", path, 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
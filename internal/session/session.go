@@ -13,7 +13,9 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/aki/amux/internal/cache"
 	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
 	"github.com/aki/amux/internal/idmap"
 	"github.com/aki/amux/internal/runtime"
 	"github.com/aki/amux/internal/runtime/proxy"
@@ -26,6 +28,59 @@ type WorkspaceManager interface {
 	Create(ctx context.Context, opts workspace.CreateOptions) (*workspace.Workspace, error)
 }
 
+// WorkspaceResolver is implemented by workspace managers that can look up
+// existing workspaces
+type WorkspaceResolver interface {
+	ResolveWorkspace(ctx context.Context, identifier workspace.Identifier) (*workspace.Workspace, error)
+}
+
+// PortAllocator is implemented by workspace managers that can allocate named
+// ports to workspaces
+type PortAllocator interface {
+	AllocatePorts(ctx context.Context, identifier workspace.Identifier, names []string) (map[string]int, error)
+}
+
+// Checkpointer is implemented by workspace managers that can checkpoint a
+// workspace before a session starts in it
+type Checkpointer interface {
+	CheckpointBeforeSession(ctx context.Context, identifier workspace.Identifier, sessionID string) (*workspace.Checkpoint, error)
+}
+
+// Snapshotter is implemented by workspace managers that can record the state
+// of a workspace's worktree around a session and restore it later
+type Snapshotter interface {
+	SnapshotSession(ctx context.Context, identifier workspace.Identifier, sessionID, point string) (*workspace.Snapshot, error)
+	RecordSessionSnapshot(ctx context.Context, identifier workspace.Identifier, sessionID, point, commit string) (*workspace.Snapshot, error)
+	DiffSnapshots(ctx context.Context, identifier workspace.Identifier, from, to string, paths []string) ([]git.FileChange, error)
+	SnapshotPatch(ctx context.Context, identifier workspace.Identifier, from, to string, paths []string) (string, error)
+	RestoreSnapshot(ctx context.Context, identifier workspace.Identifier, commit, reason string, force bool) (*workspace.Checkpoint, error)
+	DeleteSessionSnapshots(ctx context.Context, identifier workspace.Identifier, sessionID string) error
+}
+
+// WorkspaceLocker is implemented by workspace managers that limit how many
+// sessions run in a workspace at once
+type WorkspaceLocker interface {
+	AcquireSession(ctx context.Context, identifier workspace.Identifier, sessionID string) error
+	ReleaseSession(ctx context.Context, identifier workspace.Identifier, sessionID string) error
+}
+
+// WorkspaceSessionChecker is implemented by workspace managers that can evict
+// the slots of sessions that are no longer running
+type WorkspaceSessionChecker interface {
+	SetSessionCheck(check workspace.SessionCheck)
+}
+
+// WorkspaceUsageTracker is implemented by workspace managers that measure
+// the disk usage of workspaces, to count the session data of each
+type WorkspaceUsageTracker interface {
+	SetLogUsage(usage workspace.LogUsage)
+}
+
+// QuotaChecker is implemented by workspace managers that enforce disk quotas
+type QuotaChecker interface {
+	CheckQuota(ctx context.Context, identifier workspace.Identifier) error
+}
+
 // Status represents the current state of a session
 type Status string
 
@@ -68,6 +123,17 @@ type Session struct {
 
 	// Socket path for output streaming
 	SocketPath string `json:"socket_path,omitempty" yaml:"socket_path,omitempty"`
+
+	// Warnings raised while the session runs, such as files that other
+	// sessions changed too
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+
+	// Disk quota the workspace was over when the session started
+	QuotaExceeded *workspace.ErrQuotaExceeded `json:"quota_exceeded,omitempty" yaml:"quota_exceeded,omitempty"`
+
+	// Workspace state recorded when the session started and exited
+	StartSnapshot *workspace.Snapshot `json:"start_snapshot,omitempty" yaml:"start_snapshot,omitempty"`
+	EndSnapshot   *workspace.Snapshot `json:"end_snapshot,omitempty" yaml:"end_snapshot,omitempty"`
 }
 
 // Manager manages sessions across workspaces
@@ -101,6 +167,25 @@ type Manager interface {
 
 	// SendInput sends input to a running session
 	SendInput(ctx context.Context, id string, input string) error
+
+	// Adopt registers an existing runtime process as a session
+	Adopt(ctx context.Context, opts AdoptOptions) (*Session, error)
+
+	// Reap finds and cleans up proxy processes and sockets left behind by
+	// sessions that no longer have a record
+	Reap(ctx context.Context, opts ReapOptions) ([]*ReapEntry, error)
+
+	// Diff returns what a session changed in its workspace
+	Diff(ctx context.Context, id string, opts DiffOptions) (*Changes, error)
+
+	// Revert restores a session's workspace to its state before the session
+	Revert(ctx context.Context, id string, force bool) (*workspace.Checkpoint, error)
+
+	// Files returns the files that changed in a session's workspace while it ran
+	Files(ctx context.Context, id string) (*FileActivity, error)
+
+	// LogUsage returns the disk space of the session data of each workspace
+	LogUsage() (map[string]int64, error)
 }
 
 // CreateOptions defines options for creating a session
@@ -109,6 +194,7 @@ type CreateOptions struct {
 	AutoCreateWorkspace bool                   // Auto-create workspace if not specified
 	Name                string                 // Human-readable name for the session
 	Description         string                 // Description of session purpose
+	Agent               string                 // Agent configuration to start from (optional)
 	TaskName            string                 // Task to execute (optional)
 	Command             []string               // Direct command (if no task)
 	Runtime             string                 // Runtime to use (default: local)
@@ -117,6 +203,20 @@ type CreateOptions struct {
 	Metadata            map[string]interface{} // Additional metadata
 	RuntimeOptions      runtime.RuntimeOptions // Runtime-specific options
 	EnableLog           bool                   // Enable logging to file (default: false)
+	Ports               []string               // Named ports to allocate in the workspace
+	WaitForWorkspace    bool                   // Wait for a free slot in a busy workspace instead of failing
+	WaitTimeout         time.Duration          // Give up waiting after this long (0: no limit)
+}
+
+// AdoptOptions defines options for adopting an existing process as a session
+type AdoptOptions struct {
+	WorkspaceID string                 // Workspace the process works in
+	Name        string                 // Human-readable name for the session
+	Description string                 // Description of session purpose
+	Runtime     string                 // Runtime owning the process (default: tmux)
+	Target      string                 // Runtime-specific process reference (e.g., tmux session or pane)
+	Metadata    map[string]interface{} // Additional metadata
+	EnableLog   bool                   // Enable logging to file (default: false)
 }
 
 // LogReader provides access to session logs
@@ -153,7 +253,7 @@ func NewManager(store Store, runtimes map[string]runtime.Runtime, tasks *task.Ma
 		idMapper = mapper
 	}
 
-	return &manager{
+	m := &manager{
 		sessions:         make(map[string]*Session),
 		store:            store,
 		runtimes:         runtimes,
@@ -162,35 +262,31 @@ func NewManager(store Store, runtimes map[string]runtime.Runtime, tasks *task.Ma
 		configManager:    configManager,
 		idMapper:         idMapper,
 	}
+
+	// Let the workspace manager free the slots of sessions that crashed or
+	// were removed without releasing them
+	if checker, ok := workspaceManager.(WorkspaceSessionChecker); ok {
+		checker.SetSessionCheck(m.sessionRunning)
+	}
+	if tracker, ok := workspaceManager.(WorkspaceUsageTracker); ok && configManager != nil {
+		tracker.SetLogUsage(m.LogUsage)
+	}
+
+	return m
 }
 
 // Create starts a new session
 func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, error) {
-	// Generate session ID first to use in workspace name
-	var sessionID string
-	var shortID string
-
-	if m.idMapper != nil {
-		// Use ID mapper to get persistent short ID
-		fullID := fmt.Sprintf("session-%s-%d-%s",
-			opts.Name,
-			time.Now().Unix(),
-			generateRandomSuffix())
-
-		index, err := m.idMapper.Add(idmap.SessionID(fullID))
-		if err != nil {
-			return nil, fmt.Errorf("failed to acquire session ID: %w", err)
-		}
+	// Start from the agent configuration, if any
+	agent, err := m.applyAgent(&opts)
+	if err != nil {
+		return nil, err
+	}
 
-		sessionID = fullID
-		shortID = index
-	} else {
-		// Fallback to simple counter-based ID
-		m.mu.Lock()
-		counter := len(m.sessions) + 1
-		m.mu.Unlock()
-		sessionID = fmt.Sprintf("session-%d", counter)
-		shortID = fmt.Sprintf("%d", counter)
+	// Generate session ID first to use in workspace name
+	sessionID, shortID, err := m.allocateID(opts.Name)
+	if err != nil {
+		return nil, err
 	}
 
 	// Handle auto workspace creation
@@ -203,9 +299,21 @@ func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, err
 		workspaceName := sessionID // Use full session ID as workspace name
 		workspaceDesc := fmt.Sprintf("Auto-created for %s", sessionID)
 
+		// Tasks and agents in large repositories may only need part of the
+		// tree; the task's directories win over the agent's
+		var sparse []string
+		if agent != nil {
+			sparse = agent.Sparse
+		}
+		if opts.TaskName != "" {
+			if t, err := m.tasks.GetTask(opts.TaskName); err == nil && len(t.Sparse) > 0 {
+				sparse = t.Sparse
+			}
+		}
 		ws, err := m.workspaceManager.Create(ctx, workspace.CreateOptions{
 			Name:        workspaceName,
 			Description: workspaceDesc,
+			Sparse:      sparse,
 			AutoCreated: true,
 		})
 		if err != nil {
@@ -228,7 +336,7 @@ func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, err
 	spec := runtime.ExecutionSpec{
 		SessionID:   sessionID,
 		WorkingDir:  opts.WorkingDir,
-		Environment: opts.Environment,
+		Environment: sessionEnvironment(opts.Environment, sessionID, opts.Name, opts.WorkspaceID),
 		Options:     opts.RuntimeOptions,
 		EnableLog:   opts.EnableLog,
 	}
@@ -261,21 +369,97 @@ func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, err
 		if spec.WorkingDir == "" && t.WorkingDir != "" {
 			spec.WorkingDir = t.WorkingDir
 		}
+
+		opts.Ports = append(opts.Ports, t.Ports...)
 	} else if len(opts.Command) > 0 {
 		spec.Command = opts.Command
 	} else {
 		return nil, fmt.Errorf("either task name or command must be specified")
 	}
 
+	// Tell the session where its workspace is; the proxy also journals the
+	// files changed there
+	if resolver, ok := m.workspaceManager.(WorkspaceResolver); ok && opts.WorkspaceID != "" {
+		if ws, err := resolver.ResolveWorkspace(ctx, workspace.Identifier(opts.WorkspaceID)); err == nil {
+			if _, exists := spec.Environment["AMUX_WORKSPACE_PATH"]; !exists {
+				spec.Environment["AMUX_WORKSPACE_PATH"] = ws.Path
+			}
+		}
+	}
+
+	// Pass the workspace's ports in as AMUX_PORT_<NAME>
+	if len(opts.Ports) > 0 {
+		ports, err := m.allocatePorts(ctx, opts.WorkspaceID, opts.Ports)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range workspace.PortEnvironment(ports) {
+			if _, exists := spec.Environment[k]; !exists {
+				spec.Environment[k] = v
+			}
+		}
+	}
+
+	// Point package managers at the caches shared by all workspaces
+	if m.configManager != nil {
+		cacheEnv, err := cache.NewManager(m.configManager).Environment()
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up caches: %w", err)
+		}
+		for k, v := range cacheEnv {
+			if _, exists := spec.Environment[k]; !exists {
+				spec.Environment[k] = v
+			}
+		}
+	}
+
+	// Refuse or warn about sessions in workspaces taking too much disk space
+	quotaExceeded, err := m.checkQuota(ctx, opts.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Take a slot in the workspace; it is freed when the session ends
+	if err := m.acquireWorkspace(ctx, opts, sessionID); err != nil {
+		return nil, err
+	}
+	started := false
+	defer func() {
+		if !started {
+			m.releaseWorkspace(ctx, opts.WorkspaceID, sessionID)
+		}
+	}()
+
 	// Use provided metadata
 	metadata := opts.Metadata
 
-	// Generate socket path for this session
-	tmpDir := os.Getenv("TMPDIR")
-	if tmpDir == "" {
-		tmpDir = "/tmp"
+	// Checkpoint the workspace first if it asks for it, so whatever the
+	// session does can be rolled back
+	if checkpointer, ok := m.workspaceManager.(Checkpointer); ok && opts.WorkspaceID != "" {
+		cp, err := checkpointer.CheckpointBeforeSession(ctx, workspace.Identifier(opts.WorkspaceID), sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to checkpoint workspace: %w", err)
+		}
+		if cp != nil {
+			if metadata == nil {
+				metadata = make(map[string]interface{})
+			}
+			metadata["checkpoint"] = cp.ID
+		}
+	}
+
+	// Record the workspace state so the session's changes can be shown and
+	// reverted later
+	var startSnapshot *workspace.Snapshot
+	if snapshotter, ok := m.workspaceManager.(Snapshotter); ok && opts.WorkspaceID != "" {
+		startSnapshot, err = snapshotter.SnapshotSession(ctx, workspace.Identifier(opts.WorkspaceID), sessionID, workspace.SnapshotStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot workspace: %w", err)
+		}
 	}
-	socketPath := filepath.Join(tmpDir, fmt.Sprintf("amux-%s.sock", sessionID))
+
+	// Generate socket path for this session
+	socketPath := proxy.SocketPath(sessionID)
 
 	session := &Session{
 		ID:             sessionID,
@@ -294,6 +478,8 @@ func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, err
 		LastActivityAt: time.Now(),
 		EnableLog:      opts.EnableLog,
 		SocketPath:     socketPath,
+		QuotaExceeded:  quotaExceeded,
+		StartSnapshot:  startSnapshot,
 	}
 
 	// Store session BEFORE starting the process
@@ -311,7 +497,7 @@ func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, err
 	}
 
 	// Start the process AFTER saving the session
-	_, err := rt.Execute(ctx, spec)
+	_, err = rt.Execute(ctx, spec)
 	if err != nil {
 		// Clean up the session
 		m.mu.Lock()
@@ -321,9 +507,148 @@ func (m *manager) Create(ctx context.Context, opts CreateOptions) (*Session, err
 		return nil, fmt.Errorf("failed to execute: %w", err)
 	}
 
+	started = true
 	return session, nil
 }
 
+// Adopt registers an existing runtime process as a session
+func (m *manager) Adopt(ctx context.Context, opts AdoptOptions) (*Session, error) {
+	if opts.Target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+
+	if opts.Runtime == "" {
+		opts.Runtime = "tmux"
+	}
+	rt, ok := m.runtimes[opts.Runtime]
+	if !ok {
+		return nil, fmt.Errorf("runtime not found: %s", opts.Runtime)
+	}
+	adopter, ok := rt.(runtime.AdoptableRuntime)
+	if !ok {
+		return nil, fmt.Errorf("adopt not supported for runtime: %s", opts.Runtime)
+	}
+
+	sessionID, shortID, err := m.allocateID(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Give back the short ID, and the workspace slot once taken, if the
+	// session isn't adopted after all
+	adopted, acquired := false, false
+	defer func() {
+		if adopted {
+			return
+		}
+		if m.idMapper != nil {
+			_ = m.idMapper.Remove(idmap.SessionID(sessionID))
+		}
+		if acquired {
+			m.releaseWorkspace(ctx, opts.WorkspaceID, sessionID)
+		}
+	}()
+
+	// Adopted sessions take a slot in their workspace like started ones
+	if err := m.acquireWorkspace(ctx, CreateOptions{WorkspaceID: opts.WorkspaceID}, sessionID); err != nil {
+		return nil, err
+	}
+	acquired = true
+
+	metadata := opts.Metadata
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["adopted"] = true
+	metadata["adopted_target"] = opts.Target
+
+	now := time.Now()
+	session := &Session{
+		ID:             sessionID,
+		ShortID:        shortID,
+		Name:           opts.Name,
+		Description:    opts.Description,
+		WorkspaceID:    opts.WorkspaceID,
+		Runtime:        opts.Runtime,
+		Status:         StatusRunning,
+		StartedAt:      now,
+		Metadata:       metadata,
+		LastActivityAt: now,
+		EnableLog:      opts.EnableLog,
+		SocketPath:     proxy.SocketPath(sessionID),
+	}
+
+	proc, err := adopter.Adopt(ctx, runtime.AdoptSpec{
+		SessionID: sessionID,
+		Target:    opts.Target,
+		EnableLog: opts.EnableLog,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to adopt %s: %w", opts.Target, err)
+	}
+
+	// Record runtime details needed to find the process again
+	if md := proc.Metadata(); md != nil {
+		for k, v := range md.ToMap() {
+			session.Metadata[k] = v
+		}
+	}
+
+	m.mu.Lock()
+	m.sessions[session.ID] = session
+	m.mu.Unlock()
+
+	if err := m.store.Save(ctx, session); err != nil {
+		m.mu.Lock()
+		delete(m.sessions, session.ID)
+		m.mu.Unlock()
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	adopted = true
+	return session, nil
+}
+
+// allocatePorts allocates named ports in the session's workspace
+func (m *manager) allocatePorts(ctx context.Context, workspaceID string, names []string) (map[string]int, error) {
+	if workspaceID == "" {
+		return nil, fmt.Errorf("ports require a workspace")
+	}
+	allocator, ok := m.workspaceManager.(PortAllocator)
+	if !ok {
+		return nil, fmt.Errorf("port allocation not available")
+	}
+	ports, err := allocator.AllocatePorts(ctx, workspace.Identifier(workspaceID), names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate ports: %w", err)
+	}
+	return ports, nil
+}
+
+// allocateID generates a new session ID and its short index
+func (m *manager) allocateID(name string) (string, string, error) {
+	if m.idMapper != nil {
+		// Use ID mapper to get persistent short ID
+		fullID := fmt.Sprintf("session-%s-%d-%s",
+			name,
+			time.Now().Unix(),
+			generateRandomSuffix())
+
+		index, err := m.idMapper.Add(idmap.SessionID(fullID))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to acquire session ID: %w", err)
+		}
+
+		return fullID, index, nil
+	}
+
+	// Fallback to simple counter-based ID
+	m.mu.Lock()
+	counter := len(m.sessions) + 1
+	m.mu.Unlock()
+	return fmt.Sprintf("session-%d", counter), fmt.Sprintf("%d", counter), nil
+}
+
 // Get retrieves a session by ID (either short ID or full ID)
 func (m *manager) Get(ctx context.Context, id string) (*Session, error) {
 	// First try direct lookup
@@ -523,6 +848,15 @@ func (m *manager) Remove(ctx context.Context, id string) error {
 		return fmt.Errorf("cannot remove running session")
 	}
 
+	// Drop the workspace snapshots taken for the session
+	if snapshotter, ok := m.workspaceManager.(Snapshotter); ok && session.StartSnapshot != nil {
+		_ = snapshotter.DeleteSessionSnapshots(ctx, workspace.Identifier(session.WorkspaceID), session.ID)
+		// The workspace may already be gone along with its snapshots
+	}
+
+	// A session that ended unnoticed may still hold its workspace slot
+	m.releaseWorkspace(ctx, session.WorkspaceID, session.ID)
+
 	// Release ID back to pool if using ID mapper
 	if m.idMapper != nil {
 		_ = m.idMapper.Remove(idmap.SessionID(session.ID))
@@ -552,6 +886,7 @@ func (m *manager) UpdateStatus(ctx context.Context, id string, status Status) er
 	m.mu.Lock()
 	session.Status = status
 	m.mu.Unlock()
+	m.sessionEnded(ctx, session)
 
 	// Save to store
 	if err := m.store.Save(ctx, session); err != nil {
@@ -604,6 +939,8 @@ func (r *simpleLogReader) Close() error {
 
 // updateSessionFromRuntime updates session information from runtime
 func (m *manager) updateSessionFromRuntime(ctx context.Context, session *Session) {
+	warned := m.warnConflicts(session)
+
 	// For local and local-detached runtimes, read status from the proxy status file
 	if session.Runtime == "local" || session.Runtime == "local-detached" {
 		// Use config manager to get the correct amux directory
@@ -611,34 +948,30 @@ func (m *manager) updateSessionFromRuntime(ctx context.Context, session *Session
 			// In tests, configManager might be nil
 			return
 		}
-		amuxDir := m.configManager.GetAmuxDir()
-		statusPath := filepath.Join(amuxDir, "sessions", session.ID, "status.yaml")
 		// Read status file if it exists
-		if data, err := os.ReadFile(statusPath); err == nil {
-			var status proxy.Status
-			if err := yaml.Unmarshal(data, &status); err == nil {
-				// Update session based on proxy status
-				switch status.Status {
-				case "running":
-					session.Status = StatusRunning
-					session.LastActivityAt = status.LastActivityAt
-				case "exited":
-					session.Status = StatusStopped
-					if session.StoppedAt == nil {
-						session.StoppedAt = &status.EndedAt
-					}
-					session.ExitCode = &status.ExitCode
-				default:
-					// Unknown status, keep current
+		if status, ok := m.readProxyStatus(session.ID); ok {
+			// Update session based on proxy status
+			switch status.Status {
+			case "running":
+				session.Status = StatusRunning
+				session.LastActivityAt = status.LastActivityAt
+			case "exited":
+				session.Status = StatusStopped
+				if session.StoppedAt == nil {
+					session.StoppedAt = &status.EndedAt
 				}
-
-				// Update in memory and save if status changed
-				m.mu.Lock()
-				m.sessions[session.ID] = session
-				m.mu.Unlock()
-				_ = m.store.Save(ctx, session)
-				return
+				session.ExitCode = &status.ExitCode
+			default:
+				// Unknown status, keep current
 			}
+
+			// Update in memory and save if status changed
+			m.mu.Lock()
+			m.sessions[session.ID] = session
+			m.mu.Unlock()
+			m.sessionEnded(ctx, session)
+			_ = m.store.Save(ctx, session)
+			return
 		}
 
 		// If no status file or can't read it, mark as stopped
@@ -652,6 +985,7 @@ func (m *manager) updateSessionFromRuntime(ctx context.Context, session *Session
 		m.sessions[session.ID] = session
 		m.mu.Unlock()
 		// Save to disk
+		m.sessionEnded(ctx, session)
 		_ = m.store.Save(ctx, session)
 		return
 	}
@@ -671,11 +1005,16 @@ func (m *manager) updateSessionFromRuntime(ctx context.Context, session *Session
 			now := time.Now()
 			session.StoppedAt = &now
 		}
+		// The proxy records the exit code of the process it ran
+		if status, ok := m.readProxyStatus(session.ID); ok && status.Status == "exited" && session.ExitCode == nil {
+			session.ExitCode = &status.ExitCode
+		}
 		// Update in memory
 		m.mu.Lock()
 		m.sessions[session.ID] = session
 		m.mu.Unlock()
 		// Save to disk
+		m.sessionEnded(ctx, session)
 		_ = m.store.Save(ctx, session)
 		return
 	}
@@ -714,6 +1053,10 @@ func (m *manager) updateSessionFromRuntime(ctx context.Context, session *Session
 	case runtime.StateRunning:
 		// Still running, keep current status
 		session.Status = StatusRunning
+		// Activity is tracked by the proxy attached to the process
+		if status, ok := m.readProxyStatus(session.ID); ok && status.Status == "running" {
+			session.LastActivityAt = status.LastActivityAt
+		}
 	case runtime.StateStarting:
 		// Session is still starting, keep current status
 		session.Status = StatusStarting
@@ -723,14 +1066,54 @@ func (m *manager) updateSessionFromRuntime(ctx context.Context, session *Session
 	}
 
 	// Update in memory and save if status changed
-	if state != runtime.StateRunning {
+	if state != runtime.StateRunning || warned {
 		m.mu.Lock()
 		m.sessions[session.ID] = session
 		m.mu.Unlock()
+		m.sessionEnded(ctx, session)
 		_ = m.store.Save(ctx, session)
 	}
 }
 
+// sessionEnvironment returns the environment for a session's process,
+// identifying the session and workspace it belongs to
+func sessionEnvironment(env map[string]string, sessionID, name, workspaceID string) map[string]string {
+	result := make(map[string]string, len(env)+3)
+	for k, v := range env {
+		result[k] = v
+	}
+	result["AMUX_SESSION_ID"] = sessionID
+	if name != "" {
+		result["AMUX_SESSION_NAME"] = name
+	}
+	if workspaceID != "" {
+		result["AMUX_WORKSPACE_ID"] = workspaceID
+	}
+	return result
+}
+
+// readProxyStatus reads the status file written by the session's proxy
+func (m *manager) readProxyStatus(sessionID string) (*proxy.Status, bool) {
+	if m.configManager == nil {
+		return nil, false
+	}
+	statusPath := filepath.Join(m.sessionDir(sessionID), "status.yaml")
+	data, err := os.ReadFile(statusPath)
+	if err != nil {
+		return nil, false
+	}
+	var status proxy.Status
+	if err := yaml.Unmarshal(data, &status); err != nil {
+		return nil, false
+	}
+	return &status, true
+}
+
+// sessionDir returns the directory holding a session's run data
+func (m *manager) sessionDir(sessionID string) string {
+	return filepath.Join(m.configManager.GetAmuxDir(), "sessions", sessionID)
+}
+
 // generateRandomSuffix generates a random 8-character hex string
 func generateRandomSuffix() string {
 	bytes := make([]byte, 4)
@@ -3,15 +3,23 @@ package session
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/journal"
 	"github.com/aki/amux/internal/runtime"
+	"github.com/aki/amux/internal/runtime/tmux"
 	"github.com/aki/amux/internal/task"
 	"github.com/aki/amux/internal/workspace"
 )
@@ -305,7 +313,7 @@ func (s *mockStore) Load(ctx context.Context, id string) (*Session, error) {
 	defer s.mu.RUnlock()
 	session, ok := s.sessions[id]
 	if !ok {
-		return nil, fmt.Errorf("session not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
 	}
 	return session, nil
 }
@@ -338,6 +346,12 @@ type mockWorkspaceManager struct {
 	mu         sync.RWMutex
 	workspaces map[string]*workspace.Workspace
 	idCounter  int
+
+	checkpoints int
+	restored    string
+	holders     map[string][]string // Workspace ID -> sessions holding a slot
+	isRunning   workspace.SessionCheck
+	quota       error // Returned by CheckQuota
 }
 
 func newMockWorkspaceManager() *mockWorkspaceManager {
@@ -353,9 +367,11 @@ func (m *mockWorkspaceManager) Create(ctx context.Context, opts workspace.Create
 	m.idCounter++
 	ws := &workspace.Workspace{
 		ID:          fmt.Sprintf("ws-%d", m.idCounter),
+		Path:        filepath.Join(os.TempDir(), fmt.Sprintf("ws-%d", m.idCounter)),
 		Name:        opts.Name,
 		Description: opts.Description,
 		AutoCreated: opts.AutoCreated,
+		Sparse:      opts.Sparse,
 		CreatedAt:   time.Now(),
 	}
 	m.workspaces[ws.ID] = ws
@@ -372,6 +388,130 @@ func (m *mockWorkspaceManager) Get(ctx context.Context, id workspace.ID) (*works
 	return ws, nil
 }
 
+func (m *mockWorkspaceManager) ResolveWorkspace(ctx context.Context, identifier workspace.Identifier) (*workspace.Workspace, error) {
+	if ws, err := m.Get(ctx, workspace.ID(identifier)); err == nil {
+		return ws, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, ws := range m.workspaces {
+		if ws.Name == string(identifier) {
+			return ws, nil
+		}
+	}
+	return nil, fmt.Errorf("workspace not found")
+}
+
+func (m *mockWorkspaceManager) AllocatePorts(ctx context.Context, identifier workspace.Identifier, names []string) (map[string]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws, ok := m.workspaces[string(identifier)]
+	if !ok {
+		return nil, fmt.Errorf("workspace not found")
+	}
+	if ws.Ports == nil {
+		ws.Ports = make(map[string]int)
+	}
+	for _, name := range names {
+		if _, ok := ws.Ports[name]; !ok {
+			ws.Ports[name] = 40000 + len(ws.Ports)
+		}
+	}
+	return ws.Ports, nil
+}
+
+func (m *mockWorkspaceManager) CheckpointBeforeSession(ctx context.Context, identifier workspace.Identifier, sessionID string) (*workspace.Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws, ok := m.workspaces[string(identifier)]
+	if !ok || !ws.AutoCheckpoint {
+		return nil, nil
+	}
+	m.checkpoints++
+	return &workspace.Checkpoint{ID: fmt.Sprint(m.checkpoints), Message: "Before session " + sessionID}, nil
+}
+
+func (m *mockWorkspaceManager) SnapshotSession(ctx context.Context, identifier workspace.Identifier, sessionID, point string) (*workspace.Snapshot, error) {
+	if _, err := m.Get(ctx, workspace.ID(identifier)); err != nil {
+		return nil, err
+	}
+	return &workspace.Snapshot{Commit: sessionID + "-" + point, CreatedAt: time.Now()}, nil
+}
+
+func (m *mockWorkspaceManager) RecordSessionSnapshot(ctx context.Context, identifier workspace.Identifier, sessionID, point, commit string) (*workspace.Snapshot, error) {
+	if _, err := m.Get(ctx, workspace.ID(identifier)); err != nil {
+		return nil, err
+	}
+	return &workspace.Snapshot{Commit: commit, CreatedAt: time.Now()}, nil
+}
+
+func (m *mockWorkspaceManager) DiffSnapshots(ctx context.Context, identifier workspace.Identifier, from, to string, paths []string) ([]git.FileChange, error) {
+	if to == "" {
+		to = "worktree"
+	}
+	return []git.FileChange{{Path: from + ".." + to, Status: git.FileModified, Insertions: 2, Deletions: 1}}, nil
+}
+
+func (m *mockWorkspaceManager) SnapshotPatch(ctx context.Context, identifier workspace.Identifier, from, to string, paths []string) (string, error) {
+	return "patch " + from, nil
+}
+
+func (m *mockWorkspaceManager) RestoreSnapshot(ctx context.Context, identifier workspace.Identifier, commit, reason string, force bool) (*workspace.Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restored = commit
+	m.checkpoints++
+	return &workspace.Checkpoint{ID: fmt.Sprint(m.checkpoints), Message: "Before " + reason}, nil
+}
+
+func (m *mockWorkspaceManager) DeleteSessionSnapshots(ctx context.Context, identifier workspace.Identifier, sessionID string) error {
+	return nil
+}
+
+func (m *mockWorkspaceManager) AcquireSession(ctx context.Context, identifier workspace.Identifier, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ws, ok := m.workspaces[string(identifier)]
+	if !ok {
+		return fmt.Errorf("workspace not found")
+	}
+	slots, err := workspace.ParseConcurrency(ws.Concurrency)
+	if err != nil {
+		return err
+	}
+	if m.holders == nil {
+		m.holders = make(map[string][]string)
+	}
+	// Like the workspace semaphore, evict the holders that no longer run
+	if m.isRunning != nil {
+		m.holders[ws.ID] = slices.DeleteFunc(m.holders[ws.ID], func(id string) bool {
+			return !m.isRunning(id)
+		})
+	}
+	if slots > 0 && len(m.holders[ws.ID]) >= slots {
+		return &workspace.ErrWorkspaceBusy{Name: ws.Name, Concurrency: ws.Concurrency, Holders: m.holders[ws.ID]}
+	}
+	m.holders[ws.ID] = append(m.holders[ws.ID], sessionID)
+	return nil
+}
+
+func (m *mockWorkspaceManager) SetSessionCheck(check workspace.SessionCheck) {
+	m.isRunning = check
+}
+
+func (m *mockWorkspaceManager) CheckQuota(ctx context.Context, identifier workspace.Identifier) error {
+	return m.quota
+}
+
+func (m *mockWorkspaceManager) ReleaseSession(ctx context.Context, identifier workspace.Identifier, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.holders[string(identifier)] = slices.DeleteFunc(m.holders[string(identifier)], func(id string) bool {
+		return id == sessionID
+	})
+	return nil
+}
+
 // Test setup helpers
 func setupTestManager(t *testing.T) (*manager, *mockRuntime, *mockStore) {
 	store := newMockStore()
@@ -796,6 +936,403 @@ func TestManager_AutoCreateWorkspace(t *testing.T) {
 	}
 }
 
+func TestManager_CreateWithPorts(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	taskMgr := task.NewManager()
+	if err := taskMgr.LoadTasks([]*task.Task{
+		{Name: "dev", Command: "npm run dev", Ports: []string{"web", "api"}},
+	}); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, taskMgr, wsMgr, nil).(*manager)
+	ctx := context.Background()
+
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "feature"})
+
+	sess, err := mgr.Create(ctx, CreateOptions{
+		WorkspaceID: ws.ID,
+		TaskName:    "dev",
+		Runtime:     "local",
+		Environment: map[string]string{"AMUX_PORT_API": "8080"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if got := sess.Environment["AMUX_PORT_WEB"]; got != fmt.Sprint(ws.Ports["web"]) {
+		t.Errorf("Expected AMUX_PORT_WEB=%d, got %q", ws.Ports["web"], got)
+	}
+	if got := sess.Environment["AMUX_PORT_API"]; got != "8080" {
+		t.Errorf("Expected explicit AMUX_PORT_API to be kept, got %q", got)
+	}
+
+	// A second session in the same workspace gets the same ports
+	sess2, err := mgr.Create(ctx, CreateOptions{
+		WorkspaceID: ws.ID,
+		TaskName:    "dev",
+		Runtime:     "local",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if sess2.Environment["AMUX_PORT_WEB"] != sess.Environment["AMUX_PORT_WEB"] {
+		t.Errorf("Expected the same web port, got %q and %q", sess.Environment["AMUX_PORT_WEB"], sess2.Environment["AMUX_PORT_WEB"])
+	}
+
+	// Ports need a workspace
+	_, err = mgr.Create(ctx, CreateOptions{
+		Command: []string{"echo"},
+		Runtime: "local",
+		Ports:   []string{"web"},
+	})
+	if err == nil {
+		t.Error("Expected error when allocating ports without a workspace")
+	}
+}
+
+func TestManager_CreateWithCaches(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	configMgr := config.NewManager(t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Caches = []config.CacheConfig{{Name: "go"}, {Name: "gradle", Env: "GRADLE_USER_HOME"}}
+	if err := configMgr.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	mgr := NewManager(store, runtimes, task.NewManager(), newMockWorkspaceManager(), configMgr).(*manager)
+	ctx := context.Background()
+
+	sess, err := mgr.Create(ctx, CreateOptions{
+		Command:     []string{"go", "build"},
+		Runtime:     "local",
+		Environment: map[string]string{"GRADLE_USER_HOME": "/opt/gradle"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	goCache := filepath.Join(configMgr.GetCacheDir(), "go")
+	if got := sess.Environment["GOMODCACHE"]; got != goCache {
+		t.Errorf("Expected GOMODCACHE=%s, got %q", goCache, got)
+	}
+	if _, err := os.Stat(goCache); err != nil {
+		t.Errorf("Expected the cache directory to be created: %v", err)
+	}
+	if got := sess.Environment["GRADLE_USER_HOME"]; got != "/opt/gradle" {
+		t.Errorf("Expected explicit GRADLE_USER_HOME to be kept, got %q", got)
+	}
+}
+
+func TestManager_CreateWithAgent(t *testing.T) {
+	store := newMockStore()
+	rt := newMockRuntime("tmux")
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+		"tmux":  rt,
+	}
+	configMgr := config.NewManager(t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Agents["claude"] = config.Agent{
+		Name:        "Claude",
+		Runtime:     "tmux",
+		Command:     []string{"claude"},
+		Environment: map[string]string{"CLAUDE_MODE": "agent", "EDITOR": "vi"},
+		Ports:       []string{"web"},
+		RuntimeOptions: map[string]interface{}{
+			"historyLimit": 50000,
+			"mouse":        true,
+		},
+	}
+	cfg.Agents["api"] = config.Agent{
+		Name:    "API agent",
+		Runtime: "local",
+		Command: []string{"api-agent"},
+		Sparse:  []string{"services/api"},
+	}
+	if err := configMgr.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, configMgr).(*manager)
+	ctx := context.Background()
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "feature"})
+
+	sess, err := mgr.Create(ctx, CreateOptions{
+		WorkspaceID:    ws.ID,
+		Agent:          "claude",
+		Environment:    map[string]string{"EDITOR": "nano"},
+		RuntimeOptions: tmux.Options{Group: "best-of-3"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if sess.Runtime != "tmux" || !slices.Equal(sess.Command, []string{"claude"}) {
+		t.Errorf("Expected the agent's runtime and command, got %s %v", sess.Runtime, sess.Command)
+	}
+
+	if len(rt.processes) != 1 {
+		t.Fatalf("Expected the session to run in the agent's runtime")
+	}
+	var proc *mockProcess
+	for _, p := range rt.processes {
+		proc = p.(*mockProcess)
+	}
+	opts, ok := proc.spec.Options.(tmux.Options)
+	if !ok {
+		t.Fatalf("Expected tmux options, got %T", proc.spec.Options)
+	}
+	if opts.OutputHistory != 50000 || !opts.Mouse {
+		t.Errorf("Expected the agent's historyLimit and mouse, got %+v", opts)
+	}
+	if opts.Group != "best-of-3" {
+		t.Errorf("Expected the session's tmux group, got %q", opts.Group)
+	}
+	if proc.spec.Environment["CLAUDE_MODE"] != "agent" || proc.spec.Environment["EDITOR"] != "nano" {
+		t.Errorf("Expected the agent environment under the session's, got %v", proc.spec.Environment)
+	}
+	if got := sess.Environment["AMUX_PORT_WEB"]; got == "" || got != fmt.Sprint(ws.Ports["web"]) {
+		t.Errorf("Expected the agent's web port, got %q", got)
+	}
+
+	// Options of the agent's runtime don't follow it into another runtime
+	sess, err = mgr.Create(ctx, CreateOptions{WorkspaceID: ws.ID, Agent: "claude", Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if sess.Runtime != "local" {
+		t.Errorf("Expected the local runtime, got %s", sess.Runtime)
+	}
+
+	// Workspaces created for the agent check out its directories
+	sess, err = mgr.Create(ctx, CreateOptions{Agent: "api", AutoCreateWorkspace: true})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if autoWs := wsMgr.workspaces[sess.WorkspaceID]; autoWs == nil || !slices.Equal(autoWs.Sparse, []string{"services/api"}) {
+		t.Errorf("Expected a sparse workspace for the agent, got %+v", autoWs)
+	}
+
+	if _, err := mgr.Create(ctx, CreateOptions{Agent: "missing"}); err == nil {
+		t.Error("Expected error for an unknown agent")
+	}
+}
+
+func TestManager_CreateWithAutoCheckpoint(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, nil).(*manager)
+	ctx := context.Background()
+
+	plain, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "plain"})
+	guarded, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "guarded"})
+	guarded.AutoCheckpoint = true
+
+	sess, err := mgr.Create(ctx, CreateOptions{WorkspaceID: plain.ID, Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if _, ok := sess.Metadata["checkpoint"]; ok {
+		t.Error("Expected no checkpoint for a workspace without automatic checkpoints")
+	}
+
+	sess, err = mgr.Create(ctx, CreateOptions{WorkspaceID: guarded.ID, Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if got := sess.Metadata["checkpoint"]; got != "1" {
+		t.Errorf("Expected checkpoint 1 in session metadata, got %v", got)
+	}
+}
+
+func TestManager_DiffAndRevert(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, nil).(*manager)
+	ctx := context.Background()
+
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "feature"})
+	sess, err := mgr.Create(ctx, CreateOptions{WorkspaceID: ws.ID, Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if sess.StartSnapshot == nil || sess.StartSnapshot.Commit != sess.ID+"-start" {
+		t.Fatalf("Expected a start snapshot, got %+v", sess.StartSnapshot)
+	}
+
+	// While running, changes are compared with the current worktree
+	changes, err := mgr.Diff(ctx, sess.ID, DiffOptions{})
+	if err != nil {
+		t.Fatalf("Failed to diff session: %v", err)
+	}
+	if changes.To != nil || len(changes.Files) != 1 || changes.Files[0].Path != sess.ID+"-start..worktree" {
+		t.Errorf("Unexpected changes of running session: %+v", changes)
+	}
+	if changes.Insertions != 2 || changes.Deletions != 1 || changes.Patch != "" {
+		t.Errorf("Unexpected totals or patch: %+v", changes)
+	}
+
+	if _, err := mgr.Revert(ctx, sess.ID, false); err == nil {
+		t.Error("Expected error when reverting a running session")
+	}
+
+	// Stopping records the end state
+	if err := mgr.Stop(ctx, sess.ID); err != nil {
+		t.Fatalf("Failed to stop session: %v", err)
+	}
+	changes, err = mgr.Diff(ctx, sess.ID, DiffOptions{Patch: true})
+	if err != nil {
+		t.Fatalf("Failed to diff session: %v", err)
+	}
+	if changes.To == nil || changes.Files[0].Path != sess.ID+"-start.."+sess.ID+"-end" {
+		t.Errorf("Unexpected changes of stopped session: %+v", changes)
+	}
+	if changes.Patch != "patch "+sess.ID+"-start" {
+		t.Errorf("Unexpected patch: %q", changes.Patch)
+	}
+
+	checkpoint, err := mgr.Revert(ctx, sess.ID, false)
+	if err != nil {
+		t.Fatalf("Failed to revert session: %v", err)
+	}
+	if wsMgr.restored != sess.ID+"-start" {
+		t.Errorf("Expected the start snapshot to be restored, got %q", wsMgr.restored)
+	}
+	if checkpoint.Message != "Before reverting session "+sess.ID {
+		t.Errorf("Unexpected checkpoint: %+v", checkpoint)
+	}
+
+	// Sessions without a workspace have nothing to diff
+	plain, err := mgr.Create(ctx, CreateOptions{Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if _, err := mgr.Diff(ctx, plain.ID, DiffOptions{}); err == nil {
+		t.Error("Expected error when diffing a session without a start snapshot")
+	}
+}
+
+func TestManager_EndSnapshotFromProxy(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	configMgr := config.NewManager(t.TempDir())
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, configMgr).(*manager)
+	ctx := context.Background()
+
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "feature"})
+	sess, err := mgr.Create(ctx, CreateOptions{WorkspaceID: ws.ID, Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// The proxy snapshotted the worktree as the command exited
+	if err := os.MkdirAll(mgr.sessionDir(sess.ID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	status := "status: exited\nexit_code: 0\nend_snapshot: at-exit\n"
+	if err := os.WriteFile(filepath.Join(mgr.sessionDir(sess.ID), "status.yaml"), []byte(status), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.Stop(ctx, sess.ID); err != nil {
+		t.Fatalf("Failed to stop session: %v", err)
+	}
+	stopped, err := mgr.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if stopped.EndSnapshot == nil || stopped.EndSnapshot.Commit != "at-exit" {
+		t.Errorf("Expected the snapshot taken at exit, got %+v", stopped.EndSnapshot)
+	}
+}
+
+func TestManager_Files(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	configMgr := config.NewManager(t.TempDir())
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, configMgr).(*manager)
+	ctx := context.Background()
+
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "feature"})
+	sess, err := mgr.Create(ctx, CreateOptions{WorkspaceID: ws.ID, Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if got := sess.Environment["AMUX_WORKSPACE_PATH"]; got != ws.Path {
+		t.Errorf("Expected AMUX_WORKSPACE_PATH=%s, got %q", ws.Path, got)
+	}
+
+	// Nothing recorded yet
+	activity, err := mgr.Files(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to get files: %v", err)
+	}
+	if len(activity.Files) != 0 {
+		t.Errorf("Expected no files, got %+v", activity.Files)
+	}
+
+	// The proxy journals changes into the session directory
+	now := time.Now()
+	journalData := fmt.Sprintf(`{"time":%q,"op":"modify","path":"main.go","sessions":[%q]}
+{"time":%q,"op":"modify","path":"util.go","sessions":[%q,"session-other"]}
+`, now.Format(time.RFC3339Nano), sess.ID, now.Format(time.RFC3339Nano), sess.ID)
+	if err := os.MkdirAll(mgr.sessionDir(sess.ID), 0o755); err != nil {
+		t.Fatalf("Failed to create session directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mgr.sessionDir(sess.ID), journal.FileName), []byte(journalData), 0o644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	activity, err = mgr.Files(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to get files: %v", err)
+	}
+	if len(activity.Files) != 2 || activity.Files[0].Path != "main.go" || activity.Files[1].Path != "util.go" {
+		t.Fatalf("Unexpected files: %+v", activity.Files)
+	}
+	if len(activity.Conflicts) != 1 || activity.Conflicts[0] != "util.go" {
+		t.Errorf("Expected util.go to conflict, got %v", activity.Conflicts)
+	}
+
+	// The proxy records the conflict when it happens, and listing sessions
+	// turns it into a warning on the session, once
+	conflictData := fmt.Sprintf(`{"time":%q,"path":"util.go","sessions":["session-other"]}
+`, now.Format(time.RFC3339Nano))
+	if err := os.WriteFile(filepath.Join(mgr.sessionDir(sess.ID), journal.ConflictsFileName), []byte(conflictData), 0o644); err != nil {
+		t.Fatalf("Failed to write conflicts: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		sess.Status = StatusRunning
+		if _, err := mgr.List(ctx, ""); err != nil {
+			t.Fatalf("Failed to list sessions: %v", err)
+		}
+	}
+	saved, err := store.Load(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	if len(saved.Warnings) != 1 || !strings.Contains(saved.Warnings[0], "util.go") || !strings.Contains(saved.Warnings[0], "session-other") {
+		t.Errorf("Expected a warning about util.go, got %v", saved.Warnings)
+	}
+}
+
 func TestManager_SendInput(t *testing.T) {
 	// Create a custom runtime that supports InputSender from the start
 	store := newMockStore()
@@ -884,3 +1421,363 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+type mockAdoptableRuntime struct {
+	*mockRuntime
+	adopted []runtime.AdoptSpec
+}
+
+func (r *mockAdoptableRuntime) Adopt(ctx context.Context, spec runtime.AdoptSpec) (runtime.Process, error) {
+	if spec.Target == "missing" {
+		return nil, fmt.Errorf("target not found: %s", spec.Target)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.adopted = append(r.adopted, spec)
+	process := &mockProcess{
+		id:        fmt.Sprintf("mock-process-%d", len(r.processes)+1),
+		state:     runtime.StateRunning,
+		startTime: time.Now(),
+		exitCh:    make(chan struct{}),
+	}
+	r.processes[process.id] = process
+	return process, nil
+}
+
+func TestManager_Adopt(t *testing.T) {
+	mgr, _, store := setupTestManager(t)
+	ctx := context.Background()
+
+	adoptable := &mockAdoptableRuntime{mockRuntime: newMockRuntime("tmux")}
+	mgr.runtimes["tmux"] = adoptable
+
+	t.Run("adopts target", func(t *testing.T) {
+		session, err := mgr.Adopt(ctx, AdoptOptions{
+			WorkspaceID: "test-workspace",
+			Name:        "agent",
+			Target:      "agent:0.1",
+		})
+		if err != nil {
+			t.Fatalf("Failed to adopt: %v", err)
+		}
+
+		if session.Runtime != "tmux" {
+			t.Errorf("Expected runtime tmux, got %s", session.Runtime)
+		}
+		if session.Status != StatusRunning {
+			t.Errorf("Expected status %s, got %s", StatusRunning, session.Status)
+		}
+		if session.Metadata["adopted_target"] != "agent:0.1" {
+			t.Errorf("Expected adopted target in metadata, got %v", session.Metadata)
+		}
+		if len(adoptable.adopted) != 1 || adoptable.adopted[0].SessionID != session.ID {
+			t.Errorf("Expected runtime to adopt for session %s, got %v", session.ID, adoptable.adopted)
+		}
+		if _, err := store.Load(ctx, session.ID); err != nil {
+			t.Errorf("Adopted session should be saved: %v", err)
+		}
+	})
+
+	t.Run("runtime failure is not saved", func(t *testing.T) {
+		before, _ := store.List(ctx, "")
+		if _, err := mgr.Adopt(ctx, AdoptOptions{Target: "missing"}); err == nil {
+			t.Fatal("Expected error for missing target")
+		}
+		after, _ := store.List(ctx, "")
+		if len(after) != len(before) {
+			t.Errorf("Expected %d sessions, got %d", len(before), len(after))
+		}
+	})
+
+	t.Run("unsupported runtime", func(t *testing.T) {
+		if _, err := mgr.Adopt(ctx, AdoptOptions{Runtime: "local", Target: "x"}); err == nil {
+			t.Error("Expected error for runtime without adopt support")
+		}
+	})
+}
+
+// failingStore fails to save sessions
+type failingStore struct {
+	*mockStore
+}
+
+func (s *failingStore) Save(ctx context.Context, session *Session) error {
+	return fmt.Errorf("disk full")
+}
+
+func TestManager_AdoptSaveFailure(t *testing.T) {
+	store := &failingStore{mockStore: newMockStore()}
+	adoptable := &mockAdoptableRuntime{mockRuntime: newMockRuntime("tmux")}
+	runtimes := map[string]runtime.Runtime{"tmux": adoptable}
+	mgr := NewManager(store, runtimes, task.NewManager(), nil, config.NewManager(t.TempDir())).(*manager)
+	ctx := context.Background()
+
+	if _, err := mgr.Adopt(ctx, AdoptOptions{Target: "agent:0.1"}); err == nil {
+		t.Fatal("Expected error when the session can't be saved")
+	}
+
+	// The short ID was given back, so the next session gets it
+	mgr.store = newMockStore()
+	sess, err := mgr.Adopt(ctx, AdoptOptions{Target: "agent:0.1"})
+	if err != nil {
+		t.Fatalf("Failed to adopt: %v", err)
+	}
+	if sess.ShortID != "1" {
+		t.Errorf("Expected short ID 1 to be reused, got %s", sess.ShortID)
+	}
+}
+
+func TestManager_LogUsage(t *testing.T) {
+	store := newMockStore()
+	wsManager := newMockWorkspaceManager()
+	configManager := config.NewManager(t.TempDir())
+	mgr := NewManager(store, nil, task.NewManager(), wsManager, configManager).(*manager)
+	ctx := context.Background()
+
+	ws, err := wsManager.Create(ctx, workspace.CreateOptions{Name: "feature"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	// Sessions started with the workspace ID, its name ('session run -w
+	// feature') and in a workspace that is gone
+	sessions := map[string]string{"session-1": ws.ID, "session-2": "feature", "session-3": "removed"}
+	for id, workspaceID := range sessions {
+		if err := store.Save(ctx, &Session{ID: id, WorkspaceID: workspaceID}); err != nil {
+			t.Fatalf("Failed to save session: %v", err)
+		}
+		dir := mgr.sessionDir(id)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("Failed to create session directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "console.log"), make([]byte, 100), 0o644); err != nil {
+			t.Fatalf("Failed to write log: %v", err)
+		}
+	}
+
+	usage, err := mgr.LogUsage()
+	if err != nil {
+		t.Fatalf("Failed to measure log usage: %v", err)
+	}
+	if usage[ws.ID] != 200 {
+		t.Errorf("Expected 200 bytes for %s, got %d", ws.ID, usage[ws.ID])
+	}
+	if _, ok := usage["feature"]; ok {
+		t.Error("Expected usage of sessions started by name to be keyed by workspace ID")
+	}
+	if usage["removed"] != 100 {
+		t.Errorf("Expected 100 bytes for the removed workspace, got %d", usage["removed"])
+	}
+}
+
+func TestProxySessionID(t *testing.T) {
+	sessionsDir := "/project/.amux/sessions"
+
+	tests := []struct {
+		name   string
+		args   []string
+		wantID string
+		wantOK bool
+	}{
+		{
+			name:   "proxy process",
+			args:   []string{"/usr/bin/amux", "proxy", "--session-dir", "/project/.amux/sessions/session-1", "--", "claude"},
+			wantID: "session-1",
+			wantOK: true,
+		},
+		{
+			name: "other project",
+			args: []string{"amux", "proxy", "--session-dir", "/other/.amux/sessions/session-1", "--tap"},
+		},
+		{
+			name: "not a proxy",
+			args: []string{"amux", "session", "list"},
+		},
+		{
+			name: "missing value",
+			args: []string{"amux", "proxy", "--session-dir"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := proxySessionID(tt.args, sessionsDir)
+			if ok != tt.wantOK || id != tt.wantID {
+				t.Errorf("proxySessionID() = (%q, %v), want (%q, %v)", id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestManager_WorkspaceConcurrency(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, nil).(*manager)
+	ctx := context.Background()
+
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "exclusive"})
+	ws.Concurrency = workspace.ConcurrencyExclusive
+
+	origInterval := workspaceWaitInterval
+	workspaceWaitInterval = 10 * time.Millisecond
+	defer func() { workspaceWaitInterval = origInterval }()
+
+	run := func(opts CreateOptions) (*Session, error) {
+		opts.WorkspaceID = ws.ID
+		opts.Command = []string{"echo", "test"}
+		return mgr.Create(ctx, opts)
+	}
+
+	first, err := run(CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	var busy *workspace.ErrWorkspaceBusy
+	if _, err := run(CreateOptions{}); !errors.As(err, &busy) {
+		t.Fatalf("Expected busy workspace error, got %v", err)
+	}
+	if _, err := run(CreateOptions{WaitForWorkspace: true, WaitTimeout: 30 * time.Millisecond}); !errors.As(err, &busy) {
+		t.Fatalf("Expected busy workspace error after waiting, got %v", err)
+	}
+
+	// A waiting session starts once the first one stops
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = mgr.Stop(ctx, first.ID)
+	}()
+	second, err := run(CreateOptions{WaitForWorkspace: true})
+	if err != nil {
+		t.Fatalf("Expected waiting session to start: %v", err)
+	}
+	if got := wsMgr.holders[ws.ID]; len(got) != 1 || got[0] != second.ID {
+		t.Errorf("Expected only %s to hold the workspace, got %v", second.ID, got)
+	}
+
+	// A session that ends frees its slot
+	if err := mgr.UpdateStatus(ctx, second.ID, StatusStopped); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	if got := wsMgr.holders[ws.ID]; len(got) != 0 {
+		t.Errorf("Expected no holders, got %v", got)
+	}
+
+	// The workspace manager can tell stale holders from running sessions
+	if wsMgr.isRunning == nil {
+		t.Fatal("Expected the session manager to set a session check")
+	}
+	third, err := run(CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if !wsMgr.isRunning(third.ID) {
+		t.Errorf("Expected %s to be running", third.ID)
+	}
+	if wsMgr.isRunning(second.ID) || wsMgr.isRunning("session-unknown") {
+		t.Error("Expected stopped and unknown sessions to be stale")
+	}
+
+	// Adopted sessions take a slot too
+	mgr.runtimes["tmux"] = &mockAdoptableRuntime{mockRuntime: newMockRuntime("tmux")}
+	if _, err := mgr.Adopt(ctx, AdoptOptions{WorkspaceID: ws.ID, Target: "agent:0.1"}); !errors.As(err, &busy) {
+		t.Fatalf("Expected busy workspace error adopting, got %v", err)
+	}
+	if err := mgr.Stop(ctx, third.ID); err != nil {
+		t.Fatalf("Failed to stop session: %v", err)
+	}
+	adopted, err := mgr.Adopt(ctx, AdoptOptions{WorkspaceID: ws.ID, Target: "agent:0.1"})
+	if err != nil {
+		t.Fatalf("Failed to adopt: %v", err)
+	}
+	if got := wsMgr.holders[ws.ID]; len(got) != 1 || got[0] != adopted.ID {
+		t.Errorf("Expected only %s to hold the workspace, got %v", adopted.ID, got)
+	}
+}
+
+func TestManager_WorkspaceSlotOfCrashedSession(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, config.NewManager(t.TempDir())).(*manager)
+	ctx := context.Background()
+
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "exclusive"})
+	ws.Concurrency = workspace.ConcurrencyExclusive
+	sess, err := mgr.Create(ctx, CreateOptions{WorkspaceID: ws.ID, Command: []string{"echo"}, Runtime: "local"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	writeStatus := func(pid int) {
+		status := fmt.Sprintf("status: running\npid: %d\n", pid)
+		if err := os.WriteFile(filepath.Join(mgr.sessionDir(sess.ID), "status.yaml"), []byte(status), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(mgr.sessionDir(sess.ID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// While its process runs, the session keeps its slot
+	writeStatus(os.Getpid())
+	var busy *workspace.ErrWorkspaceBusy
+	if err := wsMgr.AcquireSession(ctx, workspace.Identifier(ws.ID), "session-other"); !errors.As(err, &busy) {
+		t.Fatalf("Expected busy workspace error, got %v", err)
+	}
+
+	// The process was killed without the session being marked as stopped
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to run process: %v", err)
+	}
+	writeStatus(cmd.Process.Pid)
+	if err := wsMgr.AcquireSession(ctx, workspace.Identifier(ws.ID), "session-other"); err != nil {
+		t.Fatalf("Expected the crashed session's slot to be freed, got %v", err)
+	}
+	if saved, _ := store.Load(ctx, sess.ID); saved.Status != StatusRunning {
+		t.Errorf("Expected the session check to leave the record alone, got %s", saved.Status)
+	}
+}
+
+func TestManager_CreateWithQuota(t *testing.T) {
+	store := newMockStore()
+	runtimes := map[string]runtime.Runtime{
+		"local": newMockRuntime("local"),
+	}
+	wsMgr := newMockWorkspaceManager()
+	mgr := NewManager(store, runtimes, task.NewManager(), wsMgr, nil).(*manager)
+	ctx := context.Background()
+	ws, _ := wsMgr.Create(ctx, workspace.CreateOptions{Name: "big"})
+	opts := CreateOptions{WorkspaceID: ws.ID, Command: []string{"echo"}, Runtime: "local"}
+
+	wsMgr.quota = &workspace.ErrQuotaExceeded{Workspace: "big", Used: 2 << 30, Limit: 1 << 30}
+	sess, err := mgr.Create(ctx, opts)
+	if err != nil {
+		t.Fatalf("Expected a warning quota not to block the session: %v", err)
+	}
+	if sess.QuotaExceeded == nil || sess.QuotaExceeded.Used != 2<<30 || sess.QuotaExceeded.Workspace != "big" {
+		t.Errorf("Expected the exceeded quota to be recorded, got %+v", sess.QuotaExceeded)
+	}
+
+	wsMgr.quota = &workspace.ErrQuotaExceeded{Workspace: "big", Used: 2 << 30, Limit: 1 << 30, Block: true}
+	if _, err := mgr.Create(ctx, opts); err == nil {
+		t.Error("Expected a blocking quota to refuse the session")
+	}
+
+	// Failing to measure doesn't keep sessions from starting
+	wsMgr.quota = errors.New("permission denied")
+	sess, err = mgr.Create(ctx, opts)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if sess.QuotaExceeded != nil {
+		t.Errorf("Expected no exceeded quota, got %+v", sess.QuotaExceeded)
+	}
+}
@@ -2,8 +2,12 @@ package session
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrSessionNotFound is returned by Store.Load for a session that doesn't exist
+var ErrSessionNotFound = errors.New("session not found")
+
 // Store provides persistent storage for sessions
 type Store interface {
 	// Save persists a session
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/aki/amux/internal/workspace"
+)
+
+// checkQuota checks the disk quotas of the session's workspace. Exceeded
+// quotas set to block fail the session; the others are returned to warn about.
+func (m *manager) checkQuota(ctx context.Context, workspaceID string) (*workspace.ErrQuotaExceeded, error) {
+	checker, ok := m.workspaceManager.(QuotaChecker)
+	if !ok || workspaceID == "" {
+		return nil, nil
+	}
+
+	err := checker.CheckQuota(ctx, workspace.Identifier(workspaceID))
+	var exceeded *workspace.ErrQuotaExceeded
+	switch {
+	case err == nil:
+		return nil, nil
+	case errors.As(err, &exceeded) && exceeded.Block:
+		return nil, fmt.Errorf("cannot start session: %w", err)
+	case errors.As(err, &exceeded):
+		return exceeded, nil
+	default:
+		// Measuring is best effort; it must not keep sessions from starting
+		slog.Warn("failed to check disk quota", "workspace", workspaceID, "error", err)
+		return nil, nil
+	}
+}
+
+// LogUsage returns the disk space of the run data and logs of the sessions
+// recorded for each workspace, by workspace ID. Sessions record the workspace
+// as given when they were started, so names and indexes are resolved to IDs.
+func (m *manager) LogUsage() (map[string]int64, error) {
+	if m.configManager == nil {
+		return nil, nil
+	}
+	ctx := context.Background()
+	sessions, err := m.store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	resolver, _ := m.workspaceManager.(WorkspaceResolver)
+	ids := make(map[string]string)
+	usage := make(map[string]int64)
+	for _, s := range sessions {
+		if s.WorkspaceID == "" {
+			continue
+		}
+		id, ok := ids[s.WorkspaceID]
+		if !ok {
+			// Sessions of removed workspaces keep what they recorded
+			id = s.WorkspaceID
+			if resolver != nil {
+				if ws, err := resolver.ResolveWorkspace(ctx, workspace.Identifier(s.WorkspaceID)); err == nil {
+					id = ws.ID
+				}
+			}
+			ids[s.WorkspaceID] = id
+		}
+		_ = filepath.WalkDir(m.sessionDir(s.ID), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil //nolint:nilerr // Count what can be read
+			}
+			if info, err := d.Info(); err == nil {
+				usage[id] += info.Size()
+			}
+			return nil
+		})
+	}
+	return usage, nil
+}
@@ -50,6 +50,14 @@ type Task struct {
 
 	// Timeout specifies the maximum duration for the task (only for oneshot)
 	Timeout string `yaml:"timeout,omitempty"`
+
+	// Ports names the ports the task listens on. Each workspace gets its own
+	// port for every name, passed in as AMUX_PORT_<NAME>.
+	Ports []string `yaml:"ports,omitempty"`
+
+	// Sparse lists the directories to check out, with sparse checkout, in
+	// workspaces created automatically for the task. Empty checks out all.
+	Sparse []string `yaml:"sparse,omitempty"`
 }
 
 // Validate checks if the task definition is valid
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/aki/amux/internal/core/port"
 )
 
 // Validator provides task validation functionality
@@ -32,6 +34,10 @@ func (v *Validator) ValidateTask(task *Task) error {
 		return err
 	}
 
+	if err := v.validatePorts(task); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -96,6 +102,18 @@ func (v *Validator) validateEnvironment(task *Task) error {
 	return nil
 }
 
+// validatePorts validates port names
+func (v *Validator) validatePorts(task *Task) error {
+	seen := make(map[string]bool)
+	for _, name := range task.Ports {
+		if seen[name] {
+			return fmt.Errorf("duplicate port name: %s", name)
+		}
+		seen[name] = true
+	}
+	return port.ValidateNames(task.Ports)
+}
+
 // ValidateTaskList validates a list of tasks and their dependencies
 func (v *Validator) ValidateTaskList(tasks []*Task) error {
 	// Create a map for quick lookup
@@ -78,6 +78,45 @@ func TestValidator_ValidateTask(t *testing.T) {
 			wantErr: true,
 			errMsg:  "environment variable name cannot contain '='",
 		},
+		{
+			name: "valid ports",
+			task: &Task{
+				Name:    "dev",
+				Command: "npm run dev",
+				Ports:   []string{"web", "api_v2"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid port name",
+			task: &Task{
+				Name:    "dev",
+				Command: "npm run dev",
+				Ports:   []string{"1web"},
+			},
+			wantErr: true,
+			errMsg:  "invalid port name",
+		},
+		{
+			name: "port names sharing a variable",
+			task: &Task{
+				Name:    "dev",
+				Command: "npm run dev",
+				Ports:   []string{"web-api", "web_api"},
+			},
+			wantErr: true,
+			errMsg:  "both map to AMUX_PORT_WEB_API",
+		},
+		{
+			name: "duplicate port name",
+			task: &Task{
+				Name:    "dev",
+				Command: "npm run dev",
+				Ports:   []string{"web", "web"},
+			},
+			wantErr: true,
+			errMsg:  "duplicate port name",
+		},
 	}
 
 	for _, tt := range tests {
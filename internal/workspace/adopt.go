@@ -0,0 +1,153 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aki/amux/internal/hooks"
+	"github.com/aki/amux/internal/idmap"
+)
+
+// Adopt registers a git worktree created outside amux as a workspace. The
+// worktree stays where it is; the workspace gets its metadata, storage and
+// index under the workspaces directory like any other. Removing an adopted
+// workspace later keeps its branch, since amux did not create it.
+func (m *Manager) Adopt(ctx context.Context, opts AdoptOptions) (*Workspace, error) {
+	path, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	worktrees, err := m.gitOps.ListWorktrees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	wt := m.findWorktree(path)
+	if wt == nil {
+		return nil, fmt.Errorf("'%s' is not the root of a git worktree of this repository", opts.Path)
+	}
+	if len(worktrees) > 0 && resolvePath(worktrees[0].Path) == resolvePath(wt.Path) {
+		return nil, fmt.Errorf("cannot adopt the main worktree")
+	}
+
+	existing, err := m.List(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ws := range existing {
+		if resolvePath(ws.Path) == resolvePath(path) {
+			return nil, fmt.Errorf("'%s' is already workspace '%s'", opts.Path, ws.Name)
+		}
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	baseBranch := opts.BaseBranch
+	if baseBranch == "" {
+		if baseBranch, err = m.gitOps.GetDefaultBranch(); err != nil {
+			return nil, fmt.Errorf("failed to get default branch: %w", err)
+		}
+	}
+
+	id := generateID(name)
+	workspace := &Workspace{
+		ID:          id,
+		Name:        name,
+		Branch:      wt.Branch,
+		BaseBranch:  baseBranch,
+		Path:        path,
+		Description: opts.Description,
+		StoragePath: filepath.Join(m.workspacesDir, id, "storage"),
+		CreatedAt:   time.Now(),
+		Adopted:     true,
+	}
+	if wt.Branch == "" {
+		workspace.Detached = true
+		workspace.From = wt.Commit
+	}
+
+	if err := os.MkdirAll(workspace.StoragePath, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	if err := m.saveWorkspace(ctx, workspace); err != nil {
+		_ = os.RemoveAll(filepath.Join(m.workspacesDir, id))
+		return nil, fmt.Errorf("failed to save workspace metadata: %w", err)
+	}
+
+	if index, err := m.idMapper.Add(idmap.WorkspaceID(workspace.ID)); err == nil {
+		workspace.Index = index
+	}
+
+	if !opts.NoHooks {
+		if err := m.executeHooks(ctx, workspace, hooks.EventWorkspaceCreate); err != nil {
+			slog.Error("hook execution failed", "error", err)
+		}
+	}
+
+	return workspace, nil
+}
+
+// Detach forgets a workspace without touching its worktree or branch: the
+// metadata, storage, index, checkpoints and session snapshots are dropped,
+// while the worktree stays registered with git where it is. This is the
+// reverse of Adopt.
+func (m *Manager) Detach(ctx context.Context, identifier Identifier, opts DetachOptions) (*Workspace, error) {
+	workspace, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotInUse(workspace, "detach", opts.Force); err != nil {
+		return nil, err
+	}
+
+	// Worktrees created by amux live inside the workspace directory; those
+	// are kept while everything else there is cleared out
+	workspaceDir := filepath.Join(m.workspacesDir, workspace.ID)
+	rel, err := filepath.Rel(resolvePath(workspaceDir), resolvePath(workspace.Path))
+	inside := err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	if inside && rel == "." {
+		return nil, fmt.Errorf("workspace '%s' has its worktree at the workspace directory itself", workspace.Name)
+	}
+
+	if !opts.NoHooks {
+		if err := m.executeHooks(ctx, workspace, hooks.EventWorkspaceRemove); err != nil {
+			slog.Error("hook execution failed", "error", err)
+		}
+	}
+
+	if err := m.gitOps.DeleteRefs(checkpointRefPrefix(workspace.ID)); err != nil {
+		slog.Warn("failed to delete workspace checkpoints", "workspace", workspace.ID, "error", err)
+	}
+	if err := m.gitOps.DeleteRefs(sessionSnapshotRefPrefix(workspace.ID)); err != nil {
+		slog.Warn("failed to delete session snapshots", "workspace", workspace.ID, "error", err)
+	}
+	_ = m.idMapper.Remove(idmap.WorkspaceID(workspace.ID))
+
+	if !inside {
+		if err := os.RemoveAll(workspaceDir); err != nil {
+			return nil, fmt.Errorf("failed to remove workspace directory: %w", err)
+		}
+		return workspace, nil
+	}
+	keep := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+	entries, err := os.ReadDir(workspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == keep {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(workspaceDir, entry.Name())); err != nil {
+			return nil, fmt.Errorf("failed to remove workspace directory: %w", err)
+		}
+	}
+	return workspace, nil
+}
@@ -0,0 +1,132 @@
+package workspace_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_AdoptAndDetach(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	external := filepath.Join(t.TempDir(), "hotfix")
+	git("worktree", "add", "-b", "hotfix", external)
+
+	if _, err := manager.Adopt(ctx, workspace.AdoptOptions{Path: repoDir}); err == nil {
+		t.Error("Expected error when adopting the main worktree")
+	}
+	if _, err := manager.Adopt(ctx, workspace.AdoptOptions{Path: t.TempDir()}); err == nil {
+		t.Error("Expected error when adopting a folder that is not a worktree")
+	}
+
+	ws, err := manager.Adopt(ctx, workspace.AdoptOptions{Path: external})
+	if err != nil {
+		t.Fatalf("Failed to adopt worktree: %v", err)
+	}
+	if ws.Name != "hotfix" || ws.Branch != "hotfix" || ws.Path != external || !ws.Adopted || ws.Index == "" {
+		t.Errorf("Unexpected adopted workspace: %+v", ws)
+	}
+	if _, err := os.Stat(ws.StoragePath); err != nil {
+		t.Errorf("Expected storage directory: %v", err)
+	}
+	resolved, err := manager.ResolveWorkspace(ctx, workspace.Identifier("hotfix"))
+	if err != nil {
+		t.Fatalf("Failed to resolve adopted workspace: %v", err)
+	}
+	if resolved.Status != workspace.StatusConsistent {
+		t.Errorf("Expected adopted workspace to be consistent, got %s", resolved.Status)
+	}
+	if _, err := manager.Adopt(ctx, workspace.AdoptOptions{Path: external, Name: "again"}); err == nil {
+		t.Error("Expected error when adopting a worktree twice")
+	}
+
+	t.Run("detach keeps the worktree", func(t *testing.T) {
+		if _, err := manager.Detach(ctx, workspace.Identifier(ws.Name), workspace.DetachOptions{}); err != nil {
+			t.Fatalf("Failed to detach workspace: %v", err)
+		}
+		if _, err := manager.ResolveWorkspace(ctx, workspace.Identifier(ws.Name)); err == nil {
+			t.Error("Expected detached workspace to be forgotten")
+		}
+		if _, err := os.Stat(ws.StoragePath); !os.IsNotExist(err) {
+			t.Errorf("Expected storage to be removed: %v", err)
+		}
+		if !strings.Contains(git("worktree", "list"), external) {
+			t.Error("Expected worktree to remain registered")
+		}
+	})
+
+	t.Run("detach an amux worktree", func(t *testing.T) {
+		created, err := manager.Create(ctx, workspace.CreateOptions{Name: "created"})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		if _, err := manager.Detach(ctx, workspace.Identifier(created.Name), workspace.DetachOptions{}); err != nil {
+			t.Fatalf("Failed to detach workspace: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(created.Path, "README.md")); err != nil {
+			t.Errorf("Expected worktree files to remain: %v", err)
+		}
+		if _, err := os.Stat(created.StoragePath); !os.IsNotExist(err) {
+			t.Errorf("Expected storage to be removed: %v", err)
+		}
+	})
+
+	t.Run("remove needs force and keeps the branch", func(t *testing.T) {
+		ws, err := manager.Adopt(ctx, workspace.AdoptOptions{Path: external})
+		if err != nil {
+			t.Fatalf("Failed to adopt worktree again: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(external, "wip.txt"), []byte("work in progress\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		var adopted workspace.ErrAdoptedWorktree
+		err = manager.Remove(ctx, workspace.Identifier(ws.Name), workspace.RemoveOptions{})
+		if !errors.As(err, &adopted) {
+			t.Fatalf("Expected adopted worktree error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(external, "wip.txt")); err != nil {
+			t.Errorf("Expected uncommitted files to remain: %v", err)
+		}
+		if _, err := manager.Get(ctx, workspace.ID(ws.ID)); err != nil {
+			t.Errorf("Expected workspace to remain: %v", err)
+		}
+
+		if err := manager.Remove(ctx, workspace.Identifier(ws.Name), workspace.RemoveOptions{Force: true}); err != nil {
+			t.Fatalf("Failed to remove workspace: %v", err)
+		}
+		if _, err := os.Stat(external); !os.IsNotExist(err) {
+			t.Errorf("Expected worktree to be removed: %v", err)
+		}
+		if git("branch", "--list", "hotfix") == "" {
+			t.Error("Expected branch of adopted workspace to be kept")
+		}
+	})
+}
@@ -0,0 +1,506 @@
+package workspace
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/hooks"
+	"github.com/aki/amux/internal/idmap"
+)
+
+// Names of the entries in a workspace archive
+const (
+	archiveInfoEntry     = "archive.yaml"
+	archiveMetadataEntry = "workspace.yaml"
+	archivePatchEntry    = "changes.patch"
+	archiveStorageEntry  = "storage"
+)
+
+// archiveRef keeps the commit an archived workspace was at reachable, so
+// detached workspaces and deleted branches can be restored
+func archiveRef(workspaceID string) string {
+	return "refs/amux/archives/" + workspaceID
+}
+
+// archiveDir returns the directory holding workspace archives
+func (m *Manager) archiveDir() string {
+	return filepath.Join(m.configManager.GetAmuxDir(), "archive")
+}
+
+// Archive packs a workspace's metadata, storage and uncommitted changes into
+// .amux/archive/<id>.tar.gz and removes its worktree. Unlike Remove, the
+// branch and checkpoints are kept, so the workspace can be brought back with
+// Restore.
+func (m *Manager) Archive(ctx context.Context, identifier Identifier, opts ArchiveOptions) (*ArchiveInfo, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Status != StatusConsistent {
+		return nil, fmt.Errorf("workspace %s is %s; remove it instead", ws.Name, ws.Status)
+	}
+	if err := checkNotInUse(ws, "archive", opts.Force); err != nil {
+		return nil, err
+	}
+	if opts.CurrentDir != "" {
+		if err := m.checkCurrentDirectorySafety(ws.Path, opts.CurrentDir); err != nil {
+			return nil, err
+		}
+	}
+
+	ops := git.NewOperations(ws.Path)
+	if inProgress := ops.SyncInProgress(); inProgress != "" {
+		return nil, fmt.Errorf("workspace %s has a %s in progress", ws.Name, inProgress)
+	}
+	head, err := ops.ResolveCommit("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	patch, err := ops.UncommittedPatch()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ArchiveInfo{
+		ID:          ws.ID,
+		Name:        ws.Name,
+		Branch:      ws.Branch,
+		BaseBranch:  ws.BaseBranch,
+		Description: ws.Description,
+		Detached:    ws.Detached,
+		Head:        head,
+		HasChanges:  patch != "",
+		ArchivedAt:  time.Now(),
+		Path:        filepath.Join(m.archiveDir(), ws.ID+".tar.gz"),
+	}
+	if err := m.writeArchive(info, patch); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(info.Path); err == nil {
+		info.Size = fi.Size()
+	}
+	if err := m.gitOps.UpdateRef(archiveRef(ws.ID), head); err != nil {
+		_ = os.Remove(info.Path)
+		return nil, err
+	}
+
+	// Same teardown as removal, e.g. stopping services started for the workspace
+	if !opts.NoHooks {
+		if err := m.executeHooks(ctx, ws, hooks.EventWorkspaceRemove); err != nil {
+			slog.Error("hook execution failed", "error", err)
+		}
+	}
+
+	if err := m.gitOps.RemoveWorktree(ws.Path); err != nil {
+		return nil, fmt.Errorf("archived to %s but failed to remove worktree: %w", info.Path, err)
+	}
+	_ = m.idMapper.Remove(idmap.WorkspaceID(ws.ID))
+	if err := os.RemoveAll(filepath.Join(m.workspacesDir, ws.ID)); err != nil {
+		return nil, fmt.Errorf("archived to %s but failed to remove workspace directory: %w", info.Path, err)
+	}
+
+	return info, nil
+}
+
+// writeArchive writes the archive file described by info
+func (m *Manager) writeArchive(info *ArchiveInfo, patch string) error {
+	if err := os.MkdirAll(m.archiveDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if _, err := os.Stat(info.Path); err == nil {
+		return fmt.Errorf("archive %s already exists", info.Path)
+	}
+
+	workspaceDir := filepath.Join(m.workspacesDir, info.ID)
+	infoData, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive info: %w", err)
+	}
+	metadata, err := os.ReadFile(filepath.Join(workspaceDir, "workspace.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read workspace metadata: %w", err)
+	}
+
+	tmp := info.Path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	err = writeTarFile(tw, archiveInfoEntry, infoData)
+	if err == nil {
+		err = writeTarFile(tw, archiveMetadataEntry, metadata)
+	}
+	if err == nil && patch != "" {
+		err = writeTarFile(tw, archivePatchEntry, []byte(patch))
+	}
+	if err == nil {
+		err = writeTarTree(tw, filepath.Join(workspaceDir, archiveStorageEntry), archiveStorageEntry)
+	}
+	for _, closer := range []io.Closer{tw, gz, file} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return os.Rename(tmp, info.Path)
+}
+
+// writeTarFile adds a regular file to a tar archive
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// writeTarTree adds a directory tree to a tar archive under prefix
+func writeTarTree(tw *tar.Writer, dir, prefix string) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if d.Type()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ListArchives returns the archived workspaces, most recently archived first
+func (m *Manager) ListArchives() ([]*ArchiveInfo, error) {
+	entries, err := os.ReadDir(m.archiveDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	var archives []*ArchiveInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tar.gz") {
+			continue
+		}
+		path := filepath.Join(m.archiveDir(), entry.Name())
+		info, err := readArchiveInfo(path)
+		if err != nil {
+			slog.Warn("skipping unreadable workspace archive", "path", path, "error", err)
+			continue
+		}
+		archives = append(archives, info)
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].ArchivedAt.After(archives[j].ArchivedAt)
+	})
+	return archives, nil
+}
+
+// FindArchive finds an archive by file path, workspace ID or workspace name
+func (m *Manager) FindArchive(identifier string) (*ArchiveInfo, error) {
+	if strings.HasSuffix(identifier, ".tar.gz") {
+		if _, err := os.Stat(identifier); err == nil {
+			return readArchiveInfo(identifier)
+		}
+	}
+
+	archives, err := m.ListArchives()
+	if err != nil {
+		return nil, err
+	}
+	var matches []*ArchiveInfo
+	for _, info := range archives {
+		if info.ID == identifier || info.Name == identifier || filepath.Base(info.Path) == identifier {
+			matches = append(matches, info)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("archive '%s' not found. Run 'amux ws archives' to see archived workspaces", identifier)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d archives are named '%s'; use the workspace ID instead", len(matches), identifier)
+	}
+}
+
+// readArchiveInfo reads the description at the start of an archive
+func readArchiveInfo(path string) (*ArchiveInfo, error) {
+	info := &ArchiveInfo{}
+	err := readArchive(path, func(header *tar.Header, r io.Reader) (bool, error) {
+		if header.Name != archiveInfoEntry {
+			return false, fmt.Errorf("not a workspace archive")
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return false, err
+		}
+		return true, yaml.Unmarshal(data, info)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info.Path = path
+	if fi, err := os.Stat(path); err == nil {
+		info.Size = fi.Size()
+	}
+	return info, nil
+}
+
+// readArchive calls fn for each entry of an archive until fn returns true or
+// an error
+func readArchive(path string, fn func(header *tar.Header, r io.Reader) (bool, error)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read archive %s: %w", path, err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %w", path, err)
+		}
+		done, err := fn(header, tr)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// Restore recreates an archived workspace with the same ID, name, branch and
+// storage, and reapplies its uncommitted changes. The archive is deleted once
+// the workspace is back.
+func (m *Manager) Restore(ctx context.Context, archive string, opts RestoreOptions) (*Workspace, error) {
+	info, err := m.FindArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Get(ctx, ID(info.ID)); err == nil {
+		return nil, fmt.Errorf("workspace %s already exists", info.ID)
+	}
+	if existing, err := m.ResolveWorkspace(ctx, Identifier(info.Name)); err == nil {
+		return nil, fmt.Errorf("a workspace named '%s' already exists (%s)", info.Name, existing.ID)
+	}
+
+	// Read the metadata and patch; storage is extracted once the worktree exists
+	var ws Workspace
+	var patch string
+	err = readArchive(info.Path, func(header *tar.Header, r io.Reader) (bool, error) {
+		switch header.Name {
+		case archiveMetadataEntry:
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return false, err
+			}
+			return false, yaml.Unmarshal(data, &ws)
+		case archivePatchEntry:
+			data, err := io.ReadAll(r)
+			patch = string(data)
+			return false, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ws.ID != info.ID {
+		return nil, fmt.Errorf("archive %s does not hold workspace %s", info.Path, info.ID)
+	}
+
+	workspaceDir := filepath.Join(m.workspacesDir, ws.ID)
+	if ws.Path, err = m.worktreePath(ws.Name, ws.ID); err != nil {
+		return nil, err
+	}
+	ws.StoragePath = filepath.Join(workspaceDir, archiveStorageEntry)
+	if err := os.MkdirAll(workspaceDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+
+	if err := m.restoreWorktree(&ws, info.Head); err != nil {
+		_ = os.RemoveAll(workspaceDir)
+		return nil, err
+	}
+	cleanup := func() {
+		_ = m.gitOps.RemoveWorktree(ws.Path)
+		_ = os.RemoveAll(workspaceDir)
+	}
+
+	if patch != "" {
+		if err := git.NewOperations(ws.Path).ApplyPatch(patch); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to restore uncommitted changes: %w", err)
+		}
+	}
+	if err := extractStorage(info.Path, ws.StoragePath); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	copied, err := m.copyIntoWorktree(ws.Path)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to copy files into workspace: %w", err)
+	}
+	ws.Copied = copied
+
+	if err := m.saveWorkspace(ctx, &ws); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to save workspace metadata: %w", err)
+	}
+	if index, err := m.idMapper.Add(idmap.WorkspaceID(ws.ID)); err == nil {
+		ws.Index = index
+	}
+
+	if err := os.Remove(info.Path); err != nil {
+		slog.Warn("failed to delete restored archive", "path", info.Path, "error", err)
+	}
+	if err := m.gitOps.DeleteRefs(archiveRef(ws.ID)); err != nil {
+		slog.Warn("failed to delete archive ref", "workspace", ws.ID, "error", err)
+	}
+
+	if !opts.NoHooks {
+		if err := m.executeHooks(ctx, &ws, hooks.EventWorkspaceCreate); err != nil {
+			slog.Error("hook execution failed", "error", err)
+		}
+	}
+
+	m.CheckConsistency(&ws)
+	return &ws, nil
+}
+
+// restoreWorktree checks out the worktree of an archived workspace. A branch
+// deleted since archiving is recreated at the commit it was archived at.
+func (m *Manager) restoreWorktree(ws *Workspace, head string) error {
+	if ws.Detached || ws.Branch == "" {
+		return m.addWorktree(ws.Path, head, true, ws.Sparse)
+	}
+
+	if _, err := m.gitOps.ResolveCommit("refs/heads/" + ws.Branch); err != nil {
+		if err := m.gitOps.CreateBranch(ws.Branch, head); err != nil {
+			return fmt.Errorf("failed to recreate branch %s: %w", ws.Branch, err)
+		}
+	}
+	if err := m.addWorktree(ws.Path, ws.Branch, false, ws.Sparse); err != nil {
+		return fmt.Errorf("failed to restore worktree: %w", err)
+	}
+	return nil
+}
+
+// extractStorage extracts the storage directory of an archive
+func extractStorage(archivePath, storagePath string) error {
+	if err := os.MkdirAll(storagePath, 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	err := readArchive(archivePath, func(header *tar.Header, r io.Reader) (bool, error) {
+		rel, ok := strings.CutPrefix(header.Name, archiveStorageEntry+"/")
+		if !ok || strings.TrimSuffix(rel, "/") == "" {
+			return false, nil
+		}
+		target := filepath.Join(storagePath, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, storagePath+string(filepath.Separator)) {
+			return false, fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return false, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			return false, os.MkdirAll(target, header.FileInfo().Mode().Perm())
+		case tar.TypeSymlink:
+			return false, os.Symlink(header.Linkname, target)
+		case tar.TypeReg:
+			file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode().Perm())
+			if err != nil {
+				return false, err
+			}
+			_, err = io.Copy(file, r)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			return false, err
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to extract storage: %w", err)
+	}
+	return nil
+}
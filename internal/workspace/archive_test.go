@@ -0,0 +1,144 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_ArchiveAndRestore(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "archived", Description: "put aside"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	files := map[string]string{
+		"README.md":          "changed\n",
+		"notes/untracked.md": "draft\n",
+		"blob.bin":           "\x00\x01\x02",
+	}
+	for name, content := range files {
+		path := filepath.Join(ws.Path, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(ws.StoragePath, "plans"), 0o755); err != nil {
+		t.Fatalf("Failed to create storage directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.StoragePath, "plans", "plan.md"), []byte("plan\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write storage file: %v", err)
+	}
+
+	info, err := manager.Archive(ctx, workspace.Identifier(ws.Name), workspace.ArchiveOptions{})
+	if err != nil {
+		t.Fatalf("Failed to archive workspace: %v", err)
+	}
+	if info.ID != ws.ID || info.Branch != ws.Branch || !info.HasChanges || info.Size == 0 {
+		t.Errorf("Unexpected archive info: %+v", info)
+	}
+	if _, err := os.Stat(ws.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected worktree to be removed: %v", err)
+	}
+	if _, err := manager.ResolveWorkspace(ctx, workspace.Identifier(ws.Name)); err == nil {
+		t.Error("Expected archived workspace to be gone")
+	}
+	cmd := exec.Command("git", "rev-parse", "--verify", "refs/heads/"+ws.Branch)
+	cmd.Dir = repoDir
+	if err := cmd.Run(); err != nil {
+		t.Errorf("Expected branch %s to be kept: %v", ws.Branch, err)
+	}
+
+	archives, err := manager.ListArchives()
+	if err != nil || len(archives) != 1 || archives[0].Name != ws.Name {
+		t.Fatalf("Unexpected archives: %+v (%v)", archives, err)
+	}
+
+	restored, err := manager.Restore(ctx, ws.Name, workspace.RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Failed to restore workspace: %v", err)
+	}
+	if restored.ID != ws.ID || restored.Branch != ws.Branch || restored.Description != "put aside" {
+		t.Errorf("Unexpected restored workspace: %+v", restored)
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(restored.Path, name))
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to be restored, got %q (%v)", name, data, err)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(restored.StoragePath, "plans", "plan.md")); err != nil || string(data) != "plan\n" {
+		t.Errorf("Expected storage to be restored, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(info.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected archive to be deleted after restore: %v", err)
+	}
+	if _, err := manager.Restore(ctx, ws.Name, workspace.RestoreOptions{}); err == nil {
+		t.Error("Expected error restoring a workspace that is not archived")
+	}
+}
+
+func TestManager_ArchiveDetachedAndPrune(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	cfg := config.DefaultConfig()
+	cfg.Workspace.Prune = config.PruneArchive
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	if !manager.ArchiveOnPrune() {
+		t.Error("Expected prune to archive when configured")
+	}
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{
+		Name:       "investigate",
+		From:       "HEAD",
+		BranchMode: workspace.BranchModeDetach,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	// The detached HEAD is already part of main
+	archived, err := manager.Cleanup(ctx, workspace.CleanupOptions{Merged: true, Archive: manager.ArchiveOnPrune()})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if len(archived) != 1 || archived[0] != ws.ID {
+		t.Fatalf("Expected %s to be pruned, got %v", ws.ID, archived)
+	}
+
+	restored, err := manager.Restore(ctx, ws.ID, workspace.RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Failed to restore workspace: %v", err)
+	}
+	if !restored.Detached || restored.Branch != "" || restored.Status != workspace.StatusConsistent {
+		t.Errorf("Unexpected restored workspace: %+v", restored)
+	}
+}
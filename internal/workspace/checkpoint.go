@@ -0,0 +1,206 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aki/amux/internal/git"
+)
+
+// checkpointRefPrefix returns the hidden ref namespace holding a workspace's
+// checkpoints. Refs outside refs/heads and refs/tags do not show up as
+// branches or tags, but still keep their commits from being garbage collected.
+func checkpointRefPrefix(workspaceID string) string {
+	return "refs/amux/checkpoints/" + workspaceID + "/"
+}
+
+// Checkpoint records the current state of a workspace's worktree, including
+// uncommitted and untracked files, without touching its branch or index
+func (m *Manager) Checkpoint(ctx context.Context, identifier Identifier, message string) (*Checkpoint, error) {
+	ws, err := m.checkpointableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return m.checkpoint(ws, message)
+}
+
+// ListCheckpoints returns the checkpoints of a workspace, oldest first
+func (m *Manager) ListCheckpoints(ctx context.Context, identifier Identifier) ([]*Checkpoint, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return m.listCheckpoints(ws)
+}
+
+// Rollback restores a workspace's worktree to a checkpoint. The branch is
+// reset to the commit it was at when the checkpoint was taken. The current
+// state is checkpointed first, so a rollback can itself be rolled back; that
+// checkpoint is returned. Workspaces in use by sessions are only rolled back
+// with force.
+func (m *Manager) Rollback(ctx context.Context, identifier Identifier, checkpointID string, force bool) (*Checkpoint, error) {
+	ws, err := m.checkpointableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotInUse(ws, "roll back", force); err != nil {
+		return nil, err
+	}
+
+	target, err := m.findCheckpoint(ws, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	return m.restore(ws, target.Commit, fmt.Sprintf("rollback to checkpoint %s", target.ID))
+}
+
+// SetAutoCheckpoint turns automatic checkpoints before sessions on or off
+func (m *Manager) SetAutoCheckpoint(ctx context.Context, identifier Identifier, enabled bool) error {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	ws.AutoCheckpoint = enabled
+	return m.saveWorkspace(ctx, ws)
+}
+
+// CheckpointBeforeSession takes a checkpoint of a workspace that has automatic
+// checkpoints enabled. It returns nil if the workspace does not use them.
+func (m *Manager) CheckpointBeforeSession(ctx context.Context, identifier Identifier, sessionID string) (*Checkpoint, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if !ws.AutoCheckpoint || ws.Status != StatusConsistent {
+		return nil, nil
+	}
+	return m.checkpoint(ws, fmt.Sprintf("Before session %s", sessionID))
+}
+
+// restore checkpoints the current state of a workspace's worktree and then
+// restores the snapshot, returning the checkpoint. The reason ends up in the
+// checkpoint's message.
+func (m *Manager) restore(ws *Workspace, commit, reason string) (*Checkpoint, error) {
+	safety, err := m.checkpoint(ws, "Before "+reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint current state: %w", err)
+	}
+
+	if err := git.NewOperations(ws.Path).RestoreSnapshot(commit); err != nil {
+		return safety, err
+	}
+	return safety, nil
+}
+
+// checkNotInUse refuses an action on a workspace used by sessions unless forced
+func checkNotInUse(ws *Workspace, action string, force bool) error {
+	if force {
+		return nil
+	}
+	sessionIDs, err := ws.SessionIDs()
+	if err != nil {
+		return fmt.Errorf("failed to check active sessions: %w", err)
+	}
+	if len(sessionIDs) > 0 {
+		return fmt.Errorf("cannot %s workspace '%s' - currently in use by %d session(s)", action, ws.Name, len(sessionIDs))
+	}
+	return nil
+}
+
+// checkpointableWorkspace resolves a workspace whose worktree can be checkpointed
+func (m *Manager) checkpointableWorkspace(ctx context.Context, identifier Identifier) (*Workspace, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Status != StatusConsistent {
+		return nil, fmt.Errorf("workspace %s is %s", ws.Name, ws.Status)
+	}
+	return ws, nil
+}
+
+// checkpoint snapshots the worktree and stores it under the next checkpoint number
+func (m *Manager) checkpoint(ws *Workspace, message string) (*Checkpoint, error) {
+	if message == "" {
+		message = fmt.Sprintf("Checkpoint of %s", ws.Name)
+	}
+
+	existing, err := m.listCheckpoints(ws)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if len(existing) > 0 {
+		last, _ := strconv.Atoi(existing[len(existing)-1].ID)
+		next = last + 1
+	}
+
+	ops := git.NewOperations(ws.Path)
+	commit, err := ops.Snapshot(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint workspace %s: %w", ws.Name, err)
+	}
+	id := strconv.Itoa(next)
+	if err := ops.UpdateRef(checkpointRefPrefix(ws.ID)+id, commit); err != nil {
+		return nil, err
+	}
+
+	refs, err := ops.ListRefs(checkpointRefPrefix(ws.ID) + id)
+	if err != nil || len(refs) != 1 {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", id, err)
+	}
+	return checkpointFromRef(ws.ID, refs[0]), nil
+}
+
+// listCheckpoints reads a workspace's checkpoint refs, ordered by number
+func (m *Manager) listCheckpoints(ws *Workspace) ([]*Checkpoint, error) {
+	refs, err := m.gitOps.ListRefs(checkpointRefPrefix(ws.ID))
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]*Checkpoint, 0, len(refs))
+	for _, ref := range refs {
+		checkpoints = append(checkpoints, checkpointFromRef(ws.ID, ref))
+	}
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		a, _ := strconv.Atoi(checkpoints[i].ID)
+		b, _ := strconv.Atoi(checkpoints[j].ID)
+		return a < b
+	})
+	return checkpoints, nil
+}
+
+// findCheckpoint looks a checkpoint up by number or by (abbreviated) commit
+func (m *Manager) findCheckpoint(ws *Workspace, id string) (*Checkpoint, error) {
+	checkpoints, err := m.listCheckpoints(ws)
+	if err != nil {
+		return nil, err
+	}
+	for _, cp := range checkpoints {
+		if cp.ID == id {
+			return cp, nil
+		}
+	}
+	if len(id) >= 4 {
+		for _, cp := range checkpoints {
+			if strings.HasPrefix(cp.Commit, id) {
+				return cp, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("checkpoint %s not found in workspace %s", id, ws.Name)
+}
+
+// checkpointFromRef converts a checkpoint ref into a Checkpoint
+func checkpointFromRef(workspaceID string, ref git.RefInfo) *Checkpoint {
+	return &Checkpoint{
+		ID:        strings.TrimPrefix(ref.Name, checkpointRefPrefix(workspaceID)),
+		Commit:    ref.Commit,
+		Head:      ref.Parent,
+		Message:   ref.Subject,
+		CreatedAt: ref.Date,
+	}
+}
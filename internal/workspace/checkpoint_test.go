@@ -0,0 +1,95 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_Checkpoints(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "checkpoints"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	id := workspace.Identifier(ws.Name)
+	notes := filepath.Join(ws.Path, "notes.txt")
+
+	if err := os.WriteFile(notes, []byte("good\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	cp, err := manager.Checkpoint(ctx, id, "before refactor")
+	if err != nil {
+		t.Fatalf("Failed to checkpoint: %v", err)
+	}
+	if cp.ID != "1" || cp.Message != "before refactor" || cp.Head == "" {
+		t.Errorf("Unexpected checkpoint: %+v", cp)
+	}
+
+	if err := os.WriteFile(notes, []byte("wrecked\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// Automatic checkpoints only happen when enabled
+	if cp, err := manager.CheckpointBeforeSession(ctx, id, "session-1"); err != nil || cp != nil {
+		t.Fatalf("Expected no automatic checkpoint, got %+v, %v", cp, err)
+	}
+	if err := manager.SetAutoCheckpoint(ctx, id, true); err != nil {
+		t.Fatalf("Failed to enable automatic checkpoints: %v", err)
+	}
+	auto, err := manager.CheckpointBeforeSession(ctx, id, "session-1")
+	if err != nil || auto == nil || auto.ID != "2" || auto.Message != "Before session session-1" {
+		t.Fatalf("Unexpected automatic checkpoint: %+v, %v", auto, err)
+	}
+
+	safety, err := manager.Rollback(ctx, id, "1", false)
+	if err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if safety.ID != "3" {
+		t.Errorf("Expected safety checkpoint 3, got %s", safety.ID)
+	}
+	if data, _ := os.ReadFile(notes); string(data) != "good\n" {
+		t.Errorf("Expected rolled back content, got %q", data)
+	}
+
+	checkpoints, err := manager.ListCheckpoints(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to list checkpoints: %v", err)
+	}
+	if len(checkpoints) != 3 {
+		t.Fatalf("Expected 3 checkpoints, got %d", len(checkpoints))
+	}
+
+	if _, err := manager.Rollback(ctx, id, "42", false); err == nil {
+		t.Error("Expected error for unknown checkpoint")
+	}
+
+	// Removing the workspace drops its checkpoints
+	if err := manager.Remove(ctx, workspace.Identifier(ws.ID), workspace.RemoveOptions{}); err != nil {
+		t.Fatalf("Failed to remove workspace: %v", err)
+	}
+	refs, err := git.NewOperations(repoDir).ListRefs("refs/amux/checkpoints/")
+	if err != nil {
+		t.Fatalf("Failed to list refs: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("Expected checkpoint refs to be deleted, got %d", len(refs))
+	}
+}
@@ -0,0 +1,189 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aki/amux/internal/semaphore"
+)
+
+// Concurrency policies for Workspace.Concurrency, limiting how many sessions
+// run in a workspace at once
+const (
+	ConcurrencyExclusive = "exclusive" // One session at a time
+	ConcurrencyShared    = "shared"    // DefaultSharedSlots sessions, or N with shared:N
+	ConcurrencyUnlimited = "unlimited" // No limit (the default)
+)
+
+// DefaultSharedSlots is the number of sessions a shared workspace takes
+// when no number is given
+const DefaultSharedSlots = 10
+
+// sessionStartGrace is how long a new holder is kept whatever the session
+// check says: sessions take their slot before they are recorded
+const sessionStartGrace = time.Minute
+
+// SessionCheck reports whether a session holding a slot in a workspace is
+// still running
+type SessionCheck func(sessionID string) bool
+
+// SetSessionCheck makes the workspaces of the manager evict the slots of
+// sessions that check reports as gone, whenever their holders are looked at
+func (m *Manager) SetSessionCheck(check SessionCheck) {
+	m.sessionCheck = check
+}
+
+// semaphoreOptions returns how the workspace semaphore finds stale holders
+func (w *Workspace) semaphoreOptions() semaphore.Options {
+	check := w.sessionCheck
+	if check == nil {
+		return semaphore.Options{}
+	}
+	return semaphore.Options{
+		IsAlive: func(h semaphore.HolderInfo) bool {
+			return time.Since(h.AcquiredAt) < sessionStartGrace || check(h.ID)
+		},
+	}
+}
+
+// ParseConcurrency validates a concurrency policy and returns the number of
+// sessions it allows, or 0 for no limit. An empty policy is unlimited.
+func ParseConcurrency(policy string) (int, error) {
+	switch policy {
+	case ConcurrencyExclusive:
+		return 1, nil
+	case ConcurrencyShared:
+		return DefaultSharedSlots, nil
+	case "", ConcurrencyUnlimited:
+		return 0, nil
+	}
+	if n, ok := strings.CutPrefix(policy, ConcurrencyShared+":"); ok {
+		if slots, err := strconv.Atoi(n); err == nil && slots > 0 {
+			return slots, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid concurrency '%s' (must be %s, %s, %s:N or %s)", policy, ConcurrencyExclusive, ConcurrencyShared, ConcurrencyShared, ConcurrencyUnlimited)
+}
+
+// capacity returns the semaphore capacity for the workspace's policy
+func (w *Workspace) capacity() int {
+	slots, err := ParseConcurrency(w.Concurrency)
+	if err != nil {
+		return DefaultSharedSlots
+	}
+	if slots == 0 {
+		return math.MaxInt32
+	}
+	return slots
+}
+
+// DefaultConcurrency returns the policy of workspaces that don't set their
+// own: workspace.concurrency from the configuration, or else unlimited, so
+// limits are opt-in
+func (m *Manager) DefaultConcurrency() string {
+	cfg, err := m.workspaceConfig()
+	if err != nil || cfg.Concurrency == "" {
+		return ConcurrencyUnlimited
+	}
+	return cfg.Concurrency
+}
+
+// AcquireSession takes a slot in a workspace for a session, as its
+// concurrency policy allows. It fails with ErrWorkspaceBusy when all slots
+// are taken.
+func (m *Manager) AcquireSession(ctx context.Context, identifier Identifier, sessionID string) error {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	if ws.Concurrency == "" {
+		ws.Concurrency = m.DefaultConcurrency()
+	}
+	if _, err := ParseConcurrency(ws.Concurrency); err != nil {
+		return err
+	}
+
+	if err := ws.Acquire(sessionID); err != nil {
+		if errors.Is(err, semaphore.ErrNoCapacity) {
+			holders, _ := ws.SessionIDs()
+			return &ErrWorkspaceBusy{Name: ws.Name, Concurrency: ws.Concurrency, Holders: holders}
+		}
+		if !errors.Is(err, semaphore.ErrAlreadyHeld) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseSession frees the slot a session holds in a workspace. Releasing a
+// slot that isn't held is not an error.
+func (m *Manager) ReleaseSession(ctx context.Context, identifier Identifier, sessionID string) error {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	if err := ws.Release(sessionID); err != nil && !errors.Is(err, semaphore.ErrNotHeld) {
+		return err
+	}
+	return nil
+}
+
+// SessionHolders returns the sessions holding a slot in the workspace, with
+// when they took it
+func (w *Workspace) SessionHolders() ([]SessionHolder, error) {
+	semaphorePath := w.getSemaphorePath()
+	if _, err := os.Stat(semaphorePath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	sem, err := w.openSemaphore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create semaphore: %w", err)
+	}
+	defer func() {
+		_ = sem.Close()
+	}()
+
+	return sessionHolders(sem.HolderInfos()), nil
+}
+
+// UnlockStale frees the slots of sessions that are no longer running and
+// returns them
+func (m *Manager) UnlockStale(ctx context.Context, identifier Identifier) ([]SessionHolder, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(ws.getSemaphorePath()); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	sem, err := ws.openSemaphore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create semaphore: %w", err)
+	}
+	defer func() {
+		_ = sem.Close()
+	}()
+
+	evicted, err := sem.Prune()
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock workspace: %w", err)
+	}
+	return sessionHolders(evicted), nil
+}
+
+// sessionHolders converts semaphore holders to session holders
+func sessionHolders(infos []semaphore.HolderInfo) []SessionHolder {
+	holders := make([]SessionHolder, len(infos))
+	for i, info := range infos {
+		holders[i] = SessionHolder{SessionID: info.ID, AcquiredAt: info.AcquiredAt}
+	}
+	return holders
+}
@@ -0,0 +1,188 @@
+package workspace_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestParseConcurrency(t *testing.T) {
+	tests := []struct {
+		policy  string
+		slots   int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"exclusive", 1, false},
+		{"shared", workspace.DefaultSharedSlots, false},
+		{"shared:3", 3, false},
+		{"unlimited", 0, false},
+		{"shared:0", 0, true},
+		{"shared:many", 0, true},
+		{"private", 0, true},
+	}
+	for _, tt := range tests {
+		slots, err := workspace.ParseConcurrency(tt.policy)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConcurrency(%q) error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && slots != tt.slots {
+			t.Errorf("ParseConcurrency(%q) = %d, want %d", tt.policy, slots, tt.slots)
+		}
+	}
+}
+
+func TestManager_AcquireSession(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	cfg := config.DefaultConfig()
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := manager.Create(ctx, workspace.CreateOptions{Name: "bad", Concurrency: "private"}); err == nil {
+		t.Error("Expected error for an invalid concurrency policy")
+	}
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "solo", Concurrency: workspace.ConcurrencyExclusive})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	id := workspace.Identifier(ws.Name)
+
+	if err := manager.AcquireSession(ctx, id, "session-1"); err != nil {
+		t.Fatalf("Failed to acquire workspace: %v", err)
+	}
+	// Acquiring again for the same session is a no-op
+	if err := manager.AcquireSession(ctx, id, "session-1"); err != nil {
+		t.Errorf("Expected repeated acquire to succeed: %v", err)
+	}
+
+	var busy *workspace.ErrWorkspaceBusy
+	if err := manager.AcquireSession(ctx, id, "session-2"); !errors.As(err, &busy) {
+		t.Fatalf("Expected busy workspace error, got %v", err)
+	}
+	if len(busy.Holders) != 1 || busy.Holders[0] != "session-1" {
+		t.Errorf("Expected session-1 to hold the workspace, got %v", busy.Holders)
+	}
+
+	resolved, err := manager.ResolveWorkspace(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace: %v", err)
+	}
+	holders, err := resolved.SessionHolders()
+	if err != nil {
+		t.Fatalf("Failed to list holders: %v", err)
+	}
+	if len(holders) != 1 || holders[0].SessionID != "session-1" || holders[0].AcquiredAt.IsZero() {
+		t.Errorf("Unexpected holders: %+v", holders)
+	}
+
+	if err := manager.ReleaseSession(ctx, id, "session-1"); err != nil {
+		t.Fatalf("Failed to release workspace: %v", err)
+	}
+	if err := manager.ReleaseSession(ctx, id, "session-1"); err != nil {
+		t.Errorf("Expected repeated release to succeed: %v", err)
+	}
+	if err := manager.AcquireSession(ctx, id, "session-2"); err != nil {
+		t.Errorf("Expected workspace to be free after release: %v", err)
+	}
+
+	t.Run("default from configuration", func(t *testing.T) {
+		cfg.Workspace.Concurrency = workspace.ConcurrencyExclusive
+		if err := configManager.Save(cfg); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "default"})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		id := workspace.Identifier(ws.Name)
+		if err := manager.AcquireSession(ctx, id, "session-1"); err != nil {
+			t.Fatalf("Failed to acquire workspace: %v", err)
+		}
+		if err := manager.AcquireSession(ctx, id, "session-2"); !errors.As(err, &busy) {
+			t.Errorf("Expected configured default to make the workspace exclusive, got %v", err)
+		}
+	})
+}
+
+func TestManager_UnlockStale(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "solo", Concurrency: workspace.ConcurrencyExclusive})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	id := workspace.Identifier(ws.Name)
+
+	// A session that crashed an hour ago without releasing its slot
+	crashed := func() {
+		data, _ := json.Marshal(map[string]any{
+			"capacity": 1,
+			"holders":  []map[string]any{{"id": "crashed", "acquired_at": time.Now().Add(-time.Hour)}},
+		})
+		if err := os.WriteFile(filepath.Join(filepath.Dir(ws.StoragePath), "semaphore.json"), data, 0o644); err != nil {
+			t.Fatalf("Failed to write semaphore: %v", err)
+		}
+	}
+	crashed()
+
+	// Without a session check nothing is known to be stale
+	freed, err := manager.UnlockStale(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to unlock workspace: %v", err)
+	}
+	if len(freed) != 0 {
+		t.Errorf("Expected nothing to be freed, got %+v", freed)
+	}
+
+	running := map[string]bool{}
+	manager.SetSessionCheck(func(sessionID string) bool { return running[sessionID] })
+
+	freed, err = manager.UnlockStale(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to unlock workspace: %v", err)
+	}
+	if len(freed) != 1 || freed[0].SessionID != "crashed" {
+		t.Errorf("Expected the crashed session to be freed, got %+v", freed)
+	}
+
+	// Stale holders are also evicted when a session takes a slot
+	crashed()
+	if err := manager.AcquireSession(ctx, id, "starting"); err != nil {
+		t.Fatalf("Expected the crashed session's slot to be taken over: %v", err)
+	}
+
+	// Sessions that just took their slot keep it before they are recorded
+	freed, err = manager.UnlockStale(ctx, id)
+	if err != nil {
+		t.Fatalf("Failed to unlock workspace: %v", err)
+	}
+	if len(freed) != 0 {
+		t.Errorf("Expected a starting session to keep its slot, got %+v", freed)
+	}
+}
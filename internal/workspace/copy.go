@@ -0,0 +1,246 @@
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aki/amux/internal/config"
+)
+
+// CopyEntry is a file or directory of the main checkout that is brought into
+// new workspaces by the workspace.copy configuration
+type CopyEntry struct {
+	Path    string `json:"path"`              // Relative to the project root
+	Mode    string `json:"mode"`              // copy, symlink, hardlink or reflink
+	Files   int    `json:"files"`             // Files not tracked by git
+	Size    int64  `json:"size"`              // Total size of those files in bytes
+	Skipped string `json:"skipped,omitempty"` // Why the entry was not brought in
+}
+
+// PlanCopy lists the files the workspace.copy configuration brings into new
+// workspaces. Files tracked by git are left out, as the worktree has them already.
+func (m *Manager) PlanCopy() ([]CopyEntry, error) {
+	if !m.configManager.IsInitialized() {
+		return nil, nil
+	}
+	cfg, err := m.configManager.Load()
+	if err != nil {
+		return nil, err
+	}
+	rules := cfg.Workspace.Copy
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	root := m.configManager.GetProjectRoot()
+	var entries []CopyEntry
+	var paths []string
+	for _, rule := range rules {
+		pattern := filepath.Clean(rule.Pattern)
+		if filepath.IsAbs(pattern) || pattern == ".." || strings.HasPrefix(pattern, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid copy pattern %q: must be relative to the project root", rule.Pattern)
+		}
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid copy pattern %q: %w", rule.Pattern, err)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(root, match)
+			if err != nil || rel == "." || isInternalPath(rel) || coveredBy(rel, paths) {
+				continue
+			}
+			paths = append(paths, rel)
+			entries = append(entries, CopyEntry{Path: rel, Mode: rule.GetMode()})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	tracked, err := m.gitOps.TrackedFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+	isTracked := make(map[string]bool, len(tracked))
+	for _, path := range tracked {
+		isTracked[filepath.FromSlash(path)] = true
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		trackedFiles := 0
+		err := filepath.WalkDir(filepath.Join(root, e.Path), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			if isTracked[rel] {
+				trackedFiles++
+				return nil
+			}
+			e.Files++
+			if d.Type().IsRegular() {
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				e.Size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.Path, err)
+		}
+		if e.Files == 0 && trackedFiles > 0 {
+			e.Skipped = "tracked by git"
+		}
+	}
+	return entries, nil
+}
+
+// isInternalPath reports whether a path relative to the project root belongs
+// to git or amux, which are never copied
+func isInternalPath(rel string) bool {
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return first == ".git" || first == config.AmuxDir
+}
+
+// coveredBy reports whether a path is one of, or inside one of, the given paths
+func coveredBy(rel string, paths []string) bool {
+	for _, p := range paths {
+		if rel == p || strings.HasPrefix(rel, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyIntoWorktree brings the files selected by the workspace.copy
+// configuration into a new worktree. Files that already exist in the worktree
+// are left alone.
+func (m *Manager) copyIntoWorktree(worktreePath string) ([]CopyEntry, error) {
+	entries, err := m.PlanCopy()
+	if err != nil {
+		return nil, err
+	}
+
+	root := m.configManager.GetProjectRoot()
+	for i := range entries {
+		e := &entries[i]
+		if e.Skipped != "" {
+			continue
+		}
+		src := filepath.Join(root, e.Path)
+		dst := filepath.Join(worktreePath, e.Path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", e.Path, err)
+		}
+
+		if e.Mode == config.CopyModeSymlink {
+			if _, err := os.Lstat(dst); err == nil {
+				e.Skipped = "already exists in workspace"
+				continue
+			}
+			if err := os.Symlink(src, dst); err != nil {
+				return nil, fmt.Errorf("failed to link %s: %w", e.Path, err)
+			}
+			continue
+		}
+
+		copied, err := copyTree(src, dst, e.Mode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", e.Path, err)
+		}
+		if copied == 0 && e.Files > 0 {
+			e.Skipped = "already exists in workspace"
+		}
+	}
+	return entries, nil
+}
+
+// copyTree copies a file or directory tree, skipping files that already exist
+// at the destination. It returns the number of files copied.
+func copyTree(src, dst, mode string) (int, error) {
+	copied := 0
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case d.Type().IsRegular():
+			if err := copyFile(path, target, info.Mode().Perm(), mode); err != nil {
+				return err
+			}
+		default:
+			// Sockets, pipes and devices can't be copied
+			return nil
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
+
+// copyFile copies a regular file. Hard links and reflinks fall back to a plain
+// copy when the file system can't provide them.
+func copyFile(src, dst string, perm os.FileMode, mode string) error {
+	if mode == config.CopyModeHardlink {
+		if err := os.Link(src, dst); err == nil {
+			return nil
+		}
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if mode != config.CopyModeReflink || reflink(out, in) != nil {
+		_, err = io.Copy(out, in)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_CreateCopiesFiles(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	cfg := config.DefaultConfig()
+	cfg.Workspace.Copy = []config.CopyRule{
+		{Pattern: ".env*"},
+		{Pattern: "README.md"},
+		{Pattern: "node_modules", Mode: config.CopyModeSymlink},
+		{Pattern: "cache", Mode: config.CopyModeHardlink},
+		{Pattern: "missing/*"},
+	}
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	files := map[string]string{
+		".env":              "SECRET=1\n",
+		".env.local":        "LOCAL=1\n",
+		"node_modules/a.js": "module.exports = 1\n",
+		"cache/data/blob":   "0123456789",
+	}
+	for name, content := range files {
+		path := filepath.Join(repoDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	plan, err := manager.PlanCreate(workspace.CreateOptions{Name: "bootstrap"})
+	if err != nil {
+		t.Fatalf("Failed to plan: %v", err)
+	}
+	if plan.Branch != "" || plan.BaseBranch != "main" {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+	want := map[string]workspace.CopyEntry{
+		".env":         {Path: ".env", Mode: config.CopyModeCopy, Files: 1, Size: 9},
+		".env.local":   {Path: ".env.local", Mode: config.CopyModeCopy, Files: 1, Size: 8},
+		"README.md":    {Path: "README.md", Mode: config.CopyModeCopy, Skipped: "tracked by git"},
+		"node_modules": {Path: "node_modules", Mode: config.CopyModeSymlink, Files: 1, Size: 19},
+		"cache":        {Path: "cache", Mode: config.CopyModeHardlink, Files: 1, Size: 10},
+	}
+	if len(plan.Copy) != len(want) {
+		t.Fatalf("Expected %d entries, got %+v", len(want), plan.Copy)
+	}
+	for _, e := range plan.Copy {
+		if e != want[e.Path] {
+			t.Errorf("Unexpected entry: got %+v, want %+v", e, want[e.Path])
+		}
+	}
+
+	// Nothing is created by planning
+	if entries, _ := os.ReadDir(configManager.GetWorkspacesDir()); len(entries) != 0 {
+		t.Errorf("Expected no workspaces, found %d", len(entries))
+	}
+
+	ws, err := manager.Create(context.Background(), workspace.CreateOptions{Name: "bootstrap"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if len(ws.Copied) != len(want) {
+		t.Errorf("Expected %d copied entries, got %+v", len(want), ws.Copied)
+	}
+
+	data, err := os.ReadFile(filepath.Join(ws.Path, ".env"))
+	if err != nil || string(data) != files[".env"] {
+		t.Errorf("Expected .env to be copied, got %q (%v)", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(ws.Path, ".env.local")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected .env.local to keep its permissions: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(ws.Path, "node_modules")); err != nil || link != filepath.Join(repoDir, "node_modules") {
+		t.Errorf("Expected node_modules to be linked, got %q (%v)", link, err)
+	}
+	src, _ := os.Stat(filepath.Join(repoDir, "cache/data/blob"))
+	dst, err := os.Stat(filepath.Join(ws.Path, "cache/data/blob"))
+	if err != nil || !os.SameFile(src, dst) {
+		t.Errorf("Expected cache to be hard linked: %v", err)
+	}
+}
+
+func TestManager_PlanCreate_InvalidPattern(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	cfg := config.DefaultConfig()
+	cfg.Workspace.Copy = []config.CopyRule{{Pattern: "../outside"}}
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	if _, err := manager.PlanCreate(workspace.CreateOptions{Name: "outside"}); err == nil {
+		t.Error("Expected an error for a pattern outside the project")
+	}
+	if _, err := manager.Create(context.Background(), workspace.CreateOptions{Name: "outside"}); err == nil {
+		t.Error("Expected workspace creation to fail")
+	}
+
+	// Nothing is left behind by the failed creation
+	dirs, err := os.ReadDir(filepath.Join(repoDir, ".amux", "workspaces"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to read workspaces directory: %v", err)
+	}
+	if len(dirs) != 0 {
+		t.Errorf("Expected no workspace directories, got %d", len(dirs))
+	}
+}
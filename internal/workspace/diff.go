@@ -0,0 +1,63 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aki/amux/internal/git"
+)
+
+// Diff summarizes the changes of a workspace relative to its base branch,
+// both committed on the workspace branch and uncommitted in its worktree
+func (m *Manager) Diff(ctx context.Context, identifier Identifier) (*git.DiffSummary, error) {
+	ws, err := m.diffableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return git.NewOperations(ws.Path).Diff(ws.BaseBranch)
+}
+
+// DiffPatch returns the patch of a workspace relative to its base branch,
+// optionally limited to paths
+func (m *Manager) DiffPatch(ctx context.Context, identifier Identifier, paths ...string) (string, error) {
+	ws, err := m.diffableWorkspace(ctx, identifier)
+	if err != nil {
+		return "", err
+	}
+	return git.NewOperations(ws.Path).DiffPatch(ws.BaseBranch, paths...)
+}
+
+// diffableWorkspace resolves a workspace whose worktree can be diffed
+func (m *Manager) diffableWorkspace(ctx context.Context, identifier Identifier) (*Workspace, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Status != StatusConsistent {
+		return nil, fmt.Errorf("workspace %s is %s", ws.Name, ws.Status)
+	}
+	if ws.BaseBranch == "" {
+		return nil, fmt.Errorf("workspace %s has no base branch", ws.Name)
+	}
+	return ws, nil
+}
+
+// changeSummary summarizes the changes of a workspace, or returns nil if its
+// worktree cannot be diffed
+func changeSummary(ws *Workspace) *ChangeSummary {
+	if ws.Status != StatusConsistent || ws.BaseBranch == "" {
+		return nil
+	}
+	diff, err := git.NewOperations(ws.Path).Diff(ws.BaseBranch)
+	if err != nil {
+		return nil
+	}
+	return &ChangeSummary{
+		Ahead:            diff.Ahead,
+		Behind:           diff.Behind,
+		Files:            len(diff.Files),
+		Insertions:       diff.Insertions,
+		Deletions:        diff.Deletions,
+		UncommittedFiles: diff.UncommittedFiles,
+	}
+}
@@ -1,6 +1,9 @@
 package workspace
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrNotFound is returned when a workspace is not found
 type ErrNotFound struct {
@@ -19,3 +22,26 @@ type ErrAlreadyExists struct {
 func (e ErrAlreadyExists) Error() string {
 	return fmt.Sprintf("workspace already exists: %s", e.Name)
 }
+
+// ErrWorkspaceBusy is returned when a workspace's concurrency policy has no
+// free slot for another session
+type ErrWorkspaceBusy struct {
+	Name        string
+	Concurrency string
+	Holders     []string
+}
+
+func (e *ErrWorkspaceBusy) Error() string {
+	return fmt.Sprintf("workspace '%s' is busy (concurrency: %s, held by %s)", e.Name, e.Concurrency, strings.Join(e.Holders, ", "))
+}
+
+// ErrAdoptedWorktree is returned when removing an adopted workspace without
+// force, as its worktree was created outside amux
+type ErrAdoptedWorktree struct {
+	Name string
+	Path string
+}
+
+func (e ErrAdoptedWorktree) Error() string {
+	return fmt.Sprintf("workspace '%s' is an adopted worktree at %s; detach it to keep the worktree, or force removal to delete it", e.Name, e.Path)
+}
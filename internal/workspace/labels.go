@@ -0,0 +1,217 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// labelPattern restricts labels and metadata keys to characters that are safe
+// on the command line and in comma-separated lists
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/-]*$`)
+
+// Sort orders for ListOptions.SortBy
+const (
+	SortByCreated = "created" // Newest first (default)
+	SortByUpdated = "updated" // Most recently modified first
+	SortByName    = "name"
+	SortByBranch  = "branch"
+)
+
+// validateLabel checks that a label or metadata key is well formed
+func validateLabel(kind, label string) error {
+	if !labelPattern.MatchString(label) {
+		return fmt.Errorf("invalid %s '%s': use letters, digits and . _ : / -, starting with a letter or digit", kind, label)
+	}
+	return nil
+}
+
+// normalizeLabels validates labels and returns them sorted without duplicates
+func normalizeLabels(labels []string) ([]string, error) {
+	for _, label := range labels {
+		if err := validateLabel("label", label); err != nil {
+			return nil, err
+		}
+	}
+	labels = slices.Clone(labels)
+	slices.Sort(labels)
+	return slices.Compact(labels), nil
+}
+
+// validateMetadata checks that all metadata keys are well formed
+func validateMetadata(metadata map[string]string) error {
+	for key := range metadata {
+		if err := validateLabel("metadata key", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseMetadata parses key=value pairs into values to set, and key- entries
+// into keys to unset
+func ParseMetadata(pairs []string) (set map[string]string, unset []string, err error) {
+	set = make(map[string]string)
+	for _, pair := range pairs {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			set[key] = value
+		} else if key, ok := strings.CutSuffix(pair, "-"); ok && key != "" {
+			unset = append(unset, key)
+		} else {
+			return nil, nil, fmt.Errorf("invalid metadata '%s': expected key=value or key-", pair)
+		}
+	}
+	return set, unset, nil
+}
+
+// ParseAge parses an age such as 30m, 12h or 7d
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s': use a duration such as 30m, 12h or 7d", s)
+	}
+	return d, nil
+}
+
+// SetLabels adds and removes labels on a workspace and returns it
+func (m *Manager) SetLabels(ctx context.Context, identifier Identifier, add, remove []string) (*Workspace, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := normalizeLabels(append(slices.Clone(ws.Labels), add...))
+	if err != nil {
+		return nil, err
+	}
+	ws.Labels = slices.DeleteFunc(labels, func(label string) bool {
+		return slices.Contains(remove, label)
+	})
+	if len(ws.Labels) == 0 {
+		ws.Labels = nil
+	}
+
+	if err := m.saveWorkspace(ctx, ws); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// SetMetadata sets and unsets metadata entries on a workspace and returns it
+func (m *Manager) SetMetadata(ctx context.Context, identifier Identifier, set map[string]string, unset []string) (*Workspace, error) {
+	if err := validateMetadata(set); err != nil {
+		return nil, err
+	}
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if ws.Metadata == nil {
+		ws.Metadata = make(map[string]string, len(set))
+	}
+	for key, value := range set {
+		ws.Metadata[key] = value
+	}
+	for _, key := range unset {
+		delete(ws.Metadata, key)
+	}
+	if len(ws.Metadata) == 0 {
+		ws.Metadata = nil
+	}
+
+	if err := m.saveWorkspace(ctx, ws); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// HasLabels reports whether the workspace has all of the given labels
+func (w *Workspace) HasLabels(labels ...string) bool {
+	for _, label := range labels {
+		if !slices.Contains(w.Labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks the filter and sort settings
+func (o ListOptions) validate() error {
+	if o.Branch != "" {
+		if _, err := path.Match(o.Branch, ""); err != nil {
+			return fmt.Errorf("invalid branch pattern '%s': %w", o.Branch, err)
+		}
+	}
+	switch o.SortBy {
+	case "", SortByCreated, SortByUpdated, SortByName, SortByBranch:
+		return nil
+	default:
+		return fmt.Errorf("invalid sort '%s' (must be %s, %s, %s or %s)", o.SortBy, SortByCreated, SortByUpdated, SortByName, SortByBranch)
+	}
+}
+
+// matches reports whether a workspace passes the filters
+func (o ListOptions) matches(ws *Workspace, now time.Time) bool {
+	if !ws.HasLabels(o.Labels...) {
+		return false
+	}
+	for key, value := range o.Metadata {
+		if v, ok := ws.Metadata[key]; !ok || (value != "" && v != value) {
+			return false
+		}
+	}
+	if len(o.Statuses) > 0 && !slices.Contains(o.Statuses, ws.Status) {
+		return false
+	}
+	if o.Branch != "" {
+		if ok, _ := path.Match(o.Branch, ws.Branch); !ok {
+			return false
+		}
+	}
+	if o.OlderThan > 0 && now.Sub(ws.UpdatedAt) < o.OlderThan {
+		return false
+	}
+	if o.NewerThan > 0 && now.Sub(ws.UpdatedAt) > o.NewerThan {
+		return false
+	}
+	if o.InUse != nil && (ws.SessionCount() > 0) != *o.InUse {
+		return false
+	}
+	return true
+}
+
+// sortWorkspaces orders workspaces as requested by the list options
+func sortWorkspaces(workspaces []*Workspace, by string, reverse bool) {
+	less := func(a, b *Workspace) bool {
+		switch by {
+		case SortByUpdated:
+			return a.UpdatedAt.After(b.UpdatedAt)
+		case SortByName:
+			return a.Name < b.Name
+		case SortByBranch:
+			return a.Branch < b.Branch
+		default:
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	}
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		if reverse {
+			return less(workspaces[j], workspaces[i])
+		}
+		return less(workspaces[i], workspaces[j])
+	})
+}
@@ -0,0 +1,109 @@
+package workspace_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_LabelsAndListFilters(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	auth, err := manager.Create(ctx, workspace.CreateOptions{
+		Name:     "auth",
+		Branch:   "feature/auth",
+		Labels:   []string{"bug", "auth", "bug"},
+		Metadata: map[string]string{"issue": "142"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if len(auth.Labels) != 2 || auth.Labels[0] != "auth" || auth.Labels[1] != "bug" {
+		t.Errorf("Expected sorted labels without duplicates, got %v", auth.Labels)
+	}
+	docs, err := manager.Create(ctx, workspace.CreateOptions{Name: "docs", Branch: "docs/readme"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	if _, err := manager.Create(ctx, workspace.CreateOptions{Name: "bad", Labels: []string{"has space"}}); err == nil {
+		t.Error("Expected error for an invalid label")
+	}
+
+	// Labels and metadata are persisted
+	if _, err := manager.SetLabels(ctx, workspace.Identifier(docs.Name), []string{"bug", "docs"}, []string{"docs"}); err != nil {
+		t.Fatalf("Failed to label workspace: %v", err)
+	}
+	if _, err := manager.SetMetadata(ctx, workspace.Identifier(auth.Name), map[string]string{"owner": "ana"}, []string{"issue"}); err != nil {
+		t.Fatalf("Failed to annotate workspace: %v", err)
+	}
+	reloaded, err := manager.ResolveWorkspace(ctx, workspace.Identifier(auth.Name))
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace: %v", err)
+	}
+	if len(reloaded.Metadata) != 1 || reloaded.Metadata["owner"] != "ana" {
+		t.Errorf("Unexpected metadata: %v", reloaded.Metadata)
+	}
+
+	names := func(opts workspace.ListOptions) []string {
+		workspaces, err := manager.List(ctx, opts)
+		if err != nil {
+			t.Fatalf("Failed to list workspaces: %v", err)
+		}
+		var names []string
+		for _, ws := range workspaces {
+			names = append(names, ws.Name)
+		}
+		return names
+	}
+	notInUse := false
+
+	tests := []struct {
+		name string
+		opts workspace.ListOptions
+		want []string
+	}{
+		{"label", workspace.ListOptions{Labels: []string{"bug"}, SortBy: workspace.SortByName}, []string{"auth", "docs"}},
+		{"all labels", workspace.ListOptions{Labels: []string{"bug", "auth"}}, []string{"auth"}},
+		{"metadata key", workspace.ListOptions{Metadata: map[string]string{"owner": ""}}, []string{"auth"}},
+		{"metadata value", workspace.ListOptions{Metadata: map[string]string{"owner": "bob"}}, nil},
+		{"branch pattern", workspace.ListOptions{Branch: "feature/*"}, []string{"auth"}},
+		{"status", workspace.ListOptions{Statuses: []workspace.ConsistencyStatus{workspace.StatusOrphaned}}, nil},
+		{"older than", workspace.ListOptions{OlderThan: time.Hour}, nil},
+		{"newer than", workspace.ListOptions{NewerThan: time.Hour, SortBy: workspace.SortByName, Reverse: true}, []string{"docs", "auth"}},
+		{"not in use", workspace.ListOptions{InUse: &notInUse, SortBy: workspace.SortByBranch}, []string{"docs", "auth"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := manager.List(ctx, workspace.ListOptions{SortBy: "size"}); err == nil {
+		t.Error("Expected error for an unknown sort order")
+	}
+	if d, err := workspace.ParseAge("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("Expected 7 days, got %v (%v)", d, err)
+	}
+}
@@ -0,0 +1,143 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+)
+
+// templatePlaceholder matches placeholders such as {{name}} in the
+// workspace.worktreePath and workspace.branchName templates
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z]+)\s*\}\}`)
+
+// uniquePlaceholder matches the placeholders that tell workspaces apart
+var uniquePlaceholder = regexp.MustCompile(`\{\{\s*(name|id)\s*\}\}`)
+
+// workspaceConfig returns the workspace section of the project configuration
+func (m *Manager) workspaceConfig() (config.WorkspaceConfig, error) {
+	if !m.configManager.IsInitialized() {
+		return config.WorkspaceConfig{}, nil
+	}
+	cfg, err := m.configManager.Load()
+	if err != nil {
+		return config.WorkspaceConfig{}, err
+	}
+	return cfg.Workspace, nil
+}
+
+// expandTemplate replaces the {{repo}}, {{name}}, {{id}} and {{user}}
+// placeholders in a template
+func (m *Manager) expandTemplate(tmpl, name, id string) (string, error) {
+	vars := map[string]string{
+		"repo": filepath.Base(m.configManager.GetProjectRoot()),
+		"name": name,
+		"id":   id,
+	}
+	var err error
+	result := templatePlaceholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		key := templatePlaceholder.FindStringSubmatch(match)[1]
+		if key == "user" {
+			return currentUser()
+		}
+		value, ok := vars[key]
+		if !ok && err == nil {
+			err = fmt.Errorf("unknown placeholder %s in '%s' (use {{repo}}, {{name}}, {{id}} or {{user}})", match, tmpl)
+		}
+		return value
+	})
+	return result, err
+}
+
+// currentUser returns the login name of the current user
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// Drop the domain of Windows accounts (DOMAIN\user)
+		return u.Username[strings.LastIndex(u.Username, `\`)+1:]
+	}
+	return os.Getenv("USER")
+}
+
+// worktreePath returns where the worktree of a new workspace goes. Without a
+// workspace.worktreePath template it is inside the workspace directory. The
+// part of the template before the first placeholder is fixed by the
+// configuration; whatever the workspace name, the path must stay below it.
+func (m *Manager) worktreePath(name, id string) (string, error) {
+	cfg, err := m.workspaceConfig()
+	if err != nil {
+		return "", err
+	}
+	tmpl := cfg.WorktreePath
+	if tmpl == "" {
+		return filepath.Join(m.workspacesDir, id, "worktree"), nil
+	}
+	if !uniquePlaceholder.MatchString(tmpl) {
+		return "", fmt.Errorf("invalid workspace.worktreePath '%s': must contain {{name}} or {{id}}", tmpl)
+	}
+
+	expanded, err := m.expandTemplate(tmpl, name, id)
+	if err != nil {
+		return "", fmt.Errorf("invalid workspace.worktreePath: %w", err)
+	}
+	// The directory part of the fixed prefix, e.g. ../ for ../{{repo}}-wt/{{name}}
+	root := filepath.Dir(tmpl[:strings.Index(tmpl, "{{")] + "_")
+	root, err = m.absPath(root)
+	if err != nil {
+		return "", err
+	}
+	path, err := m.absPath(expanded)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return "", fmt.Errorf("invalid worktree path '%s' for workspace '%s'", path, name)
+	}
+	if err := git.ValidateWorktreePath(root, rel); err != nil {
+		return "", fmt.Errorf("invalid worktree path '%s' for workspace '%s': %w", path, name, err)
+	}
+	return path, nil
+}
+
+// absPath resolves a path from the configuration against the project root,
+// expanding a leading ~ to the home directory
+func (m *Manager) absPath(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || os.IsPathSeparator(rest[0])) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		path = home + rest
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.configManager.GetProjectRoot(), path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// defaultBranchName returns the branch name for a new workspace created
+// without one, from the workspace.branchName template or else amux/<id>
+func (m *Manager) defaultBranchName(name, id string) (string, error) {
+	cfg, err := m.workspaceConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.BranchName == "" {
+		return fmt.Sprintf("amux/%s", id), nil
+	}
+
+	branch, err := m.expandTemplate(cfg.BranchName, name, id)
+	if err != nil {
+		return "", fmt.Errorf("invalid workspace.branchName: %w", err)
+	}
+	if err := m.gitOps.ValidateBranchName(branch); err != nil {
+		return "", fmt.Errorf("workspace.branchName '%s' gives %w", cfg.BranchName, err)
+	}
+	return branch, nil
+}
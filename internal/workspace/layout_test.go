@@ -0,0 +1,121 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_WorktreeAndBranchTemplates(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	cfg := config.DefaultConfig()
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	// Created before the templates are configured
+	legacy, err := manager.Create(ctx, workspace.CreateOptions{Name: "legacy"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	worktreeRoot := repoDir + "-worktrees"
+	t.Cleanup(func() { _ = os.RemoveAll(worktreeRoot) })
+	cfg.Workspace.WorktreePath = "../{{repo}}-worktrees/{{name}}"
+	cfg.Workspace.BranchName = "wip/{{ name }}"
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "fix-auth"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if ws.Path != filepath.Join(worktreeRoot, "fix-auth") {
+		t.Errorf("Expected worktree under %s, got %s", worktreeRoot, ws.Path)
+	}
+	if ws.Branch != "wip/fix-auth" {
+		t.Errorf("Expected branch wip/fix-auth, got %s", ws.Branch)
+	}
+	if !strings.HasPrefix(ws.StoragePath, configManager.GetWorkspacesDir()) {
+		t.Errorf("Expected storage to stay under .amux, got %s", ws.StoragePath)
+	}
+
+	// Session bookkeeping stays with the workspace metadata, not next to the worktree
+	if err := ws.Acquire("session-1"); err != nil {
+		t.Fatalf("Failed to acquire workspace: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktreeRoot, "semaphore.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected no semaphore next to the worktree: %v", err)
+	}
+	if err := ws.Release("session-1"); err != nil {
+		t.Fatalf("Failed to release workspace: %v", err)
+	}
+
+	if _, err := manager.Create(ctx, workspace.CreateOptions{Name: "../../escape", Branch: "escape"}); err == nil {
+		t.Error("Expected error for a name that escapes the worktree root")
+	}
+
+	resolved, err := manager.ResolveWorkspace(ctx, workspace.Identifier(legacy.Name))
+	if err != nil {
+		t.Fatalf("Failed to resolve existing workspace: %v", err)
+	}
+	if resolved.Path != legacy.Path || resolved.Status != workspace.StatusConsistent {
+		t.Errorf("Expected existing workspace to keep its worktree, got %s (%s)", resolved.Path, resolved.Status)
+	}
+
+	if err := manager.Remove(ctx, workspace.Identifier(ws.Name), workspace.RemoveOptions{}); err != nil {
+		t.Fatalf("Failed to remove workspace: %v", err)
+	}
+	if _, err := os.Stat(ws.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected worktree to be removed: %v", err)
+	}
+
+	// Folders git lost track of are removed too when placed outside .amux
+	stale, err := manager.Create(ctx, workspace.CreateOptions{Name: "stale"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if err := os.Remove(filepath.Join(stale.Path, ".git")); err != nil {
+		t.Fatalf("Failed to unlink worktree: %v", err)
+	}
+	if output, err := exec.Command("git", "-C", repoDir, "worktree", "prune").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to prune worktrees: %v: %s", err, output)
+	}
+	resolved, err = manager.ResolveWorkspace(ctx, workspace.Identifier(stale.Name))
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace: %v", err)
+	}
+	if resolved.Status != workspace.StatusWorktreeMissing {
+		t.Fatalf("Expected worktree-missing status, got %s", resolved.Status)
+	}
+	if err := manager.Remove(ctx, workspace.Identifier(stale.Name), workspace.RemoveOptions{}); err != nil {
+		t.Fatalf("Failed to remove workspace: %v", err)
+	}
+	if _, err := os.Stat(stale.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected folder of worktree-missing workspace to be removed: %v", err)
+	}
+
+	for _, tmpl := range []string{"../worktrees/fixed", "../{{repo}}/{{branch}}"} {
+		cfg.Workspace.WorktreePath = tmpl
+		if err := configManager.Save(cfg); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		if _, err := manager.Create(ctx, workspace.CreateOptions{Name: "bad"}); err == nil {
+			t.Errorf("Expected error for worktree path template %s", tmpl)
+		}
+	}
+}
@@ -7,12 +7,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/aki/amux/internal/cache"
 	"github.com/aki/amux/internal/config"
 	"github.com/aki/amux/internal/filemanager"
 	"github.com/aki/amux/internal/git"
@@ -27,6 +27,8 @@ type Manager struct {
 	workspacesDir string
 	idMapper      *idmap.Mapper[idmap.WorkspaceID]
 	fm            *filemanager.Manager[Workspace]
+	sessionCheck  SessionCheck
+	logUsage      LogUsage
 }
 
 // NewManager creates a new workspace manager
@@ -76,64 +78,79 @@ func NewManagerWithIDMapper(configManager *config.Manager, idMapper *idmap.Mappe
 
 // Create creates a new workspace
 func (m *Manager) Create(ctx context.Context, opts CreateOptions) (*Workspace, error) {
+	labels, err := normalizeLabels(opts.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(opts.Metadata); err != nil {
+		return nil, err
+	}
+	if opts.Concurrency != "" {
+		if _, err := ParseConcurrency(opts.Concurrency); err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate workspace ID
 	id := generateID(opts.Name)
 
-	// Determine base branch
-	baseBranch := opts.BaseBranch
-	if baseBranch == "" {
-		var err error
-		baseBranch, err = m.gitOps.GetDefaultBranch()
-		if err != nil {
-			return nil, fmt.Errorf("failed to determine base branch: %w", err)
-		}
+	plan, err := m.resolveBranch(opts, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.planSparse(opts, plan); err != nil {
+		return nil, err
 	}
+	branch := plan.Branch
 
-	// Create workspace directory structure
+	// Create workspace directory structure. The worktree goes inside it
+	// unless workspace.worktreePath puts it elsewhere.
 	workspaceDir := filepath.Join(m.workspacesDir, id)
-	worktreePath := filepath.Join(workspaceDir, "worktree")
+	worktreePath, err := m.worktreePath(opts.Name, id)
+	if err != nil {
+		return nil, err
+	}
 
 	// Ensure the workspace directory exists
 	if err := os.MkdirAll(workspaceDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create workspace directory: %w", err)
 	}
 
-	// Determine branch name
-	var branch string
-	if opts.Branch != "" {
-		branch = opts.Branch
-	} else {
-		// Auto-generate branch name
-		branch = fmt.Sprintf("amux/%s", id)
-	}
-
-	// Check if branch exists
-	exists, err := m.gitOps.BranchExists(branch)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check branch existence: %w", err)
-	}
-
 	// Handle branch creation/checkout based on mode
 	switch opts.BranchMode {
 	case BranchModeCreate:
-		// Create new branch (fail if exists)
-		if exists {
-			return nil, fmt.Errorf("cannot create branch '%s': already exists. Use -c to checkout existing branch", branch)
+		if err := m.gitOps.CreateBranch(branch, plan.startPoint()); err != nil {
+			return nil, fmt.Errorf("failed to create worktree with new branch: %w", err)
 		}
-		if err := m.gitOps.CreateWorktree(worktreePath, branch, baseBranch); err != nil {
+		if err := m.addWorktree(worktreePath, branch, false, plan.Sparse); err != nil {
+			_ = m.gitOps.DeleteBranch(branch)
 			return nil, fmt.Errorf("failed to create worktree with new branch: %w", err)
 		}
-	case BranchModeCheckout:
-		// Use existing branch (fail if doesn't exist)
-		if !exists {
-			return nil, fmt.Errorf("cannot checkout '%s': branch does not exist. Use -b to create new branch", branch)
+		if plan.Upstream != "" {
+			if err := m.gitOps.SetUpstream(branch, plan.Upstream); err != nil {
+				slog.Warn("failed to set upstream branch", "branch", branch, "error", err)
+			}
 		}
-		if err := m.gitOps.CreateWorktreeFromExistingBranch(worktreePath, branch); err != nil {
+	case BranchModeCheckout:
+		if err := m.addWorktree(worktreePath, branch, false, plan.Sparse); err != nil {
 			return nil, fmt.Errorf("failed to create worktree from existing branch: %w", err)
 		}
-	default:
-		// Should never happen as BranchModeCreate is the default
-		return nil, fmt.Errorf("invalid branch mode: %v", opts.BranchMode)
+	case BranchModeDetach:
+		if err := m.addWorktree(worktreePath, plan.startPoint(), true, plan.Sparse); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bring in untracked files such as .env before hooks run, so hooks can rely on them
+	copied, err := m.copyIntoWorktree(worktreePath)
+	if err != nil {
+		// Cleanup on failure
+		_ = m.gitOps.RemoveWorktree(worktreePath)
+		_ = os.RemoveAll(workspaceDir)
+		if opts.BranchMode == BranchModeCreate {
+			_ = m.gitOps.DeleteBranch(branch)
+		}
+		return nil, fmt.Errorf("failed to copy files into workspace: %w", err)
 	}
 
 	// Create workspace metadata
@@ -141,12 +158,19 @@ func (m *Manager) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 		ID:          id,
 		Name:        opts.Name,
 		Branch:      branch,
-		BaseBranch:  baseBranch,
+		BaseBranch:  plan.BaseBranch,
+		From:        plan.From,
+		Detached:    plan.Detached,
+		Sparse:      plan.Sparse,
 		Path:        worktreePath,
 		Description: opts.Description,
+		Labels:      labels,
+		Metadata:    opts.Metadata,
 		StoragePath: filepath.Join(workspaceDir, "storage"),
 		CreatedAt:   time.Now(),
 		AutoCreated: opts.AutoCreated,
+		Concurrency: opts.Concurrency,
+		Copied:      copied,
 	}
 
 	// Create storage directory
@@ -190,6 +214,129 @@ func (m *Manager) Create(ctx context.Context, opts CreateOptions) (*Workspace, e
 	return workspace, nil
 }
 
+// PlanCreate checks what Create would do with the given options without
+// creating anything
+func (m *Manager) PlanCreate(opts CreateOptions) (*CreatePlan, error) {
+	plan, err := m.resolveBranch(opts, "", false)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.planSparse(opts, plan); err != nil {
+		return nil, err
+	}
+
+	plan.Copy, err = m.PlanCopy()
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// resolveBranch determines the branch, base branch and starting point of a
+// new workspace and checks that they can be used with the branch mode.
+// Without a branch in the options, a new branch is named after the remote
+// branch it starts from or else after the workspace ID, or left empty if no
+// ID is given.
+func (m *Manager) resolveBranch(opts CreateOptions, id string, fetch bool) (*CreatePlan, error) {
+	plan := &CreatePlan{Name: opts.Name, BaseBranch: opts.BaseBranch}
+
+	// Determine base branch
+	if plan.BaseBranch == "" {
+		var err error
+		plan.BaseBranch, err = m.gitOps.GetDefaultBranch()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine base branch: %w", err)
+		}
+	}
+
+	// Determine the starting point, fetching remote branches that haven't been
+	// fetched yet. Without fetch, such branches are only marked as needing one.
+	if opts.From != "" {
+		if opts.BranchMode == BranchModeCheckout {
+			return nil, fmt.Errorf("cannot use --from with -c: an existing branch is checked out as it is")
+		}
+		commit, err := m.gitOps.ResolveCommit(opts.From)
+		if err != nil {
+			remote, remoteBranch, ok := m.gitOps.RemoteBranch(opts.From)
+			if !ok {
+				return nil, fmt.Errorf("cannot start from %s: %w", opts.From, err)
+			}
+			if fetch {
+				if err := m.gitOps.FetchBranch(remote, remoteBranch); err != nil {
+					return nil, fmt.Errorf("cannot start from %s: %w", opts.From, err)
+				}
+				if commit, err = m.gitOps.ResolveCommit(opts.From); err != nil {
+					return nil, fmt.Errorf("cannot start from %s: %w", opts.From, err)
+				}
+			} else {
+				plan.NeedsFetch = true
+			}
+		}
+		plan.From = opts.From
+		plan.Commit = commit
+	}
+
+	switch opts.BranchMode {
+	case BranchModeCreate:
+		// Branches started from a remote branch track it and are named after it
+		branch := opts.Branch
+		if opts.From != "" && (plan.NeedsFetch || m.gitOps.IsRemoteBranch(opts.From)) {
+			plan.Upstream = strings.TrimPrefix(opts.From, "refs/remotes/")
+			if branch == "" {
+				_, branch, _ = m.gitOps.RemoteBranch(opts.From)
+			}
+		}
+		if branch == "" && id != "" {
+			// Auto-generate branch name
+			var err error
+			if branch, err = m.defaultBranchName(opts.Name, id); err != nil {
+				return nil, err
+			}
+		}
+		plan.Branch = branch
+		if branch == "" {
+			break
+		}
+
+		// Create new branch (fail if exists). A tracking branch only clashes
+		// with a local branch, as the remote branch it tracks exists by definition.
+		exists := false
+		if plan.Upstream != "" {
+			_, err := m.gitOps.ResolveCommit("refs/heads/" + branch)
+			exists = err == nil
+		} else {
+			var err error
+			exists, err = m.gitOps.BranchExists(branch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check branch existence: %w", err)
+			}
+		}
+		if exists {
+			return nil, fmt.Errorf("cannot create branch '%s': already exists. Use -c to checkout existing branch", branch)
+		}
+	case BranchModeCheckout:
+		// Use existing branch (fail if doesn't exist)
+		plan.Branch = opts.Branch
+		exists, err := m.gitOps.BranchExists(opts.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check branch existence: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("cannot checkout '%s': branch does not exist. Use -b to create new branch", opts.Branch)
+		}
+	case BranchModeDetach:
+		if opts.Branch != "" {
+			return nil, fmt.Errorf("cannot use a branch with a detached workspace")
+		}
+		plan.Detached = true
+	default:
+		// Should never happen as BranchModeCreate is the default
+		return nil, fmt.Errorf("invalid branch mode: %v", opts.BranchMode)
+	}
+
+	return plan, nil
+}
+
 // Get retrieves a workspace by its full ID
 func (m *Manager) Get(ctx context.Context, id ID) (*Workspace, error) {
 	workspaceMetaPath := filepath.Join(m.workspacesDir, string(id), "workspace.yaml")
@@ -213,11 +360,17 @@ func (m *Manager) Get(ctx context.Context, id ID) (*Workspace, error) {
 	// Check consistency status
 	m.CheckConsistency(workspace)
 
+	workspace.sessionCheck = m.sessionCheck
+
 	return workspace, nil
 }
 
 // List returns all workspaces
 func (m *Manager) List(ctx context.Context, opts ListOptions) ([]*Workspace, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	// Ensure workspaces directory exists
 	if err := os.MkdirAll(m.workspacesDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create workspaces directory: %w", err)
@@ -230,6 +383,7 @@ func (m *Manager) List(ctx context.Context, opts ListOptions) ([]*Workspace, err
 
 	var workspaces []*Workspace
 	var existingIDs []string
+	now := time.Now()
 	for _, file := range files {
 		if !file.IsDir() {
 			continue
@@ -257,6 +411,16 @@ func (m *Manager) List(ctx context.Context, opts ListOptions) ([]*Workspace, err
 		// Check consistency status
 		m.CheckConsistency(&workspace)
 
+		workspace.sessionCheck = m.sessionCheck
+
+		if !opts.matches(&workspace, now) {
+			continue
+		}
+
+		if opts.IncludeChanges {
+			workspace.Changes = changeSummary(&workspace)
+		}
+
 		workspaces = append(workspaces, &workspace)
 	}
 
@@ -276,10 +440,7 @@ func (m *Manager) List(ctx context.Context, opts ListOptions) ([]*Workspace, err
 		_ = orphanedCount // Reference to satisfy linter
 	}
 
-	// Sort by creation time (newest first)
-	sort.Slice(workspaces, func(i, j int) bool {
-		return workspaces[i].CreatedAt.After(workspaces[j].CreatedAt)
-	})
+	sortWorkspaces(workspaces, opts.SortBy, opts.Reverse)
 
 	return workspaces, nil
 }
@@ -367,6 +528,11 @@ func (m *Manager) Remove(ctx context.Context, identifier Identifier, opts Remove
 		return err
 	}
 
+	// Adopted worktrees were created by the user and may hold their work
+	if workspace.Adopted && !opts.Force {
+		return ErrAdoptedWorktree{Name: workspace.Name, Path: workspace.Path}
+	}
+
 	// Safety check: prevent removing workspace while working inside it
 	if !opts.SkipSafetyCheck && opts.CurrentDir != "" {
 		if err := m.checkCurrentDirectorySafety(workspace.Path, opts.CurrentDir); err != nil {
@@ -385,6 +551,7 @@ func (m *Manager) Remove(ctx context.Context, identifier Identifier, opts Remove
 
 	// Check consistency to determine the right cleanup approach
 	m.CheckConsistency(workspace)
+	workspaceDir := filepath.Join(m.workspacesDir, workspace.ID)
 
 	// Handle different inconsistency cases
 	switch workspace.Status {
@@ -398,8 +565,15 @@ func (m *Manager) Remove(ctx context.Context, identifier Identifier, opts Remove
 		// Need to prune the worktree reference
 		_ = m.gitOps.PruneWorktrees()
 	case StatusWorktreeMissing:
-		// Case 2: Git worktree removed but folder exists
-		// Nothing special needed, will clean up folder below
+		// Case 2: Git worktree removed but folder exists. Folders inside the
+		// workspace directory are cleaned up below; those placed elsewhere
+		// by workspace.worktreePath are removed here, as git no longer
+		// tracks them. The project itself is never removed.
+		if !pathWithin(workspaceDir, workspace.Path) && !pathWithin(workspace.Path, m.configManager.GetProjectRoot()) {
+			if err := os.RemoveAll(workspace.Path); err != nil {
+				return fmt.Errorf("failed to remove workspace folder: %w", err)
+			}
+		}
 	case StatusOrphaned:
 		// Both are missing, just clean up metadata
 	}
@@ -412,20 +586,30 @@ func (m *Manager) Remove(ctx context.Context, identifier Identifier, opts Remove
 		}
 	}
 
-	// Delete branch
-	if err := m.gitOps.DeleteBranch(workspace.Branch); err != nil {
-		// If branch doesn't exist or is checked out in a non-existent worktree, continue
-		if !strings.Contains(err.Error(), "not found") &&
-			!strings.Contains(err.Error(), "checked out at") {
-			return fmt.Errorf("failed to delete branch: %w", err)
+	// Delete branch (detached workspaces have none, and adopted ones keep theirs)
+	if workspace.Branch != "" && !workspace.Adopted {
+		if err := m.gitOps.DeleteBranch(workspace.Branch); err != nil {
+			// If branch doesn't exist or is checked out in a non-existent worktree, continue
+			if !strings.Contains(err.Error(), "not found") &&
+				!strings.Contains(err.Error(), "checked out at") {
+				return fmt.Errorf("failed to delete branch: %w", err)
+			}
 		}
 	}
 
+	// Drop checkpoints and session snapshots, which would otherwise keep their
+	// commits alive
+	if err := m.gitOps.DeleteRefs(checkpointRefPrefix(workspace.ID)); err != nil {
+		slog.Warn("failed to delete workspace checkpoints", "workspace", workspace.ID, "error", err)
+	}
+	if err := m.gitOps.DeleteRefs(sessionSnapshotRefPrefix(workspace.ID)); err != nil {
+		slog.Warn("failed to delete session snapshots", "workspace", workspace.ID, "error", err)
+	}
+
 	// Remove index mapping
 	_ = m.idMapper.Remove(idmap.WorkspaceID(workspace.ID))
 
 	// Clean up entire workspace directory (which contains worktree and workspace.yaml)
-	workspaceDir := filepath.Join(m.workspacesDir, workspace.ID)
 	if _, err := os.Stat(workspaceDir); err == nil {
 		if err := os.RemoveAll(workspaceDir); err != nil {
 			return fmt.Errorf("failed to remove workspace directory: %w", err)
@@ -435,30 +619,17 @@ func (m *Manager) Remove(ctx context.Context, identifier Identifier, opts Remove
 	return nil
 }
 
-// Cleanup removes old workspaces based on last modified time
-func (m *Manager) Cleanup(ctx context.Context, opts CleanupOptions) ([]string, error) {
-	workspaces, err := m.List(ctx, ListOptions{})
-	if err != nil {
-		return nil, err
+// ArchiveOnPrune reports whether the configuration asks for old workspaces to
+// be archived rather than removed when pruning
+func (m *Manager) ArchiveOnPrune() bool {
+	if !m.configManager.IsInitialized() {
+		return false
 	}
-
-	cutoff := time.Now().AddDate(0, 0, -opts.Days)
-	var removed []string
-
-	for _, workspace := range workspaces {
-		if workspace.UpdatedAt.Before(cutoff) {
-			if !opts.DryRun {
-				if err := m.Remove(ctx, Identifier(workspace.ID), RemoveOptions{NoHooks: false}); err != nil {
-					// Log error but continue with other workspaces
-					fmt.Fprintf(os.Stderr, "Failed to remove workspace %s: %v\n", workspace.ID, err)
-					continue
-				}
-			}
-			removed = append(removed, workspace.ID)
-		}
+	cfg, err := m.configManager.Load()
+	if err != nil {
+		return false
 	}
-
-	return removed, nil
+	return cfg.Workspace.Prune == config.PruneArchive
 }
 
 // CheckConsistency checks the consistency status of a workspace
@@ -471,47 +642,7 @@ func (m *Manager) CheckConsistency(workspace *Workspace) {
 	}
 
 	// Check if git worktree exists
-	worktrees, err := m.gitOps.ListWorktrees()
-	workspace.WorktreeExists = false
-	if err == nil {
-		for _, wt := range worktrees {
-			// Normalize paths for comparison
-			wtPath := filepath.Clean(wt.Path)
-			wsPath := filepath.Clean(workspace.Path)
-
-			// Try to resolve symlinks for both paths to handle macOS /var -> /private/var
-			// We need to resolve even non-existent paths by resolving their parent directories
-			wtPathResolved := wtPath
-			wsPathResolved := wsPath
-
-			// For worktree path
-			if resolvedWt, err := filepath.EvalSymlinks(wtPath); err == nil {
-				wtPathResolved = resolvedWt
-			} else {
-				// If path doesn't exist, try to resolve parent directory
-				wtDir := filepath.Dir(wtPath)
-				if resolvedDir, err := filepath.EvalSymlinks(wtDir); err == nil {
-					wtPathResolved = filepath.Join(resolvedDir, filepath.Base(wtPath))
-				}
-			}
-
-			// For workspace path
-			if resolvedWs, err := filepath.EvalSymlinks(wsPath); err == nil {
-				wsPathResolved = resolvedWs
-			} else {
-				// If path doesn't exist, try to resolve parent directory
-				wsDir := filepath.Dir(wsPath)
-				if resolvedDir, err := filepath.EvalSymlinks(wsDir); err == nil {
-					wsPathResolved = filepath.Join(resolvedDir, filepath.Base(wsPath))
-				}
-			}
-
-			if wtPathResolved == wsPathResolved {
-				workspace.WorktreeExists = true
-				break
-			}
-		}
-	}
+	workspace.WorktreeExists = m.findWorktree(workspace.Path) != nil
 
 	// Determine status based on existence flags
 	if workspace.PathExists && workspace.WorktreeExists {
@@ -525,6 +656,41 @@ func (m *Manager) CheckConsistency(workspace *Workspace) {
 	}
 }
 
+// findWorktree returns the git worktree registered at path, if any
+func (m *Manager) findWorktree(path string) *git.WorktreeInfo {
+	worktrees, err := m.gitOps.ListWorktrees()
+	if err != nil {
+		return nil
+	}
+	wsPath := resolvePath(path)
+	for _, wt := range worktrees {
+		if resolvePath(wt.Path) == wsPath {
+			return wt
+		}
+	}
+	return nil
+}
+
+// resolvePath normalizes a path for comparison, resolving symlinks to handle
+// macOS /var -> /private/var. Paths that don't exist are resolved through
+// their parent directory.
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if resolvedDir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(resolvedDir, filepath.Base(path))
+	}
+	return path
+}
+
+// pathWithin reports whether path is dir or lies below it
+func pathWithin(dir, path string) bool {
+	rel, err := filepath.Rel(resolvePath(dir), resolvePath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // saveWorkspace saves workspace metadata to disk with file locking
 func (m *Manager) saveWorkspace(ctx context.Context, workspace *Workspace) error {
 	// Save workspace.yaml inside the workspace directory (not in the worktree)
@@ -578,6 +744,17 @@ func (m *Manager) executeHooks(ctx context.Context, ws *Workspace, event hooks.E
 		"AMUX_PROJECT_ROOT":          m.configManager.GetProjectRoot(),
 		"AMUX_CONFIG_DIR":            configDir,
 	}
+	for k, v := range PortEnvironment(ws.Ports) {
+		env[k] = v
+	}
+	// Hooks installing dependencies fill the shared caches
+	cacheEnv, err := cache.NewManager(m.configManager).Environment()
+	if err != nil {
+		return err
+	}
+	for k, v := range cacheEnv {
+		env[k] = v
+	}
 
 	// Execute hooks in workspace directory
 	executor := hooks.NewExecutor(configDir, env).WithWorkingDir(ws.Path)
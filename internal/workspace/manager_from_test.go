@@ -0,0 +1,153 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_CreateFrom(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	commit := func(dir, file string) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		git(dir, "add", file)
+		git(dir, "commit", "-m", "Add "+file)
+	}
+
+	// Tag a release, then move on
+	git(repoDir, "tag", "v1.0")
+	release := git(repoDir, "rev-parse", "v1.0")
+	commit(repoDir, "next.txt")
+
+	// A bare repository serves as the remote, with a branch that hasn't been fetched
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	git(repoDir, "init", "--bare", remoteDir)
+	git(repoDir, "remote", "add", "origin", remoteDir)
+	git(repoDir, "push", "origin", "main")
+	git(repoDir, "checkout", "-b", "feature/login")
+	commit(repoDir, "login.txt")
+	git(repoDir, "push", "origin", "feature/login")
+	git(repoDir, "checkout", "main")
+	git(repoDir, "branch", "-D", "feature/login")
+	git(repoDir, "update-ref", "-d", "refs/remotes/origin/feature/login")
+
+	t.Run("tag", func(t *testing.T) {
+		ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "from-tag", From: "v1.0"})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		if !strings.HasPrefix(ws.Branch, "amux/") || ws.From != "v1.0" || ws.BaseBranch != "main" {
+			t.Errorf("Unexpected workspace: %+v", ws)
+		}
+		if head := git(ws.Path, "rev-parse", "HEAD"); head != release {
+			t.Errorf("Expected HEAD at %s, got %s", release, head)
+		}
+	})
+
+	t.Run("remote branch", func(t *testing.T) {
+		plan, err := manager.PlanCreate(workspace.CreateOptions{Name: "review", From: "origin/feature/login"})
+		if err != nil {
+			t.Fatalf("Failed to plan: %v", err)
+		}
+		if plan.Branch != "feature/login" || plan.Upstream != "origin/feature/login" || !plan.NeedsFetch || plan.Commit != "" {
+			t.Errorf("Unexpected plan: %+v", plan)
+		}
+		// Planning doesn't fetch
+		if out, err := exec.Command("git", "-C", repoDir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/feature/login").CombinedOutput(); err == nil {
+			t.Errorf("Expected the remote branch not to be fetched by the plan, got %s", out)
+		}
+
+		ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "review", From: "origin/feature/login"})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		if ws.Branch != "feature/login" {
+			t.Errorf("Expected local branch feature/login, got %s", ws.Branch)
+		}
+		if upstream := git(ws.Path, "rev-parse", "--abbrev-ref", "@{upstream}"); upstream != "origin/feature/login" {
+			t.Errorf("Expected upstream origin/feature/login, got %s", upstream)
+		}
+		if _, err := os.Stat(filepath.Join(ws.Path, "login.txt")); err != nil {
+			t.Errorf("Expected remote branch contents: %v", err)
+		}
+
+		// Once fetched, the plan resolves the commit
+		plan, err = manager.PlanCreate(workspace.CreateOptions{Name: "review-plan", Branch: "review-plan", From: "origin/feature/login"})
+		if err != nil {
+			t.Fatalf("Failed to plan: %v", err)
+		}
+		if plan.NeedsFetch || plan.Commit == "" {
+			t.Errorf("Expected a resolved commit, got %+v", plan)
+		}
+
+		// The local branch exists now
+		if _, err := manager.Create(ctx, workspace.CreateOptions{Name: "review-again", From: "origin/feature/login"}); err == nil {
+			t.Error("Expected error creating an existing tracking branch")
+		}
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		ws, err := manager.Create(ctx, workspace.CreateOptions{
+			Name:       "investigate",
+			From:       release,
+			BranchMode: workspace.BranchModeDetach,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		if !ws.Detached || ws.Branch != "" {
+			t.Errorf("Expected detached workspace without branch: %+v", ws)
+		}
+		if head := git(ws.Path, "rev-parse", "--abbrev-ref", "HEAD"); head != "HEAD" {
+			t.Errorf("Expected detached HEAD, got %s", head)
+		}
+		if _, err := manager.Merge(ctx, workspace.Identifier(ws.Name), workspace.MergeOptions{}); err == nil {
+			t.Error("Expected error merging a detached workspace")
+		}
+		if err := manager.Remove(ctx, workspace.Identifier(ws.Name), workspace.RemoveOptions{}); err != nil {
+			t.Errorf("Failed to remove detached workspace: %v", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := manager.PlanCreate(workspace.CreateOptions{Name: "bad", From: "no-such-ref"}); err == nil {
+			t.Error("Expected error for an unknown ref")
+		}
+		if _, err := manager.PlanCreate(workspace.CreateOptions{
+			Name:       "bad",
+			Branch:     "main",
+			BranchMode: workspace.BranchModeCheckout,
+			From:       "v1.0",
+		}); err == nil {
+			t.Error("Expected error combining --from with checkout")
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/hooks"
+)
+
+// Merge merges a workspace branch into its base branch in the main
+// repository. The merge happens where the base branch is checked out, or in a
+// temporary worktree if it is not checked out anywhere. A merge that conflicts
+// is undone and its conflicts are returned in the result; the workspace is
+// then left in place so it can be synced and resolved.
+func (m *Manager) Merge(ctx context.Context, identifier Identifier, opts MergeOptions) (*git.MergeResult, error) {
+	ws, err := m.diffableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Detached {
+		return nil, fmt.Errorf("workspace %s is detached and has no branch to merge", ws.Name)
+	}
+
+	// Same semantics as RemoveWithSessionCheck: sessions may still be writing
+	sessionIDs, err := ws.SessionIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active sessions: %w", err)
+	}
+	if len(sessionIDs) > 0 && !opts.Force {
+		return nil, fmt.Errorf("cannot merge workspace '%s' - currently in use by %d session(s)", ws.Name, len(sessionIDs))
+	}
+
+	wsOps := git.NewOperations(ws.Path)
+	if inProgress := wsOps.SyncInProgress(); inProgress != "" {
+		return nil, fmt.Errorf("workspace %s has a %s in progress", ws.Name, inProgress)
+	}
+	dirty, err := wsOps.HasTrackedChanges()
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("workspace %s: %w", ws.Name, git.ErrUncommittedChanges)
+	}
+
+	if opts.Remove && opts.CurrentDir != "" {
+		if err := m.checkCurrentDirectorySafety(ws.Path, opts.CurrentDir); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pre-merge hooks can stop the merge (e.g., failing tests with on_error: fail)
+	if !opts.NoHooks {
+		if err := m.executeHooks(ctx, ws, hooks.EventWorkspacePreMerge); err != nil {
+			return nil, fmt.Errorf("pre-merge hook failed: %w", err)
+		}
+	}
+
+	target, err := m.gitOps.WorktreeOf(ws.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	if target == "" {
+		target = filepath.Join(m.workspacesDir, ".merge-"+ws.ID)
+		if err := m.gitOps.AddWorktree(target, ws.BaseBranch); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := m.gitOps.RemoveWorktree(target); err != nil {
+				slog.Warn("failed to remove temporary merge worktree", "path", target, "error", err)
+			}
+		}()
+	}
+
+	result, err := git.NewOperations(target).Merge(ws.Branch, opts.Mode, opts.Message)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Conflicts) > 0 {
+		return result, nil
+	}
+
+	if !opts.NoHooks {
+		if err := m.executeHooks(ctx, ws, hooks.EventWorkspacePostMerge); err != nil {
+			slog.Error("hook execution failed", "error", err)
+		}
+	}
+
+	if opts.Remove {
+		if err := m.Remove(ctx, Identifier(ws.ID), RemoveOptions{
+			NoHooks:    opts.NoHooks,
+			CurrentDir: opts.CurrentDir,
+		}); err != nil {
+			return result, fmt.Errorf("merged %s but failed to remove workspace: %w", ws.Branch, err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package workspace_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_Merge(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	gitCmd := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	commitFile := func(dir, name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		gitCmd(dir, "add", name)
+		gitCmd(dir, "commit", "-m", "Add "+name)
+	}
+
+	t.Run("into checked out base and remove", func(t *testing.T) {
+		ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "merge-main", BaseBranch: "main"})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		commitFile(ws.Path, "feature.txt")
+
+		// Uncommitted changes are not merged silently
+		if err := os.WriteFile(filepath.Join(ws.Path, "feature.txt"), []byte("dirty\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := manager.Merge(ctx, workspace.Identifier(ws.Name), workspace.MergeOptions{}); !errors.Is(err, git.ErrUncommittedChanges) {
+			t.Fatalf("Expected uncommitted changes error, got %v", err)
+		}
+		gitCmd(ws.Path, "checkout", "feature.txt")
+
+		result, err := manager.Merge(ctx, workspace.Identifier(ws.Name), workspace.MergeOptions{
+			Mode:    git.MergeSquash,
+			Message: "Add feature",
+			Remove:  true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to merge workspace: %v", err)
+		}
+		if result.Base != "main" || result.Commits != 1 || len(result.Conflicts) != 0 {
+			t.Errorf("Unexpected merge result: %+v", result)
+		}
+		if subject := gitCmd(repoDir, "log", "-1", "--format=%s", "main"); subject != "Add feature" {
+			t.Errorf("Expected squash commit on main, got %q", subject)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feature.txt")); err != nil {
+			t.Errorf("Expected merged file in main checkout: %v", err)
+		}
+		if _, err := manager.ResolveWorkspace(ctx, workspace.Identifier(ws.ID)); err == nil {
+			t.Error("Expected workspace to be removed")
+		}
+	})
+
+	t.Run("into base not checked out", func(t *testing.T) {
+		gitCmd(repoDir, "branch", "release", "main")
+		ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "merge-release", BaseBranch: "release"})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		commitFile(ws.Path, "release.txt")
+
+		result, err := manager.Merge(ctx, workspace.Identifier(ws.Name), workspace.MergeOptions{Mode: git.MergeNoFastForward})
+		if err != nil {
+			t.Fatalf("Failed to merge workspace: %v", err)
+		}
+		if result.Base != "release" || result.FastForward {
+			t.Errorf("Unexpected merge result: %+v", result)
+		}
+		if tip := gitCmd(repoDir, "rev-parse", "release"); tip != result.Commit {
+			t.Errorf("Expected release at %s, got %s", result.Commit, tip)
+		}
+		if path, _ := git.NewOperations(repoDir).WorktreeOf("release"); path != "" {
+			t.Errorf("Expected temporary worktree to be removed, found %s", path)
+		}
+		if _, err := manager.ResolveWorkspace(ctx, workspace.Identifier(ws.ID)); err != nil {
+			t.Errorf("Expected workspace to be kept: %v", err)
+		}
+	})
+}
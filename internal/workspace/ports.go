@@ -0,0 +1,138 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/aki/amux/internal/core/port"
+)
+
+// portLockTimeout bounds how long allocation waits for other amux processes
+const portLockTimeout = 10 * time.Second
+
+// ValidatePortName checks that a port name can be turned into an environment variable
+func ValidatePortName(name string) error {
+	return port.ValidateName(name)
+}
+
+// PortEnvName returns the environment variable holding a named port (e.g., web -> AMUX_PORT_WEB)
+func PortEnvName(name string) string {
+	return port.EnvName(name)
+}
+
+// PortEnvironment returns the environment variables for a set of named ports
+func PortEnvironment(ports map[string]int) map[string]string {
+	env := make(map[string]string, len(ports))
+	for name, number := range ports {
+		env[PortEnvName(name)] = fmt.Sprintf("%d", number)
+	}
+	return env
+}
+
+// PortNames returns the names of a set of ports in sorted order
+func PortNames(ports map[string]int) []string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AllocatePorts makes sure the workspace has a port for each name and returns
+// all of its ports. Ports are kept in the workspace metadata, so a workspace
+// gets the same port for a name every time. Ports handed to other workspaces
+// of the project are never reused.
+func (m *Manager) AllocatePorts(ctx context.Context, identifier Identifier, names []string) (map[string]int, error) {
+	if err := port.ValidateNames(names); err != nil {
+		return nil, err
+	}
+
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	// New names must not take the variable of a port the workspace has
+	if err := port.ValidateNames(append(PortNames(ws.Ports), names...)); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range names {
+		if _, ok := ws.Ports[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return ws.Ports, nil
+	}
+
+	// Serialize allocation across amux processes so that two workspaces
+	// cannot pick the same free port
+	lock := flock.New(filepath.Join(m.workspacesDir, ".ports.lock"))
+	lockCtx, cancel := context.WithTimeout(ctx, portLockTimeout)
+	defer cancel()
+	locked, err := lock.TryLockContext(lockCtx, 50*time.Millisecond)
+	if err != nil || !locked {
+		return nil, fmt.Errorf("failed to lock port allocation: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	workspaces, err := m.List(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[int]bool)
+	for _, other := range workspaces {
+		if other.ID == ws.ID {
+			// Re-read under the lock in case another process allocated meanwhile
+			ws = other
+		}
+		for _, number := range other.Ports {
+			taken[number] = true
+		}
+	}
+
+	if ws.Ports == nil {
+		ws.Ports = make(map[string]int)
+	}
+	for _, name := range missing {
+		if _, ok := ws.Ports[name]; ok {
+			continue
+		}
+		number, err := freePort(taken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port %s: %w", name, err)
+		}
+		ws.Ports[name] = number
+		taken[number] = true
+	}
+
+	if err := m.saveWorkspace(ctx, ws); err != nil {
+		return nil, fmt.Errorf("failed to save workspace ports: %w", err)
+	}
+
+	return ws.Ports, nil
+}
+
+// freePort asks the OS for a free TCP port not in taken
+func freePort(taken map[int]bool) (int, error) {
+	for attempt := 0; attempt < 100; attempt++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		_ = l.Close()
+		if !taken[port] {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port found")
+}
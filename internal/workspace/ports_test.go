@@ -0,0 +1,90 @@
+package workspace_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_AllocatePorts(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws1, err := manager.Create(ctx, workspace.CreateOptions{Name: "one"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	ws2, err := manager.Create(ctx, workspace.CreateOptions{Name: "two"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	ports1, err := manager.AllocatePorts(ctx, workspace.Identifier(ws1.ID), []string{"web", "api"})
+	if err != nil {
+		t.Fatalf("Failed to allocate ports: %v", err)
+	}
+	if len(ports1) != 2 || ports1["web"] == 0 || ports1["api"] == 0 || ports1["web"] == ports1["api"] {
+		t.Fatalf("Unexpected ports: %v", ports1)
+	}
+
+	// Ports are persisted and stable
+	again, err := manager.AllocatePorts(ctx, workspace.Identifier("one"), []string{"web"})
+	if err != nil {
+		t.Fatalf("Failed to allocate ports: %v", err)
+	}
+	if again["web"] != ports1["web"] {
+		t.Errorf("Expected stable web port %d, got %d", ports1["web"], again["web"])
+	}
+	reloaded, err := manager.Get(ctx, workspace.ID(ws1.ID))
+	if err != nil {
+		t.Fatalf("Failed to get workspace: %v", err)
+	}
+	if reloaded.Ports["api"] != ports1["api"] {
+		t.Errorf("Expected persisted api port %d, got %d", ports1["api"], reloaded.Ports["api"])
+	}
+
+	// Other workspaces get different ports
+	ports2, err := manager.AllocatePorts(ctx, workspace.Identifier(ws2.ID), []string{"web"})
+	if err != nil {
+		t.Fatalf("Failed to allocate ports: %v", err)
+	}
+	if ports2["web"] == ports1["web"] || ports2["web"] == ports1["api"] {
+		t.Errorf("Expected distinct ports, got %v and %v", ports1, ports2)
+	}
+
+	if _, err := manager.AllocatePorts(ctx, workspace.Identifier(ws1.ID), []string{"bad name"}); err == nil {
+		t.Error("Expected error for invalid port name")
+	}
+
+	// Names sharing an AMUX_PORT_ variable would overwrite each other
+	if _, err := manager.AllocatePorts(ctx, workspace.Identifier(ws1.ID), []string{"web-api", "web_api"}); err == nil {
+		t.Error("Expected error for port names sharing a variable")
+	}
+	if _, err := manager.AllocatePorts(ctx, workspace.Identifier(ws1.ID), []string{"WEB"}); err == nil {
+		t.Error("Expected error for a port name sharing the variable of an allocated port")
+	}
+}
+
+func TestPortEnvName(t *testing.T) {
+	tests := map[string]string{
+		"web":     "AMUX_PORT_WEB",
+		"api-v2":  "AMUX_PORT_API_V2",
+		"db_main": "AMUX_PORT_DB_MAIN",
+	}
+	for name, want := range tests {
+		if got := workspace.PortEnvName(name); got != want {
+			t.Errorf("PortEnvName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
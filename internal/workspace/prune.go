@@ -0,0 +1,185 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/aki/amux/internal/git"
+)
+
+// Cleanup removes or archives the workspaces that meet the given criteria and
+// returns their IDs. Workspaces with running sessions are left alone.
+func (m *Manager) Cleanup(ctx context.Context, opts CleanupOptions) ([]string, error) {
+	plan, err := m.PlanCleanup(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		var ids []string
+		for _, c := range plan {
+			if c.Blocked == "" {
+				ids = append(ids, c.Workspace.ID)
+			}
+		}
+		return ids, nil
+	}
+	return m.ApplyCleanup(ctx, plan), nil
+}
+
+// PlanCleanup lists the workspaces that meet the criteria in opts, with the
+// action prune would take. Workspaces that meet the criteria but are in use
+// are included with the reason they are kept.
+func (m *Manager) PlanCleanup(ctx context.Context, opts CleanupOptions) ([]*PruneCandidate, error) {
+	if opts.Days <= 0 && !opts.Merged && !opts.NoAhead && opts.SessionIdleDays <= 0 &&
+		!opts.AutoCreatedOnly && len(opts.Statuses) == 0 {
+		return nil, fmt.Errorf("no prune criteria given")
+	}
+
+	workspaces, err := m.List(ctx, ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*PruneCandidate
+	for _, ws := range workspaces {
+		reasons, ok := m.pruneReasons(ws, opts)
+		if !ok {
+			continue
+		}
+
+		candidate := &PruneCandidate{Workspace: ws, Action: PruneActionRemove, Reasons: reasons}
+		// Only complete workspaces can be archived
+		if opts.Archive && ws.Status == StatusConsistent {
+			candidate.Action = PruneActionArchive
+		}
+
+		running := opts.Sessions[ws.ID].Running
+		if holders, err := ws.SessionIDs(); err == nil && len(holders) > running {
+			running = len(holders)
+		}
+		switch {
+		case running > 0:
+			candidate.Blocked = fmt.Sprintf("%d running session(s)", running)
+		case ws.Adopted && candidate.Action == PruneActionRemove:
+			// Removing would delete a worktree amux did not create
+			candidate.Blocked = "adopted worktree"
+		}
+
+		plan = append(plan, candidate)
+	}
+	return plan, nil
+}
+
+// ApplyCleanup carries out a plan from PlanCleanup and returns the IDs of the
+// workspaces removed or archived. Blocked candidates are skipped, and failures
+// are reported without stopping the rest.
+func (m *Manager) ApplyCleanup(ctx context.Context, plan []*PruneCandidate) []string {
+	var done []string
+	for _, c := range plan {
+		if c.Blocked != "" {
+			continue
+		}
+		ws := c.Workspace
+
+		if c.Action == PruneActionArchive {
+			if _, err := m.Archive(ctx, Identifier(ws.ID), ArchiveOptions{}); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to archive workspace %s: %v\n", ws.ID, err)
+				continue
+			}
+		} else {
+			// Sessions may have started since the plan was made
+			if err := checkNotInUse(ws, "prune", false); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to remove workspace %s: %v\n", ws.ID, err)
+				continue
+			}
+			if err := m.Remove(ctx, Identifier(ws.ID), RemoveOptions{}); err != nil {
+				// Log error but continue with other workspaces
+				fmt.Fprintf(os.Stderr, "Failed to remove workspace %s: %v\n", ws.ID, err)
+				continue
+			}
+		}
+		done = append(done, ws.ID)
+	}
+	return done
+}
+
+// pruneReasons checks a workspace against the prune criteria, returning a
+// description of each criterion met and whether all of them are
+func (m *Manager) pruneReasons(ws *Workspace, opts CleanupOptions) ([]string, bool) {
+	var reasons []string
+
+	if len(opts.Statuses) > 0 {
+		if !slices.Contains(opts.Statuses, ws.Status) {
+			return nil, false
+		}
+		reasons = append(reasons, ws.Status.String())
+	}
+
+	if opts.AutoCreatedOnly {
+		if !ws.AutoCreated {
+			return nil, false
+		}
+		reasons = append(reasons, "auto-created")
+	}
+
+	if opts.Days > 0 {
+		if !ws.UpdatedAt.Before(time.Now().AddDate(0, 0, -opts.Days)) {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("idle > %dd", opts.Days))
+	}
+
+	if opts.SessionIdleDays > 0 {
+		last := opts.Sessions[ws.ID].LastActive
+		if last.After(time.Now().AddDate(0, 0, -opts.SessionIdleDays)) {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("no sessions in %dd", opts.SessionIdleDays))
+	}
+
+	if opts.Merged || opts.NoAhead {
+		ops, rev, ok := m.pruneRevision(ws)
+		if !ok {
+			return nil, false
+		}
+		// Uncommitted changes would be lost, whatever the branch looks like
+		if ws.PathExists {
+			if dirty, err := git.NewOperations(ws.Path).HasUncommittedChanges(); err != nil || dirty {
+				return nil, false
+			}
+		}
+		if opts.NoAhead {
+			ahead, err := ops.CommitsAhead(rev, ws.BaseBranch)
+			if err != nil || ahead > 0 {
+				return nil, false
+			}
+			reasons = append(reasons, "no commits ahead")
+		}
+		if opts.Merged {
+			merged, err := ops.IsMerged(rev, ws.BaseBranch)
+			if err != nil || !merged {
+				return nil, false
+			}
+			reasons = append(reasons, "merged into "+ws.BaseBranch)
+		}
+	}
+
+	return reasons, true
+}
+
+// pruneRevision returns the git operations and revision to compare with the
+// base branch: the workspace branch, or HEAD of the worktree when detached
+func (m *Manager) pruneRevision(ws *Workspace) (*git.Operations, string, bool) {
+	if ws.Branch != "" {
+		if exists, err := m.gitOps.BranchExists(ws.Branch); err == nil && exists {
+			return m.gitOps, ws.Branch, true
+		}
+	}
+	if ws.Status == StatusConsistent {
+		return git.NewOperations(ws.Path), "HEAD", true
+	}
+	return nil, "", false
+}
@@ -0,0 +1,153 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_PlanCleanup(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	commit := func(dir, file string) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		git(dir, "add", file)
+		git(dir, "commit", "-m", "Add "+file)
+	}
+	create := func(opts workspace.CreateOptions) *workspace.Workspace {
+		ws, err := manager.Create(ctx, opts)
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		return ws
+	}
+
+	untouched := create(workspace.CreateOptions{Name: "untouched"})
+	squashed := create(workspace.CreateOptions{Name: "squashed"})
+	commit(squashed.Path, "a.txt")
+	commit(squashed.Path, "b.txt")
+	git(repoDir, "merge", "--squash", squashed.Branch)
+	git(repoDir, "commit", "-m", "Squash squashed")
+	pending := create(workspace.CreateOptions{Name: "pending", AutoCreated: true})
+	commit(pending.Path, "c.txt")
+	busy := create(workspace.CreateOptions{Name: "busy"})
+	if err := busy.Acquire("session-1"); err != nil {
+		t.Fatalf("Failed to acquire workspace: %v", err)
+	}
+
+	plan := func(opts workspace.CleanupOptions) map[string]*workspace.PruneCandidate {
+		candidates, err := manager.PlanCleanup(ctx, opts)
+		if err != nil {
+			t.Fatalf("Failed to plan: %v", err)
+		}
+		byName := make(map[string]*workspace.PruneCandidate)
+		for _, c := range candidates {
+			byName[c.Workspace.Name] = c
+		}
+		return byName
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		got := plan(workspace.CleanupOptions{Merged: true})
+		if len(got) != 3 || got["untouched"] == nil || got["squashed"] == nil || got["busy"] == nil {
+			t.Fatalf("Expected untouched, squashed and busy, got %v", got)
+		}
+		if got["busy"].Blocked == "" || got["squashed"].Blocked != "" {
+			t.Errorf("Expected only busy to be blocked: %+v, %+v", got["busy"], got["squashed"])
+		}
+	})
+
+	t.Run("no commits ahead", func(t *testing.T) {
+		got := plan(workspace.CleanupOptions{NoAhead: true})
+		if len(got) != 2 || got["untouched"] == nil || got["busy"] == nil {
+			t.Errorf("Expected untouched and busy, got %v", got)
+		}
+	})
+
+	t.Run("auto-created and session activity", func(t *testing.T) {
+		got := plan(workspace.CleanupOptions{AutoCreatedOnly: true})
+		if len(got) != 1 || got["pending"] == nil || got["pending"].Action != workspace.PruneActionRemove {
+			t.Errorf("Expected pending to be removed, got %v", got)
+		}
+
+		got = plan(workspace.CleanupOptions{
+			AutoCreatedOnly: true,
+			SessionIdleDays: 3,
+			Sessions:        map[string]workspace.SessionActivity{pending.ID: {LastActive: time.Now()}},
+		})
+		if len(got) != 0 {
+			t.Errorf("Expected recently used workspace to be kept, got %v", got)
+		}
+	})
+
+	t.Run("status and archive", func(t *testing.T) {
+		if err := os.RemoveAll(untouched.Path); err != nil {
+			t.Fatalf("Failed to remove folder: %v", err)
+		}
+		got := plan(workspace.CleanupOptions{Statuses: []workspace.ConsistencyStatus{workspace.StatusFolderMissing}, Archive: true})
+		if len(got) != 1 || got["untouched"] == nil || got["untouched"].Action != workspace.PruneActionRemove {
+			t.Errorf("Expected untouched to be removed rather than archived, got %v", got)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		pruned, err := manager.Cleanup(ctx, workspace.CleanupOptions{Merged: true})
+		if err != nil {
+			t.Fatalf("Failed to prune: %v", err)
+		}
+		// untouched lost its folder, but its branch is merged all the same
+		if len(pruned) != 2 || !slices.Contains(pruned, squashed.ID) || !slices.Contains(pruned, untouched.ID) {
+			t.Errorf("Expected squashed and untouched to be pruned, got %v", pruned)
+		}
+		if _, err := manager.ResolveWorkspace(ctx, workspace.Identifier(busy.Name)); err != nil {
+			t.Errorf("Expected busy workspace to be kept: %v", err)
+		}
+	})
+
+	t.Run("adopted", func(t *testing.T) {
+		external := filepath.Join(t.TempDir(), "external")
+		git(repoDir, "worktree", "add", "-b", "external", external)
+		if _, err := manager.Adopt(ctx, workspace.AdoptOptions{Path: external}); err != nil {
+			t.Fatalf("Failed to adopt worktree: %v", err)
+		}
+
+		got := plan(workspace.CleanupOptions{NoAhead: true})
+		if got["external"] == nil || got["external"].Blocked != "adopted worktree" {
+			t.Errorf("Expected adopted worktree to be kept from removal, got %+v", got["external"])
+		}
+		got = plan(workspace.CleanupOptions{NoAhead: true, Archive: true})
+		if got["external"] == nil || got["external"].Blocked != "" {
+			t.Errorf("Expected adopted worktree to be archived, got %+v", got["external"])
+		}
+	})
+
+	if _, err := manager.PlanCleanup(ctx, workspace.CleanupOptions{}); err == nil {
+		t.Error("Expected error without criteria")
+	}
+}
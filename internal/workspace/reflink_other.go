@@ -0,0 +1,13 @@
+//go:build !linux
+
+package workspace
+
+import (
+	"errors"
+	"os"
+)
+
+// reflink is not supported on this platform, so files are always copied
+func reflink(_, _ *os.File) error {
+	return errors.ErrUnsupported
+}
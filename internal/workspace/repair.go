@@ -0,0 +1,202 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/aki/amux/internal/idmap"
+)
+
+// Repair brings inconsistent workspaces back to a usable state: a missing
+// worktree folder is checked out again from the recorded branch, a folder git
+// lost track of is registered again, and the metadata of workspaces with
+// neither is dropped. Workspaces without an index get one. An empty
+// identifier repairs all workspaces. Failed actions are reported in the
+// returned actions rather than stopping the rest.
+func (m *Manager) Repair(ctx context.Context, identifier Identifier, opts RepairOptions) ([]*RepairAction, error) {
+	var workspaces []*Workspace
+	if identifier == "" {
+		var err error
+		if workspaces, err = m.List(ctx, ListOptions{}); err != nil {
+			return nil, err
+		}
+	} else {
+		ws, err := m.ResolveWorkspace(ctx, identifier)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = []*Workspace{ws}
+	}
+
+	var actions []*RepairAction
+	for _, ws := range workspaces {
+		if action := m.planRepair(ws); action != nil {
+			actions = append(actions, action)
+		}
+	}
+	if opts.DryRun {
+		return actions, nil
+	}
+
+	for _, action := range actions {
+		if err := m.applyRepair(ctx, action); err != nil {
+			action.Error = err.Error()
+		}
+	}
+
+	// Drop index entries of workspaces that no longer exist
+	remaining, err := m.List(ctx, ListOptions{})
+	if err != nil {
+		return actions, err
+	}
+	ids := make([]idmap.WorkspaceID, len(remaining))
+	for i, ws := range remaining {
+		ids[i] = idmap.WorkspaceID(ws.ID)
+	}
+	if _, err := m.idMapper.Reconcile(ids); err != nil {
+		slog.Warn("failed to reconcile workspace index", "error", err)
+	}
+
+	return actions, nil
+}
+
+// planRepair decides how to repair a workspace, or returns nil if it needs
+// no repair
+func (m *Manager) planRepair(ws *Workspace) *RepairAction {
+	action := &RepairAction{Workspace: ws}
+	switch ws.Status {
+	case StatusFolderMissing:
+		action.Action = RepairRecreate
+		if ws.Branch != "" {
+			action.Description = fmt.Sprintf("check out branch %s again at %s", ws.Branch, ws.Path)
+		} else if wt := m.findWorktree(ws.Path); wt != nil {
+			action.Description = fmt.Sprintf("check out commit %s again (detached) at %s", shortCommit(wt.Commit), ws.Path)
+		} else {
+			action.Description = "check out the worktree again at " + ws.Path
+		}
+	case StatusWorktreeMissing:
+		action.Action = RepairReattach
+		action.Description = "register the existing folder with git again, keeping its files"
+	case StatusOrphaned:
+		action.Action = RepairDrop
+		action.Description = "drop the workspace metadata and storage"
+		if ws.Branch != "" {
+			action.Description += "; branch " + ws.Branch + " is kept"
+		}
+	default:
+		if ws.Index != "" {
+			return nil
+		}
+		action.Action = RepairReindex
+		action.Description = "assign an index"
+	}
+	return action
+}
+
+// applyRepair carries out a planned repair
+func (m *Manager) applyRepair(ctx context.Context, action *RepairAction) error {
+	ws := action.Workspace
+	switch action.Action {
+	case RepairRecreate:
+		return m.recreateWorktree(ws)
+	case RepairReattach:
+		return m.reattachWorktree(ws)
+	case RepairDrop:
+		return m.dropWorkspace(ws)
+	case RepairReindex:
+		index, err := m.idMapper.Add(idmap.WorkspaceID(ws.ID))
+		if err != nil {
+			return fmt.Errorf("failed to assign index: %w", err)
+		}
+		ws.Index = index
+		return nil
+	}
+	return fmt.Errorf("unknown repair action '%s'", action.Action)
+}
+
+// recreateWorktree checks out the worktree of a workspace whose folder is
+// missing, on its branch or, when detached, at the commit git recorded
+func (m *Manager) recreateWorktree(ws *Workspace) error {
+	var commit string
+	if wt := m.findWorktree(ws.Path); wt != nil {
+		commit = wt.Commit
+	}
+
+	// Forget the stale worktree so its branch can be checked out again
+	if err := m.gitOps.PruneWorktrees(); err != nil {
+		return err
+	}
+
+	if ws.Branch != "" {
+		if err := m.addWorktree(ws.Path, ws.Branch, false, ws.Sparse); err != nil {
+			return err
+		}
+	} else {
+		if commit == "" {
+			return fmt.Errorf("cannot tell which commit detached workspace '%s' was at", ws.Name)
+		}
+		if err := m.addWorktree(ws.Path, commit, true, ws.Sparse); err != nil {
+			return err
+		}
+	}
+
+	// Bring back files such as .env that a fresh checkout lacks
+	copied, err := m.copyIntoWorktree(ws.Path)
+	if err != nil {
+		return fmt.Errorf("failed to copy files into workspace: %w", err)
+	}
+	ws.Copied = copied
+	m.CheckConsistency(ws)
+	return nil
+}
+
+// reattachWorktree registers the folder of a workspace with git again
+func (m *Manager) reattachWorktree(ws *Workspace) error {
+	// Enough when the repository or the folder was moved
+	repairErr := m.gitOps.RepairWorktree(ws.Path)
+	m.CheckConsistency(ws)
+	if ws.Status == StatusConsistent {
+		return nil
+	}
+
+	// Git has no record of the worktree left, so register the folder anew
+	if ws.Branch == "" {
+		if repairErr != nil {
+			return fmt.Errorf("%w; detached workspaces can't be registered again, remove the workspace instead", repairErr)
+		}
+		return fmt.Errorf("detached workspace '%s' can't be registered again, remove it instead", ws.Name)
+	}
+	if err := m.gitOps.ReattachWorktree(ws.Path, ws.Branch); err != nil {
+		return err
+	}
+	m.CheckConsistency(ws)
+	return nil
+}
+
+// dropWorkspace deletes the metadata, storage, index and hidden refs of a
+// workspace whose worktree is gone. Its branch is kept.
+func (m *Manager) dropWorkspace(ws *Workspace) error {
+	if err := m.gitOps.DeleteRefs(checkpointRefPrefix(ws.ID)); err != nil {
+		slog.Warn("failed to delete workspace checkpoints", "workspace", ws.ID, "error", err)
+	}
+	if err := m.gitOps.DeleteRefs(sessionSnapshotRefPrefix(ws.ID)); err != nil {
+		slog.Warn("failed to delete session snapshots", "workspace", ws.ID, "error", err)
+	}
+	_ = m.idMapper.Remove(idmap.WorkspaceID(ws.ID))
+
+	if err := os.RemoveAll(filepath.Join(m.workspacesDir, ws.ID)); err != nil {
+		return fmt.Errorf("failed to remove workspace directory: %w", err)
+	}
+	return nil
+}
+
+// shortCommit abbreviates a commit hash for display
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
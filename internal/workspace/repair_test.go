@@ -0,0 +1,133 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_Repair(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	create := func(name string) *workspace.Workspace {
+		ws, err := manager.Create(ctx, workspace.CreateOptions{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		return ws
+	}
+	// worktreeAdminDir finds the directory git keeps for a worktree under .git/worktrees
+	worktreeAdminDir := func(ws *workspace.Workspace) string {
+		data, err := os.ReadFile(filepath.Join(ws.Path, ".git"))
+		if err != nil {
+			t.Fatalf("Failed to read .git file: %v", err)
+		}
+		return strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+	}
+
+	// The folder of one workspace is deleted
+	folderMissing := create("folder-missing")
+	if err := os.RemoveAll(folderMissing.Path); err != nil {
+		t.Fatalf("Failed to remove folder: %v", err)
+	}
+
+	// Git forgets another, which has uncommitted work
+	worktreeMissing := create("worktree-missing")
+	if err := os.WriteFile(filepath.Join(worktreeMissing.Path, "README.md"), []byte("work in progress\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.RemoveAll(worktreeAdminDir(worktreeMissing)); err != nil {
+		t.Fatalf("Failed to remove worktree admin dir: %v", err)
+	}
+
+	// Both are gone for a third
+	orphaned := create("orphaned")
+	if err := os.RemoveAll(worktreeAdminDir(orphaned)); err != nil {
+		t.Fatalf("Failed to remove worktree admin dir: %v", err)
+	}
+	if err := os.RemoveAll(orphaned.Path); err != nil {
+		t.Fatalf("Failed to remove folder: %v", err)
+	}
+
+	create("healthy")
+
+	plan, err := manager.Repair(ctx, "", workspace.RepairOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to plan repair: %v", err)
+	}
+	actions := make(map[string]string)
+	for _, a := range plan {
+		actions[a.Workspace.Name] = a.Action
+	}
+	want := map[string]string{
+		"folder-missing":   workspace.RepairRecreate,
+		"worktree-missing": workspace.RepairReattach,
+		"orphaned":         workspace.RepairDrop,
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("Expected actions %v, got %v", want, actions)
+	}
+	for name, action := range want {
+		if actions[name] != action {
+			t.Errorf("Expected %s for %s, got %s", action, name, actions[name])
+		}
+	}
+	if _, err := os.Stat(folderMissing.Path); !os.IsNotExist(err) {
+		t.Error("Expected dry run to leave the workspace alone")
+	}
+
+	done, err := manager.Repair(ctx, "", workspace.RepairOptions{})
+	if err != nil {
+		t.Fatalf("Failed to repair: %v", err)
+	}
+	for _, a := range done {
+		if a.Error != "" {
+			t.Errorf("Repair of %s failed: %s", a.Workspace.Name, a.Error)
+		}
+	}
+
+	workspaces, err := manager.List(ctx, workspace.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list workspaces: %v", err)
+	}
+	if len(workspaces) != 3 {
+		t.Errorf("Expected the orphaned workspace to be dropped, got %d workspaces", len(workspaces))
+	}
+	for _, ws := range workspaces {
+		if ws.Status != workspace.StatusConsistent || ws.Index == "" {
+			t.Errorf("Expected %s to be consistent and indexed: %s, index %q", ws.Name, ws.Status, ws.Index)
+		}
+	}
+
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = worktreeMissing.Path
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "M README.md" {
+		t.Errorf("Expected the uncommitted change to be kept, got %q", output)
+	}
+
+	cmd = exec.Command("git", "rev-parse", "--verify", "refs/heads/"+orphaned.Branch)
+	cmd.Dir = repoDir
+	if err := cmd.Run(); err != nil {
+		t.Errorf("Expected branch %s to be kept: %v", orphaned.Branch, err)
+	}
+}
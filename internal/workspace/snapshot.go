@@ -0,0 +1,129 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aki/amux/internal/git"
+)
+
+// Points at which a session's worktree snapshots are taken
+const (
+	SnapshotStart = "start"
+	SnapshotEnd   = "end"
+)
+
+// sessionSnapshotRefPrefix returns the hidden ref namespace holding the
+// snapshots taken for the sessions of a workspace
+func sessionSnapshotRefPrefix(workspaceID string) string {
+	return "refs/amux/sessions/" + workspaceID + "/"
+}
+
+// SnapshotSession records the state of a workspace's worktree, including
+// uncommitted and untracked files, when a session starts or ends. Nothing is
+// recorded for workspaces that are not consistent, and nil is returned.
+func (m *Manager) SnapshotSession(ctx context.Context, identifier Identifier, sessionID, point string) (*Snapshot, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Status != StatusConsistent {
+		return nil, nil
+	}
+
+	commit, err := git.NewOperations(ws.Path).Snapshot(fmt.Sprintf("Session %s %s", sessionID, point))
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot workspace %s: %w", ws.Name, err)
+	}
+	return m.recordSnapshot(ws, sessionID, point, commit)
+}
+
+// RecordSessionSnapshot records a snapshot commit taken elsewhere, such as by
+// the session's proxy when its command exited, as a session's snapshot
+func (m *Manager) RecordSessionSnapshot(ctx context.Context, identifier Identifier, sessionID, point, commit string) (*Snapshot, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return m.recordSnapshot(ws, sessionID, point, commit)
+}
+
+// recordSnapshot keeps a snapshot commit under the session's hidden ref, so
+// it isn't garbage collected, and returns it
+func (m *Manager) recordSnapshot(ws *Workspace, sessionID, point, commit string) (*Snapshot, error) {
+	ops := git.NewOperations(ws.Path)
+	ref := sessionSnapshotRefPrefix(ws.ID) + sessionID + "/" + point
+	if err := ops.UpdateRef(ref, commit); err != nil {
+		return nil, err
+	}
+
+	refs, err := ops.ListRefs(ref)
+	if err != nil || len(refs) != 1 {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", ref, err)
+	}
+	return &Snapshot{Commit: refs[0].Commit, Head: refs[0].Parent, CreatedAt: refs[0].Date}, nil
+}
+
+// DeleteSessionSnapshots deletes the snapshots taken for a session
+func (m *Manager) DeleteSessionSnapshots(ctx context.Context, identifier Identifier, sessionID string) error {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	return m.gitOps.DeleteRefs(sessionSnapshotRefPrefix(ws.ID) + sessionID + "/")
+}
+
+// DiffSnapshots lists the files of a workspace that differ between two
+// snapshots. Without to, from is compared with the current worktree. Paths
+// limit the result to these files and the files below them.
+func (m *Manager) DiffSnapshots(ctx context.Context, identifier Identifier, from, to string, paths []string) ([]git.FileChange, error) {
+	ops, to, err := m.snapshotDiffTarget(ctx, identifier, to)
+	if err != nil {
+		return nil, err
+	}
+	return ops.DiffSnapshots(from, to, paths...)
+}
+
+// SnapshotPatch returns the patch of a workspace between two snapshots.
+// Without to, from is compared with the current worktree.
+func (m *Manager) SnapshotPatch(ctx context.Context, identifier Identifier, from, to string, paths []string) (string, error) {
+	ops, to, err := m.snapshotDiffTarget(ctx, identifier, to)
+	if err != nil {
+		return "", err
+	}
+	return ops.SnapshotPatch(from, to, paths...)
+}
+
+// RestoreSnapshot puts a workspace's worktree back into the state recorded by
+// a snapshot. Like Rollback, the current state is checkpointed first, with the
+// reason in its message, and that checkpoint is returned. Workspaces in use by
+// sessions are only restored with force.
+func (m *Manager) RestoreSnapshot(ctx context.Context, identifier Identifier, commit, reason string, force bool) (*Checkpoint, error) {
+	ws, err := m.checkpointableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotInUse(ws, "restore", force); err != nil {
+		return nil, err
+	}
+	return m.restore(ws, commit, reason)
+}
+
+// snapshotDiffTarget returns the git operations of a workspace and the
+// snapshot to diff against, snapshotting the current worktree if none is given
+func (m *Manager) snapshotDiffTarget(ctx context.Context, identifier Identifier, to string) (*git.Operations, string, error) {
+	ws, err := m.checkpointableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, "", err
+	}
+	ops := git.NewOperations(ws.Path)
+	if to != "" {
+		return ops, to, nil
+	}
+	// Not referenced by any ref, so git collects it eventually
+	current, err := ops.Snapshot("Current worktree")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to snapshot workspace %s: %w", ws.Name, err)
+	}
+	return ops, current, nil
+}
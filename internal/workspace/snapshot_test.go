@@ -0,0 +1,140 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_SessionSnapshots(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "snapshots"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	id := workspace.Identifier(ws.Name)
+	notes := filepath.Join(ws.Path, "notes.txt")
+
+	if err := os.WriteFile(notes, []byte("before\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	start, err := manager.SnapshotSession(ctx, id, "session-1", workspace.SnapshotStart)
+	if err != nil || start == nil || start.Commit == "" || start.Head == "" {
+		t.Fatalf("Unexpected start snapshot: %+v, %v", start, err)
+	}
+
+	// The session edits a file and adds another
+	if err := os.WriteFile(notes, []byte("after\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.Path, "added.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	// Without an end snapshot the current worktree is compared
+	files, err := manager.DiffSnapshots(ctx, id, start.Commit, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to diff snapshots: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "added.txt" || files[1].Path != "notes.txt" {
+		t.Errorf("Unexpected changes: %+v", files)
+	}
+
+	end, err := manager.SnapshotSession(ctx, id, "session-1", workspace.SnapshotEnd)
+	if err != nil || end == nil {
+		t.Fatalf("Unexpected end snapshot: %+v, %v", end, err)
+	}
+	patch, err := manager.SnapshotPatch(ctx, id, start.Commit, end.Commit, []string{"notes.txt"})
+	if err != nil {
+		t.Fatalf("Failed to get patch: %v", err)
+	}
+	if !strings.Contains(patch, "-before") || !strings.Contains(patch, "+after") || strings.Contains(patch, "added.txt") {
+		t.Errorf("Unexpected patch:\n%s", patch)
+	}
+
+	// Restoring the start snapshot undoes the session, saving the current state first
+	saved, err := manager.RestoreSnapshot(ctx, id, start.Commit, "reverting session session-1", false)
+	if err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	if saved.Message != "Before reverting session session-1" {
+		t.Errorf("Unexpected checkpoint message: %q", saved.Message)
+	}
+	if data, _ := os.ReadFile(notes); string(data) != "before\n" {
+		t.Errorf("Expected notes.txt to be restored, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Path, "added.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected added.txt to be removed, got %v", err)
+	}
+
+	if err := manager.DeleteSessionSnapshots(ctx, id, "session-1"); err != nil {
+		t.Fatalf("Failed to delete snapshots: %v", err)
+	}
+	if _, err := manager.DiffSnapshots(ctx, id, "refs/amux/sessions/"+ws.ID+"/session-1/start", "", nil); err == nil {
+		t.Error("Expected the start snapshot ref to be deleted")
+	}
+}
+
+func TestManager_RecordSessionSnapshot(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "recorded"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	id := workspace.Identifier(ws.Name)
+
+	// Snapshot taken by the session's proxy as its command exited
+	if err := os.WriteFile(filepath.Join(ws.Path, "notes.txt"), []byte("at exit\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	commit, err := git.NewOperations(ws.Path).Snapshot("Session session-1 end")
+	if err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	end, err := manager.RecordSessionSnapshot(ctx, id, "session-1", workspace.SnapshotEnd, commit)
+	if err != nil {
+		t.Fatalf("Failed to record snapshot: %v", err)
+	}
+	if end.Commit != commit || end.Head == "" {
+		t.Errorf("Unexpected snapshot: %+v", end)
+	}
+	files, err := manager.DiffSnapshots(ctx, id, "refs/amux/sessions/"+ws.ID+"/session-1/end", "", nil)
+	if err != nil {
+		t.Fatalf("Expected the snapshot to be kept under the session's ref: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no changes since the snapshot, got %+v", files)
+	}
+
+	if _, err := manager.RecordSessionSnapshot(ctx, id, "session-1", workspace.SnapshotEnd, "0123456789abcdef0123456789abcdef01234567"); err == nil {
+		t.Error("Expected error for a missing commit")
+	}
+}
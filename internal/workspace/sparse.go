@@ -0,0 +1,144 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aki/amux/internal/git"
+)
+
+// normalizeSparse cleans up the directories of a sparse checkout, given
+// relative to the repository root, and sorts them
+func normalizeSparse(dirs []string) ([]string, error) {
+	var result []string
+	for _, dir := range dirs {
+		dir = strings.Trim(filepath.ToSlash(strings.TrimSpace(dir)), "/")
+		if dir == "" {
+			continue
+		}
+		clean := path.Clean(dir)
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("invalid sparse path '%s': must be a directory inside the repository", dir)
+		}
+		if !slices.Contains(result, clean) {
+			result = append(result, clean)
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
+// planSparse checks the sparse directories of a new workspace against the
+// commit it checks out
+func (m *Manager) planSparse(opts CreateOptions, plan *CreatePlan) error {
+	dirs, err := normalizeSparse(opts.Sparse)
+	if err != nil {
+		return err
+	}
+	rev := plan.startPoint()
+	if opts.BranchMode == BranchModeCheckout {
+		rev = plan.Branch
+	}
+	for _, dir := range dirs {
+		if !m.gitOps.IsDirectory(rev, dir) {
+			return fmt.Errorf("invalid sparse path '%s': not a directory in %s", dir, rev)
+		}
+	}
+	plan.Sparse = dirs
+	return nil
+}
+
+// addWorktree checks out the worktree of a workspace on an existing branch,
+// or at a commit when detach is set. With sparse directories, only those are
+// checked out.
+func (m *Manager) addWorktree(worktreePath, rev string, detach bool, sparse []string) error {
+	if len(sparse) > 0 {
+		return m.gitOps.CreateSparseWorktree(worktreePath, rev, detach, sparse)
+	}
+	if detach {
+		return m.gitOps.CreateDetachedWorktree(worktreePath, rev)
+	}
+	return m.gitOps.CreateWorktreeFromExistingBranch(worktreePath, rev)
+}
+
+// UpdateSparse adds directories to and removes them from the sparse checkout
+// of a workspace. Files with local changes in removed directories are kept.
+func (m *Manager) UpdateSparse(ctx context.Context, identifier Identifier, add, remove []string) (*Workspace, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+	if len(ws.Sparse) == 0 {
+		return nil, fmt.Errorf("workspace '%s' is not a sparse checkout", ws.Name)
+	}
+	if ws.Status != StatusConsistent {
+		return nil, fmt.Errorf("workspace %s is %s", ws.Name, ws.Status)
+	}
+
+	add, err = normalizeSparse(add)
+	if err != nil {
+		return nil, err
+	}
+	remove, err = normalizeSparse(remove)
+	if err != nil {
+		return nil, err
+	}
+
+	wt := git.NewOperations(ws.Path)
+	dirs := slices.Clone(ws.Sparse)
+	for _, dir := range remove {
+		i := slices.Index(dirs, dir)
+		if i < 0 {
+			return nil, fmt.Errorf("'%s' is not a sparse path of workspace '%s'", dir, ws.Name)
+		}
+		dirs = slices.Delete(dirs, i, i+1)
+	}
+	for _, dir := range add {
+		if !wt.IsDirectory("HEAD", dir) {
+			return nil, fmt.Errorf("invalid sparse path '%s': not a directory in %s", dir, ws.BranchLabel())
+		}
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("workspace '%s' needs at least one sparse path", ws.Name)
+	}
+	slices.Sort(dirs)
+
+	if err := wt.SetSparseCheckout(dirs); err != nil {
+		return nil, err
+	}
+	ws.Sparse = dirs
+	if err := m.saveWorkspace(ctx, ws); err != nil {
+		return nil, fmt.Errorf("failed to save workspace metadata: %w", err)
+	}
+	return ws, nil
+}
+
+// IsMaterialized reports whether a file or directory in the workspace,
+// relative to its root, is checked out. Everything is in a full checkout; in
+// a sparse one, the sparse directories are, along with files at the top level
+// and files directly in the parents of the sparse directories.
+func (w *Workspace) IsMaterialized(relPath string, dir bool) bool {
+	relPath = path.Clean(filepath.ToSlash(relPath))
+	if len(w.Sparse) == 0 || relPath == "." {
+		return true
+	}
+	parent := path.Dir(relPath)
+	for _, sparse := range w.Sparse {
+		// Inside a sparse directory, or a directory leading to one
+		if relPath == sparse || strings.HasPrefix(relPath, sparse+"/") || strings.HasPrefix(sparse, relPath+"/") {
+			return true
+		}
+		// A file directly in a directory leading to one
+		if !dir && (parent == "." || strings.HasPrefix(sparse, parent+"/")) {
+			return true
+		}
+	}
+	return false
+}
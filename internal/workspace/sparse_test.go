@@ -0,0 +1,149 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+// createMonorepo creates a repository with a few directories to check out
+// sparsely
+func createMonorepo(t *testing.T) string {
+	t.Helper()
+	repoDir := helpers.CreateTestRepo(t)
+	for _, file := range []string{"services/api/main.go", "services/web/index.html", "libs/common/util.go", "docs/guide.md"} {
+		fullPath := filepath.Join(repoDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(file+"\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "-m", "Add monorepo layout"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	return repoDir
+}
+
+func TestManager_SparseWorkspace(t *testing.T) {
+	repoDir := createMonorepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, sparse := range [][]string{{"../outside"}, {"."}, {"services/missing"}, {"README.md"}} {
+		if _, err := manager.Create(ctx, workspace.CreateOptions{Name: "bad", Sparse: sparse}); err == nil {
+			t.Errorf("Expected error for sparse paths %v", sparse)
+		}
+	}
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "api", Sparse: []string{"services/api/", "libs/common", "services/api"}})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if !slices.Equal(ws.Sparse, []string{"libs/common", "services/api"}) {
+		t.Errorf("Expected cleaned up sparse paths, got %v", ws.Sparse)
+	}
+
+	exists := func(file string) bool {
+		_, err := os.Stat(filepath.Join(ws.Path, file))
+		return err == nil
+	}
+	for _, file := range []string{"README.md", "services/api/main.go", "libs/common/util.go"} {
+		if !exists(file) {
+			t.Errorf("Expected %s to be checked out", file)
+		}
+	}
+	for _, file := range []string{"services/web", "docs"} {
+		if exists(file) {
+			t.Errorf("Expected %s not to be checked out", file)
+		}
+	}
+
+	// The sparse paths are part of the workspace metadata
+	resolved, err := manager.ResolveWorkspace(ctx, workspace.Identifier(ws.Name))
+	if err != nil {
+		t.Fatalf("Failed to resolve workspace: %v", err)
+	}
+	if !slices.Equal(resolved.Sparse, ws.Sparse) {
+		t.Errorf("Expected sparse paths %v to be saved, got %v", ws.Sparse, resolved.Sparse)
+	}
+
+	id := workspace.Identifier(ws.Name)
+	ws, err = manager.UpdateSparse(ctx, id, []string{"docs"}, []string{"libs/common"})
+	if err != nil {
+		t.Fatalf("Failed to update sparse checkout: %v", err)
+	}
+	if !slices.Equal(ws.Sparse, []string{"docs", "services/api"}) {
+		t.Errorf("Unexpected sparse paths %v", ws.Sparse)
+	}
+	if !exists("docs/guide.md") || exists("libs/common") {
+		t.Error("Expected docs to be checked out and libs/common to be removed")
+	}
+
+	if _, err := manager.UpdateSparse(ctx, id, nil, []string{"libs/common"}); err == nil {
+		t.Error("Expected error when removing a path that isn't checked out")
+	}
+	if _, err := manager.UpdateSparse(ctx, id, nil, []string{"docs", "services/api"}); err == nil {
+		t.Error("Expected error when removing every path")
+	}
+	if _, err := manager.UpdateSparse(ctx, id, []string{"services/missing"}, nil); err == nil {
+		t.Error("Expected error when adding a path that doesn't exist")
+	}
+
+	full, err := manager.Create(ctx, workspace.CreateOptions{Name: "full"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if _, err := manager.UpdateSparse(ctx, workspace.Identifier(full.Name), []string{"docs"}, nil); err == nil {
+		t.Error("Expected error for a workspace that isn't sparse")
+	}
+}
+
+func TestWorkspace_IsMaterialized(t *testing.T) {
+	ws := &workspace.Workspace{Sparse: []string{"services/api"}}
+	tests := []struct {
+		path string
+		dir  bool
+		want bool
+	}{
+		{"", true, true},
+		{"README.md", false, true},
+		{"services", true, true},
+		{"services/go.mod", false, true},
+		{"services/api", true, true},
+		{"services/api/internal/handler.go", false, true},
+		{"services/web", true, false},
+		{"services/web/index.html", false, false},
+		{"docs", true, false},
+		{"docs/guide.md", false, false},
+	}
+	for _, tt := range tests {
+		if got := ws.IsMaterialized(tt.path, tt.dir); got != tt.want {
+			t.Errorf("IsMaterialized(%q, %v) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+
+	full := &workspace.Workspace{}
+	if !full.IsMaterialized("docs/guide.md", false) {
+		t.Error("Expected everything to be materialized in a full checkout")
+	}
+}
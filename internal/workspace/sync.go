@@ -0,0 +1,48 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aki/amux/internal/git"
+)
+
+// Sync brings a workspace branch up to date with its base branch. Nothing is
+// fetched unless opts.Fetch is set. If the sync stops on conflicts, the rebase
+// or merge is left in progress so it can be resolved in the worktree, and the
+// conflicts are returned in the result.
+func (m *Manager) Sync(ctx context.Context, identifier Identifier, opts SyncOptions) (*git.SyncResult, error) {
+	ws, err := m.diffableWorkspace(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = git.SyncRebase
+	}
+
+	ops := git.NewOperations(ws.Path)
+	base := ws.BaseBranch
+	if opts.Fetch {
+		base, err = ops.FetchUpstream(ws.BaseBranch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ops.Sync(base, strategy)
+}
+
+// AbortSync undoes an unfinished rebase or merge in a workspace and returns
+// its strategy
+func (m *Manager) AbortSync(ctx context.Context, identifier Identifier) (git.SyncStrategy, error) {
+	ws, err := m.ResolveWorkspace(ctx, identifier)
+	if err != nil {
+		return "", err
+	}
+	if ws.Status != StatusConsistent {
+		return "", fmt.Errorf("workspace %s is %s", ws.Name, ws.Status)
+	}
+	return git.NewOperations(ws.Path).AbortSync()
+}
@@ -0,0 +1,59 @@
+package workspace_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/git"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestManager_Sync(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	if err := configManager.Save(config.DefaultConfig()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	ws, err := manager.Create(ctx, workspace.CreateOptions{Name: "sync", BaseBranch: "main"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	// Advance the base branch in the main repository
+	if err := os.WriteFile(filepath.Join(repoDir, "main.txt"), []byte("main\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	for _, args := range [][]string{{"add", "main.txt"}, {"commit", "-m", "Add main.txt"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+
+	result, err := manager.Sync(ctx, workspace.Identifier(ws.Name), workspace.SyncOptions{})
+	if err != nil {
+		t.Fatalf("Failed to sync workspace: %v", err)
+	}
+	if result.Strategy != git.SyncRebase || result.Commits != 1 || len(result.Conflicts) != 0 {
+		t.Errorf("Unexpected sync result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Path, "main.txt")); err != nil {
+		t.Errorf("Expected base branch changes in worktree: %v", err)
+	}
+
+	if _, err := manager.AbortSync(ctx, workspace.Identifier(ws.Name)); err == nil {
+		t.Error("Expected error aborting without a sync in progress")
+	}
+}
@@ -1,7 +1,10 @@
 package workspace
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/aki/amux/internal/git"
 )
 
 // ID is the full UUID of a workspace
@@ -46,6 +49,16 @@ func (s ConsistencyStatus) String() string {
 	}
 }
 
+// ParseConsistencyStatus parses the string form of a ConsistencyStatus
+func ParseConsistencyStatus(s string) (ConsistencyStatus, error) {
+	for _, status := range []ConsistencyStatus{StatusConsistent, StatusFolderMissing, StatusWorktreeMissing, StatusOrphaned} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid status '%s' (must be consistent, folder-missing, worktree-missing or orphaned)", s)
+}
+
 // MarshalJSON implements json.Marshaler for ConsistencyStatus
 func (s ConsistencyStatus) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + s.String() + `"`), nil
@@ -88,10 +101,95 @@ type Workspace struct {
 	UpdatedAt   time.Time `yaml:"-" json:"updatedAt"` // Dynamically populated from filesystem
 	AutoCreated bool      `yaml:"autoCreated,omitempty" json:"autoCreated,omitempty"`
 
+	// From is the commit, tag or remote branch the workspace was started from, if not the base branch
+	From string `yaml:"from,omitempty" json:"from,omitempty"`
+	// Detached workspaces have no branch; HEAD points directly at a commit
+	Detached bool `yaml:"detached,omitempty" json:"detached,omitempty"`
+	// Adopted workspaces wrap a worktree created outside amux; removing them keeps their branch
+	Adopted bool `yaml:"adopted,omitempty" json:"adopted,omitempty"`
+	// Sparse lists the directories checked out with sparse checkout in cone
+	// mode, besides top-level files; empty when the whole tree is checked out
+	Sparse []string `yaml:"sparse,omitempty" json:"sparse,omitempty"`
+
+	// Labels tag the workspace for filtering (e.g., bug, needs-review)
+	Labels []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Metadata holds free-form key/value pairs such as an issue number or owner
+	Metadata map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+
+	// Ports holds the named ports allocated to this workspace (e.g., web -> 41237)
+	Ports map[string]int `yaml:"ports,omitempty" json:"ports,omitempty"`
+
+	// AutoCheckpoint takes a checkpoint before each session starts in this workspace
+	AutoCheckpoint bool `yaml:"autoCheckpoint,omitempty" json:"autoCheckpoint,omitempty"`
+
+	// Concurrency limits how many sessions run in the workspace at once:
+	// exclusive, shared, shared:N or unlimited (default: workspace.concurrency in config)
+	Concurrency string `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// Sessions holding a slot in the workspace (not persisted, filled in by 'ws show')
+	Holders []SessionHolder `yaml:"-" json:"holders,omitempty"`
+
+	// Files brought in from the main checkout on creation (not persisted)
+	Copied []CopyEntry `yaml:"-" json:"copied,omitempty"`
+
 	// Consistency status fields (not persisted)
 	PathExists     bool              `yaml:"-" json:"pathExists"`
 	WorktreeExists bool              `yaml:"-" json:"worktreeExists"`
 	Status         ConsistencyStatus `yaml:"-" json:"status"`
+
+	// Changes relative to the base branch (not persisted, see ListOptions.IncludeChanges)
+	Changes *ChangeSummary `yaml:"-" json:"changes,omitempty"`
+
+	// Disk space taken by the workspace (not persisted, see Manager.MeasureUsage)
+	Usage *DiskUsage `yaml:"-" json:"usage,omitempty"`
+
+	// sessionCheck finds stale session holders (see Manager.SetSessionCheck)
+	sessionCheck SessionCheck
+}
+
+// SessionHolder is a session holding a slot in a workspace
+type SessionHolder struct {
+	SessionID  string    `json:"sessionId"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+}
+
+// Checkpoint is a recorded state of a workspace's worktree, including
+// uncommitted and untracked files, that the workspace can be rolled back to
+type Checkpoint struct {
+	ID        string    `json:"id"`      // Sequence number within the workspace
+	Commit    string    `json:"commit"`  // Commit holding the worktree state
+	Head      string    `json:"head"`    // Branch commit when the checkpoint was taken
+	Message   string    `json:"message"` // Note describing the checkpoint
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Snapshot is the state of a workspace's worktree recorded for a session
+type Snapshot struct {
+	Commit    string    `json:"commit" yaml:"commit"` // Commit holding the worktree state
+	Head      string    `json:"head" yaml:"head"`     // Branch commit when the snapshot was taken
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+// ChangeSummary is a compact summary of the changes of a workspace relative
+// to its base branch
+type ChangeSummary struct {
+	Ahead            int `json:"ahead"`
+	Behind           int `json:"behind"`
+	Files            int `json:"files"`
+	Insertions       int `json:"insertions"`
+	Deletions        int `json:"deletions"`
+	UncommittedFiles int `json:"uncommittedFiles"`
+}
+
+// BranchLabel returns the branch of the workspace for display, noting where
+// detached workspaces are detached at
+func (w *Workspace) BranchLabel() string {
+	if !w.Detached {
+		return w.Branch
+	}
+	if w.From != "" {
+		return "(detached at " + w.From + ")"
+	}
+	return "(detached)"
 }
 
 // GetStoragePath returns the storage path for the workspace
@@ -107,6 +205,9 @@ const (
 	BranchModeCreate BranchMode = iota
 	// BranchModeCheckout uses an existing branch
 	BranchModeCheckout
+	// BranchModeDetach checks out a commit without a branch, e.g. for
+	// read-only investigation
+	BranchModeDetach
 )
 
 // CreateOptions represents options for creating a new workspace
@@ -115,14 +216,53 @@ type CreateOptions struct {
 	BaseBranch  string
 	Branch      string     // Branch name (either new or existing)
 	BranchMode  BranchMode // How to handle the branch (default: BranchModeCreate)
+	From        string     // Commit, tag or remote branch (e.g., origin/fix) to start from instead of the base branch
 	Description string
-	AutoCreated bool // Internal: whether workspace was auto-created by session
-	NoHooks     bool // Skip hook execution
+	Labels      []string
+	Metadata    map[string]string
+	Concurrency string   // Concurrency policy (default: workspace.concurrency in config)
+	Sparse      []string // Directories to check out (sparse checkout in cone mode); empty for all
+	AutoCreated bool     // Internal: whether workspace was auto-created by session
+	NoHooks     bool     // Skip hook execution
+}
+
+// CreatePlan describes what creating a workspace would do
+type CreatePlan struct {
+	Name       string      `json:"name"`
+	Branch     string      `json:"branch,omitempty"` // Empty when detached or named after the new workspace
+	BaseBranch string      `json:"baseBranch"`
+	From       string      `json:"from,omitempty"`       // Ref the workspace starts from instead of the base branch
+	Commit     string      `json:"commit,omitempty"`     // Commit From resolves to
+	NeedsFetch bool        `json:"needsFetch,omitempty"` // From is a remote branch that isn't fetched yet
+	Upstream   string      `json:"upstream,omitempty"`   // Remote branch the new branch tracks
+	Detached   bool        `json:"detached,omitempty"`
+	Sparse     []string    `json:"sparse,omitempty"` // Directories checked out, if not all
+	Copy       []CopyEntry `json:"copy,omitempty"`   // Files brought in from the main checkout
+}
+
+// startPoint returns the commit a new workspace starts from
+func (p *CreatePlan) startPoint() string {
+	if p.Commit != "" {
+		return p.Commit
+	}
+	return p.BaseBranch
 }
 
 // ListOptions represents options for listing workspaces
 type ListOptions struct {
-	// Reserved for future filtering options
+	IncludeChanges bool // Summarize changes relative to the base branch (runs git per workspace)
+
+	// Filters; a workspace is listed only if it passes all that are set
+	Labels    []string            // Has all of these labels
+	Metadata  map[string]string   // Has these metadata keys, with these values unless empty
+	Statuses  []ConsistencyStatus // Is in one of these states
+	Branch    string              // Branch matches this glob pattern (e.g., feature/*)
+	OlderThan time.Duration       // Last modified longer ago than this
+	NewerThan time.Duration       // Last modified more recently than this
+	InUse     *bool               // Has (or has no) running sessions
+
+	SortBy  string // SortByCreated (default), SortByUpdated, SortByName or SortByBranch
+	Reverse bool   // Reverse the sort order
 }
 
 // RemoveOptions represents options for removing a workspace
@@ -130,10 +270,124 @@ type RemoveOptions struct {
 	NoHooks         bool   // Skip hook execution
 	CurrentDir      string // Current working directory (for safety check)
 	SkipSafetyCheck bool   // Skip current directory safety check
+	Force           bool   // Also remove the worktree of adopted workspaces
+}
+
+// AdoptOptions represents options for adopting an existing git worktree
+type AdoptOptions struct {
+	Path        string // Worktree to adopt
+	Name        string // Workspace name (default: the worktree folder name)
+	BaseBranch  string // Base branch (default: the repository's default branch)
+	Description string
+	NoHooks     bool // Skip hook execution
+}
+
+// DetachOptions represents options for forgetting a workspace without removing its worktree
+type DetachOptions struct {
+	Force   bool // Detach even while sessions use the workspace
+	NoHooks bool // Skip hook execution
+}
+
+// SyncOptions represents options for syncing a workspace with its base branch
+type SyncOptions struct {
+	Strategy git.SyncStrategy // Rebase (default) or merge
+	Fetch    bool             // Fetch the base branch's remote and sync with its upstream
+}
+
+// MergeOptions represents options for merging a workspace into its base branch
+type MergeOptions struct {
+	Mode       git.MergeMode // How to merge (default: fast-forward when possible)
+	Message    string        // Commit message for merge and squash commits
+	Remove     bool          // Remove the workspace and delete its branch after merging
+	Force      bool          // Merge even if sessions are using the workspace
+	NoHooks    bool          // Skip hook execution
+	CurrentDir string        // Current working directory (for the removal safety check)
+}
+
+// ArchiveOptions represents options for archiving a workspace
+type ArchiveOptions struct {
+	Force      bool   // Archive even if sessions are using the workspace
+	NoHooks    bool   // Skip hook execution
+	CurrentDir string // Current working directory (for safety check)
+}
+
+// RestoreOptions represents options for restoring an archived workspace
+type RestoreOptions struct {
+	NoHooks bool // Skip hook execution
 }
 
-// CleanupOptions represents options for cleaning up old workspaces
+// ArchiveInfo describes an archived workspace
+type ArchiveInfo struct {
+	ID          string    `yaml:"id" json:"id"`
+	Name        string    `yaml:"name" json:"name"`
+	Branch      string    `yaml:"branch,omitempty" json:"branch,omitempty"`
+	BaseBranch  string    `yaml:"baseBranch" json:"baseBranch"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+	Detached    bool      `yaml:"detached,omitempty" json:"detached,omitempty"`
+	Head        string    `yaml:"head" json:"head"`             // Commit the worktree was at
+	HasChanges  bool      `yaml:"hasChanges" json:"hasChanges"` // Uncommitted changes are included
+	ArchivedAt  time.Time `yaml:"archivedAt" json:"archivedAt"`
+
+	// Archive file (not stored in the archive)
+	Path string `yaml:"-" json:"path"`
+	Size int64  `yaml:"-" json:"size"`
+}
+
+// Repair actions
+const (
+	RepairRecreate = "recreate" // Check the worktree out again from the recorded branch
+	RepairReattach = "reattach" // Register the existing folder with git again
+	RepairDrop     = "drop"     // Drop the metadata of a workspace that is gone
+	RepairReindex  = "reindex"  // Assign a missing index
+)
+
+// RepairOptions represents options for repairing workspaces
+type RepairOptions struct {
+	DryRun bool // Only plan the repairs
+}
+
+// RepairAction is a fix for an inconsistent workspace
+type RepairAction struct {
+	Workspace   *Workspace `json:"workspace"`
+	Action      string     `json:"action"`
+	Description string     `json:"description"`     // What the action does, for the user
+	Error       string     `json:"error,omitempty"` // Why the action failed
+}
+
+// CleanupOptions represents options for cleaning up old workspaces. A
+// workspace is pruned only if it meets every criterion that is set.
 type CleanupOptions struct {
-	Days   int
-	DryRun bool
+	Days    int // Idle for more than N days (0 disables)
+	DryRun  bool
+	Archive bool // Archive instead of removing
+
+	Merged          bool                // Branch is merged into the base branch, including squash merges
+	NoAhead         bool                // No commits ahead of the base branch
+	SessionIdleDays int                 // No session activity in the last N days (0 disables)
+	AutoCreatedOnly bool                // Only workspaces created automatically for sessions
+	Statuses        []ConsistencyStatus // Only workspaces in one of these states
+
+	// Sessions holds the session activity per workspace ID, gathered by the
+	// caller since workspaces don't know about sessions
+	Sessions map[string]SessionActivity
+}
+
+// SessionActivity summarizes the sessions of a workspace
+type SessionActivity struct {
+	Running    int       // Sessions still starting or running
+	LastActive time.Time // Most recent activity of any session
+}
+
+// Prune actions
+const (
+	PruneActionRemove  = "remove"
+	PruneActionArchive = "archive"
+)
+
+// PruneCandidate is a workspace that meets the prune criteria
+type PruneCandidate struct {
+	Workspace *Workspace `json:"workspace"`
+	Action    string     `json:"action"`            // remove or archive
+	Reasons   []string   `json:"reasons"`           // Criteria the workspace meets
+	Blocked   string     `json:"blocked,omitempty"` // Why the workspace is kept anyway
 }
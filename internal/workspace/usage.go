@@ -0,0 +1,326 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aki/amux/internal/cache"
+)
+
+// Quota actions for config.QuotaConfig
+const (
+	QuotaWarn  = "warn"
+	QuotaBlock = "block"
+)
+
+// usageFileName is the file in the amux directory keeping recent disk usage
+// measurements
+const usageFileName = "usage.json"
+
+// usageMaxAge is how long a measurement is used to check quotas before the
+// disk space is measured again
+const usageMaxAge = 5 * time.Minute
+
+// sizeUnits are the size suffixes ParseSize accepts, longest first
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// DiskUsage is the disk space a workspace takes
+type DiskUsage struct {
+	Worktree int64 `json:"worktree"` // Worktree files, including ignored ones such as node_modules
+	Storage  int64 `json:"storage"`  // Metadata and storage under .amux/workspaces
+	Logs     int64 `json:"logs"`     // Data and logs of the sessions run in the workspace
+}
+
+// Total returns the disk space of the worktree, storage and logs together
+func (u *DiskUsage) Total() int64 {
+	return u.Worktree + u.Storage + u.Logs
+}
+
+// LogUsage returns the disk space of the session data of each workspace, by
+// workspace ID
+type LogUsage func() (map[string]int64, error)
+
+// SetLogUsage lets the manager count the session data of workspaces in their
+// disk usage
+func (m *Manager) SetLogUsage(usage LogUsage) {
+	m.logUsage = usage
+}
+
+// ErrQuotaExceeded is returned when a workspace, or the project, takes more
+// disk space than its quota
+type ErrQuotaExceeded struct {
+	Workspace string `json:"workspace,omitempty" yaml:"workspace,omitempty"` // Empty for the project quota
+	Used      int64  `json:"used" yaml:"used"`                               // Bytes
+	Limit     int64  `json:"limit" yaml:"limit"`                             // Bytes
+	Block     bool   `json:"block,omitempty" yaml:"block,omitempty"`         // Whether new sessions are refused
+}
+
+// Scope names what the exceeded quota is for
+func (e *ErrQuotaExceeded) Scope() string {
+	if e.Workspace == "" {
+		return "project"
+	}
+	return fmt.Sprintf("workspace '%s'", e.Workspace)
+}
+
+func (e *ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("%s uses %d bytes of disk space, over its quota of %d bytes", e.Scope(), e.Used, e.Limit)
+}
+
+// ParseSize parses a size such as 500MB, 5G or 1.5GB (units of 1024 bytes)
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if n, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(n), unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s': use a size such as 500MB or 5GB", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// MeasureUsage fills in the disk usage of workspaces. Worktrees are walked
+// in full, so this takes a while for large ones.
+func (m *Manager) MeasureUsage(workspaces []*Workspace) error {
+	var logs map[string]int64
+	if m.logUsage != nil {
+		var err error
+		if logs, err = m.logUsage(); err != nil {
+			return fmt.Errorf("failed to measure session logs: %w", err)
+		}
+	}
+
+	for _, ws := range workspaces {
+		usage := &DiskUsage{Logs: logs[ws.ID]}
+		if ws.Path != "" {
+			usage.Worktree = dirSize(ws.Path, "")
+		}
+		if ws.StoragePath != "" {
+			usage.Storage = dirSize(filepath.Dir(ws.StoragePath), ws.Path)
+		}
+		ws.Usage = usage
+	}
+	return nil
+}
+
+// ProjectUsage returns the disk space of all workspaces, filling in their
+// usage, and of the shared caches
+func (m *Manager) ProjectUsage(ctx context.Context) (workspaces []*Workspace, caches []cache.Usage, total int64, err error) {
+	workspaces, err = m.List(ctx, ListOptions{})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if err := m.MeasureUsage(workspaces); err != nil {
+		return nil, nil, 0, err
+	}
+	caches, err = cache.NewManager(m.configManager).Usage()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	for _, ws := range workspaces {
+		total += ws.Usage.Total()
+	}
+	for _, c := range caches {
+		total += c.Size
+	}
+	m.recordUsage(workspaces, &total)
+	return workspaces, caches, total, nil
+}
+
+// CheckQuota measures a workspace, and the whole project, against the quotas
+// in workspace.quota. It returns ErrQuotaExceeded for the first quota that is
+// exceeded, and nil when none is or no quota is set. Measurements taken in
+// the last few minutes, by this check or by ProjectUsage, are used instead of
+// walking the worktrees again.
+func (m *Manager) CheckQuota(ctx context.Context, identifier Identifier) error {
+	cfg, err := m.workspaceConfig()
+	if err != nil {
+		return err
+	}
+	quota := cfg.Quota
+	if quota.Workspace == "" && quota.Project == "" {
+		return nil
+	}
+	block := quota.Action == QuotaBlock
+
+	if quota.Workspace != "" {
+		limit, err := ParseSize(quota.Workspace)
+		if err != nil {
+			return fmt.Errorf("invalid workspace.quota.workspace: %w", err)
+		}
+		ws, err := m.ResolveWorkspace(ctx, identifier)
+		if err != nil {
+			return err
+		}
+		used, ok := m.recentUsage().workspace(ws.ID)
+		if !ok {
+			if err := m.MeasureUsage([]*Workspace{ws}); err != nil {
+				return err
+			}
+			used = ws.Usage.Total()
+			m.recordUsage([]*Workspace{ws}, nil)
+		}
+		if used > limit {
+			return &ErrQuotaExceeded{Workspace: ws.Name, Used: used, Limit: limit, Block: block}
+		}
+	}
+
+	if quota.Project != "" {
+		limit, err := ParseSize(quota.Project)
+		if err != nil {
+			return fmt.Errorf("invalid workspace.quota.project: %w", err)
+		}
+		used, ok := m.recentUsage().project()
+		if !ok {
+			if _, _, used, err = m.ProjectUsage(ctx); err != nil {
+				return err
+			}
+		}
+		if used > limit {
+			return &ErrQuotaExceeded{Used: used, Limit: limit, Block: block}
+		}
+	}
+	return nil
+}
+
+// usageRecord is a disk usage measurement and when it was taken
+type usageRecord struct {
+	Total      int64     `json:"total"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// recent reports whether the measurement can still be used to check quotas
+func (r *usageRecord) recent() bool {
+	return r != nil && time.Since(r.MeasuredAt) < usageMaxAge
+}
+
+// usageRecords are the recent measurements kept in the usage file
+type usageRecords struct {
+	Workspaces map[string]*usageRecord `json:"workspaces,omitempty"` // By workspace ID
+	Project    *usageRecord            `json:"project,omitempty"`
+}
+
+// workspace returns the recent total of a workspace, if any
+func (r *usageRecords) workspace(id string) (int64, bool) {
+	rec := r.Workspaces[id]
+	if !rec.recent() {
+		return 0, false
+	}
+	return rec.Total, true
+}
+
+// project returns the recent total of the project, if any
+func (r *usageRecords) project() (int64, bool) {
+	if !r.Project.recent() {
+		return 0, false
+	}
+	return r.Project.Total, true
+}
+
+// recentUsage reads the usage file. A missing or unreadable file has no
+// measurements.
+func (m *Manager) recentUsage() *usageRecords {
+	records := &usageRecords{}
+	data, err := os.ReadFile(filepath.Join(m.configManager.GetAmuxDir(), usageFileName))
+	if err == nil {
+		_ = json.Unmarshal(data, records)
+	}
+	return records
+}
+
+// recordUsage keeps the measured workspaces, and the project total if given,
+// in the usage file. Failing to write it only means measuring again.
+func (m *Manager) recordUsage(workspaces []*Workspace, project *int64) {
+	records := m.recentUsage()
+	if records.Workspaces == nil {
+		records.Workspaces = make(map[string]*usageRecord)
+	}
+	// Old measurements are of no use, and may be of removed workspaces
+	for id, rec := range records.Workspaces {
+		if !rec.recent() {
+			delete(records.Workspaces, id)
+		}
+	}
+	now := time.Now()
+	for _, ws := range workspaces {
+		if ws.Usage != nil {
+			records.Workspaces[ws.ID] = &usageRecord{Total: ws.Usage.Total(), MeasuredAt: now}
+		}
+	}
+	if project != nil {
+		records.Project = &usageRecord{Total: *project, MeasuredAt: now}
+	}
+
+	if err := writeUsage(filepath.Join(m.configManager.GetAmuxDir(), usageFileName), records); err != nil {
+		slog.Debug("failed to record disk usage", "error", err)
+	}
+}
+
+// writeUsage replaces the usage file, so that concurrent readers never see
+// it half written
+func writeUsage(path string, records *usageRecords) error {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), usageFileName+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// dirSize returns the size of the regular files under a directory, leaving
+// out the directory skip (e.g., a worktree inside the workspace storage).
+// Files that can't be read, or a missing directory, are left out.
+func dirSize(dir, skip string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // Count what can be read
+		}
+		if d.IsDir() && skip != "" && path == skip {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
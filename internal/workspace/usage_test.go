@@ -0,0 +1,154 @@
+package workspace_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aki/amux/internal/config"
+	"github.com/aki/amux/internal/tests/helpers"
+	"github.com/aki/amux/internal/workspace"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"512", 512},
+		{"100B", 100},
+		{"4k", 4 << 10},
+		{"500MB", 500 << 20},
+		{"1.5GB", 3 << 29},
+		{"5 GiB", 5 << 30},
+		{"1T", 1 << 40},
+	}
+	for _, tt := range tests {
+		got, err := workspace.ParseSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseSize(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "GB", "-1GB", "5 PB", "lots"} {
+		if _, err := workspace.ParseSize(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestManager_UsageAndQuota(t *testing.T) {
+	repoDir := helpers.CreateTestRepo(t)
+	configManager := config.NewManager(repoDir)
+	cfg := config.DefaultConfig()
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	manager, err := workspace.NewManager(configManager)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	ctx := context.Background()
+
+	big, err := manager.Create(ctx, workspace.CreateOptions{Name: "big"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	small, err := manager.Create(ctx, workspace.CreateOptions{Name: "small"})
+	if err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	// Build output is ignored by git but still takes space
+	if err := os.WriteFile(filepath.Join(big.Path, "build.bin"), make([]byte, 64<<10), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	manager.SetLogUsage(func() (map[string]int64, error) {
+		return map[string]int64{big.ID: 1000}, nil
+	})
+
+	if err := manager.MeasureUsage([]*workspace.Workspace{big, small}); err != nil {
+		t.Fatalf("Failed to measure usage: %v", err)
+	}
+	if big.Usage.Worktree < 64<<10 {
+		t.Errorf("Expected the worktree to count build.bin, got %d bytes", big.Usage.Worktree)
+	}
+	if big.Usage.Storage == 0 {
+		t.Error("Expected the workspace metadata to count as storage")
+	}
+	if big.Usage.Storage >= big.Usage.Worktree {
+		t.Errorf("Expected the worktree not to be counted as storage, got %d bytes", big.Usage.Storage)
+	}
+	if big.Usage.Logs != 1000 || small.Usage.Logs != 0 {
+		t.Errorf("Expected logs of 1000 and 0 bytes, got %d and %d", big.Usage.Logs, small.Usage.Logs)
+	}
+	if big.Usage.Total() != big.Usage.Worktree+big.Usage.Storage+big.Usage.Logs {
+		t.Error("Expected the total to add up the worktree, storage and logs")
+	}
+
+	// No quota by default
+	if err := manager.CheckQuota(ctx, workspace.Identifier(big.Name)); err != nil {
+		t.Errorf("Expected no quota, got %v", err)
+	}
+
+	cfg.Workspace.Quota = config.QuotaConfig{Workspace: "32KB"}
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	err = manager.CheckQuota(ctx, workspace.Identifier(big.Name))
+	var exceeded *workspace.ErrQuotaExceeded
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if exceeded.Workspace != "big" || exceeded.Block {
+		t.Errorf("Expected a warning for workspace big, got %+v", exceeded)
+	}
+	if err := manager.CheckQuota(ctx, workspace.Identifier(small.Name)); err != nil {
+		t.Errorf("Expected small to be within its quota, got %v", err)
+	}
+
+	// The project quota counts every workspace
+	cfg.Workspace.Quota = config.QuotaConfig{Project: "48KB", Action: workspace.QuotaBlock}
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	err = manager.CheckQuota(ctx, workspace.Identifier(small.Name))
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if exceeded.Workspace != "" || !exceeded.Block {
+		t.Errorf("Expected the project quota to block, got %+v", exceeded)
+	}
+
+	// Recent measurements are reused, so that starting a session doesn't walk
+	// every worktree; measuring the project again, as amux du does, refreshes them
+	if err := os.Remove(filepath.Join(big.Path, "build.bin")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	cfg.Workspace.Quota = config.QuotaConfig{Workspace: "32KB"}
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if err := manager.CheckQuota(ctx, workspace.Identifier(big.Name)); !errors.As(err, &exceeded) {
+		t.Errorf("Expected the recent measurement to be used, got %v", err)
+	}
+	if _, _, _, err := manager.ProjectUsage(ctx); err != nil {
+		t.Fatalf("Failed to measure the project: %v", err)
+	}
+	if err := manager.CheckQuota(ctx, workspace.Identifier(big.Name)); err != nil {
+		t.Errorf("Expected big to be within its quota once measured again, got %v", err)
+	}
+
+	cfg.Workspace.Quota = config.QuotaConfig{Workspace: "lots"}
+	if err := configManager.Save(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if err := manager.CheckQuota(ctx, workspace.Identifier(big.Name)); err == nil || errors.As(err, &exceeded) {
+		t.Errorf("Expected an invalid quota error, got %v", err)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/aki/amux/internal/semaphore"
 )
 
-// sessionHolder implements semaphore.Holder interface for session IDs
+// sessionHolder implements semaphore.Holder interface for session IDs.
+// Sessions take their slot before their process starts, so holders carry no
+// PID; the manager's session check tells whether they still run.
 type sessionHolder struct {
 	sessionID string
 }
@@ -19,18 +21,24 @@ func (h *sessionHolder) ID() string {
 
 // getSemaphorePath returns the path to the workspace semaphore file
 func (w *Workspace) getSemaphorePath() string {
-	// Semaphore is stored in the workspace directory, not in the worktree
-	// Extract workspace directory from the worktree path
-	workspaceDir := filepath.Dir(w.Path) // Remove "worktree" from path
+	// Semaphore is stored in the workspace directory next to the storage
+	// folder, not in the worktree, which may live elsewhere
+	workspaceDir := filepath.Dir(w.StoragePath)
+	if w.StoragePath == "" {
+		workspaceDir = filepath.Dir(w.Path) // Older workspaces: remove "worktree" from path
+	}
 	return filepath.Join(workspaceDir, "semaphore.json")
 }
 
+// openSemaphore opens the workspace semaphore with the capacity of the
+// workspace's concurrency policy
+func (w *Workspace) openSemaphore() (*semaphore.FileSemaphore, error) {
+	return semaphore.NewWithOptions(w.getSemaphorePath(), w.capacity(), w.semaphoreOptions())
+}
+
 // Acquire acquires the workspace semaphore for a session
 func (w *Workspace) Acquire(sessionID string) error {
-	semaphorePath := w.getSemaphorePath()
-
-	// Create semaphore with capacity 10 (reasonable limit for concurrent sessions)
-	sem, err := semaphore.New(semaphorePath, 10)
+	sem, err := w.openSemaphore()
 	if err != nil {
 		return fmt.Errorf("failed to create semaphore: %w", err)
 	}
@@ -49,10 +57,7 @@ func (w *Workspace) Acquire(sessionID string) error {
 
 // Release releases the workspace semaphore for a session
 func (w *Workspace) Release(sessionID string) error {
-	semaphorePath := w.getSemaphorePath()
-
-	// Create semaphore
-	sem, err := semaphore.New(semaphorePath, 10)
+	sem, err := w.openSemaphore()
 	if err != nil {
 		return fmt.Errorf("failed to create semaphore: %w", err)
 	}
@@ -78,8 +83,7 @@ func (w *Workspace) SessionIDs() ([]string, error) {
 		return []string{}, nil
 	}
 
-	// Create semaphore
-	sem, err := semaphore.New(semaphorePath, 10)
+	sem, err := w.openSemaphore()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create semaphore: %w", err)
 	}
@@ -101,8 +105,7 @@ func (w *Workspace) IsAvailable() (bool, error) {
 		return true, nil
 	}
 
-	// Create semaphore
-	sem, err := semaphore.New(semaphorePath, 10)
+	sem, err := w.openSemaphore()
 	if err != nil {
 		return false, fmt.Errorf("failed to create semaphore: %w", err)
 	}
@@ -124,8 +127,7 @@ func (w *Workspace) SessionCount() int {
 		return 0
 	}
 
-	// Create semaphore
-	sem, err := semaphore.New(semaphorePath, 10)
+	sem, err := w.openSemaphore()
 	if err != nil {
 		return 0
 	}